@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+
+	pers "github.com/dhth/omm/internal/persistence"
+	"github.com/dhth/omm/internal/report"
+	"github.com/dhth/omm/internal/stats"
+	"github.com/dhth/omm/internal/types"
+	"github.com/dhth/omm/internal/ui"
+	"github.com/dhth/omm/internal/ui/theme"
+	"github.com/dhth/omm/internal/views"
+	"github.com/spf13/cobra"
+)
+
+func completeThemes(_ *cobra.Command, _ []string, _ string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	return theme.All(), cobra.ShellCompDirectiveNoFileComp
+}
+
+func completeListDensities(_ *cobra.Command, _ []string, _ string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	return []cobra.Completion{ui.CompactDensityVal, ui.SpaciousDensityVal}, cobra.ShellCompDirectiveNoFileComp
+}
+
+func completeFilterModes(_ *cobra.Command, _ []string, _ string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	return []cobra.Completion{ui.FilterPrefixVal, ui.FilterSummaryVal, ui.FilterContextVal}, cobra.ShellCompDirectiveNoFileComp
+}
+
+func completeStatsIntervals(_ *cobra.Command, _ []string, _ string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	return []cobra.Completion{string(stats.Day), string(stats.Week)}, cobra.ShellCompDirectiveNoFileComp
+}
+
+func completeReportPeriods(_ *cobra.Command, _ []string, _ string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	return []cobra.Completion{string(report.Today), string(report.Week), string(report.Custom)}, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeViews suggests views defined in the config file, and ones saved via
+// the TUI.
+func completeViews(cmd *cobra.Command, _ []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	configPathFlag := cmd.Flags().Lookup("config-path")
+	if configPathFlag == nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	cfg, err := initializeConfig(cmd, expandTilde(configPathFlag.Value.String()))
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var saved []types.SavedView
+	db, ok := openDBForCompletion(cmd)
+	if ok {
+		defer db.Close()
+		saved, _ = pers.FetchSavedViews(db)
+	}
+
+	var completions []cobra.Completion
+	for _, v := range views.Merge(cfg.views, saved) {
+		if strings.HasPrefix(v.Name, toComplete) {
+			completions = append(completions, cobra.CompletionWithDesc(v.Name, v.Definition))
+		}
+	}
+
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeTaskSummary suggests prefixes of active tasks (in the order they
+// first appear in the task list) when typing the summary of a new task.
+func completeTaskSummary(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	if len(args) > 0 || strings.Contains(toComplete, types.PrefixDelimiter) {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	db, ok := openDBForCompletion(cmd)
+	if !ok {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	defer db.Close()
+
+	tasks, err := pers.FetchAllActiveTasks(db)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var prefixes []types.TaskPrefix
+	counts := make(map[types.TaskPrefix]int)
+	for _, t := range tasks {
+		prefix, ok := t.Prefix()
+		if !ok || !strings.HasPrefix(string(prefix), toComplete) {
+			continue
+		}
+		if counts[prefix] == 0 {
+			prefixes = append(prefixes, prefix)
+		}
+		counts[prefix]++
+	}
+
+	completions := make([]cobra.Completion, len(prefixes))
+	for i, p := range prefixes {
+		completions[i] = cobra.CompletionWithDesc(
+			fmt.Sprintf("%s%s ", p, types.PrefixDelimiter),
+			fmt.Sprintf("%d active task(s)", counts[p]),
+		)
+	}
+
+	return completions, cobra.ShellCompDirectiveNoSpace | cobra.ShellCompDirectiveNoFileComp
+}
+
+// completePrefixes suggests prefixes of all tasks, for the first numArgs
+// arguments of a command.
+func completePrefixes(numArgs int) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		if len(args) >= numArgs {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		db, ok := openDBForCompletion(cmd)
+		if !ok {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		defer db.Close()
+
+		prefixes, err := pers.FetchPrefixes(db)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		var completions []cobra.Completion
+		for _, p := range prefixes {
+			if strings.HasPrefix(string(p.Prefix), toComplete) {
+				completions = append(completions, cobra.CompletionWithDesc(
+					string(p.Prefix),
+					fmt.Sprintf("%d active, %d archived task(s)", p.NumActive, p.NumArchived),
+				))
+			}
+		}
+
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// openDBForCompletion opens the database read-only; completions should neither
+// create, nor migrate it. Flags are resolved the same way they are for regular
+// commands, since PersistentPreRunE doesn't run for completions.
+func openDBForCompletion(cmd *cobra.Command) (*sql.DB, bool) {
+	configPathFlag := cmd.Flags().Lookup("config-path")
+	dbPathFlag := cmd.Flags().Lookup("db-path")
+	if configPathFlag == nil || dbPathFlag == nil {
+		return nil, false
+	}
+
+	_, err := initializeConfig(cmd, expandTilde(configPathFlag.Value.String()))
+	if err != nil {
+		return nil, false
+	}
+
+	dbPath := expandTilde(dbPathFlag.Value.String())
+	_, err = os.Stat(dbPath)
+	if err != nil {
+		return nil, false
+	}
+
+	db, err := getDB(fmt.Sprintf("file:%s?mode=ro", dbPath))
+	if err != nil {
+		return nil, false
+	}
+
+	return db, true
+}
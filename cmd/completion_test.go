@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	pers "github.com/dhth/omm/internal/persistence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompletingTaskSummariesSuggestsPrefixes(t *testing.T) {
+	// GIVEN
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "omm.db")
+	configPath := filepath.Join(tempDir, "omm.toml")
+
+	db, err := setupDB(dbPath)
+	require.NoError(t, err)
+	for _, summary := range []string{"work: task 1", "home: task 2", "work: task 3", "task 4"} {
+		_, err = importTask(db, summary)
+		require.NoError(t, err)
+	}
+	// snoozed tasks are still active
+	require.NoError(t, pers.SnoozeTask(db, 2, time.Now().Add(time.Hour)))
+	require.NoError(t, db.Close())
+
+	testCases := []struct {
+		name       string
+		toComplete string
+		expected   []string
+	}{
+		{
+			name:       "all prefixes",
+			toComplete: "",
+			expected:   []string{"work: \t2 active task(s)", "home: \t1 active task(s)"},
+		},
+		{
+			name:       "matching prefixes",
+			toComplete: "ho",
+			expected:   []string{"home: \t1 active task(s)"},
+		},
+		{
+			name:       "no suggestions once a prefix is typed",
+			toComplete: "work: ",
+			expected:   []string{},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			rootCmd, err := NewRootCommand("dev")
+			require.NoError(t, err)
+
+			var out bytes.Buffer
+			rootCmd.SetOut(&out)
+			rootCmd.SetErr(&bytes.Buffer{})
+			rootCmd.SetArgs([]string{"__complete", "-c", configPath, "-d", dbPath, tt.toComplete})
+
+			// WHEN
+			err = rootCmd.Execute()
+
+			// THEN
+			require.NoError(t, err)
+			lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+			// the last line holds the completion directive
+			assert.Equal(t, tt.expected, lines[:len(lines)-1])
+		})
+	}
+}
+
+func TestCompletingPrefixCommandsSuggestsPrefixes(t *testing.T) {
+	// GIVEN
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "omm.db")
+	configPath := filepath.Join(tempDir, "omm.toml")
+
+	db, err := setupDB(dbPath)
+	require.NoError(t, err)
+	for _, summary := range []string{"work: task 1", "home: task 2", "work: task 3", "task 4"} {
+		_, err = importTask(db, summary)
+		require.NoError(t, err)
+	}
+	require.NoError(t, pers.ChangeTaskStatus(db, 2, false, time.Now()))
+	require.NoError(t, db.Close())
+
+	testCases := []struct {
+		name     string
+		args     []string
+		expected []string
+	}{
+		{
+			name:     "prefix to rename",
+			args:     []string{"rename", ""},
+			expected: []string{"home\t0 active, 1 archived task(s)", "work\t2 active, 0 archived task(s)"},
+		},
+		{
+			name:     "new name of a renamed prefix",
+			args:     []string{"rename", "work", ""},
+			expected: []string{},
+		},
+		{
+			name:     "prefix to merge into",
+			args:     []string{"merge", "work", "h"},
+			expected: []string{"home\t0 active, 1 archived task(s)"},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			rootCmd, err := NewRootCommand("dev")
+			require.NoError(t, err)
+
+			var out bytes.Buffer
+			rootCmd.SetOut(&out)
+			rootCmd.SetErr(&bytes.Buffer{})
+			args := append([]string{"__complete", "prefix", tt.args[0], "-c", configPath, "-d", dbPath}, tt.args[1:]...)
+			rootCmd.SetArgs(args)
+
+			// WHEN
+			err = rootCmd.Execute()
+
+			// THEN
+			require.NoError(t, err)
+			lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+			// the last line holds the completion directive
+			assert.Equal(t, tt.expected, lines[:len(lines)-1])
+		})
+	}
+}
+
+func TestCompletingDoesntCreateDB(t *testing.T) {
+	testCases := []struct {
+		name string
+		args []string
+	}{
+		{
+			name: "flag values",
+			args: []string{"__complete", "--theme", ""},
+		},
+		{
+			name: "task summaries",
+			args: []string{"__complete", ""},
+		},
+		{
+			name: "prefixes",
+			args: []string{"__completeNoDesc", "prefix", "rename", ""},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			tempDir := t.TempDir()
+			t.Setenv("HOME", tempDir)
+			t.Setenv("XDG_CONFIG_HOME", filepath.Join(tempDir, "config"))
+			t.Setenv("XDG_DATA_HOME", filepath.Join(tempDir, "data"))
+
+			rootCmd, err := NewRootCommand("dev")
+			require.NoError(t, err)
+			rootCmd.SetOut(&bytes.Buffer{})
+			rootCmd.SetErr(&bytes.Buffer{})
+			rootCmd.SetArgs(tt.args)
+
+			// WHEN
+			err = rootCmd.Execute()
+
+			// THEN
+			require.NoError(t, err)
+			entries, err := os.ReadDir(tempDir)
+			require.NoError(t, err)
+			assert.Empty(t, entries)
+		})
+	}
+}
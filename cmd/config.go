@@ -0,0 +1,362 @@
+package cmd
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dhth/omm/internal/hooks"
+	"github.com/dhth/omm/internal/ui"
+	"github.com/dhth/omm/internal/ui/theme"
+	"github.com/dhth/omm/internal/views"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+type configSource string
+
+const (
+	configSourceFlag    configSource = "flag"
+	configSourceEnv     configSource = "env"
+	configSourceFile    configSource = "file"
+	configSourceDefault configSource = "default"
+)
+
+var (
+	errConfigFileAlreadyExists = errors.New("config file already exists")
+	errConfigInvalid           = errors.New("config is invalid")
+
+	//go:embed assets/sample-config.toml
+	sampleConfig string
+)
+
+// hookConfigKeys are config keys that don't have a corresponding flag.
+var hookConfigKeys = []string{"on_create", "on_update", "on_archive", "on_unarchive", "on_delete", "hook_timeout"}
+
+// viewsConfigKey is the table views are defined in, in the config file; the
+// keys of views are flattened to "views.<name>".
+const viewsConfigKey = "views"
+
+// prefixColorsConfigKey is the table colors are pinned to prefixes in; its keys
+// are flattened to "prefix_colors.<prefix>", or "prefix_colors.<theme>.<prefix>"
+// for colors pinned for a single theme.
+const prefixColorsConfigKey = "prefix_colors"
+
+// configKeysNotAllowed are flags that can't be set via the config file.
+var configKeysNotAllowed = []string{"config_path", "help", "version"}
+
+type configEntry struct {
+	key    string
+	value  string
+	source configSource
+}
+
+func configKey(flagName string) string {
+	return strings.ReplaceAll(flagName, "-", "_")
+}
+
+func envVarForConfigKey(key string) string {
+	return fmt.Sprintf("%s_%s", envPrefix, strings.ToUpper(key))
+}
+
+// knownConfigKeys returns every key that can be set via the config file; ie,
+// the flags of all commands, and hooks.
+func knownConfigKeys(rootCmd *cobra.Command) map[string]bool {
+	keys := make(map[string]bool)
+	var visit func(c *cobra.Command)
+	visit = func(c *cobra.Command) {
+		c.Flags().VisitAll(func(f *pflag.Flag) {
+			keys[configKey(f.Name)] = true
+		})
+		for _, sub := range c.Commands() {
+			visit(sub)
+		}
+	}
+	visit(rootCmd)
+
+	for _, k := range hookConfigKeys {
+		keys[k] = true
+	}
+	for _, k := range configKeysNotAllowed {
+		delete(keys, k)
+	}
+
+	return keys
+}
+
+// readConfigFile returns the values set in the config file, keyed by their
+// (flattened) names. A missing file is treated as an empty one.
+func readConfigFile(path string) (map[string]any, error) {
+	_, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]any{}, nil
+	}
+
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetConfigType("toml")
+
+	err = v.ReadInConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]any)
+	for _, k := range v.AllKeys() {
+		values[k] = v.Get(k)
+	}
+
+	return values, nil
+}
+
+// resolveConfig determines the effective value of each setting along with where
+// it came from, following the same precedence as initializeConfig: flags, then
+// env vars, then the config file, and then defaults.
+func resolveConfig(flags *pflag.FlagSet, fileValues map[string]any, lookupEnv func(string) (string, bool)) []configEntry {
+	var entries []configEntry
+
+	resolve := func(key, flagValue string, flagChanged bool, defaultValue string) configEntry {
+		if flagChanged {
+			return configEntry{key, flagValue, configSourceFlag}
+		}
+
+		envValue, ok := lookupEnv(envVarForConfigKey(key))
+		if ok {
+			return configEntry{key, envValue, configSourceEnv}
+		}
+
+		fileValue, ok := fileValues[key]
+		if ok {
+			return configEntry{key, fmt.Sprintf("%v", fileValue), configSourceFile}
+		}
+
+		return configEntry{key, defaultValue, configSourceDefault}
+	}
+
+	flags.VisitAll(func(f *pflag.Flag) {
+		key := configKey(f.Name)
+		if slices.Contains(configKeysNotAllowed, key) {
+			return
+		}
+
+		entry := resolve(key, f.Value.String(), f.Changed, f.DefValue)
+
+		// the editor falls back to $EDITOR/$VISUAL before its default value
+		if key == "editor" && entry.source == configSourceDefault {
+			for _, envVar := range []string{"EDITOR", "VISUAL"} {
+				val, ok := lookupEnv(envVar)
+				if ok && val != "" {
+					entry = configEntry{key, val, configSource(fmt.Sprintf("%s ($%s)", configSourceEnv, envVar))}
+					break
+				}
+			}
+		}
+
+		entries = append(entries, entry)
+	})
+
+	for _, key := range hookConfigKeys {
+		defaultValue := ""
+		if key == "hook_timeout" {
+			defaultValue = hooks.DefaultTimeout.String()
+		}
+		entries = append(entries, resolve(key, "", false, defaultValue))
+	}
+
+	var tableKeys []string
+	for k := range fileValues {
+		if isViewConfigKey(k) || isPrefixColorConfigKey(k) {
+			tableKeys = append(tableKeys, k)
+		}
+	}
+	slices.Sort(tableKeys)
+	for _, key := range tableKeys {
+		entries = append(entries, resolve(key, "", false, ""))
+	}
+
+	return entries
+}
+
+func showConfig(flags *pflag.FlagSet, configPath string, writer io.Writer) error {
+	fileValues, err := readConfigFile(configPath)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(writer, "config file: %s\n\n", configPath)
+
+	tw := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEY\tVALUE\tSOURCE")
+	for _, e := range resolveConfig(flags, fileValues, os.LookupEnv) {
+		fmt.Fprintf(tw, "%s\t%q\t%s\n", e.key, e.value, e.source)
+	}
+
+	return tw.Flush()
+}
+
+// validateConfig returns the problems with the config; unknown keys in the
+// config file, and effective values that omm won't accept.
+func validateConfig(flags *pflag.FlagSet, fileValues map[string]any, knownKeys map[string]bool, lookupEnv func(string) (string, bool)) []string {
+	var problems []string
+
+	fileKeys := make([]string, 0, len(fileValues))
+	for k := range fileValues {
+		fileKeys = append(fileKeys, k)
+	}
+	slices.Sort(fileKeys)
+
+	for _, k := range fileKeys {
+		if !knownKeys[k] && !isViewConfigKey(k) && !isPrefixColorConfigKey(k) {
+			problems = append(problems, fmt.Sprintf("unknown key in config file: %q", k))
+		}
+	}
+
+	for _, e := range resolveConfig(flags, fileValues, lookupEnv) {
+		if e.source == configSourceDefault {
+			continue
+		}
+
+		problem := validateConfigValue(flags, e)
+		if problem != "" {
+			problems = append(problems, fmt.Sprintf("%s (set via %s): %s", e.key, e.source, problem))
+		}
+	}
+
+	return problems
+}
+
+func isViewConfigKey(key string) bool {
+	return strings.HasPrefix(key, viewsConfigKey+".")
+}
+
+func isPrefixColorConfigKey(key string) bool {
+	return strings.HasPrefix(key, prefixColorsConfigKey+".")
+}
+
+func validateConfigValue(flags *pflag.FlagSet, e configEntry) string {
+	if isViewConfigKey(e.key) {
+		_, err := views.Parse(strings.TrimPrefix(e.key, viewsConfigKey+"."), e.value, views.FromConfig)
+		if err != nil {
+			return err.Error()
+		}
+		return ""
+	}
+
+	if isPrefixColorConfigKey(e.key) {
+		themeName, _, themed := strings.Cut(strings.TrimPrefix(e.key, prefixColorsConfigKey+"."), ".")
+		if themed && !slices.Contains(theme.All(), themeName) {
+			return fmt.Sprintf("%q is not a valid theme; possible values: [%s]", themeName, strings.Join(theme.All(), ", "))
+		}
+		if !theme.IsColorValid(e.value) {
+			return theme.ErrPrefixColorInvalid.Error()
+		}
+		return ""
+	}
+
+	switch e.key {
+	case "theme":
+		_, err := theme.Get(e.value)
+		if err != nil {
+			return fmt.Sprintf("%q is not a valid theme; possible values: [%s]", e.value, strings.Join(theme.All(), ", "))
+		}
+	case "list_density":
+		if e.value != ui.CompactDensityVal && e.value != ui.SpaciousDensityVal {
+			return fmt.Sprintf("%q is not valid; possible values: [%s, %s]", e.value, ui.CompactDensityVal, ui.SpaciousDensityVal)
+		}
+	case "filter_mode":
+		if !slices.Contains([]string{ui.FilterPrefixVal, ui.FilterSummaryVal, ui.FilterContextVal}, e.value) {
+			return fmt.Sprintf("%q is not valid; possible values: [%s, %s, %s]", e.value, ui.FilterPrefixVal, ui.FilterSummaryVal, ui.FilterContextVal)
+		}
+	case "editor":
+		fields := strings.Fields(e.value)
+		if len(fields) == 0 {
+			return "editor command is empty"
+		}
+		_, err := exec.LookPath(fields[0])
+		if err != nil {
+			return fmt.Sprintf("%q is not an executable in $PATH", fields[0])
+		}
+	case "db_path":
+		if filepath.Ext(e.value) != ".db" {
+			return errDBFileExtIncorrect.Error()
+		}
+	case "board_columns":
+		_, err := ui.ParseBoardColumns(e.value)
+		if err != nil {
+			return err.Error()
+		}
+	case "hook_timeout":
+		d, err := time.ParseDuration(e.value)
+		if err != nil || d <= 0 {
+			return errHookTimeoutInvalid.Error()
+		}
+	}
+
+	f := flags.Lookup(strings.ReplaceAll(e.key, "_", "-"))
+	if f == nil {
+		return ""
+	}
+
+	var err error
+	switch f.Value.Type() {
+	case "bool":
+		_, err = strconv.ParseBool(e.value)
+	case "uint8":
+		_, err = strconv.ParseUint(e.value, 10, 8)
+	}
+	if err != nil {
+		return fmt.Sprintf("%q is not a valid %s", e.value, f.Value.Type())
+	}
+
+	return ""
+}
+
+func checkConfig(rootCmd *cobra.Command, flags *pflag.FlagSet, configPath string, writer io.Writer) error {
+	fileValues, err := readConfigFile(configPath)
+	if err != nil {
+		return fmt.Errorf("%w: %s", errConfigInvalid, err.Error())
+	}
+
+	problems := validateConfig(flags, fileValues, knownConfigKeys(rootCmd), os.LookupEnv)
+	if len(problems) == 0 {
+		fmt.Fprintln(writer, "config is valid")
+		return nil
+	}
+
+	for _, p := range problems {
+		fmt.Fprintf(writer, "- %s\n", p)
+	}
+
+	return fmt.Errorf("%w (%d problem(s))", errConfigInvalid, len(problems))
+}
+
+func writeSampleConfig(configPath string, force bool, writer io.Writer) error {
+	_, err := os.Stat(configPath)
+	if err == nil && !force {
+		return fmt.Errorf("%w at %s; use --force to overwrite it", errConfigFileAlreadyExists, configPath)
+	}
+
+	err = os.MkdirAll(filepath.Dir(configPath), 0o755)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(configPath, []byte(sampleConfig), 0o644)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(writer, "wrote a sample config file to %s\n", configPath)
+	return nil
+}
@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dhth/omm/internal/ui/theme"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getTestFlags(t *testing.T, args ...string) *pflag.FlagSet {
+	t.Helper()
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.String("config-path", "omm.toml", "")
+	flags.String("theme", "gruvbox-dark", "")
+	flags.String("list-density", "compact", "")
+	flags.String("editor", "vi", "")
+	flags.Bool("show-context", false, "")
+	flags.String("board-columns", "todo,doing,blocked,done", "")
+	require.NoError(t, flags.Parse(args))
+
+	return flags
+}
+
+func lookupEnvFrom(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+}
+
+func TestResolveConfig(t *testing.T) {
+	// GIVEN
+	flags := getTestFlags(t, "--theme", "dracula")
+	fileValues := map[string]any{
+		"theme":        "onedark",
+		"list_density": "spacious",
+		"show_context": true,
+		"on_create":    "echo created",
+	}
+	env := map[string]string{
+		"OMM_LIST_DENSITY": "compact",
+		"OMM_HOOK_TIMEOUT": "10s",
+		"VISUAL":           "nano",
+	}
+
+	// WHEN
+	entries := resolveConfig(flags, fileValues, lookupEnvFrom(env))
+
+	// THEN
+	got := make(map[string]configEntry)
+	for _, e := range entries {
+		got[e.key] = e
+	}
+
+	assert.NotContains(t, got, "config_path")
+	assert.Equal(t, configEntry{"theme", "dracula", configSourceFlag}, got["theme"])
+	assert.Equal(t, configEntry{"list_density", "compact", configSourceEnv}, got["list_density"])
+	assert.Equal(t, configEntry{"show_context", "true", configSourceFile}, got["show_context"])
+	assert.Equal(t, configEntry{"editor", "nano", "env ($VISUAL)"}, got["editor"])
+	assert.Equal(t, configEntry{"on_create", "echo created", configSourceFile}, got["on_create"])
+	assert.Equal(t, configEntry{"on_delete", "", configSourceDefault}, got["on_delete"])
+	assert.Equal(t, configEntry{"hook_timeout", "10s", configSourceEnv}, got["hook_timeout"])
+}
+
+func TestValidateConfig(t *testing.T) {
+	knownKeys := map[string]bool{
+		"theme":         true,
+		"list_density":  true,
+		"editor":        true,
+		"show_context":  true,
+		"hook_timeout":  true,
+		"board_columns": true,
+	}
+
+	testCases := []struct {
+		name       string
+		args       []string
+		fileValues map[string]any
+		env        map[string]string
+		expected   []string
+	}{
+		{
+			name: "valid config",
+			fileValues: map[string]any{
+				"theme":        "dracula",
+				"list_density": "spacious",
+				"editor":       "sh -c",
+				"hook_timeout": "2s",
+			},
+		},
+		{
+			name:       "unknown keys",
+			fileValues: map[string]any{"colour": "red", "theme": "dracula"},
+			expected:   []string{`unknown key in config file: "colour"`},
+		},
+		{
+			name: "invalid values",
+			fileValues: map[string]any{
+				"list_density":  "dense",
+				"editor":        "this-editor-does-not-exist --wait",
+				"hook_timeout":  "0s",
+				"board_columns": "todo,,done",
+			},
+			expected: []string{
+				"board_columns (set via file): board column name is empty",
+				`editor (set via file): "this-editor-does-not-exist" is not an executable in $PATH`,
+				`list_density (set via file): "dense" is not valid; possible values: [compact, spacious]`,
+				"hook_timeout (set via file): hook_timeout needs to be a positive duration (eg. 5s)",
+			},
+		},
+		{
+			name: "prefix colors",
+			fileValues: map[string]any{
+				"prefix_colors.work":           "#fabd2f",
+				"prefix_colors.infra":          "yellow",
+				"prefix_colors.dracula.work":   "212",
+				"prefix_colors.solarized.work": "4",
+			},
+			expected: []string{
+				"prefix_colors.infra (set via file): " + theme.ErrPrefixColorInvalid.Error(),
+				`prefix_colors.solarized.work (set via file): "solarized" is not a valid theme; possible values: [` + strings.Join(theme.All(), ", ") + "]",
+			},
+		},
+		{
+			name:     "invalid values via env vars",
+			env:      map[string]string{"OMM_SHOW_CONTEXT": "maybe"},
+			expected: []string{`show_context (set via env): "maybe" is not a valid bool`},
+		},
+		{
+			name:       "flags take precedence over invalid values in the file",
+			args:       []string{"--list-density", "compact"},
+			fileValues: map[string]any{"list_density": "dense"},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			flags := getTestFlags(t, tt.args...)
+
+			// WHEN
+			got := validateConfig(flags, tt.fileValues, knownKeys, lookupEnvFrom(tt.env))
+
+			// THEN
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestSampleConfigIsValid(t *testing.T) {
+	// GIVEN
+	configPath := filepath.Join(t.TempDir(), "omm", "omm.toml")
+	rootCmd, err := NewRootCommand("dev")
+	require.NoError(t, err)
+
+	// WHEN
+	err = writeSampleConfig(configPath, false, io.Discard)
+
+	// THEN
+	require.NoError(t, err)
+
+	err = writeSampleConfig(configPath, false, io.Discard)
+	require.ErrorIs(t, err, errConfigFileAlreadyExists)
+
+	err = writeSampleConfig(configPath, true, io.Discard)
+	require.NoError(t, err)
+
+	err = checkConfig(rootCmd, getTestFlags(t), configPath, io.Discard)
+	require.NoError(t, err)
+}
@@ -2,6 +2,37 @@ package cmd
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	pers "github.com/dhth/omm/internal/persistence"
+)
+
+const (
+	backupTimestampFormat = "20060102T150405.000"
+	backupsToKeepDefault  = 10
+)
+
+var (
+	errDBHasProblems       = errors.New("database has problems")
+	errDBIsCorrupted       = errors.New("database is corrupted")
+	errNoBackupsFound      = errors.New("no backups found")
+	errBackupInvalid       = errors.New("backup is not usable")
+	errBackupAlreadyExists = errors.New("backup file already exists")
+	errFileAlreadyExists   = errors.New("file already exists")
+
+	errDBDoesntExist                = errors.New("database doesn't exist")
+	errDryRunFailed                 = errors.New("migrations would fail")
+	errCouldntBackUpBeforeMigration = errors.New("couldn't back up database before migrating it")
+	errDBRolledBack                 = errors.New("database was rolled back to how it was before the migration")
 )
 
 func getDB(dbpath string) (*sql.DB, error) {
@@ -13,3 +44,508 @@ func getDB(dbpath string) (*sql.DB, error) {
 	db.SetMaxIdleConns(1)
 	return db, err
 }
+
+func dbFileStem(dbPath string) string {
+	return strings.TrimSuffix(filepath.Base(dbPath), filepath.Ext(dbPath))
+}
+
+// backupsDirForDB returns the directory that backups of the database at dbPath
+// are written to by default; it lives next to the database file.
+func backupsDirForDB(dbPath string) string {
+	return filepath.Join(filepath.Dir(dbPath), dbFileStem(dbPath)+"-backups")
+}
+
+func backupFileName(dbPath string, now time.Time) string {
+	return fmt.Sprintf("%s-%s.db", dbFileStem(dbPath), now.UTC().Format(backupTimestampFormat))
+}
+
+// listBackups returns the backups of the database at dbPath present in dir,
+// oldest first.
+func listBackups(dir, dbPath string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := dbFileStem(dbPath) + "-"
+	var backups []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ".db") {
+			continue
+		}
+
+		_, err := time.Parse(backupTimestampFormat, strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".db"))
+		if err != nil {
+			continue
+		}
+
+		backups = append(backups, filepath.Join(dir, name))
+	}
+
+	// the timestamp format sorts chronologically
+	slices.Sort(backups)
+
+	return backups, nil
+}
+
+// backupDB writes a backup of the database to target; if target is a
+// directory (or empty, in which case the default backups directory is used),
+// the backup gets a timestamped name, and only the latest keep backups in the
+// directory are retained (all of them if keep is 0).
+func backupDB(db *sql.DB, dbPath, target string, keep uint, now time.Time, writer io.Writer) error {
+	if target == "" {
+		target = backupsDirForDB(dbPath)
+	}
+
+	backupPath := target
+	toDir := filepath.Ext(target) != ".db"
+	if toDir {
+		backupPath = filepath.Join(target, backupFileName(dbPath, now))
+	}
+
+	_, err := os.Stat(backupPath)
+	if err == nil {
+		return fmt.Errorf("%w: %s", errBackupAlreadyExists, backupPath)
+	}
+
+	err = os.MkdirAll(filepath.Dir(backupPath), 0o755)
+	if err != nil {
+		return err
+	}
+
+	err = pers.BackupDB(db, backupPath)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(writer, "backed up database to %s\n", backupPath)
+
+	if !toDir || keep == 0 {
+		return nil
+	}
+
+	backups, err := listBackups(target, dbPath)
+	if err != nil {
+		return err
+	}
+
+	if len(backups) <= int(keep) {
+		return nil
+	}
+
+	for _, b := range backups[:len(backups)-int(keep)] {
+		err = os.Remove(b)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(writer, "removed old backup %s\n", b)
+	}
+
+	return nil
+}
+
+// resolveBackup returns the backup file to restore from; source can either be
+// a backup file, or a directory of backups (the default backups directory if
+// empty), in which case the latest backup in it is used.
+func resolveBackup(dbPath, source string) (string, error) {
+	if source == "" {
+		source = backupsDirForDB(dbPath)
+	}
+
+	info, err := os.Stat(source)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", fmt.Errorf("%w at %s", errNoBackupsFound, source)
+		}
+		return "", err
+	}
+
+	if !info.IsDir() {
+		return source, nil
+	}
+
+	backups, err := listBackups(source, dbPath)
+	if err != nil {
+		return "", err
+	}
+
+	if len(backups) == 0 {
+		return "", fmt.Errorf("%w in %s", errNoBackupsFound, source)
+	}
+
+	return backups[len(backups)-1], nil
+}
+
+// restoreDB replaces the database at dbPath with a backup. The current
+// database file is moved to the backups directory rather than being deleted,
+// since it might be the only copy of some tasks; this also works when it's too
+// corrupted to be opened.
+func restoreDB(dbPath, source string, now time.Time, writer io.Writer) error {
+	backupPath, err := resolveBackup(dbPath, source)
+	if err != nil {
+		return err
+	}
+
+	backupDB, err := getDB(fmt.Sprintf("file:%s?mode=ro", backupPath))
+	if err != nil {
+		return fmt.Errorf("%w: %s", errBackupInvalid, err.Error())
+	}
+	defer backupDB.Close()
+
+	problems, err := pers.CheckIntegrity(backupDB)
+	if err != nil {
+		return fmt.Errorf("%w: %s", errBackupInvalid, err.Error())
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: integrity check failed: %s", errBackupInvalid, strings.Join(problems, "; "))
+	}
+
+	_, err = pers.CheckDBVersion(backupDB)
+	if err != nil {
+		return fmt.Errorf("%w: %s", errBackupInvalid, err.Error())
+	}
+
+	previousPath := filepath.Join(backupsDirForDB(dbPath), fmt.Sprintf("%s-pre-restore-%s.db", dbFileStem(dbPath), now.UTC().Format(backupTimestampFormat)))
+	movedPrevious, err := replaceDBFile(dbPath, backupDB, previousPath, now)
+	if err != nil {
+		return err
+	}
+
+	if movedPrevious {
+		fmt.Fprintf(writer, "moved the previous database to %s\n", previousPath)
+	}
+	fmt.Fprintf(writer, "restored database from %s\n", backupPath)
+
+	return nil
+}
+
+// replaceDBFile replaces the database file at dbPath with a copy of source. If
+// previousPath is not empty, the current database file (if any) is moved there
+// instead of being overwritten.
+func replaceDBFile(dbPath string, source *sql.DB, previousPath string, now time.Time) (bool, error) {
+	// write the copy next to the database first, so that the database file can
+	// then be swapped atomically
+	tempPath := fmt.Sprintf("%s.restore-%d", dbPath, now.UnixNano())
+	err := pers.BackupDB(source, tempPath)
+	if err != nil {
+		return false, err
+	}
+
+	var movedPrevious bool
+	_, err = os.Stat(dbPath)
+	if err == nil && previousPath != "" {
+		err = os.MkdirAll(filepath.Dir(previousPath), 0o755)
+		if err != nil {
+			_ = os.Remove(tempPath)
+			return false, err
+		}
+
+		err = os.Rename(dbPath, previousPath)
+		if err != nil {
+			_ = os.Remove(tempPath)
+			return false, err
+		}
+		movedPrevious = true
+	}
+
+	err = os.Rename(tempPath, dbPath)
+	if err != nil {
+		return movedPrevious, err
+	}
+
+	return movedPrevious, nil
+}
+
+// upgradeDB applies pending migrations to the database at dbPath. The database
+// is backed up before that, and if a migration fails, it's rolled back to the
+// backup; migrations are applied one transaction at a time, so a failure would
+// otherwise leave the database partially migrated. The backup is only kept
+// around if a migration fails.
+func upgradeDB(db *sql.DB, dbPath string, now time.Time) error {
+	currentVersion, pending, err := pers.PendingMigrations(db)
+	if err != nil {
+		return err
+	}
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	backupPath := filepath.Join(backupsDirForDB(dbPath), fmt.Sprintf("%s-pre-migration-v%d-%s.db", dbFileStem(dbPath), currentVersion, now.UTC().Format(backupTimestampFormat)))
+	err = os.MkdirAll(filepath.Dir(backupPath), 0o755)
+	if err != nil {
+		return fmt.Errorf("%w: %s", errCouldntBackUpBeforeMigration, err.Error())
+	}
+
+	err = pers.BackupDB(db, backupPath)
+	if err != nil {
+		return fmt.Errorf("%w: %s", errCouldntBackUpBeforeMigration, err.Error())
+	}
+
+	migrationErr := pers.UpgradeDB(db, currentVersion)
+	if migrationErr == nil {
+		_ = os.Remove(backupPath)
+		return nil
+	}
+
+	err = rollBackDB(db, dbPath, backupPath, now)
+	if err != nil {
+		return fmt.Errorf("%w; rolling back to the backup at %s failed as well: %s", migrationErr, backupPath, err.Error())
+	}
+
+	return fmt.Errorf("%w; %w (a copy is at %s)", migrationErr, errDBRolledBack, backupPath)
+}
+
+func migrateDB(db *sql.DB, dbPath string, now time.Time, writer io.Writer) error {
+	currentVersion, pending, err := pers.PendingMigrations(db)
+	if err != nil {
+		return err
+	}
+
+	if len(pending) == 0 {
+		fmt.Fprintf(writer, "database is up to date (version %d)\n", currentVersion)
+		return nil
+	}
+
+	err = upgradeDB(db, dbPath, now)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(writer, "migrated database from version %d to %d\n", currentVersion, pending[len(pending)-1])
+
+	return nil
+}
+
+func showMigrationStatus(db *sql.DB, writer io.Writer) error {
+	history, err := pers.FetchDBVersionHistory(db)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(writer, "history:")
+	tw := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	for _, v := range history {
+		fmt.Fprintf(tw, "  version %d\t%s\n", v.Version, v.CreatedAt.Format(time.DateTime))
+	}
+	err = tw.Flush()
+	if err != nil {
+		return err
+	}
+
+	currentVersion, pending, err := pers.PendingMigrations(db)
+	if errors.Is(err, pers.ErrDBDowngraded) {
+		fmt.Fprintf(writer, "\ndatabase is at version %d, which is newer than what this version of omm supports\n", currentVersion)
+		_, canRead, compatErr := pers.CanReadNewerDB(db)
+		if compatErr == nil && canRead {
+			fmt.Fprintln(writer, "this version of omm can read it, but not make changes to it")
+		} else {
+			fmt.Fprintln(writer, `this version of omm can't use it; use the newer version to write a copy that it can use (via "omm db export-compatible")`)
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(pending) == 0 {
+		fmt.Fprintln(writer, "\nno pending migrations")
+		return nil
+	}
+
+	fmt.Fprintln(writer, "\npending migrations:")
+	for _, v := range pending {
+		fmt.Fprintf(writer, "  version %d\n", v)
+	}
+
+	return nil
+}
+
+// dryRunMigrations applies pending migrations to a temporary copy of the
+// database, leaving the database itself untouched.
+func dryRunMigrations(db *sql.DB, writer io.Writer) error {
+	currentVersion, pending, err := pers.PendingMigrations(db)
+	if err != nil {
+		return err
+	}
+
+	if len(pending) == 0 {
+		fmt.Fprintf(writer, "database is up to date (version %d)\n", currentVersion)
+		return nil
+	}
+
+	tempDir, err := os.MkdirTemp("", "omm-migrate-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tempDir)
+
+	copyPath := filepath.Join(tempDir, "omm.db")
+	err = pers.BackupDB(db, copyPath)
+	if err != nil {
+		return err
+	}
+
+	copyDB, err := getDB(copyPath)
+	if err != nil {
+		return err
+	}
+	defer copyDB.Close()
+
+	err = pers.UpgradeDB(copyDB, currentVersion)
+	if err != nil {
+		return fmt.Errorf("%w: %s", errDryRunFailed, err.Error())
+	}
+
+	problems, err := pers.CheckIntegrity(copyDB)
+	if err != nil {
+		return err
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: integrity check failed after migrating: %s", errDryRunFailed, strings.Join(problems, "; "))
+	}
+
+	fmt.Fprintf(writer, "migrations from version %d to %d would succeed; the database was not modified\n", currentVersion, pending[len(pending)-1])
+
+	return nil
+}
+
+func rollBackDB(db *sql.DB, dbPath, backupPath string, now time.Time) error {
+	err := db.Close()
+	if err != nil {
+		return err
+	}
+
+	backupDB, err := getDB(fmt.Sprintf("file:%s?mode=ro", backupPath))
+	if err != nil {
+		return err
+	}
+	defer backupDB.Close()
+
+	_, err = replaceDBFile(dbPath, backupDB, "", now)
+	return err
+}
+
+func checkDB(db *sql.DB, writer io.Writer) error {
+	integrityProblems, err := pers.CheckIntegrity(db)
+	if err != nil {
+		return err
+	}
+
+	if len(integrityProblems) > 0 {
+		for _, p := range integrityProblems {
+			fmt.Fprintf(writer, "- %s\n", p)
+		}
+		return fmt.Errorf("%w; restore it from a backup using \"omm db restore\"", errDBIsCorrupted)
+	}
+
+	seqProblems, err := pers.FindSequenceProblems(db)
+	if err != nil {
+		return err
+	}
+
+	if seqProblems.Empty() {
+		fmt.Fprintln(writer, "no problems found")
+		return nil
+	}
+
+	for _, p := range seqProblems.Describe() {
+		fmt.Fprintf(writer, "- %s\n", p)
+	}
+
+	return fmt.Errorf("%w; fix them using \"omm db repair\"", errDBHasProblems)
+}
+
+func repairDB(db *sql.DB, writer io.Writer) error {
+	integrityProblems, err := pers.CheckIntegrity(db)
+	if err != nil {
+		return err
+	}
+
+	if len(integrityProblems) > 0 {
+		return fmt.Errorf("%w, and can't be repaired; restore it from a backup using \"omm db restore\"", errDBIsCorrupted)
+	}
+
+	seqProblems, err := pers.RepairTaskSequence(db)
+	if err != nil {
+		return err
+	}
+
+	if seqProblems.Empty() {
+		fmt.Fprintln(writer, "nothing to repair")
+		return nil
+	}
+
+	for _, p := range seqProblems.Describe() {
+		fmt.Fprintf(writer, "fixed: %s\n", p)
+	}
+
+	return nil
+}
+
+func vacuumDB(db *sql.DB, dbPath string, writer io.Writer) error {
+	before, err := os.Stat(dbPath)
+	if err != nil {
+		return err
+	}
+
+	err = pers.VacuumDB(db)
+	if err != nil {
+		return err
+	}
+
+	after, err := os.Stat(dbPath)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(writer, "database size: %d bytes -> %d bytes\n", before.Size(), after.Size())
+
+	return nil
+}
+
+// openNewerDB opens a database that has been migrated by a newer version of omm
+// in read-only mode, provided this version of omm can read it.
+func openNewerDB(dbPath string) (*sql.DB, error) {
+	db, err := getDB(fmt.Sprintf("file:%s?mode=ro", dbPath))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errCouldntOpenDB, err.Error())
+	}
+
+	_, ok, err := pers.CanReadNewerDB(db)
+	if err != nil || !ok {
+		_ = db.Close()
+		return nil, pers.ErrDBDowngraded
+	}
+
+	return db, nil
+}
+
+func exportCompatibleDB(db *sql.DB, path string, version int, writer io.Writer) error {
+	if filepath.Ext(path) != ".db" {
+		return errDBFileExtIncorrect
+	}
+
+	_, err := os.Stat(path)
+	if err == nil {
+		return fmt.Errorf("%w: %s", errFileAlreadyExists, path)
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return err
+	}
+
+	exportedVersion, err := pers.ExportCompatibleDB(db, path, version)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(writer, "wrote a copy of the database (at version %d) to %s\n", exportedVersion, path)
+
+	return nil
+}
@@ -0,0 +1,224 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	pers "github.com/dhth/omm/internal/persistence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupRetainsLatestBackups(t *testing.T) {
+	// GIVEN
+	dbPath := filepath.Join(t.TempDir(), "omm.db")
+	db, err := setupDB(dbPath)
+	require.NoError(t, err)
+	defer db.Close()
+
+	start := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	// WHEN
+	for i := range 4 {
+		err = backupDB(db, dbPath, "", 2, start.Add(time.Duration(i)*time.Minute), io.Discard)
+		require.NoError(t, err)
+	}
+
+	// THEN
+	backupsDir := backupsDirForDB(dbPath)
+	backups, err := listBackups(backupsDir, dbPath)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(backupsDir, "omm-20260101T100200.000.db"),
+		filepath.Join(backupsDir, "omm-20260101T100300.000.db"),
+	}, backups)
+
+	err = backupDB(db, dbPath, "", 2, start.Add(3*time.Minute), io.Discard)
+	assert.ErrorIs(t, err, errBackupAlreadyExists)
+}
+
+func TestRestoreReplacesTheDatabase(t *testing.T) {
+	// GIVEN
+	dbPath := filepath.Join(t.TempDir(), "omm.db")
+	db, err := setupDB(dbPath)
+	require.NoError(t, err)
+
+	_, err = importTask(db, "task 1")
+	require.NoError(t, err)
+	require.NoError(t, backupDB(db, dbPath, "", backupsToKeepDefault, time.Now(), io.Discard))
+	_, err = importTask(db, "task 2")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	// a database that can't be opened anymore should still be restorable
+	require.NoError(t, os.WriteFile(dbPath, []byte("not a database"), 0o644))
+
+	// WHEN
+	err = restoreDB(dbPath, "", time.Now(), io.Discard)
+
+	// THEN
+	require.NoError(t, err)
+
+	db, err = setupDB(dbPath)
+	require.NoError(t, err)
+	defer db.Close()
+
+	tasks, err := pers.FetchActiveTasks(db, 10)
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "task 1", tasks[0].Summary)
+
+	entries, err := os.ReadDir(backupsDirForDB(dbPath))
+	require.NoError(t, err)
+	assert.Len(t, entries, 2, "the previous database should be kept alongside the backup")
+}
+
+func TestRestoreRejectsInvalidBackups(t *testing.T) {
+	// GIVEN
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "omm.db")
+	backupPath := filepath.Join(tempDir, "backup.db")
+	require.NoError(t, os.WriteFile(backupPath, []byte("not a database"), 0o644))
+
+	// WHEN
+	err := restoreDB(dbPath, backupPath, time.Now(), io.Discard)
+
+	// THEN
+	assert.ErrorIs(t, err, errBackupInvalid)
+
+	err = restoreDB(dbPath, filepath.Join(tempDir, "backups"), time.Now(), io.Discard)
+	assert.ErrorIs(t, err, errNoBackupsFound)
+}
+
+func TestUpgradeDBRollsBackFailedMigrations(t *testing.T) {
+	testCases := []struct {
+		name            string
+		setupQuery      string
+		expectedVersion int
+		expectedErr     error
+	}{
+		{
+			name: "migrations succeed",
+		},
+		{
+			// migration 2 succeeds, but 3 fails since the column it adds exists
+			// already
+			name:            "a migration fails",
+			setupQuery:      "ALTER TABLE task ADD COLUMN uuid TEXT;",
+			expectedVersion: 1,
+			expectedErr:     errDBRolledBack,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			dbPath := filepath.Join(t.TempDir(), "omm.db")
+			db, err := getDB(dbPath)
+			require.NoError(t, err)
+			require.NoError(t, pers.InitDB(db))
+			if tt.setupQuery != "" {
+				_, err = db.Exec(tt.setupQuery)
+				require.NoError(t, err)
+			}
+
+			// WHEN
+			err = upgradeDB(db, dbPath, time.Now())
+
+			// THEN
+			if tt.expectedErr != nil {
+				require.ErrorIs(t, err, tt.expectedErr)
+				require.ErrorIs(t, err, pers.ErrDBMigrationFailed)
+			} else {
+				require.NoError(t, err)
+			}
+			_ = db.Close()
+
+			db, err = getDB(dbPath)
+			require.NoError(t, err)
+			defer db.Close()
+
+			version, pending, err := pers.PendingMigrations(db)
+			require.NoError(t, err)
+
+			if tt.expectedErr == nil {
+				assert.Empty(t, pending)
+			} else {
+				assert.Equal(t, tt.expectedVersion, version)
+				_, err = db.Exec("SELECT context FROM task;")
+				assert.Error(t, err, "migration 2 should've been rolled back")
+			}
+
+			entries, err := os.ReadDir(backupsDirForDB(dbPath))
+			require.NoError(t, err)
+			if tt.expectedErr == nil {
+				assert.Empty(t, entries, "backup should've been removed after a successful migration")
+			} else {
+				require.Len(t, entries, 1)
+				assert.Contains(t, entries[0].Name(), "omm-pre-migration-v1-")
+			}
+		})
+	}
+}
+
+func TestNewerDatabasesAreOpenedReadOnly(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		minCompatibleVersion int
+		args                 []string
+		expectedErr          error
+	}{
+		{
+			name:                 "reading tasks",
+			minCompatibleVersion: 1,
+			args:                 []string{"db", "check"},
+		},
+		{
+			name:                 "adding a task",
+			minCompatibleVersion: 1,
+			args:                 []string{"new task"},
+			expectedErr:          errDBIsReadOnly,
+		},
+		{
+			name:                 "incompatible database",
+			minCompatibleVersion: 1000,
+			args:                 []string{"db", "check"},
+			expectedErr:          pers.ErrDBDowngraded,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			tempDir := t.TempDir()
+			dbPath := filepath.Join(tempDir, "omm.db")
+			db, err := setupDB(dbPath)
+			require.NoError(t, err)
+			_, err = db.Exec("INSERT INTO db_versions (version, created_at) VALUES (1000, ?);", time.Now().Add(time.Minute).UTC())
+			require.NoError(t, err)
+			_, err = db.Exec("INSERT INTO db_compatibility (version, min_compatible_version) VALUES (1000, ?);", tt.minCompatibleVersion)
+			require.NoError(t, err)
+			require.NoError(t, db.Close())
+
+			configPath := filepath.Join(tempDir, "omm.toml")
+			require.NoError(t, os.WriteFile(configPath, nil, 0o644))
+
+			rootCmd, err := NewRootCommand("dev")
+			require.NoError(t, err)
+			rootCmd.SetArgs(append(tt.args, "-c", configPath, "-d", dbPath))
+
+			// WHEN
+			err = rootCmd.Execute()
+
+			// THEN
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/dhth/omm/internal/gitstore"
+)
+
+// gitRepoForDB returns the git repository that holds snapshots of the database
+// at dbPath; it lives next to the database file.
+func gitRepoForDB(dbPath string) gitstore.Repo {
+	dir := filepath.Dir(dbPath)
+	name := strings.TrimSuffix(filepath.Base(dbPath), filepath.Ext(dbPath))
+
+	return gitstore.Repo{Dir: filepath.Join(dir, name+"-git")}
+}
+
+func initGitRepo(db *sql.DB, repo gitstore.Repo, remoteName, remoteURL string, writer io.Writer) error {
+	_, err := repo.Init(db, remoteName, remoteURL)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(writer, "tasks will be snapshotted to the git repository at %s\n", repo.Dir)
+	if remoteURL != "" {
+		fmt.Fprintf(writer, "remote %q set to %s\n", remoteName, remoteURL)
+	}
+
+	return nil
+}
+
+func snapshotToGitRepo(db *sql.DB, repo gitstore.Repo, message string, writer io.Writer) error {
+	committed, err := repo.Commit(db, message)
+	if err != nil {
+		return err
+	}
+
+	if committed {
+		fmt.Fprintln(writer, "snapshot committed")
+	} else {
+		fmt.Fprintln(writer, "nothing changed since the last snapshot")
+	}
+
+	return nil
+}
+
+func syncGitRepo(db *sql.DB, repo gitstore.Repo, remoteName string, writer io.Writer) error {
+	result, err := repo.Sync(db, remoteName)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case result.FastForward:
+		fmt.Fprintf(writer, "fast-forwarded to %s\n", result.RemoteBranch)
+	case result.Merged:
+		fmt.Fprintf(writer, "merged changes from %s\n", result.RemoteBranch)
+	}
+
+	for _, c := range result.Conflicts {
+		fmt.Fprintf(writer, "conflict: %q (%s): %s\n", c.Summary, c.UUID, c.Reason)
+	}
+
+	if result.Pushed {
+		fmt.Fprintf(writer, "pushed to %s\n", result.RemoteBranch)
+	}
+
+	if !result.FastForward && !result.Merged && !result.Pushed {
+		fmt.Fprintln(writer, "already up to date")
+	}
+
+	return nil
+}
+
+// snapshotMu prevents automatic snapshots (which the TUI takes in the
+// background) from running concurrently.
+var snapshotMu sync.Mutex
+
+func commitSnapshot(db *sql.DB, repo gitstore.Repo) error {
+	snapshotMu.Lock()
+	defer snapshotMu.Unlock()
+
+	_, err := repo.Commit(db, "snapshot")
+	return err
+}
+
+// autoSnapshot commits a snapshot of the database if the user has set up a git
+// repository for it. Failing to do so is not fatal; the change has already
+// been saved to the database, and will be part of the next snapshot.
+func autoSnapshot(db *sql.DB, dbPath string, writer io.Writer) {
+	repo := gitRepoForDB(dbPath)
+	if !repo.Exists() {
+		return
+	}
+
+	err := commitSnapshot(db, repo)
+	if err != nil {
+		fmt.Fprintf(writer, "Warning: couldn't snapshot tasks to %s: %s\n", repo.Dir, err.Error())
+	}
+}
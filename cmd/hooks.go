@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/dhth/omm/internal/hooks"
+	pers "github.com/dhth/omm/internal/persistence"
+	"github.com/dhth/omm/internal/types"
+)
+
+// runCreateHooks runs the on_create hook for numTasks tasks inserted in one
+// go, the last of which has the ID lastID. Failures are reported as warnings;
+// the tasks have been saved regardless.
+func runCreateHooks(db *sql.DB, hooksCfg hooks.Config, lastID int64, numTasks int, writer io.Writer) {
+	if !hooksCfg.IsSet(hooks.EventCreate) {
+		return
+	}
+
+	for id := lastID - int64(numTasks) + 1; id <= lastID; id++ {
+		task, err := pers.FetchTaskByID(db, uint64(id))
+		if err == nil {
+			err = hooksCfg.Run(hooks.EventCreate, task)
+		}
+		if err != nil {
+			fmt.Fprintf(writer, "Warning: %s\n", err.Error())
+		}
+	}
+}
+
+// runWithHooks runs a command that can change any number of tasks in ways that
+// aren't known upfront (eg. an import, or a sync), and then runs hooks for the
+// changes it made. These are worked out by comparing all tasks before and after
+// the command ran. Failures of hooks are reported as warnings.
+func runWithHooks(db *sql.DB, hooksCfg hooks.Config, writer io.Writer, run func() error) error {
+	anySet := slices.ContainsFunc(
+		[]hooks.Event{hooks.EventCreate, hooks.EventUpdate, hooks.EventArchive, hooks.EventUnarchive, hooks.EventDelete},
+		hooksCfg.IsSet,
+	)
+	if !anySet {
+		return run()
+	}
+
+	before, err := fetchTasksByID(db)
+	if err != nil {
+		return err
+	}
+
+	runErr := run()
+
+	after, err := fetchTasksByID(db)
+	if err != nil {
+		fmt.Fprintf(writer, "Warning: couldn't run hooks: %s\n", err.Error())
+		return runErr
+	}
+
+	for _, change := range getTaskChanges(before, after) {
+		err = hooksCfg.Run(change.event, change.task)
+		if err != nil {
+			fmt.Fprintf(writer, "Warning: %s\n", err.Error())
+		}
+	}
+
+	return runErr
+}
+
+// fetchTasksByID fetches all tasks (active, snoozed, and archived).
+func fetchTasksByID(db *sql.DB) (map[uint64]types.Task, error) {
+	activeTasks, err := pers.FetchAllActiveTasks(db)
+	if err != nil {
+		return nil, err
+	}
+
+	archivedTasks, err := pers.FetchInActiveTasks(db, pers.TaskNumLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := make(map[uint64]types.Task, len(activeTasks)+len(archivedTasks))
+	for _, t := range append(activeTasks, archivedTasks...) {
+		tasks[t.ID] = t
+	}
+
+	return tasks, nil
+}
+
+type taskChange struct {
+	event hooks.Event
+	task  types.Task
+}
+
+// getTaskChanges returns the lifecycle events that turn one set of tasks into
+// another, in the order of the tasks' IDs. A task whose summary (or context)
+// changed along with its status gets both an update, and an archive/unarchive
+// event. Deleted tasks are passed on as they were before being deleted.
+func getTaskChanges(before, after map[uint64]types.Task) []taskChange {
+	ids := make([]uint64, 0, len(before)+len(after))
+	for id := range before {
+		ids = append(ids, id)
+	}
+	for id := range after {
+		if _, ok := before[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+
+	var changes []taskChange
+	for _, id := range ids {
+		old, existed := before[id]
+		t, exists := after[id]
+
+		switch {
+		case !existed:
+			changes = append(changes, taskChange{hooks.EventCreate, t})
+		case !exists:
+			changes = append(changes, taskChange{hooks.EventDelete, old})
+		default:
+			if t.Summary != old.Summary || !equalContexts(t.Context, old.Context) {
+				changes = append(changes, taskChange{hooks.EventUpdate, t})
+			}
+
+			if t.Active != old.Active {
+				event := hooks.EventArchive
+				if t.Active {
+					event = hooks.EventUnarchive
+				}
+				changes = append(changes, taskChange{event, t})
+			}
+		}
+	}
+
+	return changes
+}
+
+func equalContexts(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return *a == *b
+}
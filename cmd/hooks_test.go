@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dhth/omm/internal/hooks"
+	pers "github.com/dhth/omm/internal/persistence"
+	"github.com/dhth/omm/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetTaskChanges(t *testing.T) {
+	// GIVEN
+	context := "context"
+	newContext := "new context"
+	before := map[uint64]types.Task{
+		1: {ID: 1, Summary: "unchanged", Active: true},
+		2: {ID: 2, Summary: "summary", Active: true},
+		3: {ID: 3, Summary: "context", Context: &context, Active: true},
+		4: {ID: 4, Summary: "archived", Active: true},
+		5: {ID: 5, Summary: "unarchived", Active: false},
+		6: {ID: 6, Summary: "renamed and archived", Active: true},
+		7: {ID: 7, Summary: "deleted", Active: true},
+	}
+	after := map[uint64]types.Task{
+		1: {ID: 1, Summary: "unchanged", Active: true},
+		2: {ID: 2, Summary: "new summary", Active: true},
+		3: {ID: 3, Summary: "context", Context: &newContext, Active: true},
+		4: {ID: 4, Summary: "archived", Active: false},
+		5: {ID: 5, Summary: "unarchived", Active: true},
+		6: {ID: 6, Summary: "renamed", Active: false},
+		8: {ID: 8, Summary: "created", Active: true},
+	}
+
+	// WHEN
+	got := getTaskChanges(before, after)
+
+	// THEN
+	expected := []taskChange{
+		{hooks.EventUpdate, after[2]},
+		{hooks.EventUpdate, after[3]},
+		{hooks.EventArchive, after[4]},
+		{hooks.EventUnarchive, after[5]},
+		{hooks.EventUpdate, after[6]},
+		{hooks.EventArchive, after[6]},
+		{hooks.EventDelete, before[7]},
+		{hooks.EventCreate, after[8]},
+	}
+	assert.Equal(t, expected, got)
+}
+
+func TestRunWithHooksRunsHooksForChangedTasks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks in this test are POSIX shell commands")
+	}
+
+	// GIVEN
+	tempDir := t.TempDir()
+	db, err := setupDB(filepath.Join(tempDir, "omm.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	for _, summary := range []string{"ops: task 1", "home: task 2", "ops: task 3"} {
+		_, err = importTask(db, summary)
+		require.NoError(t, err)
+	}
+
+	logPath := filepath.Join(tempDir, "hooks.log")
+	command := fmt.Sprintf(`echo "$OMM_HOOK_EVENT $OMM_TASK_ID" >> %q`, logPath)
+	hooksCfg := hooks.Config{OnCreate: command, OnUpdate: command}
+
+	// WHEN
+	err = runWithHooks(db, hooksCfg, io.Discard, func() error {
+		return renamePrefix(db, "ops", "infra", time.Now(), io.Discard)
+	})
+
+	// THEN
+	require.NoError(t, err)
+	log, err := os.ReadFile(logPath)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"update 1", "update 3"}, strings.Split(strings.TrimSpace(string(log)), "\n"))
+
+	task, err := pers.FetchTaskByID(db, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "infra: task 1", task.Summary)
+}
@@ -12,13 +12,13 @@ import (
 
 var errWillExceedCapacity = errors.New("import will exceed capacity")
 
-func importTask(db *sql.DB, taskSummary string) error {
-	numTasks, err := pers.FetchNumActiveTasksShown(db)
+func importTask(db *sql.DB, taskSummary string) (int64, error) {
+	numTasks, err := pers.FetchNumActiveTasks(db)
 	if err != nil {
-		return err
+		return -1, err
 	}
 	if numTasks+1 > pers.TaskNumLimit {
-		return fmt.Errorf("%w (current task count: %d)", errWillExceedCapacity, numTasks)
+		return -1, fmt.Errorf("%w (current task count: %d)", errWillExceedCapacity, numTasks)
 	}
 
 	now := time.Now()
@@ -28,17 +28,16 @@ func importTask(db *sql.DB, taskSummary string) error {
 		CreatedAt: now,
 		UpdatedAt: now,
 	}
-	_, err = pers.InsertTasks(db, []types.Task{task}, true)
-	return err
+	return pers.InsertTasks(db, []types.Task{task}, true)
 }
 
-func importTasks(db *sql.DB, taskSummaries []string) error {
-	numTasks, err := pers.FetchNumActiveTasksShown(db)
+func importTasks(db *sql.DB, taskSummaries []string) (int64, error) {
+	numTasks, err := pers.FetchNumActiveTasks(db)
 	if err != nil {
-		return err
+		return -1, err
 	}
 	if numTasks+len(taskSummaries) > pers.TaskNumLimit {
-		return fmt.Errorf("%w (current task count: %d)", errWillExceedCapacity, numTasks)
+		return -1, fmt.Errorf("%w (current task count: %d)", errWillExceedCapacity, numTasks)
 	}
 
 	now := time.Now()
@@ -51,6 +50,5 @@ func importTasks(db *sql.DB, taskSummaries []string) error {
 			UpdatedAt: now,
 		}
 	}
-	_, err = pers.InsertTasks(db, tasks, true)
-	return err
+	return pers.InsertTasks(db, tasks, true)
 }
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"time"
+
+	pers "github.com/dhth/omm/internal/persistence"
+)
+
+func renamePrefix(db *sql.DB, from, to string, now time.Time, writer io.Writer) error {
+	numChanged, err := pers.RenamePrefix(db, from, to, now)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(writer, "renamed prefix in %d task(s)\n", numChanged)
+	return nil
+}
+
+func mergePrefixes(db *sql.DB, from, into string, now time.Time, writer io.Writer) error {
+	numChanged, err := pers.MergePrefixes(db, from, into, now)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(writer, "merged prefix in %d task(s)\n", numChanged)
+	return nil
+}
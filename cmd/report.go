@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/dhth/omm/internal/report"
+)
+
+var (
+	errSinceNeededForCustomPeriod = errors.New("--since is needed for a custom period")
+	errRangeOnlyForCustomPeriod   = errors.New("--since and --until can only be used with a custom period")
+	errUntilInvalid               = errors.New("until needs to be a date (eg. 2026-01-31), or a number of days/weeks (eg. 30d, 4w)")
+	errPeriodEmpty                = errors.New("the end of the period needs to be after its start")
+)
+
+// parseUntil parses the end of a period; a date includes the whole of that
+// day.
+func parseUntil(value string, now time.Time) (time.Time, error) {
+	t, err := parseSince(value, now)
+	if err != nil {
+		return time.Time{}, errUntilInvalid
+	}
+
+	_, err = time.ParseInLocation(time.DateOnly, value, now.Location())
+	if err == nil {
+		return t.AddDate(0, 0, 1), nil
+	}
+
+	return t, nil
+}
+
+// getReportRange returns the start and (exclusive) end of the period a report
+// covers.
+func getReportRange(period report.Period, since, until string, now time.Time) (time.Time, time.Time, error) {
+	if period != report.Custom {
+		if since != "" || until != "" {
+			return time.Time{}, time.Time{}, errRangeOnlyForCustomPeriod
+		}
+
+		return period.Start(now), now, nil
+	}
+
+	if since == "" {
+		return time.Time{}, time.Time{}, errSinceNeededForCustomPeriod
+	}
+
+	start, err := parseSince(since, now)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	end := now
+	if until != "" {
+		end, err = parseUntil(until, now)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+	}
+
+	if !end.After(start) {
+		return time.Time{}, time.Time{}, errPeriodEmpty
+	}
+
+	return start, end, nil
+}
+
+func printReport(db *sql.DB, opts report.Options, outputPath string, writer io.Writer) error {
+	r, err := report.Load(db, opts)
+	if err != nil {
+		return err
+	}
+
+	md := report.Markdown(r)
+
+	if outputPath == "" {
+		fmt.Fprint(writer, md)
+		return nil
+	}
+
+	err = os.WriteFile(outputPath, []byte(md), 0o644)
+	if err != nil {
+		return fmt.Errorf("couldn't write report: %w", err)
+	}
+
+	return nil
+}
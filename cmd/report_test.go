@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dhth/omm/internal/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetReportRange(t *testing.T) {
+	// a wednesday
+	now := time.Date(2026, 3, 11, 12, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name          string
+		period        report.Period
+		since         string
+		until         string
+		expectedSince time.Time
+		expectedUntil time.Time
+		err           error
+	}{
+		{
+			name:          "today",
+			period:        report.Today,
+			expectedSince: time.Date(2026, 3, 11, 0, 0, 0, 0, time.UTC),
+			expectedUntil: now,
+		},
+		{
+			name:          "week",
+			period:        report.Week,
+			expectedSince: time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC),
+			expectedUntil: now,
+		},
+		{
+			name:          "custom period with dates",
+			period:        report.Custom,
+			since:         "2026-03-02",
+			until:         "2026-03-06",
+			expectedSince: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC),
+			expectedUntil: time.Date(2026, 3, 7, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:          "custom period till now",
+			period:        report.Custom,
+			since:         "3d",
+			expectedSince: time.Date(2026, 3, 8, 12, 0, 0, 0, time.UTC),
+			expectedUntil: now,
+		},
+		{
+			name:   "custom period without a start",
+			period: report.Custom,
+			until:  "2026-03-06",
+			err:    errSinceNeededForCustomPeriod,
+		},
+		{
+			name:   "custom period ending before it starts",
+			period: report.Custom,
+			since:  "2026-03-06",
+			until:  "2026-03-02",
+			err:    errPeriodEmpty,
+		},
+		{
+			name:   "range with a period that's not custom",
+			period: report.Week,
+			since:  "2026-03-02",
+			err:    errRangeOnlyForCustomPeriod,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			gotSince, gotUntil, err := getReportRange(tt.period, tt.since, tt.until, now)
+
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedSince, gotSince)
+			assert.Equal(t, tt.expectedUntil, gotUntil)
+		})
+	}
+}
@@ -13,10 +13,16 @@ import (
 	"strings"
 	"time"
 
+	"github.com/dhth/omm/internal/hooks"
+	"github.com/dhth/omm/internal/mcp"
 	pers "github.com/dhth/omm/internal/persistence"
+	"github.com/dhth/omm/internal/query"
+	"github.com/dhth/omm/internal/report"
+	"github.com/dhth/omm/internal/stats"
 	"github.com/dhth/omm/internal/types"
 	"github.com/dhth/omm/internal/ui"
 	"github.com/dhth/omm/internal/ui/theme"
+	"github.com/dhth/omm/internal/views"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
@@ -46,11 +52,15 @@ var (
 	errMaxImportLimitExceeded   = errors.New("import limit exceeded")
 	errNothingToImport          = errors.New("nothing to import")
 	errListDensityIncorrect     = errors.New("list density is incorrect; valid values: compact/spacious")
+	errFilterModeIncorrect      = errors.New("filter mode is incorrect; valid values: prefix/summary/context")
+	errQueryInvalid             = errors.New("query is invalid")
 	errCouldntCreateDBDirectory = errors.New("couldn't create directory for database")
 	errCouldntCreateDB          = errors.New("couldn't create database")
 	errCouldntInitializeDB      = errors.New("couldn't initialize database")
 	errCouldntOpenDB            = errors.New("couldn't open database")
 	errCouldntSetupGuide        = errors.New("couldn't set up guided walkthrough")
+	errHookTimeoutInvalid       = errors.New("hook_timeout needs to be a positive duration (eg. 5s)")
+	errDBIsReadOnly             = errors.New("the database was migrated by a newer version of omm, and can only be read by this version; upgrade omm to make changes")
 
 	//go:embed assets/CHANGELOG.md
 	updateContents string
@@ -61,6 +71,8 @@ Archive/Delete tasks that are not active using ctrl+d/ctrl+x.
 
 `, importTasksLimit)
 
+	readOnlyDBMsg = "Warning: the database was migrated by a newer version of omm; opening it in read-only mode"
+
 	taskCapacityMsg = fmt.Sprintf(`A maximum of %d tasks that can be active at a time.
 Archive/Delete tasks that are not active using ctrl+d/ctrl+x.
 
@@ -107,8 +119,9 @@ func setupDB(dbPathFull string) (*sql.DB, error) {
 		if err != nil {
 			return nil, fmt.Errorf("%w: %s", errCouldntOpenDB, err.Error())
 		}
-		err = pers.UpgradeDBIfNeeded(db)
+		err = upgradeDB(db, dbPathFull, time.Now())
 		if err != nil {
+			_ = db.Close()
 			return nil, err
 		}
 	}
@@ -127,11 +140,39 @@ func NewRootCommand(version string) (*cobra.Command, error) {
 		printTasksNum         uint8
 		taskListTitle         string
 		listDensityFlagInp    string
+		filterModeFlagInp     string
 		editorFlagInp         string
 		editorCmd             string
 		showContextFlagInp    bool
 		confirmBeforeDeletion bool
 		circularNav           bool
+		boardColumnsFlagInp   string
+		syncDir               string
+		gitRemoteName         string
+		gitRemoteURL          string
+		serveAddr             string
+		serveAuthToken        string
+		mcpReadOnly           bool
+		dbReadOnly            bool
+		configInitForce       bool
+		backupsToKeep         uint
+		migrateStatus         bool
+		migrateDryRun         bool
+		exportDBVersion       int
+		statsSince            string
+		statsPrefix           string
+		statsInterval         string
+		statsJSON             bool
+		reportPeriod          string
+		reportSince           string
+		reportUntil           string
+		reportNumTop          int
+		reportOutputPath      string
+		fileCfg               fileConfig
+		viewName              string
+		taskQuery             string
+		includeSnoozed        bool
+		stripPrefixes         bool
 	)
 
 	rootCmd := &cobra.Command{
@@ -149,8 +190,8 @@ Tip: Quickly add a task using 'omm "task summary goes here"'.
 		SilenceUsage:  true,
 		SilenceErrors: true,
 		Version:       version,
-		PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
-			if cmd.CalledAs() == "updates" {
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if !needsDB(cmd) {
 				return nil
 			}
 
@@ -169,7 +210,7 @@ Tip: Quickly add a task using 'omm "task summary goes here"'.
 				}
 			}
 
-			err = initializeConfig(cmd, configPathFull)
+			fileCfg, err = initializeConfig(cmd, configPathFull)
 			if err != nil {
 				return err
 			}
@@ -188,6 +229,20 @@ Tip: Quickly add a task using 'omm "task summary goes here"'.
 			}
 
 			db, err = setupDB(dbPathFull)
+			if errors.Is(err, pers.ErrDBDowngraded) {
+				db, err = openNewerDB(dbPathFull)
+				if err == nil {
+					dbReadOnly = true
+					if !worksWithReadOnlyDB(cmd, args) {
+						_ = db.Close()
+						return errDBIsReadOnly
+					}
+					if cmd.HasParent() {
+						fmt.Fprintln(os.Stderr, readOnlyDBMsg)
+					}
+				}
+			}
+
 			switch {
 			case errors.Is(err, errCouldntCreateDB):
 				fmt.Fprintf(os.Stderr, `Couldn't create omm's local database. This is a fatal error.
@@ -209,19 +264,39 @@ Clean up error: %s
 				}
 			case errors.Is(err, errCouldntOpenDB):
 				fmt.Fprintf(os.Stderr, `Couldn't open omm's local database. This is a fatal error.
+If you've backed it up before (using "omm db backup"), you can restore the
+latest backup using "omm db restore".
+
 %s
 
 `, reportIssueMsg)
 			case errors.Is(err, pers.ErrCouldntFetchDBVersion):
 				fmt.Fprintf(os.Stderr, `Couldn't get omm's latest database version. This is a fatal error.
+If you've backed it up before (using "omm db backup"), you can restore the
+latest backup using "omm db restore".
+
 %s
 
 `, reportIssueMsg)
 			case errors.Is(err, pers.ErrDBDowngraded):
-				fmt.Fprintf(os.Stderr, `Looks like you downgraded omm. You should either delete omm's database file (you
-will lose data by doing that), or upgrade omm to the latest version.
+				fmt.Fprintf(os.Stderr, `Looks like you downgraded omm. The database was migrated by a newer version of omm
+in a way that this version can't work with. You can either upgrade omm to the
+latest version, or use the newer version to export a copy of the database that
+this version can work with (using "omm db export-compatible").
+
+%s
+
+`, reportIssueMsg)
+			case errors.Is(err, pers.ErrDBMigrationFailed) && errors.Is(err, errDBRolledBack):
+				fmt.Fprintf(os.Stderr, `Something went wrong migrating omm's database. This is not supposed to happen.
+omm backed up the database before migrating it, and has rolled it back to how it
+was, so no data was lost. You can keep using the previous version of omm till
+this is fixed.
 
 %s
+Sorry for breaking the upgrade step!
+
+---
 
 `, reportIssueMsg)
 			case errors.Is(err, pers.ErrDBMigrationFailed):
@@ -229,7 +304,8 @@ will lose data by doing that), or upgrade omm to the latest version.
 You can try running omm by passing it a custom database file path (using
 --db-path; this will create a new database) to see if that fixes things. If that
 works, you can either delete the previous database, or keep using this new
-database (both are not ideal).
+database (both are not ideal). If you've backed it up before (using
+"omm db backup"), you can also restore the latest backup using "omm db restore".
 
 %s
 Sorry for breaking the upgrade step!
@@ -245,6 +321,13 @@ Sorry for breaking the upgrade step!
 
 			return nil
 		},
+		PersistentPostRun: func(cmd *cobra.Command, _ []string) {
+			if !needsDB(cmd) || dbReadOnly || cmd.CalledAs() == "guide" || cmd.HasParent() && cmd.Parent().Name() == "git" {
+				return
+			}
+
+			autoSnapshot(db, dbPathFull, os.Stderr)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if len(args) != 0 {
 				summaryValid, err := types.CheckIfTaskSummaryValid(args[0])
@@ -252,7 +335,7 @@ Sorry for breaking the upgrade step!
 					return fmt.Errorf("%w", err)
 				}
 
-				err = importTask(db, args[0])
+				lastID, err := importTask(db, args[0])
 				if errors.Is(err, errWillExceedCapacity) {
 					fmt.Fprint(os.Stderr, taskCapacityMsg)
 				}
@@ -260,6 +343,8 @@ Sorry for breaking the upgrade step!
 				if err != nil {
 					return err
 				}
+
+				runCreateHooks(db, fileCfg.hooks, lastID, 1, os.Stderr)
 				return nil
 			}
 
@@ -280,6 +365,18 @@ Sorry for breaking the upgrade step!
 				return errListDensityIncorrect
 			}
 
+			var fm ui.FilterModeType
+			switch filterModeFlagInp {
+			case ui.FilterPrefixVal:
+				fm = ui.FilterPrefix
+			case ui.FilterSummaryVal:
+				fm = ui.FilterSummary
+			case ui.FilterContextVal:
+				fm = ui.FilterSummaryAndContext
+			default:
+				return errFilterModeIncorrect
+			}
+
 			if len(taskListTitle) > taskListTitleMaxLen {
 				taskListTitle = taskListTitle[:taskListTitleMaxLen]
 			}
@@ -289,14 +386,41 @@ Sorry for breaking the upgrade step!
 				return themeErr
 			}
 
+			boardColumns, err := ui.ParseBoardColumns(boardColumnsFlagInp)
+			if err != nil {
+				return err
+			}
+
 			config := ui.Config{
 				DBPath:                dbPathFull,
 				ListDensity:           ld,
+				FilterMode:            fm,
 				TaskListTitle:         taskListTitle,
 				TextEditorCmd:         strings.Fields(editorCmd),
 				ShowContext:           showContextFlagInp,
 				ConfirmBeforeDeletion: confirmBeforeDeletion,
 				CircularNav:           circularNav,
+				BoardColumns:          boardColumns,
+				Hooks:                 fileCfg.hooks,
+				Views:                 fileCfg.views,
+				PrefixColors:          fileCfg.prefixColors,
+				ReadOnly:              dbReadOnly,
+			}
+
+			if !dbReadOnly {
+				_, err = pers.WakeSnoozedTasks(db, time.Now())
+				if err != nil {
+					return err
+				}
+
+				// changes made via the TUI are snapshotted as they happen (rather
+				// than once it's closed), the same way other commands' changes are
+				repo := gitRepoForDB(dbPathFull)
+				if repo.Exists() {
+					config.Snapshot = func() error {
+						return commitSnapshot(db, repo)
+					}
+				}
 			}
 
 			ui.RenderUI(db, config, thm)
@@ -336,7 +460,7 @@ Sorry for breaking the upgrade step!
 				return errNothingToImport
 			}
 
-			err := importTasks(db, tasks)
+			lastID, err := importTasks(db, tasks)
 			if errors.Is(err, errWillExceedCapacity) {
 				fmt.Fprint(os.Stderr, taskCapacityMsg)
 			}
@@ -344,6 +468,7 @@ Sorry for breaking the upgrade step!
 				return err
 			}
 
+			runCreateHooks(db, fileCfg.hooks, lastID, len(tasks), os.Stderr)
 			return nil
 		},
 	}
@@ -351,8 +476,571 @@ Sorry for breaking the upgrade step!
 	tasksCmd := &cobra.Command{
 		Use:   "tasks",
 		Short: "Output tasks tracked by omm to stdout",
+		Example: `omm tasks -n 20
+omm tasks --view work
+omm tasks --query 'prefix:infra AND age>7d AND text~"deploy"'
+omm tasks --include-snoozed`,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if !dbReadOnly {
+				_, err := pers.WakeSnoozedTasks(db, time.Now())
+				if err != nil {
+					return err
+				}
+			}
+
+			var q *query.Query
+			switch {
+			case viewName != "":
+				v, err := getView(db, fileCfg.views, viewName)
+				if err != nil {
+					return err
+				}
+				vq := v.Query()
+				q = &vq
+			case taskQuery != "":
+				pq, err := query.Parse(taskQuery)
+				if err != nil {
+					return fmt.Errorf("%w: %w", errQueryInvalid, err)
+				}
+				q = &pq
+			}
+
+			return printTasks(db, printTasksNum, q, includeSnoozed, os.Stdout)
+		},
+	}
+
+	statsCmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Show statistics about tasks",
+		Long: `Show statistics about tasks.
+
+Shows the number of tasks created and archived over time, the age of active
+tasks, and counts per prefix. omm doesn't record when a task was archived, so
+the time it was last updated is used instead.
+`,
+		Example: `omm stats --since 30d --interval day
+omm stats --since 2026-01-01 --prefix work --json`,
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			since, err := parseSince(statsSince, time.Now())
+			if err != nil {
+				return err
+			}
+
+			interval, err := stats.ParseInterval(statsInterval)
+			if err != nil {
+				return err
+			}
+
+			opts := stats.Options{
+				Since:    since,
+				Prefix:   statsPrefix,
+				Interval: interval,
+			}
+
+			return printStats(db, opts, statsJSON, os.Stdout)
+		},
+	}
+
+	reportCmd := &cobra.Command{
+		Use:   "report",
+		Short: "Generate a markdown digest of tasks",
+		Long: `Generate a markdown digest of tasks.
+
+The digest lists the tasks archived, created, and whose context was updated in
+a period, along with the current top tasks by priority, grouped by prefix.
+Periods are either "today", "week" (starting on monday), or "custom" (set via
+--since and --until). omm doesn't record when a task was archived, so the time
+it was last updated is used instead.
+`,
+		Example: `omm report
+omm report --period week --top 10
+omm report --period custom --since 2026-01-05 --until 2026-01-09 -o digest.md`,
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			period, err := report.ParsePeriod(reportPeriod)
+			if err != nil {
+				return err
+			}
+
+			since, until, err := getReportRange(period, reportSince, reportUntil, time.Now())
+			if err != nil {
+				return err
+			}
+
+			opts := report.Options{
+				Since:  since,
+				Until:  until,
+				NumTop: reportNumTop,
+			}
+
+			return printReport(db, opts, expandTilde(reportOutputPath), os.Stdout)
+		},
+	}
+
+	taskwarriorCmd := &cobra.Command{
+		Use:     "taskwarrior",
+		Aliases: []string{"tw"},
+		Short:   "Move tasks between omm and taskwarrior",
+	}
+
+	taskwarriorImportCmd := &cobra.Command{
+		Use:   "import",
+		Short: "Import the output of taskwarrior's \"task export\" from stdin",
+		Long: `Import the output of taskwarrior's "task export" from stdin.
+
+Projects are turned into prefixes, annotations into context, and completed tasks
+are archived. Pending tasks are added to the top of the list, ordered by their
+urgency. Tasks that were imported (or exported) before are matched using their
+UUIDs, and are updated in place.
+`,
+		Example: "task export | omm taskwarrior import",
+		Args:    cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			err := runWithHooks(db, fileCfg.hooks, os.Stderr, func() error {
+				return importTaskwarriorTasks(db, os.Stdin, os.Stdout)
+			})
+			if errors.Is(err, errWillExceedCapacity) {
+				fmt.Fprint(os.Stderr, taskCapacityMsg)
+			}
+
+			return err
+		},
+	}
+
+	taskwarriorExportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export tasks as taskwarrior compatible JSON to stdout",
+		Long: `Export tasks as taskwarrior compatible JSON to stdout.
+
+Each task's UUID is retained, which means the output can be fed to taskwarrior's
+"task import" (or back to "omm taskwarrior import") any number of times without
+creating duplicates.
+`,
+		Example: "omm taskwarrior export | task import",
+		Args:    cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return exportTaskwarriorTasks(db, os.Stdout)
+		},
+	}
+
+	syncCmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Sync tasks with a directory of markdown files",
+		Long: `Sync tasks with a directory of markdown files.
+
+Each task is mirrored as a markdown file, with the task's details in its front
+matter, and its context as the file's body. Changes made on either side since
+the last sync are applied to the other. New files (with a "summary" in their
+front matter) are added as tasks, and deleting a file deletes its task (and vice
+versa).
+
+When a task and its file have both changed since the last sync, the conflict is
+reported, and neither side is modified.
+`,
+		Example: "omm sync --dir ~/notes/omm",
+		Args:    cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return runWithHooks(db, fileCfg.hooks, os.Stderr, func() error {
+				return syncWithDir(db, expandTilde(syncDir), os.Stdout)
+			})
+		},
+	}
+
+	tagsCmd := &cobra.Command{
+		Use:   "tags",
+		Short: "Manage tags added to tasks",
+		Long: `Manage tags added to tasks.
+
+Tags are added to a task by mentioning them in its summary (eg. "fix login flow
+#auth #urgent"). A task can have any number of tags; they can be renamed,
+merged, and given colors via the TUI.
+`,
+	}
+
+	tagsListCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List tags, along with the number of tasks that have them",
+		Args:  cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return printTags(db, os.Stdout)
+		},
+	}
+
+	tagsFromPrefixesCmd := &cobra.Command{
+		Use:   "from-prefixes",
+		Short: "Add tags corresponding to the prefixes of tasks",
+		Long: `Add tags corresponding to the prefixes of tasks.
+
+Each task with a prefix gets a tag named after it (eg. "side project: add
+readme" becomes "side project: add readme #side-project"). Prefixes can be
+removed in the process via --strip-prefixes. If a summary would become invalid
+(eg. too long) as a result, no tasks are changed.
+`,
+		Example: "omm tags from-prefixes --strip-prefixes",
+		Args:    cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return runWithHooks(db, fileCfg.hooks, os.Stderr, func() error {
+				return addTagsFromPrefixes(db, stripPrefixes, time.Now(), os.Stdout)
+			})
+		},
+	}
+
+	prefixCmd := &cobra.Command{
+		Use:   "prefix",
+		Short: "Manage prefixes of tasks",
+		Long: `Manage prefixes of tasks.
+
+Changes apply to the summaries of all tasks (active and archived) with a prefix,
+and are made in a single transaction: if any summary would become invalid (eg.
+too long) as a result, no tasks are changed.
+`,
+	}
+
+	prefixRenameCmd := &cobra.Command{
+		Use:   "rename <old> <new>",
+		Short: "Rename a prefix",
+		Long: `Rename a prefix.
+
+Fails if the new prefix is already in use; use "omm prefix merge" to combine two
+prefixes.
+`,
+		Example: "omm prefix rename infra platform",
+		Args:    cobra.ExactArgs(2),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return runWithHooks(db, fileCfg.hooks, os.Stderr, func() error {
+				return renamePrefix(db, args[0], args[1], time.Now(), os.Stdout)
+			})
+		},
+	}
+
+	prefixMergeCmd := &cobra.Command{
+		Use:     "merge <from> <into>",
+		Short:   "Replace a prefix with another one",
+		Example: "omm prefix merge ops infra",
+		Args:    cobra.ExactArgs(2),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return runWithHooks(db, fileCfg.hooks, os.Stderr, func() error {
+				return mergePrefixes(db, args[0], args[1], time.Now(), os.Stdout)
+			})
+		},
+	}
+
+	gitCmd := &cobra.Command{
+		Use:   "git",
+		Short: "Keep a history of tasks in a git repository, and sync it via a remote",
+		Long: `Keep a history of tasks in a git repository, and sync it via a remote.
+
+Once set up (using "omm git init"), a snapshot of omm's tasks is committed to a
+git repository (which lives next to omm's database) after every change (the TUI
+does so a second after a change; "omm serve" and "omm mcp" do so when they
+exit). Tasks are stored as one line of JSON each, sorted by their UUIDs, which keeps diffs
+small and readable.
+
+"omm git sync" merges snapshots from a remote (any git remote works, including a
+local bare repository) with the local ones, updates the database accordingly,
+and pushes the result. Changes to different tasks (or different fields of the
+same task) are combined; when both sides changed the same field, the most recent
+change wins, and the conflict is reported.
+`,
+	}
+
+	gitInitCmd := &cobra.Command{
+		Use:     "init",
+		Short:   "Set up a git repository for snapshots of tasks",
+		Example: "omm git init --remote git@github.com:user/omm-tasks.git",
+		Args:    cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return initGitRepo(db, gitRepoForDB(dbPathFull), gitRemoteName, gitRemoteURL, os.Stdout)
+		},
+	}
+
+	gitSnapshotCmd := &cobra.Command{
+		Use:   "snapshot",
+		Short: "Commit a snapshot of tasks to the git repository",
+		Args:  cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return snapshotToGitRepo(db, gitRepoForDB(dbPathFull), "snapshot", os.Stdout)
+		},
+	}
+
+	gitSyncCmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Merge snapshots from a git remote, and push local ones to it",
+		Args:  cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return runWithHooks(db, fileCfg.hooks, os.Stderr, func() error {
+				return syncGitRepo(db, gitRepoForDB(dbPathFull), gitRemoteName, os.Stdout)
+			})
+		},
+	}
+
+	gitRestoreCmd := &cobra.Command{
+		Use:   "restore <revision>",
+		Short: "Rebuild tasks from a snapshot in the git repository",
+		Long: `Rebuild tasks from a snapshot in the git repository.
+
+The revision can be anything git understands (a commit hash, HEAD~2, etc.). The
+restored state is committed as a new snapshot, so restoring can itself be undone.
+`,
+		Example: "omm git restore HEAD~1",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return runWithHooks(db, fileCfg.hooks, os.Stderr, func() error {
+				return gitRepoForDB(dbPathFull).Restore(db, args[0])
+			})
+		},
+	}
+
+	gitLogCmd := &cobra.Command{
+		Use:   "log",
+		Short: "Show recent snapshots in the git repository",
+		Args:  cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			log, err := gitRepoForDB(dbPathFull).Log(20)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintln(os.Stdout, log)
+			return nil
+		},
+	}
+
+	serveCmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve a JSON API for reading and modifying tasks over HTTP",
+		Long: `Serve a JSON API for reading and modifying tasks over HTTP.
+
+Endpoints:
+  GET    /api/tasks?status=active|archived&prefix=<prefix>
+  POST   /api/tasks                  {"summary": "...", "context": "..."}
+  GET    /api/tasks/{id}
+  PATCH  /api/tasks/{id}             {"summary": "...", "context": "..."}
+  DELETE /api/tasks/{id}
+  POST   /api/tasks/{id}/archive
+  POST   /api/tasks/{id}/unarchive
+  POST   /api/tasks/{id}/move        {"position": 0}
+
+New (and unarchived) tasks are added to the top of the list. Setting a task's
+context to "" removes it. Errors are returned as {"error": "..."}.
+
+If an auth token is set (via --auth-token, or OMM_AUTH_TOKEN), requests need to
+send it in an "Authorization: Bearer <token>" header.
+`,
+		Example: "omm serve --addr 127.0.0.1:8080 --auth-token secret",
+		Args:    cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return serveAPI(db, serveAddr, serveAuthToken, fileCfg.hooks, os.Stdout)
+		},
+	}
+
+	mcpCmd := &cobra.Command{
+		Use:   "mcp",
+		Short: "Run a Model Context Protocol (MCP) server over stdio",
+		Long: `Run a Model Context Protocol (MCP) server over stdio.
+
+This lets AI assistants that support MCP list, add, update, archive, and reorder
+omm's tasks. Each task's summary and context is also available as a markdown
+resource (at omm://task/<id>).
+
+Use --read-only to only expose the tools that don't modify tasks.
+`,
+		Example: `# register omm with an MCP client
+omm mcp --read-only`,
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return mcp.New(db, version, mcpReadOnly || dbReadOnly, fileCfg.hooks, os.Stderr).Serve(os.Stdin, os.Stdout)
+		},
+	}
+
+	dbCmd := &cobra.Command{
+		Use:   "db",
+		Short: "Maintain omm's database",
+	}
+
+	dbBackupCmd := &cobra.Command{
+		Use:   "backup [PATH]",
+		Short: "Back up the database",
+		Long: `Back up the database.
+
+PATH can either be a directory (in which case the backup gets a timestamped
+name), or a file ending in .db. Backups are written to a directory next to the
+database file by default. When backing up to a directory, only the latest
+backups (as per --keep) are retained.
+`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			var target string
+			if len(args) > 0 {
+				target = expandTilde(args[0])
+			}
+
+			return backupDB(db, dbPathFull, target, backupsToKeep, time.Now(), os.Stdout)
+		},
+	}
+
+	dbRestoreCmd := &cobra.Command{
+		Use:   "restore [PATH]",
+		Short: "Restore the database from a backup",
+		Long: `Restore the database from a backup.
+
+PATH can either be a backup file, or a directory of backups (in which case the
+latest one is used); it defaults to the directory "omm db backup" writes to.
+The current database file is moved to the backups directory, rather than being
+deleted.
+`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// the current database might be too corrupted to be opened, so this
+			// command sets up config on its own
+			_, err := initializeConfig(cmd, expandTilde(configPath))
+			if err != nil {
+				return err
+			}
+
+			dbPathFull = expandTilde(dbPath)
+			if filepath.Ext(dbPathFull) != ".db" {
+				return errDBFileExtIncorrect
+			}
+
+			var source string
+			if len(args) > 0 {
+				source = expandTilde(args[0])
+			}
+
+			return restoreDB(dbPathFull, source, time.Now(), os.Stdout)
+		},
+	}
+
+	dbMigrateCmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Migrate the database to the latest version",
+		Long: `Migrate the database to the latest version.
+
+omm migrates its database automatically when needed; this command lets you
+inspect and test migrations beforehand. The database is backed up before being
+migrated, and is rolled back to the backup if a migration fails.
+`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			// migrations would otherwise be applied before this command gets a
+			// chance to look at them
+			_, err := initializeConfig(cmd, expandTilde(configPath))
+			if err != nil {
+				return err
+			}
+
+			dbPathFull = expandTilde(dbPath)
+			_, err = os.Stat(dbPathFull)
+			if err != nil {
+				return fmt.Errorf("%w at %s", errDBDoesntExist, dbPathFull)
+			}
+
+			db, err = getDB(dbPathFull)
+			if err != nil {
+				return fmt.Errorf("%w: %s", errCouldntOpenDB, err.Error())
+			}
+			defer db.Close()
+
+			switch {
+			case migrateStatus:
+				return showMigrationStatus(db, os.Stdout)
+			case migrateDryRun:
+				return dryRunMigrations(db, os.Stdout)
+			default:
+				return migrateDB(db, dbPathFull, time.Now(), os.Stdout)
+			}
+		},
+	}
+
+	dbExportCompatibleCmd := &cobra.Command{
+		Use:   "export-compatible PATH",
+		Short: "Write a copy of the database that an older version of omm can work with",
+		Long: `Write a copy of the database that an older version of omm can work with.
+
+The copy has the migrations beyond --version undone; data that's held in parts
+of the database added by those migrations is not part of the copy (eg. task
+context, for versions before 2). Run "omm db migrate --status" using the older
+version of omm to find out which database version it supports.
+`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return exportCompatibleDB(db, expandTilde(args[0]), exportDBVersion, os.Stdout)
+		},
+	}
+
+	dbCheckCmd := &cobra.Command{
+		Use:   "check",
+		Short: "Check the database for problems",
+		Long: `Check the database for problems.
+
+Runs SQLite's integrity check, and verifies that the task list's ordering only
+refers to existing active tasks, and includes all of them.
+`,
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return checkDB(db, os.Stdout)
+		},
+	}
+
+	dbRepairCmd := &cobra.Command{
+		Use:   "repair",
+		Short: "Fix problems reported by \"omm db check\"",
+		Args:  cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return repairDB(db, os.Stdout)
+		},
+	}
+
+	dbVacuumCmd := &cobra.Command{
+		Use:   "vacuum",
+		Short: "Reclaim unused space in the database file",
+		Args:  cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return vacuumDB(db, dbPathFull, os.Stdout)
+		},
+	}
+
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Manage omm's config",
+	}
+
+	configInitCmd := &cobra.Command{
+		Use:   "init",
+		Short: "Write a sample config file",
+		Args:  cobra.NoArgs,
 		RunE: func(_ *cobra.Command, _ []string) error {
-			return printTasks(db, printTasksNum, os.Stdout)
+			configPathFull = expandTilde(configPath)
+			if filepath.Ext(configPathFull) != ".toml" {
+				return errConfigFileExtIncorrect
+			}
+
+			return writeSampleConfig(configPathFull, configInitForce, os.Stdout)
+		},
+	}
+
+	configValidateCmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Check the config file and env vars for problems",
+		Long: `Check the config file and env vars for problems.
+
+Reports keys in the config file that omm doesn't recognize, and values (set via
+the config file, env vars, or flags) that omm won't accept.
+`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return checkConfig(rootCmd, cmd.Flags(), expandTilde(configPath), os.Stdout)
+		},
+	}
+
+	configShowCmd := &cobra.Command{
+		Use:   "show",
+		Short: "Show the effective config, along with where each value comes from",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return showConfig(cmd.Flags(), expandTilde(configPath), os.Stdout)
 		},
 	}
 
@@ -387,6 +1075,7 @@ Sorry for breaking the upgrade step!
 				ShowContext:           true,
 				Guide:                 true,
 				ConfirmBeforeDeletion: true,
+				BoardColumns:          strings.Split(ui.DefaultBoardColumns, ","),
 			}
 
 			ui.RenderUI(db, config, thm)
@@ -442,32 +1131,209 @@ Sorry for breaking the upgrade step!
 	rootCmd.Flags().StringVarP(&themeName, "theme", "t", theme.DefaultThemeName, themeFlagUsage)
 	rootCmd.Flags().StringVar(&taskListTitle, "title", ui.TaskListDefaultTitle, fmt.Sprintf("title of the task list, will trim till %d chars", taskListTitleMaxLen))
 	rootCmd.Flags().StringVar(&listDensityFlagInp, "list-density", ui.CompactDensityVal, fmt.Sprintf("type of density for the list; possible values: [%s, %s]", ui.CompactDensityVal, ui.SpaciousDensityVal))
+	rootCmd.Flags().StringVar(&filterModeFlagInp, "filter-mode", ui.FilterPrefixVal, fmt.Sprintf("what to match against when filtering task lists; possible values: [%s, %s, %s]", ui.FilterPrefixVal, ui.FilterSummaryVal, ui.FilterContextVal))
 	rootCmd.Flags().StringVar(&editorFlagInp, "editor", "vi", "editor command to run when adding/editing context to a task")
 	rootCmd.Flags().BoolVar(&showContextFlagInp, "show-context", false, "whether to start omm with a visible task context pane or not; this can later be toggled on/off in the TUI")
 	rootCmd.Flags().BoolVar(&confirmBeforeDeletion, "confirm-before-deletion", true, "whether to ask for confirmation before deleting a task")
 	rootCmd.Flags().BoolVar(&circularNav, "circular-nav", false, "whether to enable circular navigation for lists (cycle back to the first entry from the last, and vice versa)")
+	rootCmd.Flags().StringVar(&boardColumnsFlagInp, "board-columns", ui.DefaultBoardColumns, "comma separated columns of the task board; the last one holds archived tasks")
 
 	tasksCmd.Flags().Uint8VarP(&printTasksNum, "num", "n", printTasksDefault, "number of tasks to print")
+	tasksCmd.Flags().StringVar(&viewName, "view", "", "only print tasks matching a view (defined in the config file, or saved via the TUI)")
+	tasksCmd.Flags().StringVar(&taskQuery, "query", "", `only print tasks matching a query (eg. 'prefix:infra AND age>7d AND text~"deploy"')`)
+	tasksCmd.MarkFlagsMutuallyExclusive("view", "query")
+	tasksCmd.Flags().BoolVar(&includeSnoozed, "include-snoozed", false, "also print snoozed tasks (after the other ones)")
 	tasksCmd.Flags().StringVarP(&configPath, "config-path", "c", defaultConfigPath, fmt.Sprintf("location of omm's TOML config file%s", configPathAdditionalCxt))
 	tasksCmd.Flags().StringVarP(&dbPath, "db-path", "d", defaultDBPath, fmt.Sprintf("location of omm's database file%s", dbPathAdditionalCxt))
 
+	statsCmd.Flags().StringVar(&statsSince, "since", statsSinceDefault, "start of the period to show the trend for; either a date (eg. 2026-01-31), or a number of days/weeks (eg. 30d, 4w)")
+	statsCmd.Flags().StringVar(&statsPrefix, "prefix", "", "only consider tasks with this prefix")
+	statsCmd.Flags().StringVar(&statsInterval, "interval", string(stats.Week), "interval to group the trend by; possible values: [day, week]")
+	statsCmd.Flags().BoolVar(&statsJSON, "json", false, "output stats as JSON")
+	statsCmd.Flags().StringVarP(&configPath, "config-path", "c", defaultConfigPath, fmt.Sprintf("location of omm's TOML config file%s", configPathAdditionalCxt))
+	statsCmd.Flags().StringVarP(&dbPath, "db-path", "d", defaultDBPath, fmt.Sprintf("location of omm's database file%s", dbPathAdditionalCxt))
+
+	reportCmd.Flags().StringVar(&reportPeriod, "period", string(report.Today), "period to report on; possible values: [today, week, custom]")
+	reportCmd.Flags().StringVar(&reportSince, "since", "", "start of a custom period; either a date (eg. 2026-01-31), or a number of days/weeks (eg. 30d, 4w)")
+	reportCmd.Flags().StringVar(&reportUntil, "until", "", "end of a custom period (defaults to now); either a date (which is included), or a number of days/weeks")
+	reportCmd.Flags().IntVar(&reportNumTop, "top", report.DefaultNumTop, "number of top active tasks to include")
+	reportCmd.Flags().StringVarP(&reportOutputPath, "output", "o", "", "file to write the report to (instead of stdout)")
+	reportCmd.Flags().StringVarP(&configPath, "config-path", "c", defaultConfigPath, fmt.Sprintf("location of omm's TOML config file%s", configPathAdditionalCxt))
+	reportCmd.Flags().StringVarP(&dbPath, "db-path", "d", defaultDBPath, fmt.Sprintf("location of omm's database file%s", dbPathAdditionalCxt))
+
 	importCmd.Flags().StringVarP(&configPath, "config-path", "c", defaultConfigPath, fmt.Sprintf("location of omm's TOML config file%s", configPathAdditionalCxt))
 	importCmd.Flags().StringVarP(&dbPath, "db-path", "d", defaultDBPath, fmt.Sprintf("location of omm's database file%s", dbPathAdditionalCxt))
 
+	for _, c := range []*cobra.Command{taskwarriorImportCmd, taskwarriorExportCmd} {
+		c.Flags().StringVarP(&configPath, "config-path", "c", defaultConfigPath, fmt.Sprintf("location of omm's TOML config file%s", configPathAdditionalCxt))
+		c.Flags().StringVarP(&dbPath, "db-path", "d", defaultDBPath, fmt.Sprintf("location of omm's database file%s", dbPathAdditionalCxt))
+	}
+
+	for _, c := range []*cobra.Command{tagsListCmd, tagsFromPrefixesCmd} {
+		c.Flags().StringVarP(&configPath, "config-path", "c", defaultConfigPath, fmt.Sprintf("location of omm's TOML config file%s", configPathAdditionalCxt))
+		c.Flags().StringVarP(&dbPath, "db-path", "d", defaultDBPath, fmt.Sprintf("location of omm's database file%s", dbPathAdditionalCxt))
+	}
+	tagsFromPrefixesCmd.Flags().BoolVar(&stripPrefixes, "strip-prefixes", false, "remove prefixes from summaries once tags are added")
+
+	for _, c := range []*cobra.Command{prefixRenameCmd, prefixMergeCmd} {
+		c.Flags().StringVarP(&configPath, "config-path", "c", defaultConfigPath, fmt.Sprintf("location of omm's TOML config file%s", configPathAdditionalCxt))
+		c.Flags().StringVarP(&dbPath, "db-path", "d", defaultDBPath, fmt.Sprintf("location of omm's database file%s", dbPathAdditionalCxt))
+	}
+
+	syncCmd.Flags().StringVar(&syncDir, "dir", "", "directory to sync tasks with")
+	syncCmd.Flags().StringVarP(&configPath, "config-path", "c", defaultConfigPath, fmt.Sprintf("location of omm's TOML config file%s", configPathAdditionalCxt))
+	syncCmd.Flags().StringVarP(&dbPath, "db-path", "d", defaultDBPath, fmt.Sprintf("location of omm's database file%s", dbPathAdditionalCxt))
+	_ = syncCmd.MarkFlagRequired("dir")
+
+	for _, c := range []*cobra.Command{gitInitCmd, gitSnapshotCmd, gitSyncCmd, gitRestoreCmd, gitLogCmd} {
+		c.Flags().StringVarP(&configPath, "config-path", "c", defaultConfigPath, fmt.Sprintf("location of omm's TOML config file%s", configPathAdditionalCxt))
+		c.Flags().StringVarP(&dbPath, "db-path", "d", defaultDBPath, fmt.Sprintf("location of omm's database file%s", dbPathAdditionalCxt))
+	}
+	gitInitCmd.Flags().StringVar(&gitRemoteURL, "remote", "", "URL of the git remote to sync with")
+	for _, c := range []*cobra.Command{gitInitCmd, gitSyncCmd} {
+		c.Flags().StringVar(&gitRemoteName, "remote-name", "origin", "name of the git remote")
+	}
+
+	serveCmd.Flags().StringVar(&serveAddr, "addr", defaultServeAddr, "address to listen on")
+	serveCmd.Flags().StringVar(&serveAuthToken, "auth-token", "", "bearer token that clients need to send; requests are not authenticated if empty")
+	serveCmd.Flags().StringVarP(&configPath, "config-path", "c", defaultConfigPath, fmt.Sprintf("location of omm's TOML config file%s", configPathAdditionalCxt))
+	serveCmd.Flags().StringVarP(&dbPath, "db-path", "d", defaultDBPath, fmt.Sprintf("location of omm's database file%s", dbPathAdditionalCxt))
+
+	mcpCmd.Flags().BoolVar(&mcpReadOnly, "read-only", false, "only expose tools that don't modify tasks")
+	mcpCmd.Flags().StringVarP(&configPath, "config-path", "c", defaultConfigPath, fmt.Sprintf("location of omm's TOML config file%s", configPathAdditionalCxt))
+	mcpCmd.Flags().StringVarP(&dbPath, "db-path", "d", defaultDBPath, fmt.Sprintf("location of omm's database file%s", dbPathAdditionalCxt))
+
+	for _, c := range []*cobra.Command{dbBackupCmd, dbRestoreCmd, dbMigrateCmd, dbExportCompatibleCmd, dbCheckCmd, dbRepairCmd, dbVacuumCmd} {
+		c.Flags().StringVarP(&configPath, "config-path", "c", defaultConfigPath, fmt.Sprintf("location of omm's TOML config file%s", configPathAdditionalCxt))
+		c.Flags().StringVarP(&dbPath, "db-path", "d", defaultDBPath, fmt.Sprintf("location of omm's database file%s", dbPathAdditionalCxt))
+	}
+	dbBackupCmd.Flags().UintVar(&backupsToKeep, "keep", backupsToKeepDefault, "number of backups to retain when backing up to a directory; 0 retains all")
+	dbMigrateCmd.Flags().BoolVar(&migrateStatus, "status", false, "show the database's version history, and pending migrations")
+	dbMigrateCmd.Flags().BoolVar(&migrateDryRun, "dry-run", false, "apply pending migrations to a temporary copy of the database")
+	dbMigrateCmd.MarkFlagsMutuallyExclusive("status", "dry-run")
+	dbExportCompatibleCmd.Flags().IntVar(&exportDBVersion, "version", 0, "database version the copy needs to be at")
+	_ = dbExportCompatibleCmd.MarkFlagRequired("version")
+
+	configInitCmd.Flags().StringVarP(&configPath, "config-path", "c", defaultConfigPath, fmt.Sprintf("location of omm's TOML config file%s", configPathAdditionalCxt))
+	configInitCmd.Flags().BoolVar(&configInitForce, "force", false, "overwrite the config file if it already exists")
+	// validate and show accept the same flags as the root command, so that
+	// values passed via flags are accounted for
+	for _, c := range []*cobra.Command{configValidateCmd, configShowCmd} {
+		c.Flags().AddFlagSet(rootCmd.Flags())
+	}
+
 	guideCmd.Flags().StringVar(&editorFlagInp, "editor", "vi", "editor command to run when adding/editing context to a task")
 	guideCmd.Flags().StringVarP(&themeName, "theme", "t", theme.DefaultThemeName, themeFlagUsage)
 
 	rootCmd.AddCommand(importCmd)
 	rootCmd.AddCommand(tasksCmd)
+	rootCmd.AddCommand(statsCmd)
+	rootCmd.AddCommand(reportCmd)
 	rootCmd.AddCommand(guideCmd)
+	taskwarriorCmd.AddCommand(taskwarriorImportCmd)
+	taskwarriorCmd.AddCommand(taskwarriorExportCmd)
+	rootCmd.AddCommand(taskwarriorCmd)
+	rootCmd.AddCommand(syncCmd)
+	tagsCmd.AddCommand(tagsListCmd)
+	tagsCmd.AddCommand(tagsFromPrefixesCmd)
+	rootCmd.AddCommand(tagsCmd)
+	prefixCmd.AddCommand(prefixRenameCmd)
+	prefixCmd.AddCommand(prefixMergeCmd)
+	rootCmd.AddCommand(prefixCmd)
+	gitCmd.AddCommand(gitInitCmd)
+	gitCmd.AddCommand(gitSnapshotCmd)
+	gitCmd.AddCommand(gitSyncCmd)
+	gitCmd.AddCommand(gitRestoreCmd)
+	gitCmd.AddCommand(gitLogCmd)
+	rootCmd.AddCommand(gitCmd)
+	rootCmd.AddCommand(serveCmd)
+	rootCmd.AddCommand(mcpCmd)
+	dbCmd.AddCommand(dbBackupCmd)
+	dbCmd.AddCommand(dbRestoreCmd)
+	dbCmd.AddCommand(dbMigrateCmd)
+	dbCmd.AddCommand(dbExportCompatibleCmd)
+	dbCmd.AddCommand(dbCheckCmd)
+	dbCmd.AddCommand(dbRepairCmd)
+	dbCmd.AddCommand(dbVacuumCmd)
+	rootCmd.AddCommand(dbCmd)
+	configCmd.AddCommand(configInitCmd)
+	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configShowCmd)
+	rootCmd.AddCommand(configCmd)
 	rootCmd.AddCommand(updatesCmd)
 
-	rootCmd.CompletionOptions.DisableDefaultCmd = true
+	rootCmd.ValidArgsFunction = completeTaskSummary
+	for _, c := range []*cobra.Command{rootCmd, guideCmd} {
+		_ = c.RegisterFlagCompletionFunc("theme", completeThemes)
+	}
+	_ = rootCmd.RegisterFlagCompletionFunc("list-density", completeListDensities)
+	_ = rootCmd.RegisterFlagCompletionFunc("filter-mode", completeFilterModes)
+	_ = statsCmd.RegisterFlagCompletionFunc("interval", completeStatsIntervals)
+	_ = reportCmd.RegisterFlagCompletionFunc("period", completeReportPeriods)
+	_ = tasksCmd.RegisterFlagCompletionFunc("view", completeViews)
+	// the new name of a renamed prefix can't be an existing one
+	prefixRenameCmd.ValidArgsFunction = completePrefixes(1)
+	prefixMergeCmd.ValidArgsFunction = completePrefixes(2)
 
 	return rootCmd, nil
 }
 
-func initializeConfig(cmd *cobra.Command, configFile string) error {
+// needsDB reports whether a command works with omm's database (and config).
+func needsDB(cmd *cobra.Command) bool {
+	if cmd.CalledAs() == "updates" {
+		return false
+	}
+
+	// completions open the database themselves (read-only), see
+	// openDBForCompletion
+	if cmd.Name() == cobra.ShellCompRequestCmd || cmd.Name() == cobra.ShellCompNoDescRequestCmd {
+		return false
+	}
+
+	if cmd.HasParent() && cmd.Parent().Name() == "completion" {
+		return false
+	}
+
+	// config commands resolve config themselves, without binding it to flags
+	if cmd.HasParent() && cmd.Parent().Name() == "config" {
+		return false
+	}
+
+	// the database being restored might not be usable, and migrations need to be
+	// inspected before they're applied
+	if cmd.HasParent() && cmd.Parent().Name() == "db" && (cmd.Name() == "restore" || cmd.Name() == "migrate") {
+		return false
+	}
+
+	return true
+}
+
+// worksWithReadOnlyDB reports whether a command can run against a database
+// that's been migrated by a newer version of omm; such a database is opened in
+// read-only mode.
+func worksWithReadOnlyDB(cmd *cobra.Command, args []string) bool {
+	if !cmd.HasParent() {
+		// the TUI doesn't allow changes in read-only mode; adding a task does
+		return len(args) == 0
+	}
+
+	switch cmd.CommandPath() {
+	case "omm tasks", "omm tags list", "omm stats", "omm report", "omm mcp", "omm taskwarrior export", "omm db backup", "omm db check":
+		return true
+	}
+
+	return false
+}
+
+// fileConfig holds the settings that aren't exposed as flags.
+type fileConfig struct {
+	hooks        hooks.Config
+	views        []views.View
+	prefixColors theme.PrefixColors
+}
+
+func initializeConfig(cmd *cobra.Command, configFile string) (fileConfig, error) {
+	var cfg fileConfig
 	v := viper.New()
 
 	v.SetConfigName(filepath.Base(configFile))
@@ -476,7 +1342,7 @@ func initializeConfig(cmd *cobra.Command, configFile string) error {
 
 	err := v.ReadInConfig()
 	if err != nil && !errors.As(err, &viper.ConfigFileNotFoundError{}) {
-		return err
+		return cfg, err
 	}
 
 	v.SetEnvPrefix(envPrefix)
@@ -485,10 +1351,49 @@ func initializeConfig(cmd *cobra.Command, configFile string) error {
 
 	err = bindFlags(cmd, v)
 	if err != nil {
-		return err
+		return cfg, err
+	}
+
+	cfg.hooks, err = getHooksConfig(v)
+	if err != nil {
+		return cfg, err
+	}
+
+	cfg.views, err = views.FromConfigValues(v.GetStringMapString(viewsConfigKey))
+	if err != nil {
+		return cfg, err
+	}
+
+	cfg.prefixColors, err = theme.ParsePrefixColors(v.GetStringMap(prefixColorsConfigKey))
+	if err != nil {
+		return cfg, err
+	}
+
+	return cfg, nil
+}
+
+// getHooksConfig reads hooks from the config file (or env vars); hooks are not
+// exposed as flags.
+func getHooksConfig(v *viper.Viper) (hooks.Config, error) {
+	hooksCfg := hooks.Config{
+		OnCreate:    v.GetString("on_create"),
+		OnUpdate:    v.GetString("on_update"),
+		OnArchive:   v.GetString("on_archive"),
+		OnUnarchive: v.GetString("on_unarchive"),
+		OnDelete:    v.GetString("on_delete"),
+		Timeout:     hooks.DefaultTimeout,
+	}
+
+	timeout := v.GetString("hook_timeout")
+	if timeout != "" {
+		d, err := time.ParseDuration(timeout)
+		if err != nil || d <= 0 {
+			return hooksCfg, fmt.Errorf("%w: %q", errHookTimeoutInvalid, timeout)
+		}
+		hooksCfg.Timeout = d
 	}
 
-	return nil
+	return hooksCfg, nil
 }
 
 func bindFlags(cmd *cobra.Command, v *viper.Viper) error {
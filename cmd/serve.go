@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/dhth/omm/internal/hooks"
+	"github.com/dhth/omm/internal/server"
+)
+
+const (
+	defaultServeAddr     = "127.0.0.1:8080"
+	serverShutdownPeriod = 5 * time.Second
+)
+
+func serveAPI(db *sql.DB, addr, token string, hooksCfg hooks.Config, writer io.Writer) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	srv := &http.Server{
+		Handler:           server.New(db, token, hooksCfg, os.Stderr),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	fmt.Fprintf(writer, "serving omm's API on http://%s\n", listener.Addr().String())
+	if token == "" {
+		fmt.Fprintln(writer, "Warning: no auth token set; anyone who can reach this address can modify tasks")
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.Serve(listener)
+	}()
+
+	select {
+	case err = <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), serverShutdownPeriod)
+	defer cancel()
+
+	err = srv.Shutdown(shutdownCtx)
+	if err != nil {
+		return err
+	}
+
+	err = <-errCh
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+
+	return err
+}
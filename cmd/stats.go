@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dhth/omm/internal/stats"
+)
+
+const statsSinceDefault = "8w"
+
+var errSinceInvalid = errors.New("since needs to be a date (eg. 2026-01-31), or a number of days/weeks (eg. 30d, 4w)")
+
+// parseSince parses either a date, or a period relative to now (in days or
+// weeks).
+func parseSince(value string, now time.Time) (time.Time, error) {
+	t, err := time.ParseInLocation(time.DateOnly, value, now.Location())
+	if err == nil {
+		return t, nil
+	}
+
+	if len(value) < 2 {
+		return time.Time{}, errSinceInvalid
+	}
+
+	num, err := strconv.Atoi(value[:len(value)-1])
+	if err != nil || num < 0 {
+		return time.Time{}, errSinceInvalid
+	}
+
+	switch strings.ToLower(value[len(value)-1:]) {
+	case "d":
+		return now.AddDate(0, 0, -num), nil
+	case "w":
+		return now.AddDate(0, 0, -7*num), nil
+	default:
+		return time.Time{}, errSinceInvalid
+	}
+}
+
+func printStats(db *sql.DB, opts stats.Options, asJSON bool, writer io.Writer) error {
+	s, err := stats.Load(db, opts, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(s)
+	}
+
+	fmt.Fprint(writer, stats.Render(s))
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2026, 3, 11, 12, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name     string
+		value    string
+		expected time.Time
+		err      error
+	}{
+		{
+			name:     "a date",
+			value:    "2026-01-31",
+			expected: time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "days",
+			value:    "10d",
+			expected: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "weeks",
+			value:    "2W",
+			expected: time.Date(2026, 2, 25, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "unknown unit",
+			value: "2m",
+			err:   errSinceInvalid,
+		},
+		{
+			name:  "negative number",
+			value: "-2d",
+			err:   errSinceInvalid,
+		},
+		{
+			name:  "no number",
+			value: "d",
+			err:   errSinceInvalid,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSince(tt.value, now)
+
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
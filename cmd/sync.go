@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/dhth/omm/internal/mdsync"
+)
+
+var errSyncHadConflicts = errors.New("sync finished with conflicts")
+
+func syncWithDir(db *sql.DB, dir string, writer io.Writer) error {
+	report, err := mdsync.Sync(db, dir, time.Now())
+	if err != nil {
+		return err
+	}
+
+	for _, c := range report.Changes {
+		fmt.Fprintf(writer, "%-14s task %-6d %s\n", c.Kind, c.TaskID, c.FileName)
+	}
+
+	for _, c := range report.Conflicts {
+		if c.TaskID == 0 {
+			fmt.Fprintf(writer, "%-14s %s: %s\n", "conflict", c.FileName, c.Reason)
+			continue
+		}
+		fmt.Fprintf(writer, "%-14s task %-6d %s: %s\n", "conflict", c.TaskID, c.FileName, c.Reason)
+	}
+
+	if len(report.Changes) == 0 && len(report.Conflicts) == 0 {
+		fmt.Fprintln(writer, "everything is in sync")
+	}
+
+	if len(report.Conflicts) > 0 {
+		return fmt.Errorf("%w (%d conflict(s))", errSyncHadConflicts, len(report.Conflicts))
+	}
+
+	return nil
+}
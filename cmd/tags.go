@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	pers "github.com/dhth/omm/internal/persistence"
+	"github.com/dhth/omm/internal/types"
+)
+
+func printTags(db *sql.DB, writer io.Writer) error {
+	tags, err := pers.FetchTags(db)
+	if err != nil {
+		return err
+	}
+
+	if len(tags) == 0 {
+		fmt.Fprintln(writer, "no tags found")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	for _, t := range tags {
+		fmt.Fprintf(tw, "%s%s\t%d", types.TagPrefix, t.Name, t.NumTasks)
+		if t.Color != "" {
+			fmt.Fprintf(tw, "\t%s", t.Color)
+		}
+		fmt.Fprintln(tw)
+	}
+
+	return tw.Flush()
+}
+
+func addTagsFromPrefixes(db *sql.DB, stripPrefix bool, now time.Time, writer io.Writer) error {
+	numChanged, err := pers.AddTagsFromPrefixes(db, stripPrefix, now)
+	if err != nil {
+		return err
+	}
+
+	if numChanged == 0 {
+		fmt.Fprintln(writer, "no tasks needed to be changed")
+		return nil
+	}
+
+	fmt.Fprintf(writer, "added tags to %d task(s)\n", numChanged)
+	return nil
+}
@@ -4,17 +4,67 @@ import (
 	"database/sql"
 	"fmt"
 	"io"
+	"time"
 
 	pers "github.com/dhth/omm/internal/persistence"
+	"github.com/dhth/omm/internal/query"
+	"github.com/dhth/omm/internal/types"
+	"github.com/dhth/omm/internal/views"
 )
 
-func printTasks(db *sql.DB, limit uint8, writer io.Writer) error {
-	tasks, err := pers.FetchActiveTasks(db, int(limit))
+// printTasks prints the summaries of active tasks, in the order of the task
+// list; snoozed tasks, if included, follow them in the order they wake up in.
+func printTasks(db *sql.DB, limit uint8, q *query.Query, includeSnoozed bool, writer io.Writer) error {
+	var snoozed []types.Task
+	if includeSnoozed {
+		var err error
+		snoozed, err = pers.FetchSnoozedTasks(db, pers.TaskNumLimit)
+		if err != nil {
+			return err
+		}
+	}
+
+	if q == nil {
+		tasks, err := pers.FetchActiveTasks(db, int(limit))
+		if err != nil {
+			return err
+		}
+
+		tasks = append(tasks, snoozed...)
+		return writeTaskSummaries(tasks[:min(len(tasks), int(limit))], writer)
+	}
+
+	// the SQL condition narrows down tasks as much as it can; the rest of the
+	// query is evaluated on the tasks it selects
+	now := time.Now()
+	condition, args := q.SQL(now)
+	tasks, err := pers.FetchActiveTasksWhere(db, condition, args, pers.TaskNumLimit)
 	if err != nil {
 		return err
 	}
+
+	tasks = q.Filter(append(tasks, snoozed...), now)
+	return writeTaskSummaries(tasks[:min(len(tasks), int(limit))], writer)
+}
+
+func writeTaskSummaries(tasks []types.Task, writer io.Writer) error {
 	for _, task := range tasks {
 		fmt.Fprintf(writer, "%s\n", task.Summary)
 	}
 	return nil
 }
+
+// getView returns a view defined in the config file, or one saved via the TUI.
+func getView(db *sql.DB, configViews []views.View, name string) (views.View, error) {
+	saved, err := pers.FetchSavedViews(db)
+	if err != nil {
+		return views.View{}, err
+	}
+
+	view, ok := views.Find(views.Merge(configViews, saved), name)
+	if !ok {
+		return views.View{}, fmt.Errorf("%w: %q", views.ErrViewNotFound, name)
+	}
+
+	return view, nil
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	pers "github.com/dhth/omm/internal/persistence"
+	tw "github.com/dhth/omm/internal/taskwarrior"
+	"github.com/dhth/omm/internal/types"
+)
+
+func importTaskwarriorTasks(db *sql.DB, reader io.Reader, writer io.Writer) error {
+	twTasks, err := tw.Parse(reader)
+	if err != nil {
+		return err
+	}
+
+	tasks, skipped := tw.ToOmmTasks(twTasks)
+	for _, s := range skipped {
+		fmt.Fprintf(writer, "skipped task %q (%s): %s\n", s.Task.Description, s.Task.UUID, s.Reason.Error())
+	}
+
+	if len(tasks) == 0 {
+		return errNothingToImport
+	}
+
+	if len(tasks) > importTasksLimit {
+		fmt.Fprint(writer, maxImportNumMsg)
+		return fmt.Errorf("%w", errMaxImportLimitExceeded)
+	}
+
+	numTasks, err := pers.FetchNumActiveTasks(db)
+	if err != nil {
+		return err
+	}
+
+	activeTasks, err := pers.FetchAllActiveTasks(db)
+	if err != nil {
+		return err
+	}
+
+	activeUUIDs := make(map[string]bool, len(activeTasks))
+	for _, t := range activeTasks {
+		activeUUIDs[t.UUID] = true
+	}
+
+	// tasks that are already active don't add to the task count
+	numNewlyActive := 0
+	for _, t := range tasks {
+		if t.Active && !activeUUIDs[t.UUID] {
+			numNewlyActive++
+		}
+	}
+
+	if numTasks+numNewlyActive > pers.TaskNumLimit {
+		return fmt.Errorf("%w (current task count: %d)", errWillExceedCapacity, numTasks)
+	}
+
+	numInserted, numUpdated, err := pers.UpsertTasksByUUID(db, tasks)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(writer, "imported %d new task(s), updated %d existing task(s)\n", numInserted, numUpdated)
+
+	return nil
+}
+
+func exportTaskwarriorTasks(db *sql.DB, writer io.Writer) error {
+	activeTasks, err := pers.FetchAllActiveTasks(db)
+	if err != nil {
+		return err
+	}
+
+	archivedTasks, err := pers.FetchInActiveTasks(db, pers.TaskNumLimit)
+	if err != nil {
+		return err
+	}
+
+	tasks := make([]types.Task, 0, len(activeTasks)+len(archivedTasks))
+	tasks = append(tasks, activeTasks...)
+	tasks = append(tasks, archivedTasks...)
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(tw.FromOmmTasks(tasks))
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	pers "github.com/dhth/omm/internal/persistence"
+	"github.com/dhth/omm/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportingTaskwarriorTasksOnlyCountsNewlyActiveTasksTowardsCapacity(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input string
+		err   error
+	}{
+		{
+			name: "tasks that are already active",
+			input: `[
+{"uuid":"uuid-1","description":"task 1 (updated)","status":"pending"},
+{"uuid":"uuid-2","description":"task 2 (updated)","status":"pending"},
+{"uuid":"uuid-new","description":"new task","status":"pending"}
+]`,
+		},
+		{
+			name: "new tasks",
+			input: `[
+{"uuid":"uuid-new-1","description":"new task 1","status":"pending"},
+{"uuid":"uuid-new-2","description":"new task 2","status":"pending"},
+{"uuid":"uuid-new-3","description":"new task 3","status":"pending"}
+]`,
+			err: errWillExceedCapacity,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			db, err := setupDB(filepath.Join(t.TempDir(), "omm.db"))
+			require.NoError(t, err)
+			t.Cleanup(func() { db.Close() })
+
+			now := time.Now()
+			tasks := make([]types.Task, pers.TaskNumLimit-2)
+			for i := range tasks {
+				tasks[i] = types.Task{
+					UUID:      fmt.Sprintf("uuid-%d", i+1),
+					Summary:   fmt.Sprintf("task %d", i+1),
+					Active:    true,
+					CreatedAt: now,
+					UpdatedAt: now,
+				}
+			}
+			for batch := range slices.Chunk(tasks, importTasksLimit) {
+				_, err = pers.InsertTasks(db, batch, true)
+				require.NoError(t, err)
+			}
+
+			// WHEN
+			err = importTaskwarriorTasks(db, strings.NewReader(tt.input), io.Discard)
+
+			// THEN
+			if tt.err != nil {
+				require.ErrorIs(t, err, tt.err)
+				return
+			}
+
+			require.NoError(t, err)
+			numTasks, err := pers.FetchNumActiveTasksShown(db)
+			require.NoError(t, err)
+			assert.Equal(t, pers.TaskNumLimit-1, numTasks)
+		})
+	}
+}
@@ -0,0 +1,314 @@
+package gitstore
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/dhth/omm/internal/types"
+)
+
+// Conflict describes a task that was changed in incompatible ways on both
+// sides of a merge. Conflicts are resolved automatically (the most recently
+// updated version wins), and are reported so that they can be reviewed.
+type Conflict struct {
+	UUID    string
+	Summary string
+	Reason  string
+}
+
+// Merge performs a three-way merge of two snapshots that share the common
+// ancestor base. Tasks are merged field by field: a change made on only one
+// side is kept, and when both sides changed the same field differently, the
+// version of the task updated most recently wins.
+func Merge(base, ours, theirs Snapshot) (Snapshot, []Conflict) {
+	merged := emptySnapshot()
+	var conflicts []Conflict
+
+	uuids := make(map[string]struct{})
+	for _, s := range []Snapshot{base, ours, theirs} {
+		for u := range s.Tasks {
+			uuids[u] = struct{}{}
+		}
+	}
+
+	sortedUUIDs := make([]string, 0, len(uuids))
+	for u := range uuids {
+		sortedUUIDs = append(sortedUUIDs, u)
+	}
+	slices.Sort(sortedUUIDs)
+
+	for _, u := range sortedUUIDs {
+		b, inBase := base.Tasks[u]
+		o, inOurs := ours.Tasks[u]
+		t, inTheirs := theirs.Tasks[u]
+
+		switch {
+		case inOurs && inTheirs:
+			if !inBase {
+				// added on both sides; this only happens when the same task
+				// was imported on both sides (eg. via taskwarrior)
+				if sameContent(o, t) {
+					merged.Tasks[u] = latest(o, t)
+					continue
+				}
+				b = types.Task{}
+			}
+
+			task, conflict := mergeTask(b, o, t)
+			merged.Tasks[u] = task
+			if conflict {
+				conflicts = append(conflicts, Conflict{u, task.Summary, "changed on both sides"})
+			}
+
+		case inOurs && !inTheirs:
+			if !inBase {
+				merged.Tasks[u] = o
+				continue
+			}
+			if !sameContent(o, b) {
+				// deleted on their side, but changed on ours; keep it
+				merged.Tasks[u] = o
+				conflicts = append(conflicts, Conflict{u, o.Summary, "deleted on the remote, but changed locally; kept"})
+			}
+
+		case !inOurs && inTheirs:
+			if !inBase {
+				merged.Tasks[u] = t
+				continue
+			}
+			if !sameContent(t, b) {
+				merged.Tasks[u] = t
+				conflicts = append(conflicts, Conflict{u, t.Summary, "deleted locally, but changed on the remote; kept"})
+			}
+		}
+	}
+
+	merged.Sequence = mergeSequences(base.Sequence, ours.Sequence, theirs.Sequence, merged)
+
+	blockedBy, dependencyConflicts := mergeDependencies(base.BlockedBy, ours.BlockedBy, theirs.BlockedBy, merged)
+	merged.BlockedBy = blockedBy
+	conflicts = append(conflicts, dependencyConflicts...)
+
+	return merged, conflicts
+}
+
+func mergeTask(base, ours, theirs types.Task) (types.Task, bool) {
+	conflict := false
+	theirsIsNewer := theirs.UpdatedAt.After(ours.UpdatedAt)
+
+	merged := ours
+	merged.UpdatedAt = latest(ours, theirs).UpdatedAt
+
+	pick := func(b, o, t string) string {
+		switch {
+		case o == t:
+			return o
+		case o == b:
+			return t
+		case t == b:
+			return o
+		}
+		conflict = true
+		if theirsIsNewer {
+			return t
+		}
+		return o
+	}
+
+	merged.Summary = pick(base.Summary, ours.Summary, theirs.Summary)
+
+	ctx := pick(contextOf(base), contextOf(ours), contextOf(theirs))
+	switch ctx {
+	case "":
+		merged.Context = nil
+	default:
+		merged.Context = &ctx
+	}
+
+	merged.Active = pick(boolStr(base.Active), boolStr(ours.Active), boolStr(theirs.Active)) == boolStr(true)
+	merged.Status = pick(base.Status, ours.Status, theirs.Status)
+
+	if merged.CreatedAt.IsZero() || (!theirs.CreatedAt.IsZero() && theirs.CreatedAt.Before(merged.CreatedAt)) {
+		merged.CreatedAt = theirs.CreatedAt
+	}
+
+	return merged, conflict
+}
+
+// mergeSequences merges the priority order of active tasks. The side that
+// reordered tasks (relative to base) is used as the primary order; if both
+// did, ours wins. Tasks only present in the other side's order are placed
+// right after the task preceding them there. Tasks that are active but not
+// present in either order (eg. unarchived on one side without an order
+// change) are put at the top.
+func mergeSequences(base, ours, theirs []string, merged Snapshot) []string {
+	isActive := func(u string) bool {
+		t, ok := merged.Tasks[u]
+		return ok && t.Active
+	}
+
+	primary, secondary := ours, theirs
+	if !reordered(base, ours) && reordered(base, theirs) {
+		primary, secondary = theirs, ours
+	}
+
+	result := make([]string, 0, len(primary)+len(secondary))
+	placed := make(map[string]bool)
+	for _, u := range primary {
+		if isActive(u) && !placed[u] {
+			result = append(result, u)
+			placed[u] = true
+		}
+	}
+
+	for i, u := range secondary {
+		if !isActive(u) || placed[u] {
+			continue
+		}
+
+		insertAt := 0
+		for j := i - 1; j >= 0; j-- {
+			index := slices.Index(result, secondary[j])
+			if index != -1 {
+				insertAt = index + 1
+				break
+			}
+		}
+		result = slices.Insert(result, insertAt, u)
+		placed[u] = true
+	}
+
+	var missing []string
+	for _, u := range merged.sortedUUIDs() {
+		if isActive(u) && !placed[u] {
+			missing = append(missing, u)
+		}
+	}
+
+	return append(missing, result...)
+}
+
+// mergeDependencies merges the links between tasks and the ones blocking them.
+// A link is kept unless it was removed on either side, or one of its tasks
+// isn't part of the merge. Links added on both sides can form a cycle; the
+// ones that would complete it are dropped, and reported as conflicts.
+func mergeDependencies(base, ours, theirs map[string][]string, merged Snapshot) (map[string][]string, []Conflict) {
+	type link struct{ task, blocker string }
+
+	toSet := func(blockedBy map[string][]string) map[link]bool {
+		links := make(map[link]bool)
+		for task, blockers := range blockedBy {
+			for _, blocker := range blockers {
+				links[link{task, blocker}] = true
+			}
+		}
+		return links
+	}
+	b, o, t := toSet(base), toSet(ours), toSet(theirs)
+
+	union := maps.Clone(o)
+	maps.Copy(union, t)
+
+	var candidates []link
+	for l := range union {
+		if b[l] && !(o[l] && t[l]) {
+			// removed on one side
+			continue
+		}
+		_, taskOK := merged.Tasks[l.task]
+		_, blockerOK := merged.Tasks[l.blocker]
+		if taskOK && blockerOK && l.task != l.blocker {
+			candidates = append(candidates, l)
+		}
+	}
+	slices.SortFunc(candidates, func(x, y link) int {
+		return cmp.Or(strings.Compare(x.task, y.task), strings.Compare(x.blocker, y.blocker))
+	})
+
+	blockedBy := make(map[string][]string)
+	var conflicts []Conflict
+	for _, l := range candidates {
+		if isBlockedBy(blockedBy, l.blocker, l.task) {
+			conflicts = append(conflicts, Conflict{l.task, merged.Tasks[l.task].Summary, "blocked by a task it blocks on the other side; dependency dropped"})
+			continue
+		}
+		blockedBy[l.task] = append(blockedBy[l.task], l.blocker)
+	}
+
+	return blockedBy, conflicts
+}
+
+// isBlockedBy reports whether a task is, directly or transitively, blocked by
+// another one.
+func isBlockedBy(blockedBy map[string][]string, task, blocker string) bool {
+	seen := make(map[string]bool)
+	pending := slices.Clone(blockedBy[task])
+	for len(pending) > 0 {
+		u := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if u == blocker {
+			return true
+		}
+		if seen[u] {
+			continue
+		}
+		seen[u] = true
+		pending = append(pending, blockedBy[u]...)
+	}
+
+	return false
+}
+
+// reordered reports whether the relative order of the tasks common to both
+// sequences has changed.
+func reordered(before, after []string) bool {
+	inAfter := make(map[string]bool, len(after))
+	for _, u := range after {
+		inAfter[u] = true
+	}
+	inBefore := make(map[string]bool, len(before))
+	for _, u := range before {
+		inBefore[u] = true
+	}
+
+	var b, a []string
+	for _, u := range before {
+		if inAfter[u] {
+			b = append(b, u)
+		}
+	}
+	for _, u := range after {
+		if inBefore[u] {
+			a = append(a, u)
+		}
+	}
+
+	return !slices.Equal(a, b)
+}
+
+func sameContent(a, b types.Task) bool {
+	return a.Summary == b.Summary && a.Active == b.Active && a.Status == b.Status && contextOf(a) == contextOf(b)
+}
+
+func latest(a, b types.Task) types.Task {
+	if b.UpdatedAt.After(a.UpdatedAt) {
+		return b
+	}
+	return a
+}
+
+func contextOf(t types.Task) string {
+	if t.Context == nil {
+		return ""
+	}
+	return *t.Context
+}
+
+func boolStr(b bool) string {
+	if b {
+		return "true"
+	}
+	return "false"
+}
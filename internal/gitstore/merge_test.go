@@ -0,0 +1,237 @@
+package gitstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dhth/omm/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var refTime = time.Date(2024, 8, 1, 10, 0, 0, 0, time.UTC)
+
+func newSnapshot(tasks []types.Task, sequence ...string) Snapshot {
+	s := emptySnapshot()
+	for _, t := range tasks {
+		s.Tasks[t.UUID] = t
+	}
+	s.Sequence = sequence
+
+	return s
+}
+
+func task(uuid, summary string, active bool, updatedAfter time.Duration) types.Task {
+	return types.Task{
+		UUID:      uuid,
+		Summary:   summary,
+		Active:    active,
+		CreatedAt: refTime,
+		UpdatedAt: refTime.Add(updatedAfter),
+	}
+}
+
+func TestMergeKeepsChangesFromBothSides(t *testing.T) {
+	// GIVEN
+	base := newSnapshot([]types.Task{
+		task("a", "task a", true, 0),
+		task("b", "task b", true, 0),
+		task("c", "task c", true, 0),
+	}, "a", "b", "c")
+
+	ours := newSnapshot([]types.Task{
+		task("a", "task a (ours)", true, time.Minute),
+		task("b", "task b", true, 0),
+		task("c", "task c", true, 0),
+		task("d", "task d (ours)", true, time.Minute),
+	}, "d", "a", "b", "c")
+
+	theirs := newSnapshot([]types.Task{
+		task("a", "task a", true, 0),
+		task("b", "task b", false, 2*time.Minute),
+		task("c", "task c", true, 0),
+		task("e", "task e (theirs)", true, time.Minute),
+	}, "a", "e", "c")
+	theirsA := theirs.Tasks["a"]
+	theirsA.Status = "doing"
+	theirsA.UpdatedAt = refTime.Add(time.Minute)
+	theirs.Tasks["a"] = theirsA
+
+	// WHEN
+	got, conflicts := Merge(base, ours, theirs)
+
+	// THEN
+	assert.Empty(t, conflicts)
+	require.Len(t, got.Tasks, 5)
+	assert.Equal(t, "task a (ours)", got.Tasks["a"].Summary)
+	assert.Equal(t, "doing", got.Tasks["a"].Status)
+	assert.False(t, got.Tasks["b"].Active)
+	assert.Equal(t, []string{"d", "a", "e", "c"}, got.Sequence)
+}
+
+func TestMergeResolvesConflictsInFavourOfLatestChange(t *testing.T) {
+	// GIVEN
+	base := newSnapshot([]types.Task{task("a", "task a", true, 0)}, "a")
+	ours := newSnapshot([]types.Task{task("a", "task a (ours)", true, time.Minute)}, "a")
+	theirs := newSnapshot([]types.Task{task("a", "task a (theirs)", true, 2*time.Minute)}, "a")
+
+	// WHEN
+	got, conflicts := Merge(base, ours, theirs)
+
+	// THEN
+	require.Len(t, conflicts, 1)
+	assert.Equal(t, "a", conflicts[0].UUID)
+	assert.Equal(t, "task a (theirs)", got.Tasks["a"].Summary)
+	assert.Equal(t, refTime.Add(2*time.Minute), got.Tasks["a"].UpdatedAt)
+}
+
+func TestMergeHandlesDeletions(t *testing.T) {
+	// GIVEN
+	base := newSnapshot([]types.Task{
+		task("a", "task a", true, 0),
+		task("b", "task b", true, 0),
+		task("c", "task c", true, 0),
+	}, "a", "b", "c")
+
+	// a is deleted on our side; b is deleted on theirs but changed on ours
+	ours := newSnapshot([]types.Task{
+		task("b", "task b (changed)", true, time.Minute),
+		task("c", "task c", true, 0),
+	}, "b", "c")
+
+	theirs := newSnapshot([]types.Task{
+		task("a", "task a", true, 0),
+		task("c", "task c", true, 0),
+	}, "a", "c")
+
+	// WHEN
+	got, conflicts := Merge(base, ours, theirs)
+
+	// THEN
+	require.Len(t, conflicts, 1)
+	assert.Equal(t, "b", conflicts[0].UUID)
+	assert.NotContains(t, got.Tasks, "a")
+	assert.Contains(t, got.Tasks, "b")
+	assert.Equal(t, []string{"b", "c"}, got.Sequence)
+}
+
+func TestMergeUsesReorderingFromTheSideThatReordered(t *testing.T) {
+	// GIVEN
+	tasks := []types.Task{
+		task("a", "task a", true, 0),
+		task("b", "task b", true, 0),
+		task("c", "task c", true, 0),
+	}
+	base := newSnapshot(tasks, "a", "b", "c")
+	ours := newSnapshot(tasks, "a", "b", "c")
+	theirs := newSnapshot(tasks, "c", "a", "b")
+
+	// WHEN
+	got, conflicts := Merge(base, ours, theirs)
+
+	// THEN
+	assert.Empty(t, conflicts)
+	assert.Equal(t, []string{"c", "a", "b"}, got.Sequence)
+}
+
+func TestMergeDependencies(t *testing.T) {
+	tasks := []types.Task{
+		task("a", "task a", true, 0),
+		task("b", "task b", true, 0),
+		task("c", "task c", true, 0),
+	}
+
+	testCases := []struct {
+		name              string
+		base              map[string][]string
+		ours              map[string][]string
+		theirs            map[string][]string
+		expected          map[string][]string
+		expectedConflicts []string
+	}{
+		{
+			name:     "added on both sides",
+			ours:     map[string][]string{"a": {"b"}},
+			theirs:   map[string][]string{"a": {"c"}, "b": {"c"}},
+			expected: map[string][]string{"a": {"b", "c"}, "b": {"c"}},
+		},
+		{
+			name:     "removed on one side",
+			base:     map[string][]string{"a": {"b", "c"}},
+			ours:     map[string][]string{"a": {"b", "c"}},
+			theirs:   map[string][]string{"a": {"c"}},
+			expected: map[string][]string{"a": {"c"}},
+		},
+		{
+			name:              "cycle across sides",
+			ours:              map[string][]string{"a": {"b"}},
+			theirs:            map[string][]string{"b": {"a"}},
+			expected:          map[string][]string{"a": {"b"}},
+			expectedConflicts: []string{"b"},
+		},
+		{
+			name:     "task deleted",
+			base:     map[string][]string{"a": {"b"}},
+			ours:     map[string][]string{"a": {"b", "d"}},
+			theirs:   map[string][]string{"a": {"b"}},
+			expected: map[string][]string{"a": {"b"}},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			base := newSnapshot(tasks, "a", "b", "c")
+			base.BlockedBy = tt.base
+			ours := newSnapshot(tasks, "a", "b", "c")
+			ours.BlockedBy = tt.ours
+			theirs := newSnapshot(tasks, "a", "b", "c")
+			theirs.BlockedBy = tt.theirs
+
+			// WHEN
+			got, conflicts := Merge(base, ours, theirs)
+
+			// THEN
+			assert.Equal(t, tt.expected, got.BlockedBy)
+			var conflictUUIDs []string
+			for _, c := range conflicts {
+				conflictUUIDs = append(conflictUUIDs, c.UUID)
+			}
+			assert.Equal(t, tt.expectedConflicts, conflictUUIDs)
+		})
+	}
+}
+
+func TestSnapshotEncodingIsDeterministic(t *testing.T) {
+	// GIVEN
+	context := "line 1\nline 2"
+	a := task("a", "task a", true, 0)
+	a.Context = &context
+	a.Status = "doing"
+	snapshot := newSnapshot([]types.Task{
+		task("c", "task c", false, 0),
+		a,
+		task("b", "task b", true, 0),
+	}, "b", "a")
+	snapshot.BlockedBy["b"] = []string{"c", "a"}
+
+	// WHEN
+	tasksData, seqData, err := snapshot.encode()
+	require.NoError(t, err)
+	tasksDataAgain, _, err := snapshot.encode()
+	require.NoError(t, err)
+	decoded, err := decodeSnapshot(tasksData, seqData)
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, tasksData, tasksDataAgain)
+	assert.Equal(t, `{"uuid":"a","summary":"task a","active":true,"status":"doing","created_at":"2024-08-01T10:00:00Z","updated_at":"2024-08-01T10:00:00Z","context":"line 1\nline 2"}
+{"uuid":"b","summary":"task b","active":true,"created_at":"2024-08-01T10:00:00Z","updated_at":"2024-08-01T10:00:00Z","blocked_by":["a","c"]}
+{"uuid":"c","summary":"task c","active":false,"created_at":"2024-08-01T10:00:00Z","updated_at":"2024-08-01T10:00:00Z"}
+`, string(tasksData))
+	assert.Equal(t, "b\na\n", string(seqData))
+	assert.Equal(t, snapshot.Sequence, decoded.Sequence)
+	assert.Equal(t, context, *decoded.Tasks["a"].Context)
+	assert.Equal(t, "doing", decoded.Tasks["a"].Status)
+	assert.Equal(t, map[string][]string{"b": {"a", "c"}}, decoded.BlockedBy)
+}
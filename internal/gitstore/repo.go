@@ -0,0 +1,348 @@
+package gitstore
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	defaultBranch       = "main"
+	fallbackAuthorName  = "omm"
+	fallbackAuthorEmail = "omm@localhost"
+)
+
+var (
+	ErrGitNotFound      = errors.New("git executable not found")
+	ErrRepoNotFound     = errors.New("git repository not initialized")
+	ErrRemoteNotFound   = errors.New("git remote not configured")
+	ErrGitCommandFailed = errors.New("git command failed")
+)
+
+// Repo is a local git repository that holds snapshots of omm's tasks.
+type Repo struct {
+	Dir string
+}
+
+// SyncResult describes what a call to Sync did.
+type SyncResult struct {
+	Committed    bool
+	FastForward  bool
+	Merged       bool
+	Pushed       bool
+	RemoteBranch string
+	Conflicts    []Conflict
+}
+
+// Exists reports whether the repository has been initialized.
+func (r Repo) Exists() bool {
+	_, err := os.Stat(filepath.Join(r.Dir, ".git"))
+	return err == nil
+}
+
+// Init creates the repository (if needed), commits a snapshot of the
+// database, and configures the remote, if provided.
+func (r Repo) Init(db *sql.DB, remoteName, remoteURL string) (bool, error) {
+	_, err := exec.LookPath("git")
+	if err != nil {
+		return false, ErrGitNotFound
+	}
+
+	if !r.Exists() {
+		err = os.MkdirAll(r.Dir, 0o755)
+		if err != nil {
+			return false, err
+		}
+
+		_, err = r.git("init", "--quiet", "--initial-branch="+defaultBranch)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if remoteURL != "" {
+		_, err = r.git("remote", "get-url", remoteName)
+		if err == nil {
+			_, err = r.git("remote", "set-url", remoteName, remoteURL)
+		} else {
+			_, err = r.git("remote", "add", remoteName, remoteURL)
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return r.Commit(db, "initial snapshot")
+}
+
+// Commit writes a snapshot of the database to the repository, and commits it
+// if anything changed since the last commit.
+func (r Repo) Commit(db *sql.DB, message string) (bool, error) {
+	if !r.Exists() {
+		return false, fmt.Errorf("%w at %s", ErrRepoNotFound, r.Dir)
+	}
+
+	snapshot, err := FromDB(db)
+	if err != nil {
+		return false, err
+	}
+
+	err = snapshot.write(r.Dir)
+	if err != nil {
+		return false, err
+	}
+
+	return r.commitWorkTree(message)
+}
+
+// Sync commits local changes, merges the remote's snapshot into the local one
+// (updating the database accordingly), and pushes the result to the remote.
+// If the database can't be updated (eg. because the merged snapshot has more
+// active tasks than omm allows), the repository is left at the local snapshot.
+func (r Repo) Sync(db *sql.DB, remoteName string) (SyncResult, error) {
+	var result SyncResult
+
+	committed, err := r.Commit(db, "snapshot")
+	if err != nil {
+		return result, err
+	}
+	result.Committed = committed
+
+	_, err = r.git("remote", "get-url", remoteName)
+	if err != nil {
+		return result, fmt.Errorf("%w: %q", ErrRemoteNotFound, remoteName)
+	}
+
+	branch, err := r.git("rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return result, err
+	}
+
+	_, err = r.git("fetch", "--quiet", remoteName)
+	if err != nil {
+		return result, err
+	}
+
+	remoteRef := fmt.Sprintf("refs/remotes/%s/%s", remoteName, branch)
+	result.RemoteBranch = fmt.Sprintf("%s/%s", remoteName, branch)
+
+	theirsRev, err := r.git("rev-parse", "--verify", "--quiet", remoteRef+"^{commit}")
+	if err != nil {
+		// the remote doesn't have this branch yet
+		err = r.push(remoteName, branch)
+		if err != nil {
+			return result, err
+		}
+		result.Pushed = true
+		return result, nil
+	}
+
+	oursRev, err := r.git("rev-parse", "HEAD")
+	if err != nil {
+		return result, err
+	}
+
+	baseRev, mbErr := r.git("merge-base", oursRev, theirsRev)
+
+	switch {
+	case mbErr == nil && baseRev == theirsRev:
+		// nothing new on the remote
+	case mbErr == nil && baseRev == oursRev:
+		// the database is updated before HEAD is moved, so that a failure
+		// doesn't leave the repository ahead of the database (which the next
+		// snapshot would then revert)
+		snapshot, err := r.readSnapshot(theirsRev)
+		if err != nil {
+			return result, err
+		}
+
+		err = snapshot.ApplyToDB(db)
+		if err != nil {
+			return result, err
+		}
+
+		_, err = r.git("merge", "--quiet", "--ff-only", theirsRev)
+		if err != nil {
+			return result, err
+		}
+		result.FastForward = true
+	default:
+		base := emptySnapshot()
+		if mbErr == nil {
+			base, err = r.readSnapshot(baseRev)
+			if err != nil {
+				return result, err
+			}
+		}
+
+		ours, err := r.readSnapshot(oursRev)
+		if err != nil {
+			return result, err
+		}
+
+		theirs, err := r.readSnapshot(theirsRev)
+		if err != nil {
+			return result, err
+		}
+
+		merged, conflicts := Merge(base, ours, theirs)
+		result.Conflicts = conflicts
+
+		err = merged.ApplyToDB(db)
+		if err != nil {
+			return result, err
+		}
+
+		err = r.commitMerge(merged, theirsRev, result.RemoteBranch)
+		if err != nil {
+			// the database already holds the merged tasks; the next sync
+			// will commit and merge them again
+			_, resetErr := r.git("reset", "--quiet", "--hard", oursRev)
+			return result, errors.Join(err, resetErr)
+		}
+		result.Merged = true
+	}
+
+	ahead, err := r.git("rev-list", "--count", remoteRef+"..HEAD")
+	if err != nil {
+		return result, err
+	}
+
+	if ahead != "0" {
+		err = r.push(remoteName, branch)
+		if err != nil {
+			return result, err
+		}
+		result.Pushed = true
+	}
+
+	return result, nil
+}
+
+// commitMerge records a merge of theirsRev into HEAD, with merged as its
+// content.
+func (r Repo) commitMerge(merged Snapshot, theirsRev, remoteBranch string) error {
+	// record the merge without letting git touch the snapshot files; their
+	// content is determined by Merge
+	_, err := r.git(r.withIdentity("merge", "--quiet", "--no-commit", "--no-ff", "--allow-unrelated-histories", "-s", "ours", theirsRev)...)
+	if err != nil {
+		return err
+	}
+
+	err = merged.write(r.Dir)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.git("add", "--all")
+	if err != nil {
+		return err
+	}
+
+	_, err = r.git(r.withIdentity("commit", "--quiet", "--allow-empty", "-m", fmt.Sprintf("merge %s", remoteBranch))...)
+	return err
+}
+
+// Restore rebuilds the database from the snapshot at the given revision.
+func (r Repo) Restore(db *sql.DB, rev string) error {
+	if !r.Exists() {
+		return fmt.Errorf("%w at %s", ErrRepoNotFound, r.Dir)
+	}
+
+	snapshot, err := r.readSnapshot(rev)
+	if err != nil {
+		return err
+	}
+
+	err = snapshot.ApplyToDB(db)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.Commit(db, fmt.Sprintf("restore %s", rev))
+	return err
+}
+
+// Log returns git's one line log of the repository.
+func (r Repo) Log(limit int) (string, error) {
+	if !r.Exists() {
+		return "", fmt.Errorf("%w at %s", ErrRepoNotFound, r.Dir)
+	}
+
+	return r.git("log", "--oneline", fmt.Sprintf("--max-count=%d", limit))
+}
+
+func (r Repo) commitWorkTree(message string) (bool, error) {
+	_, err := r.git("add", "--all")
+	if err != nil {
+		return false, err
+	}
+
+	status, err := r.git("status", "--porcelain")
+	if err != nil {
+		return false, err
+	}
+
+	if status == "" {
+		return false, nil
+	}
+
+	_, err = r.git(r.withIdentity("commit", "--quiet", "-m", message)...)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r Repo) push(remoteName, branch string) error {
+	_, err := r.git("push", "--quiet", remoteName, fmt.Sprintf("HEAD:refs/heads/%s", branch))
+	return err
+}
+
+func (r Repo) readSnapshot(rev string) (Snapshot, error) {
+	tasksData, err := r.git("show", fmt.Sprintf("%s:%s", rev, tasksFileName))
+	if err != nil {
+		return emptySnapshot(), err
+	}
+
+	seqData, err := r.git("show", fmt.Sprintf("%s:%s", rev, sequenceFileName))
+	if err != nil {
+		return emptySnapshot(), err
+	}
+
+	return decodeSnapshot([]byte(tasksData), []byte(seqData))
+}
+
+// withIdentity prepends a fallback author identity to a git command, if the
+// user hasn't configured one; commits would fail otherwise.
+func (r Repo) withIdentity(args ...string) []string {
+	email, err := r.git("config", "user.email")
+	if err == nil && email != "" {
+		return args
+	}
+
+	return append([]string{
+		"-c", "user.name=" + fallbackAuthorName,
+		"-c", "user.email=" + fallbackAuthorEmail,
+	}, args...)
+}
+
+func (r Repo) git(args ...string) (string, error) {
+	c := exec.Command("git", append([]string{"-C", r.Dir}, args...)...)
+	var stdout, stderr bytes.Buffer
+	c.Stdout = &stdout
+	c.Stderr = &stderr
+
+	err := c.Run()
+	if err != nil {
+		return "", fmt.Errorf("%w (git %s): %s", ErrGitCommandFailed, strings.Join(args, " "), strings.TrimSpace(stderr.String()))
+	}
+
+	return strings.TrimRight(stdout.String(), "\n"), nil
+}
@@ -0,0 +1,328 @@
+package gitstore
+
+import (
+	"cmp"
+	"database/sql"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	pers "github.com/dhth/omm/internal/persistence"
+	"github.com/dhth/omm/internal/testutil"
+	"github.com/dhth/omm/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func activeSummaries(t *testing.T, db *sql.DB) []string {
+	t.Helper()
+
+	tasks, err := pers.FetchActiveTasks(db, pers.TaskNumLimit)
+	require.NoError(t, err)
+
+	summaries := make([]string, len(tasks))
+	for i, task := range tasks {
+		summaries[i] = task.Summary
+	}
+
+	return summaries
+}
+
+func dependencySummaries(t *testing.T, db *sql.DB) [][2]string {
+	t.Helper()
+
+	tasks, err := pers.FetchActiveTasks(db, pers.TaskNumLimit)
+	require.NoError(t, err)
+	summaries := make(map[uint64]string, len(tasks))
+	for _, task := range tasks {
+		summaries[task.ID] = task.Summary
+	}
+
+	dependencies, err := pers.FetchTaskDependencies(db)
+	require.NoError(t, err)
+
+	result := make([][2]string, len(dependencies))
+	for i, d := range dependencies {
+		result[i] = [2]string{summaries[d.TaskID], summaries[d.BlockerID]}
+	}
+	slices.SortFunc(result, func(a, b [2]string) int {
+		return cmp.Or(strings.Compare(a[0], b[0]), strings.Compare(a[1], b[1]))
+	})
+
+	return result
+}
+
+func TestSyncBetweenTwoReposViaABareRemote(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not available")
+	}
+
+	// GIVEN
+	remote := filepath.Join(t.TempDir(), "remote.git")
+	out, err := exec.Command("git", "init", "--quiet", "--bare", remote).CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	now := time.Now()
+	dbA := testutil.NewDB(t)
+	_, err = pers.InsertTasks(dbA, []types.Task{
+		{Summary: "task 1", Active: true, CreatedAt: now, UpdatedAt: now},
+		{Summary: "task 2", Active: true, CreatedAt: now, UpdatedAt: now},
+	}, false)
+	require.NoError(t, err)
+	require.NoError(t, pers.UpdateTaskStatus(dbA, 2, "doing", now))
+
+	repoA := Repo{Dir: filepath.Join(t.TempDir(), "a")}
+	_, err = repoA.Init(dbA, "origin", remote)
+	require.NoError(t, err)
+	_, err = repoA.Sync(dbA, "origin")
+	require.NoError(t, err)
+
+	dbB := testutil.NewDB(t)
+	repoB := Repo{Dir: filepath.Join(t.TempDir(), "b")}
+	_, err = repoB.Init(dbB, "origin", remote)
+	require.NoError(t, err)
+
+	// WHEN
+	_, err = repoB.Sync(dbB, "origin")
+	require.NoError(t, err)
+
+	// THEN
+	assert.Equal(t, []string{"task 1", "task 2"}, activeSummaries(t, dbB))
+	tasksB, err := pers.FetchActiveTasks(dbB, pers.TaskNumLimit)
+	require.NoError(t, err)
+	assert.Equal(t, "doing", tasksB[1].Status)
+
+	// WHEN both sides change
+	later := now.Add(time.Minute)
+	_, err = pers.InsertTasks(dbA, []types.Task{{Summary: "task from a", Active: true, CreatedAt: later, UpdatedAt: later}}, true)
+	require.NoError(t, err)
+	_, err = pers.InsertTasks(dbB, []types.Task{{Summary: "task from b", Active: true, CreatedAt: later, UpdatedAt: later}}, false)
+	require.NoError(t, err)
+
+	_, err = repoA.Sync(dbA, "origin")
+	require.NoError(t, err)
+	result, err := repoB.Sync(dbB, "origin")
+	require.NoError(t, err)
+	_, err = repoA.Sync(dbA, "origin")
+	require.NoError(t, err)
+
+	// THEN
+	assert.True(t, result.Merged)
+	assert.Empty(t, result.Conflicts)
+	expected := []string{"task from a", "task 1", "task 2", "task from b"}
+	assert.Equal(t, expected, activeSummaries(t, dbB))
+	assert.Equal(t, expected, activeSummaries(t, dbA))
+}
+
+func TestSyncKeepsSnoozedTasks(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not available")
+	}
+
+	// GIVEN
+	remote := filepath.Join(t.TempDir(), "remote.git")
+	out, err := exec.Command("git", "init", "--quiet", "--bare", remote).CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	now := time.Now()
+	dbA := testutil.NewDB(t)
+	_, err = pers.InsertTasks(dbA, []types.Task{
+		{Summary: "task 1", Active: true, CreatedAt: now, UpdatedAt: now},
+		{Summary: "task 2", Active: true, CreatedAt: now, UpdatedAt: now},
+	}, false)
+	require.NoError(t, err)
+
+	repoA := Repo{Dir: filepath.Join(t.TempDir(), "a")}
+	_, err = repoA.Init(dbA, "origin", remote)
+	require.NoError(t, err)
+	_, err = repoA.Sync(dbA, "origin")
+	require.NoError(t, err)
+
+	dbB := testutil.NewDB(t)
+	repoB := Repo{Dir: filepath.Join(t.TempDir(), "b")}
+	_, err = repoB.Init(dbB, "origin", remote)
+	require.NoError(t, err)
+	_, err = repoB.Sync(dbB, "origin")
+	require.NoError(t, err)
+
+	require.NoError(t, pers.SnoozeTask(dbA, 2, now.Add(time.Hour)))
+
+	// WHEN
+	_, err = repoA.Sync(dbA, "origin")
+	require.NoError(t, err)
+	_, err = repoB.Sync(dbB, "origin")
+	require.NoError(t, err)
+	_, err = repoA.Sync(dbA, "origin")
+	require.NoError(t, err)
+
+	// THEN
+	assert.Equal(t, []string{"task 1"}, activeSummaries(t, dbA))
+	snoozed, err := pers.FetchSnoozedTasks(dbA, 10)
+	require.NoError(t, err)
+	require.Len(t, snoozed, 1)
+	assert.Equal(t, "task 2", snoozed[0].Summary)
+
+	// snoozing is local to a database
+	assert.Equal(t, []string{"task 1", "task 2"}, activeSummaries(t, dbB))
+}
+
+func TestSyncDoesntMoveHeadIfUpdatingTheDBFails(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not available")
+	}
+
+	testCases := []struct {
+		name         string
+		localChanges bool
+		expected     []string
+	}{
+		{
+			name:     "fast-forward",
+			expected: []string{"task from a", "task 1"},
+		},
+		{
+			name:         "merge",
+			localChanges: true,
+			expected:     []string{"task from a", "task 1", "task from b"},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			remote := filepath.Join(t.TempDir(), "remote.git")
+			out, err := exec.Command("git", "init", "--quiet", "--bare", remote).CombinedOutput()
+			require.NoError(t, err, string(out))
+
+			now := time.Now()
+			dbA := testutil.NewDB(t)
+			_, err = pers.InsertTasks(dbA, []types.Task{{Summary: "task 1", Active: true, CreatedAt: now, UpdatedAt: now}}, false)
+			require.NoError(t, err)
+
+			repoA := Repo{Dir: filepath.Join(t.TempDir(), "a")}
+			_, err = repoA.Init(dbA, "origin", remote)
+			require.NoError(t, err)
+			_, err = repoA.Sync(dbA, "origin")
+			require.NoError(t, err)
+
+			dbB := testutil.NewDB(t)
+			repoB := Repo{Dir: filepath.Join(t.TempDir(), "b")}
+			_, err = repoB.Init(dbB, "origin", remote)
+			require.NoError(t, err)
+			_, err = repoB.Sync(dbB, "origin")
+			require.NoError(t, err)
+
+			later := now.Add(time.Minute)
+			_, err = pers.InsertTasks(dbA, []types.Task{{Summary: "task from a", Active: true, CreatedAt: later, UpdatedAt: later}}, true)
+			require.NoError(t, err)
+			_, err = repoA.Sync(dbA, "origin")
+			require.NoError(t, err)
+
+			if tt.localChanges {
+				_, err = pers.InsertTasks(dbB, []types.Task{{Summary: "task from b", Active: true, CreatedAt: later, UpdatedAt: later}}, false)
+				require.NoError(t, err)
+			}
+
+			_, err = dbB.Exec(`
+CREATE TRIGGER fail_sync BEFORE INSERT ON task
+WHEN NEW.summary = 'task from a'
+BEGIN
+    SELECT RAISE(ABORT, 'failing on purpose');
+END;
+`)
+			require.NoError(t, err)
+
+			// WHEN
+			_, err = repoB.Sync(dbB, "origin")
+
+			// THEN
+			require.Error(t, err)
+			assert.NotContains(t, activeSummaries(t, dbB), "task from a")
+			headTasks, err := repoB.git("show", "HEAD:"+tasksFileName)
+			require.NoError(t, err)
+			assert.NotContains(t, headTasks, "task from a")
+
+			// WHEN the database can be updated again
+			_, err = dbB.Exec("DROP TRIGGER fail_sync;")
+			require.NoError(t, err)
+			_, err = repoB.Sync(dbB, "origin")
+
+			// THEN
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, activeSummaries(t, dbB))
+		})
+	}
+}
+
+func TestApplyToDBRejectsTooManyActiveTasks(t *testing.T) {
+	// GIVEN
+	db := testutil.NewDB(t)
+	now := time.Now()
+	_, err := pers.InsertTasks(db, []types.Task{{Summary: "task 1", Active: true, CreatedAt: now, UpdatedAt: now}}, false)
+	require.NoError(t, err)
+
+	snapshot := emptySnapshot()
+	for range pers.TaskNumLimit + 1 {
+		u := pers.NewTaskUUID()
+		snapshot.Tasks[u] = types.Task{UUID: u, Summary: "task", Active: true, CreatedAt: now, UpdatedAt: now}
+		snapshot.Sequence = append(snapshot.Sequence, u)
+	}
+
+	// WHEN
+	err = snapshot.ApplyToDB(db)
+
+	// THEN
+	require.ErrorIs(t, err, pers.ErrTooManyTasks)
+	assert.Equal(t, []string{"task 1"}, activeSummaries(t, db))
+}
+
+func TestSyncAndRestoreKeepDependencies(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not available")
+	}
+
+	// GIVEN
+	remote := filepath.Join(t.TempDir(), "remote.git")
+	out, err := exec.Command("git", "init", "--quiet", "--bare", remote).CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	now := time.Now()
+	dbA := testutil.NewDB(t)
+	_, err = pers.InsertTasks(dbA, []types.Task{
+		{Summary: "task 1", Active: true, CreatedAt: now, UpdatedAt: now},
+		{Summary: "task 2", Active: true, CreatedAt: now, UpdatedAt: now},
+		{Summary: "task 3", Active: true, CreatedAt: now, UpdatedAt: now},
+	}, false)
+	require.NoError(t, err)
+	require.NoError(t, pers.AddTaskDependency(dbA, 1, 2, now))
+	require.NoError(t, pers.AddTaskDependency(dbA, 1, 3, now))
+
+	repoA := Repo{Dir: filepath.Join(t.TempDir(), "a")}
+	_, err = repoA.Init(dbA, "origin", remote)
+	require.NoError(t, err)
+	_, err = repoA.Sync(dbA, "origin")
+	require.NoError(t, err)
+
+	dbB := testutil.NewDB(t)
+	repoB := Repo{Dir: filepath.Join(t.TempDir(), "b")}
+	_, err = repoB.Init(dbB, "origin", remote)
+	require.NoError(t, err)
+
+	// WHEN
+	_, err = repoB.Sync(dbB, "origin")
+	require.NoError(t, err)
+
+	// THEN
+	expected := [][2]string{{"task 1", "task 2"}, {"task 1", "task 3"}}
+	assert.Equal(t, expected, dependencySummaries(t, dbB))
+
+	// WHEN
+	require.NoError(t, pers.RemoveTaskDependency(dbA, 1, 3))
+	require.NoError(t, repoA.Restore(dbA, "HEAD"))
+
+	// THEN
+	assert.Equal(t, expected, dependencySummaries(t, dbA))
+}
@@ -0,0 +1,243 @@
+package gitstore
+
+import (
+	"bufio"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	pers "github.com/dhth/omm/internal/persistence"
+	"github.com/dhth/omm/internal/types"
+)
+
+const (
+	tasksFileName    = "tasks.jsonl"
+	sequenceFileName = "sequence.txt"
+)
+
+var ErrSnapshotMalformed = errors.New("snapshot is malformed")
+
+// snapshotTask is the serialized form of a task. Fields are listed in the
+// order they're written in, which keeps the output deterministic.
+type snapshotTask struct {
+	UUID      string    `json:"uuid"`
+	Summary   string    `json:"summary"`
+	Active    bool      `json:"active"`
+	Status    string    `json:"status,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Context   *string   `json:"context,omitempty"`
+	BlockedBy []string  `json:"blocked_by,omitempty"`
+}
+
+// Snapshot is a database independent representation of omm's tasks, keyed by
+// their UUIDs. Sequence holds the UUIDs of active tasks in priority order, and
+// BlockedBy the UUIDs of the tasks blocking a task.
+type Snapshot struct {
+	Tasks     map[string]types.Task
+	Sequence  []string
+	BlockedBy map[string][]string
+}
+
+func emptySnapshot() Snapshot {
+	return Snapshot{
+		Tasks:     make(map[string]types.Task),
+		BlockedBy: make(map[string][]string),
+	}
+}
+
+// FromDB builds a snapshot of the tasks in the database.
+func FromDB(db *sql.DB) (Snapshot, error) {
+	snapshot := emptySnapshot()
+
+	err := pers.EnsureTaskUUIDs(db)
+	if err != nil {
+		return snapshot, err
+	}
+
+	activeTasks, err := pers.FetchActiveTasks(db, pers.TaskNumLimit)
+	if err != nil {
+		return snapshot, err
+	}
+
+	archivedTasks, err := pers.FetchInActiveTasks(db, pers.TaskNumLimit)
+	if err != nil {
+		return snapshot, err
+	}
+
+	// snoozing is local to a database; snoozed tasks are included (so that
+	// they're not treated as deleted), but aren't part of the sequence
+	snoozedTasks, err := pers.FetchSnoozedTasks(db, pers.TaskNumLimit)
+	if err != nil {
+		return snapshot, err
+	}
+
+	snapshot.Sequence = make([]string, 0, len(activeTasks))
+	for _, t := range activeTasks {
+		snapshot.Tasks[t.UUID] = t
+		snapshot.Sequence = append(snapshot.Sequence, t.UUID)
+	}
+
+	for _, t := range archivedTasks {
+		snapshot.Tasks[t.UUID] = t
+	}
+
+	for _, t := range snoozedTasks {
+		t.SnoozedUntil = nil
+		snapshot.Tasks[t.UUID] = t
+	}
+
+	dependencies, err := pers.FetchTaskDependencies(db)
+	if err != nil {
+		return snapshot, err
+	}
+
+	uuids := make(map[uint64]string, len(snapshot.Tasks))
+	for u, t := range snapshot.Tasks {
+		uuids[t.ID] = u
+	}
+
+	for _, d := range dependencies {
+		taskUUID, taskOK := uuids[d.TaskID]
+		blockerUUID, blockerOK := uuids[d.BlockerID]
+		if !taskOK || !blockerOK {
+			continue
+		}
+		snapshot.BlockedBy[taskUUID] = append(snapshot.BlockedBy[taskUUID], blockerUUID)
+	}
+
+	for _, blockers := range snapshot.BlockedBy {
+		slices.Sort(blockers)
+	}
+
+	return snapshot, nil
+}
+
+// ApplyToDB makes the database reflect the snapshot.
+func (s Snapshot) ApplyToDB(db *sql.DB) error {
+	tasks := make([]types.Task, 0, len(s.Tasks))
+	for _, u := range s.sortedUUIDs() {
+		tasks = append(tasks, s.Tasks[u])
+	}
+
+	return pers.ReplaceTasks(db, tasks, s.Sequence, s.BlockedBy)
+}
+
+func (s Snapshot) sortedUUIDs() []string {
+	uuids := make([]string, 0, len(s.Tasks))
+	for u := range s.Tasks {
+		uuids = append(uuids, u)
+	}
+	slices.Sort(uuids)
+
+	return uuids
+}
+
+// encode returns the contents of the tasks and sequence files. Tasks are
+// written one per line, sorted by UUID, so that a change to a task shows up
+// as a change to a single line.
+func (s Snapshot) encode() ([]byte, []byte, error) {
+	var tasksBuf bytes.Buffer
+	for _, u := range s.sortedUUIDs() {
+		t := s.Tasks[u]
+		line, err := json.Marshal(snapshotTask{
+			UUID:      t.UUID,
+			Summary:   t.Summary,
+			Active:    t.Active,
+			Status:    t.Status,
+			CreatedAt: t.CreatedAt.UTC(),
+			UpdatedAt: t.UpdatedAt.UTC(),
+			Context:   t.Context,
+			BlockedBy: slices.Sorted(slices.Values(s.BlockedBy[u])),
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		tasksBuf.Write(line)
+		tasksBuf.WriteByte('\n')
+	}
+
+	var seqBuf bytes.Buffer
+	for _, u := range s.Sequence {
+		seqBuf.WriteString(u)
+		seqBuf.WriteByte('\n')
+	}
+
+	return tasksBuf.Bytes(), seqBuf.Bytes(), nil
+}
+
+func (s Snapshot) write(dir string) error {
+	tasksData, seqData, err := s.encode()
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(filepath.Join(dir, tasksFileName), tasksData, 0o644)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(dir, sequenceFileName), seqData, 0o644)
+}
+
+func decodeSnapshot(tasksData, seqData []byte) (Snapshot, error) {
+	snapshot := emptySnapshot()
+
+	scanner := bufio.NewScanner(bytes.NewReader(tasksData))
+	scanner.Buffer(make([]byte, 0, 64*1024), 2*pers.ContextMaxBytes)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var st snapshotTask
+		err := json.Unmarshal(line, &st)
+		if err != nil {
+			return snapshot, fmt.Errorf("%w (%s, line %d): %s", ErrSnapshotMalformed, tasksFileName, lineNum, err.Error())
+		}
+
+		if st.UUID == "" {
+			return snapshot, fmt.Errorf("%w (%s, line %d): task has no uuid", ErrSnapshotMalformed, tasksFileName, lineNum)
+		}
+
+		snapshot.Tasks[st.UUID] = types.Task{
+			UUID:      st.UUID,
+			Summary:   st.Summary,
+			Context:   st.Context,
+			Active:    st.Active,
+			Status:    st.Status,
+			CreatedAt: st.CreatedAt.Local(),
+			UpdatedAt: st.UpdatedAt.Local(),
+		}
+
+		if len(st.BlockedBy) > 0 {
+			slices.Sort(st.BlockedBy)
+			snapshot.BlockedBy[st.UUID] = st.BlockedBy
+		}
+	}
+
+	err := scanner.Err()
+	if err != nil {
+		return snapshot, err
+	}
+
+	for line := range strings.SplitSeq(string(seqData), "\n") {
+		u := strings.TrimSpace(line)
+		if u == "" {
+			continue
+		}
+		snapshot.Sequence = append(snapshot.Sequence, u)
+	}
+
+	return snapshot, nil
+}
@@ -0,0 +1,154 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/dhth/omm/internal/types"
+)
+
+const DefaultTimeout = 5 * time.Second
+
+type Event string
+
+const (
+	EventCreate    Event = "create"
+	EventUpdate    Event = "update"
+	EventArchive   Event = "archive"
+	EventUnarchive Event = "unarchive"
+	EventDelete    Event = "delete"
+)
+
+var (
+	ErrHookFailed   = errors.New("hook failed")
+	ErrHookTimedOut = errors.New("hook timed out")
+)
+
+// Config holds the shell commands to run on task lifecycle events. Empty
+// commands are not run.
+type Config struct {
+	OnCreate    string
+	OnUpdate    string
+	OnArchive   string
+	OnUnarchive string
+	OnDelete    string
+	Timeout     time.Duration
+}
+
+func (c Config) command(event Event) string {
+	switch event {
+	case EventCreate:
+		return c.OnCreate
+	case EventUpdate:
+		return c.OnUpdate
+	case EventArchive:
+		return c.OnArchive
+	case EventUnarchive:
+		return c.OnUnarchive
+	case EventDelete:
+		return c.OnDelete
+	}
+
+	return ""
+}
+
+// IsSet reports whether a hook is configured for the event.
+func (c Config) IsSet(event Event) bool {
+	return strings.TrimSpace(c.command(event)) != ""
+}
+
+// payload is what hooks receive on stdin.
+type payload struct {
+	Event Event       `json:"event"`
+	Task  taskPayload `json:"task"`
+}
+
+type taskPayload struct {
+	ID        uint64    `json:"id"`
+	UUID      string    `json:"uuid,omitempty"`
+	Summary   string    `json:"summary"`
+	Prefix    string    `json:"prefix,omitempty"`
+	Context   *string   `json:"context"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Run runs the hook configured for the event (if any) with the task serialized
+// as JSON on stdin. The command is run via the shell, and is killed if it
+// doesn't finish within the configured timeout.
+func (c Config) Run(event Event, task types.Task) error {
+	command := c.command(event)
+	if strings.TrimSpace(command) == "" {
+		return nil
+	}
+
+	var prefix string
+	p, ok := task.Prefix()
+	if ok {
+		prefix = string(p)
+	}
+
+	input, err := json.Marshal(payload{
+		Event: event,
+		Task: taskPayload{
+			ID:        task.ID,
+			UUID:      task.UUID,
+			Summary:   task.Summary,
+			Prefix:    prefix,
+			Context:   task.Context,
+			Active:    task.Active,
+			CreatedAt: task.CreatedAt,
+			UpdatedAt: task.UpdatedAt,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stderr = &stderr
+	cmd.Env = append(os.Environ(),
+		"OMM_HOOK_EVENT="+string(event),
+		fmt.Sprintf("OMM_TASK_ID=%d", task.ID),
+	)
+	cmd.WaitDelay = time.Second
+
+	err = cmd.Run()
+	if ctx.Err() != nil {
+		return fmt.Errorf("%w: on_%s (after %s)", ErrHookTimedOut, event, timeout)
+	}
+	if err != nil {
+		errMsg := strings.TrimSpace(stderr.String())
+		if errMsg == "" {
+			errMsg = err.Error()
+		}
+		return fmt.Errorf("%w: on_%s: %s", ErrHookFailed, event, errMsg)
+	}
+
+	return nil
+}
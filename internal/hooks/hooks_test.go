@@ -0,0 +1,90 @@
+package hooks
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/dhth/omm/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks in this test are POSIX shell commands")
+	}
+
+	context := "some context"
+	task := types.Task{
+		ID:        1,
+		UUID:      "a-uuid",
+		Summary:   "home: a task",
+		Context:   &context,
+		Active:    false,
+		CreatedAt: time.Date(2024, 8, 1, 10, 0, 0, 0, time.UTC),
+		UpdatedAt: time.Date(2024, 8, 1, 11, 0, 0, 0, time.UTC),
+	}
+
+	t.Run("hook receives the task on stdin", func(t *testing.T) {
+		// GIVEN
+		outFile := filepath.Join(t.TempDir(), "out.json")
+		cfg := Config{OnArchive: "cat > " + outFile}
+
+		// WHEN
+		err := cfg.Run(EventArchive, task)
+
+		// THEN
+		require.NoError(t, err)
+		data, err := os.ReadFile(outFile)
+		require.NoError(t, err)
+
+		var got payload
+		require.NoError(t, json.Unmarshal(data, &got))
+		assert.Equal(t, EventArchive, got.Event)
+		assert.Equal(t, "home", got.Task.Prefix)
+		assert.Equal(t, "a-uuid", got.Task.UUID)
+		assert.Equal(t, &context, got.Task.Context)
+	})
+
+	t.Run("events without hooks are a no-op", func(t *testing.T) {
+		// GIVEN
+		cfg := Config{OnArchive: "exit 1"}
+
+		// WHEN
+		err := cfg.Run(EventCreate, task)
+
+		// THEN
+		assert.NoError(t, err)
+		assert.False(t, cfg.IsSet(EventCreate))
+		assert.True(t, cfg.IsSet(EventArchive))
+	})
+
+	t.Run("failures include stderr", func(t *testing.T) {
+		// GIVEN
+		cfg := Config{OnDelete: "echo 'journal is locked' >&2; exit 1"}
+
+		// WHEN
+		err := cfg.Run(EventDelete, task)
+
+		// THEN
+		require.ErrorIs(t, err, ErrHookFailed)
+		assert.Contains(t, err.Error(), "journal is locked")
+	})
+
+	t.Run("slow hooks are killed", func(t *testing.T) {
+		// GIVEN
+		cfg := Config{OnUpdate: "sleep 5", Timeout: 100 * time.Millisecond}
+
+		// WHEN
+		start := time.Now()
+		err := cfg.Run(EventUpdate, task)
+
+		// THEN
+		require.ErrorIs(t, err, ErrHookTimedOut)
+		assert.Less(t, time.Since(start), 3*time.Second)
+	})
+}
@@ -0,0 +1,103 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	pers "github.com/dhth/omm/internal/persistence"
+	"github.com/dhth/omm/internal/types"
+)
+
+const (
+	taskURIPrefix    = "omm://task/"
+	markdownMimeType = "text/markdown"
+)
+
+func taskURI(id uint64) string {
+	return fmt.Sprintf("%s%d", taskURIPrefix, id)
+}
+
+// listResources lists a resource for every active task, in priority order
+// (with snoozed tasks at the end).
+func (s *Server) listResources() (any, error) {
+	tasks, err := pers.FetchAllActiveTasks(s.db)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]map[string]any, len(tasks))
+	for i, t := range tasks {
+		resources[i] = map[string]any{
+			"uri":      taskURI(t.ID),
+			"name":     t.Summary,
+			"mimeType": markdownMimeType,
+		}
+	}
+
+	return map[string]any{"resources": resources}, nil
+}
+
+func (s *Server) listResourceTemplates() any {
+	return map[string]any{
+		"resourceTemplates": []map[string]any{
+			{
+				"uriTemplate": taskURIPrefix + "{id}",
+				"name":        "task",
+				"description": "A task's summary and markdown context; works for archived tasks as well",
+				"mimeType":    markdownMimeType,
+			},
+		},
+	}
+}
+
+type readResourceParams struct {
+	URI string `json:"uri"`
+}
+
+func (s *Server) readResource(params json.RawMessage) (any, error) {
+	var p readResourceParams
+	err := json.Unmarshal(params, &p)
+	if err != nil {
+		return nil, newRPCError(codeInvalidParams, err.Error())
+	}
+
+	idStr, ok := strings.CutPrefix(p.URI, taskURIPrefix)
+	if !ok {
+		return nil, newRPCError(codeInvalidParams, fmt.Sprintf("unknown resource: %q", p.URI))
+	}
+
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return nil, newRPCError(codeInvalidParams, fmt.Sprintf("unknown resource: %q", p.URI))
+	}
+
+	task, err := pers.FetchTaskByID(s.db, id)
+	if err != nil {
+		return nil, newRPCError(codeInvalidParams, fmt.Sprintf("%s: %q", err.Error(), p.URI))
+	}
+
+	return map[string]any{
+		"contents": []map[string]any{
+			{
+				"uri":      p.URI,
+				"mimeType": markdownMimeType,
+				"text":     taskMarkdown(task),
+			},
+		},
+	}, nil
+}
+
+func taskMarkdown(t types.Task) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# %s\n", t.Summary)
+	if !t.Active {
+		sb.WriteString("\n_archived_\n")
+	}
+	if t.Context != nil {
+		fmt.Fprintf(&sb, "\n%s\n", strings.TrimRight(*t.Context, "\n"))
+	}
+
+	return sb.String()
+}
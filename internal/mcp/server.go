@@ -0,0 +1,189 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"slices"
+	"sync"
+
+	"github.com/dhth/omm/internal/hooks"
+	pers "github.com/dhth/omm/internal/persistence"
+)
+
+const (
+	serverName              = "omm"
+	latestProtocolVersion   = "2025-06-18"
+	maxMessageBytes         = 4 * pers.ContextMaxBytes
+	serverInstructionsWrite = `omm is a task manager that keeps active tasks in a single list, ordered by priority (the first task is the most important one). Tasks can have a prefix (the part of the summary before a ":"), and a markdown context with further details. Archived tasks are tasks that are done.`
+	serverInstructionsRead  = serverInstructionsWrite + ` This server is read-only; tasks can't be modified through it.`
+)
+
+var supportedProtocolVersions = []string{latestProtocolVersion, "2025-03-26", "2024-11-05"}
+
+// Server speaks the Model Context Protocol over a stream of newline delimited
+// JSON-RPC messages, exposing omm's tasks as tools and resources.
+type Server struct {
+	db       *sql.DB
+	readOnly bool
+	version  string
+	hooks    hooks.Config
+	errLog   io.Writer
+	tools    []tool
+	mu       sync.Mutex
+}
+
+// New returns a Server for the database. When readOnly is true, only the tools
+// that don't modify tasks are exposed. Hooks are run after tasks are changed;
+// hook failures are written to errLog.
+func New(db *sql.DB, version string, readOnly bool, hooksCfg hooks.Config, errLog io.Writer) *Server {
+	s := &Server{db: db, readOnly: readOnly, version: version, hooks: hooksCfg, errLog: errLog}
+	for _, t := range s.allTools() {
+		if readOnly && !t.readOnly {
+			continue
+		}
+		s.tools = append(s.tools, t)
+	}
+
+	return s
+}
+
+// Serve reads messages from reader, and writes responses to writer, until
+// reader is exhausted.
+func (s *Server) Serve(reader io.Reader, writer io.Writer) error {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxMessageBytes)
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetEscapeHTML(false)
+
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		resp, ok := s.handleMessage(line)
+		if !ok {
+			continue
+		}
+
+		err := encoder.Encode(resp)
+		if err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+// handleMessage returns the response for a message, and whether there is one to
+// send at all (notifications don't get responses).
+func (s *Server) handleMessage(data []byte) (response, bool) {
+	var req request
+	err := json.Unmarshal(data, &req)
+	if err != nil {
+		return errorResponse(json.RawMessage("null"), newRPCError(codeParseError, "parse error: "+err.Error())), true
+	}
+
+	if req.JSONRPC != jsonRPCVersion || req.Method == "" {
+		if req.isNotification() {
+			return response{}, false
+		}
+		return errorResponse(req.ID, newRPCError(codeInvalidRequest, "invalid request")), true
+	}
+
+	result, err := s.dispatch(req)
+	if req.isNotification() {
+		return response{}, false
+	}
+
+	if err != nil {
+		var rpcErr *rpcError
+		if !errors.As(err, &rpcErr) {
+			rpcErr = newRPCError(codeInternalError, err.Error())
+		}
+		return errorResponse(req.ID, rpcErr), true
+	}
+
+	return response{JSONRPC: jsonRPCVersion, ID: req.ID, Result: result}, true
+}
+
+func (s *Server) dispatch(req request) (any, error) {
+	// requests are handled one at a time; the database only allows a single
+	// connection anyway
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch req.Method {
+	case "initialize":
+		return s.initialize(req.Params)
+	case "ping":
+		return struct{}{}, nil
+	case "tools/list":
+		return s.listTools(), nil
+	case "tools/call":
+		return s.callTool(req.Params)
+	case "resources/list":
+		return s.listResources()
+	case "resources/templates/list":
+		return s.listResourceTemplates(), nil
+	case "resources/read":
+		return s.readResource(req.Params)
+	}
+
+	if req.isNotification() {
+		// eg. notifications/initialized, notifications/cancelled
+		return nil, nil
+	}
+
+	return nil, newRPCError(codeMethodNotFound, "method not found: "+req.Method)
+}
+
+type initializeParams struct {
+	ProtocolVersion string `json:"protocolVersion"`
+}
+
+func (s *Server) initialize(params json.RawMessage) (any, error) {
+	var p initializeParams
+	if len(params) > 0 {
+		err := json.Unmarshal(params, &p)
+		if err != nil {
+			return nil, newRPCError(codeInvalidParams, err.Error())
+		}
+	}
+
+	version := latestProtocolVersion
+	if slices.Contains(supportedProtocolVersions, p.ProtocolVersion) {
+		version = p.ProtocolVersion
+	}
+
+	instructions := serverInstructionsWrite
+	if s.readOnly {
+		instructions = serverInstructionsRead
+	}
+
+	return map[string]any{
+		"protocolVersion": version,
+		"capabilities": map[string]any{
+			"tools":     map[string]any{},
+			"resources": map[string]any{},
+		},
+		"serverInfo": map[string]any{
+			"name":    serverName,
+			"version": s.version,
+		},
+		"instructions": instructions,
+	}, nil
+}
+
+func errorResponse(id json.RawMessage, err *rpcError) response {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+
+	return response{JSONRPC: jsonRPCVersion, ID: id, Error: err}
+}
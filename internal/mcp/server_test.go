@@ -0,0 +1,274 @@
+package mcp
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dhth/omm/internal/hooks"
+	pers "github.com/dhth/omm/internal/persistence"
+	"github.com/dhth/omm/internal/testutil"
+	"github.com/dhth/omm/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db := testutil.NewDB(t)
+
+	now := time.Now()
+	context := "some **context**"
+	_, err := pers.InsertTasks(db, []types.Task{
+		{Summary: "home: task 1", Context: &context, Active: true, CreatedAt: now, UpdatedAt: now},
+		{Summary: "work: task 2", Active: true, CreatedAt: now, UpdatedAt: now},
+		{Summary: "task 3", Active: false, CreatedAt: now, UpdatedAt: now},
+	}, false)
+	require.NoError(t, err)
+
+	return db
+}
+
+type testResponse struct {
+	ID     int             `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+}
+
+// exchange sends messages to the server, and returns the responses it sent
+// back, keyed by the request IDs.
+func exchange(t *testing.T, server *Server, messages ...string) map[int]testResponse {
+	t.Helper()
+
+	var out bytes.Buffer
+	require.NoError(t, server.Serve(strings.NewReader(strings.Join(messages, "\n")), &out))
+
+	responses := make(map[int]testResponse)
+	for line := range strings.SplitSeq(strings.TrimSpace(out.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var resp testResponse
+		require.NoError(t, json.Unmarshal([]byte(line), &resp))
+		responses[resp.ID] = resp
+	}
+
+	return responses
+}
+
+type toolCallResult struct {
+	Content []struct {
+		Text string `json:"text"`
+	} `json:"content"`
+	IsError bool `json:"isError"`
+}
+
+func decodeToolResult(t *testing.T, resp testResponse) (string, bool) {
+	t.Helper()
+
+	require.Nil(t, resp.Error)
+	var result toolCallResult
+	require.NoError(t, json.Unmarshal(resp.Result, &result))
+	require.Len(t, result.Content, 1)
+
+	return result.Content[0].Text, result.IsError
+}
+
+func TestInitializeAndListTools(t *testing.T) {
+	testCases := []struct {
+		name          string
+		readOnly      bool
+		expectedTools []string
+	}{
+		{
+			name:     "read-write",
+			readOnly: false,
+			expectedTools: []string{
+				"list_tasks", "get_task", "add_task", "update_summary", "update_context",
+				"archive_task", "unarchive_task", "move_task",
+			},
+		},
+		{
+			name:          "read-only",
+			readOnly:      true,
+			expectedTools: []string{"list_tasks", "get_task"},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			server := New(getTestDB(t), "v1.0.0", tt.readOnly, hooks.Config{}, io.Discard)
+
+			// WHEN
+			responses := exchange(t, server,
+				`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`,
+				`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+				`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+			)
+
+			// THEN
+			require.Len(t, responses, 2)
+
+			var initResult struct {
+				ProtocolVersion string `json:"protocolVersion"`
+				ServerInfo      struct {
+					Version string `json:"version"`
+				} `json:"serverInfo"`
+			}
+			require.NoError(t, json.Unmarshal(responses[1].Result, &initResult))
+			assert.Equal(t, "2025-03-26", initResult.ProtocolVersion)
+			assert.Equal(t, "v1.0.0", initResult.ServerInfo.Version)
+
+			var toolsResult struct {
+				Tools []struct {
+					Name string `json:"name"`
+				} `json:"tools"`
+			}
+			require.NoError(t, json.Unmarshal(responses[2].Result, &toolsResult))
+			names := make([]string, len(toolsResult.Tools))
+			for i, tool := range toolsResult.Tools {
+				names[i] = tool.Name
+			}
+			assert.Equal(t, tt.expectedTools, names)
+		})
+	}
+}
+
+func TestCallingTools(t *testing.T) {
+	// GIVEN
+	db := getTestDB(t)
+	server := New(db, "dev", false, hooks.Config{}, io.Discard)
+
+	// WHEN
+	responses := exchange(t, server,
+		`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"add_task","arguments":{"summary":"work: task 4","context":"details"}}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"archive_task","arguments":{"id":1}}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"move_task","arguments":{"id":4,"position":1}}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"update_context","arguments":{"id":2,"context":"new context"}}}`,
+		`{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"list_tasks","arguments":{"prefix":"work"}}}`,
+		`{"jsonrpc":"2.0","id":6,"method":"tools/call","params":{"name":"add_task","arguments":{"summary":"  "}}}`,
+	)
+
+	// THEN
+	require.Len(t, responses, 6)
+	for id := 1; id <= 5; id++ {
+		text, isError := decodeToolResult(t, responses[id])
+		assert.False(t, isError, "tool call %d failed: %s", id, text)
+	}
+
+	text, _ := decodeToolResult(t, responses[5])
+	var listed []taskResult
+	require.NoError(t, json.Unmarshal([]byte(text), &listed))
+	require.Len(t, listed, 2)
+	assert.Equal(t, "work: task 2", listed[0].Summary)
+	assert.Equal(t, "work: task 4", listed[1].Summary)
+	require.NotNil(t, listed[1].Position)
+	assert.Equal(t, 1, *listed[1].Position)
+
+	text, isError := decodeToolResult(t, responses[6])
+	assert.True(t, isError)
+	assert.Equal(t, types.ErrTaskSummaryEmpty.Error(), text)
+
+	task, err := pers.FetchTaskByID(db, 2)
+	require.NoError(t, err)
+	require.NotNil(t, task.Context)
+	assert.Equal(t, "new context", *task.Context)
+}
+
+func TestListingSnoozedTasks(t *testing.T) {
+	// GIVEN
+	db := getTestDB(t)
+	require.NoError(t, pers.SnoozeTask(db, 1, time.Now().Add(time.Hour)))
+	server := New(db, "dev", true, hooks.Config{}, io.Discard)
+
+	// WHEN
+	responses := exchange(t, server,
+		`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"list_tasks","arguments":{}}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"resources/list"}`,
+	)
+
+	// THEN
+	text, isError := decodeToolResult(t, responses[1])
+	require.False(t, isError, text)
+	var listed []taskResult
+	require.NoError(t, json.Unmarshal([]byte(text), &listed))
+	require.Len(t, listed, 2)
+	assert.Equal(t, uint64(2), listed[0].ID)
+	require.NotNil(t, listed[0].Position)
+	assert.Equal(t, 0, *listed[0].Position)
+	assert.Equal(t, uint64(1), listed[1].ID)
+	assert.True(t, listed[1].Active)
+	assert.Nil(t, listed[1].Position)
+
+	var listResult struct {
+		Resources []struct {
+			URI string `json:"uri"`
+		} `json:"resources"`
+	}
+	require.NoError(t, json.Unmarshal(responses[2].Result, &listResult))
+	require.Len(t, listResult.Resources, 2)
+	assert.Equal(t, "omm://task/1", listResult.Resources[1].URI)
+}
+
+func TestReadOnlyServerRejectsWriteTools(t *testing.T) {
+	// GIVEN
+	server := New(getTestDB(t), "dev", true, hooks.Config{}, io.Discard)
+
+	// WHEN
+	responses := exchange(t, server,
+		`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"archive_task","arguments":{"id":1}}}`,
+	)
+
+	// THEN
+	require.NotNil(t, responses[1].Error)
+	assert.Equal(t, codeInvalidParams, responses[1].Error.Code)
+}
+
+func TestReadingResources(t *testing.T) {
+	// GIVEN
+	server := New(getTestDB(t), "dev", true, hooks.Config{}, io.Discard)
+
+	// WHEN
+	responses := exchange(t, server,
+		`{"jsonrpc":"2.0","id":1,"method":"resources/list"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"resources/read","params":{"uri":"omm://task/1"}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"resources/read","params":{"uri":"omm://task/100"}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"unknown/method"}`,
+		`not json`,
+	)
+
+	// THEN
+	var listResult struct {
+		Resources []struct {
+			URI string `json:"uri"`
+		} `json:"resources"`
+	}
+	require.NoError(t, json.Unmarshal(responses[1].Result, &listResult))
+	require.Len(t, listResult.Resources, 2)
+	assert.Equal(t, "omm://task/1", listResult.Resources[0].URI)
+
+	var readResult struct {
+		Contents []struct {
+			Text string `json:"text"`
+		} `json:"contents"`
+	}
+	require.NoError(t, json.Unmarshal(responses[2].Result, &readResult))
+	require.Len(t, readResult.Contents, 1)
+	assert.Equal(t, "# home: task 1\n\nsome **context**\n", readResult.Contents[0].Text)
+
+	require.NotNil(t, responses[3].Error)
+	assert.Equal(t, codeInvalidParams, responses[3].Error.Code)
+
+	require.NotNil(t, responses[4].Error)
+	assert.Equal(t, codeMethodNotFound, responses[4].Error.Code)
+
+	// the parse error has a null id
+	require.NotNil(t, responses[0].Error)
+	assert.Equal(t, codeParseError, responses[0].Error.Code)
+}
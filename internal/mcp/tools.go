@@ -0,0 +1,547 @@
+package mcp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dhth/omm/internal/hooks"
+	pers "github.com/dhth/omm/internal/persistence"
+	"github.com/dhth/omm/internal/types"
+)
+
+const defaultListLimit = 50
+
+var (
+	errUnknownTool         = errors.New("unknown tool")
+	errInvalidStatus       = errors.New(`invalid status; possible values: [active, archived]`)
+	errTaskIDMissing       = errors.New("task id is missing")
+	errContextTooLarge     = errors.New("task context is too large")
+	errWillExceedCapacity  = errors.New("adding a task will exceed the active task limit")
+	errTaskAlreadyArchived = errors.New("task is already archived")
+	errTaskAlreadyActive   = errors.New("task is already active")
+)
+
+type tool struct {
+	name        string
+	description string
+	inputSchema map[string]any
+	readOnly    bool
+	handle      func(args json.RawMessage) (any, error)
+}
+
+// taskResult is the representation of a task returned by tools.
+type taskResult struct {
+	ID        uint64    `json:"id"`
+	Summary   string    `json:"summary"`
+	Prefix    string    `json:"prefix,omitempty"`
+	Active    bool      `json:"active"`
+	Position  *int      `json:"position,omitempty"`
+	Context   *string   `json:"context,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func objectSchema(properties map[string]any, required ...string) map[string]any {
+	schema := map[string]any{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+
+	return schema
+}
+
+var taskIDProperty = map[string]any{
+	"type":        "integer",
+	"description": "ID of the task",
+}
+
+func (s *Server) allTools() []tool {
+	return []tool{
+		{
+			name:        "list_tasks",
+			description: "List tasks. Active tasks are returned in priority order (the first one is the most important); archived tasks are returned most recently updated first. Contexts are not included; use get_task for those.",
+			inputSchema: objectSchema(map[string]any{
+				"status": map[string]any{
+					"type":        "string",
+					"enum":        []string{"active", "archived"},
+					"description": "which tasks to list; defaults to active",
+				},
+				"prefix": map[string]any{
+					"type":        "string",
+					"description": "only list tasks with this prefix (the part of the summary before the first \":\")",
+				},
+				"limit": map[string]any{
+					"type":        "integer",
+					"description": fmt.Sprintf("maximum number of tasks to return; defaults to %d", defaultListLimit),
+				},
+			}),
+			readOnly: true,
+			handle:   s.listTasksTool,
+		},
+		{
+			name:        "get_task",
+			description: "Get a task, including its context.",
+			inputSchema: objectSchema(map[string]any{"id": taskIDProperty}, "id"),
+			readOnly:    true,
+			handle:      s.getTaskTool,
+		},
+		{
+			name:        "add_task",
+			description: fmt.Sprintf("Add a task to the top of the active list (or the bottom, if at_end is set). Summaries can be prefixed (eg. \"work: write report\"), and can't be longer than %d characters.", types.TaskSummaryMaxLen),
+			inputSchema: objectSchema(map[string]any{
+				"summary": map[string]any{"type": "string", "description": "summary of the task"},
+				"context": map[string]any{"type": "string", "description": "markdown context for the task"},
+				"at_end":  map[string]any{"type": "boolean", "description": "add the task to the bottom of the list instead"},
+			}, "summary"),
+			handle: s.addTaskTool,
+		},
+		{
+			name:        "update_summary",
+			description: "Change the summary of a task.",
+			inputSchema: objectSchema(map[string]any{
+				"id":      taskIDProperty,
+				"summary": map[string]any{"type": "string", "description": "new summary of the task"},
+			}, "id", "summary"),
+			handle: s.updateSummaryTool,
+		},
+		{
+			name:        "update_context",
+			description: "Replace the markdown context of a task. An empty context removes it.",
+			inputSchema: objectSchema(map[string]any{
+				"id":      taskIDProperty,
+				"context": map[string]any{"type": "string", "description": "new context of the task"},
+			}, "id", "context"),
+			handle: s.updateContextTool,
+		},
+		{
+			name:        "archive_task",
+			description: "Archive an active task (ie, mark it as done).",
+			inputSchema: objectSchema(map[string]any{"id": taskIDProperty}, "id"),
+			handle:      s.archiveTaskTool,
+		},
+		{
+			name:        "unarchive_task",
+			description: "Move an archived task back to the top of the active list.",
+			inputSchema: objectSchema(map[string]any{"id": taskIDProperty}, "id"),
+			handle:      s.unarchiveTaskTool,
+		},
+		{
+			name:        "move_task",
+			description: "Move an active task to a position in the active list. Position 0 is the top of the list.",
+			inputSchema: objectSchema(map[string]any{
+				"id":       taskIDProperty,
+				"position": map[string]any{"type": "integer", "description": "new (zero based) position of the task"},
+			}, "id", "position"),
+			handle: s.moveTaskTool,
+		},
+	}
+}
+
+func (s *Server) listTools() any {
+	tools := make([]map[string]any, len(s.tools))
+	for i, t := range s.tools {
+		tools[i] = map[string]any{
+			"name":        t.name,
+			"description": t.description,
+			"inputSchema": t.inputSchema,
+			"annotations": map[string]any{
+				"readOnlyHint": t.readOnly,
+			},
+		}
+	}
+
+	return map[string]any{"tools": tools}
+}
+
+type callToolParams struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+// callTool runs a tool. Errors that the model could act on (eg. an invalid
+// summary) are returned as tool results with isError set, as opposed to
+// protocol errors.
+func (s *Server) callTool(params json.RawMessage) (any, error) {
+	var p callToolParams
+	err := json.Unmarshal(params, &p)
+	if err != nil {
+		return nil, newRPCError(codeInvalidParams, err.Error())
+	}
+
+	var t *tool
+	for i := range s.tools {
+		if s.tools[i].name == p.Name {
+			t = &s.tools[i]
+			break
+		}
+	}
+
+	if t == nil {
+		return nil, newRPCError(codeInvalidParams, fmt.Sprintf("%s: %q", errUnknownTool.Error(), p.Name))
+	}
+
+	args := p.Arguments
+	if len(args) == 0 || string(args) == "null" {
+		args = json.RawMessage("{}")
+	}
+
+	result, err := t.handle(args)
+	if err != nil {
+		return toolResult(err.Error(), true), nil
+	}
+
+	text, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return toolResult(string(text), false), nil
+}
+
+func toolResult(text string, isError bool) map[string]any {
+	return map[string]any{
+		"content": []map[string]any{{"type": "text", "text": text}},
+		"isError": isError,
+	}
+}
+
+func decodeArgs(args json.RawMessage, v any) error {
+	err := json.Unmarshal(args, v)
+	if err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	return nil
+}
+
+func toTaskResult(t types.Task, position *int, withContext bool) taskResult {
+	var prefix string
+	p, ok := t.Prefix()
+	if ok {
+		prefix = string(p)
+	}
+
+	result := taskResult{
+		ID:        t.ID,
+		Summary:   t.Summary,
+		Prefix:    prefix,
+		Active:    t.Active,
+		Position:  position,
+		CreatedAt: t.CreatedAt,
+		UpdatedAt: t.UpdatedAt,
+	}
+	if withContext {
+		result.Context = t.Context
+	}
+
+	return result
+}
+
+type listTasksArgs struct {
+	Status string `json:"status"`
+	Prefix string `json:"prefix"`
+	Limit  int    `json:"limit"`
+}
+
+func (s *Server) listTasksTool(args json.RawMessage) (any, error) {
+	var a listTasksArgs
+	err := decodeArgs(args, &a)
+	if err != nil {
+		return nil, err
+	}
+
+	var tasks []types.Task
+	active := true
+	switch a.Status {
+	case "", "active":
+		tasks, err = pers.FetchAllActiveTasks(s.db)
+	case "archived":
+		active = false
+		tasks, err = pers.FetchInActiveTasks(s.db, pers.TaskNumLimit)
+	default:
+		return nil, errInvalidStatus
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	limit := a.Limit
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	prefix := strings.TrimSpace(a.Prefix)
+
+	results := make([]taskResult, 0, min(limit, len(tasks)))
+	for i, t := range tasks {
+		if len(results) >= limit {
+			break
+		}
+
+		if prefix != "" {
+			p, ok := t.Prefix()
+			if !ok || string(p) != prefix {
+				continue
+			}
+		}
+
+		// snoozed tasks aren't in the task list, so they don't have a position
+		var position *int
+		if active && t.SnoozedUntil == nil {
+			position = &i
+		}
+		results = append(results, toTaskResult(t, position, false))
+	}
+
+	return results, nil
+}
+
+type taskIDArgs struct {
+	ID uint64 `json:"id"`
+}
+
+func (s *Server) fetchTask(args json.RawMessage) (types.Task, error) {
+	var a taskIDArgs
+	err := decodeArgs(args, &a)
+	if err != nil {
+		return types.Task{}, err
+	}
+
+	if a.ID == 0 {
+		return types.Task{}, errTaskIDMissing
+	}
+
+	return pers.FetchTaskByID(s.db, a.ID)
+}
+
+func (s *Server) getTaskTool(args json.RawMessage) (any, error) {
+	task, err := s.fetchTask(args)
+	if err != nil {
+		return nil, err
+	}
+
+	return toTaskResult(task, nil, true), nil
+}
+
+type addTaskArgs struct {
+	Summary string `json:"summary"`
+	Context string `json:"context"`
+	AtEnd   bool   `json:"at_end"`
+}
+
+func (s *Server) addTaskTool(args json.RawMessage) (any, error) {
+	var a addTaskArgs
+	err := decodeArgs(args, &a)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := strings.TrimSpace(a.Summary)
+	_, err = types.CheckIfTaskSummaryValid(summary)
+	if err != nil {
+		return nil, err
+	}
+
+	var context *string
+	if a.Context != "" {
+		if len(a.Context) > pers.ContextMaxBytes {
+			return nil, errContextTooLarge
+		}
+		context = &a.Context
+	}
+
+	err = s.checkCapacity()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	lastID, err := pers.InsertTasks(s.db, []types.Task{{
+		Summary:   summary,
+		Context:   context,
+		Active:    true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}}, !a.AtEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	task, err := pers.FetchTaskByID(s.db, uint64(lastID))
+	if err != nil {
+		return nil, err
+	}
+
+	s.runHook(hooks.EventCreate, task)
+	return toTaskResult(task, nil, true), nil
+}
+
+type updateSummaryArgs struct {
+	ID      uint64 `json:"id"`
+	Summary string `json:"summary"`
+}
+
+func (s *Server) updateSummaryTool(args json.RawMessage) (any, error) {
+	task, err := s.fetchTask(args)
+	if err != nil {
+		return nil, err
+	}
+
+	var a updateSummaryArgs
+	err = decodeArgs(args, &a)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := strings.TrimSpace(a.Summary)
+	_, err = types.CheckIfTaskSummaryValid(summary)
+	if err != nil {
+		return nil, err
+	}
+
+	err = pers.UpdateTaskSummary(s.db, task.ID, summary, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return s.fetchUpdatedTask(task.ID, hooks.EventUpdate)
+}
+
+type updateContextArgs struct {
+	ID      uint64 `json:"id"`
+	Context string `json:"context"`
+}
+
+func (s *Server) updateContextTool(args json.RawMessage) (any, error) {
+	task, err := s.fetchTask(args)
+	if err != nil {
+		return nil, err
+	}
+
+	var a updateContextArgs
+	err = decodeArgs(args, &a)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(a.Context) > pers.ContextMaxBytes {
+		return nil, errContextTooLarge
+	}
+
+	if a.Context == "" {
+		err = pers.UnsetTaskContext(s.db, task.ID, time.Now())
+	} else {
+		err = pers.UpdateTaskContext(s.db, task.ID, a.Context, time.Now())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.fetchUpdatedTask(task.ID, hooks.EventUpdate)
+}
+
+func (s *Server) archiveTaskTool(args json.RawMessage) (any, error) {
+	return s.changeTaskStatus(args, false)
+}
+
+func (s *Server) unarchiveTaskTool(args json.RawMessage) (any, error) {
+	return s.changeTaskStatus(args, true)
+}
+
+func (s *Server) changeTaskStatus(args json.RawMessage, active bool) (any, error) {
+	task, err := s.fetchTask(args)
+	if err != nil {
+		return nil, err
+	}
+
+	if task.Active == active {
+		if active {
+			return nil, errTaskAlreadyActive
+		}
+		return nil, errTaskAlreadyArchived
+	}
+
+	if active {
+		err = s.checkCapacity()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = pers.SetTaskActive(s.db, task.ID, active, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	event := hooks.EventArchive
+	if active {
+		event = hooks.EventUnarchive
+	}
+	return s.fetchUpdatedTask(task.ID, event)
+}
+
+type moveTaskArgs struct {
+	ID       uint64 `json:"id"`
+	Position int    `json:"position"`
+}
+
+func (s *Server) moveTaskTool(args json.RawMessage) (any, error) {
+	task, err := s.fetchTask(args)
+	if err != nil {
+		return nil, err
+	}
+
+	var a moveTaskArgs
+	err = decodeArgs(args, &a)
+	if err != nil {
+		return nil, err
+	}
+
+	err = pers.MoveTask(s.db, task.ID, a.Position)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.fetchUpdatedTask(task.ID, "")
+}
+
+// fetchUpdatedTask returns the current state of a task, after running the hook
+// for event (if any).
+func (s *Server) fetchUpdatedTask(id uint64, event hooks.Event) (any, error) {
+	task, err := pers.FetchTaskByID(s.db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if event != "" {
+		s.runHook(event, task)
+	}
+	return toTaskResult(task, nil, false), nil
+}
+
+// runHook runs the hook for an event; failures don't fail the tool call, as
+// the change has been saved already.
+func (s *Server) runHook(event hooks.Event, task types.Task) {
+	err := s.hooks.Run(event, task)
+	if err != nil {
+		fmt.Fprintf(s.errLog, "Warning: %s\n", err.Error())
+	}
+}
+
+func (s *Server) checkCapacity() error {
+	numTasks, err := pers.FetchNumActiveTasks(s.db)
+	if err != nil {
+		return err
+	}
+
+	if numTasks+1 > pers.TaskNumLimit {
+		return errWillExceedCapacity
+	}
+
+	return nil
+}
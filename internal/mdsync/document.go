@@ -0,0 +1,162 @@
+package mdsync
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dhth/omm/internal/types"
+	"go.yaml.in/yaml/v3"
+)
+
+const (
+	frontMatterDelimiter = "---"
+	slugMaxLen           = 48
+)
+
+var (
+	errFrontMatterMissing    = errors.New("front matter is missing")
+	errFrontMatterUnclosed   = errors.New("front matter is not closed")
+	errFrontMatterMalformed  = errors.New("front matter is malformed")
+	nonSlugCharsRegex        = regexp.MustCompile(`[^a-z0-9]+`)
+	frontMatterDelimiterLine = []byte(frontMatterDelimiter + "\n")
+)
+
+type frontMatter struct {
+	ID        uint64    `yaml:"id,omitempty"`
+	UUID      string    `yaml:"uuid,omitempty"`
+	Summary   string    `yaml:"summary"`
+	Prefix    string    `yaml:"prefix,omitempty"`
+	Active    *bool     `yaml:"active,omitempty"`
+	Order     int       `yaml:"order,omitempty"`
+	CreatedAt time.Time `yaml:"created_at,omitempty"`
+	UpdatedAt time.Time `yaml:"updated_at,omitempty"`
+}
+
+// document is the representation of a task as a markdown file: the task's
+// attributes live in YAML front matter, and its context is the file's body.
+type document struct {
+	frontMatter
+	context *string
+}
+
+func (d document) active() bool {
+	// files created by hand without an "active" key are considered active
+	return d.Active == nil || *d.Active
+}
+
+func newDocument(task types.Task, order int) document {
+	prefix, _, _ := task.GetPrefixAndSummaryContent()
+	active := task.Active
+
+	return document{
+		frontMatter: frontMatter{
+			ID:        task.ID,
+			UUID:      task.UUID,
+			Summary:   task.Summary,
+			Prefix:    prefix,
+			Active:    &active,
+			Order:     order,
+			CreatedAt: task.CreatedAt.UTC(),
+			UpdatedAt: task.UpdatedAt.UTC(),
+		},
+		context: task.Context,
+	}
+}
+
+func (d document) render() ([]byte, error) {
+	fm, err := yaml.Marshal(d.frontMatter)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.Write(frontMatterDelimiterLine)
+	buf.Write(fm)
+	buf.Write(frontMatterDelimiterLine)
+	if d.context != nil {
+		buf.WriteString(*d.context)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func parseDocument(data []byte) (document, error) {
+	var doc document
+
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	if !bytes.HasPrefix(data, frontMatterDelimiterLine) {
+		return doc, errFrontMatterMissing
+	}
+
+	rest := data[len(frontMatterDelimiterLine):]
+	var fm, body []byte
+	switch {
+	case bytes.HasPrefix(rest, frontMatterDelimiterLine):
+		body = rest[len(frontMatterDelimiterLine):]
+	default:
+		end := bytes.Index(rest, []byte("\n"+frontMatterDelimiter+"\n"))
+		if end == -1 {
+			if !bytes.HasSuffix(rest, []byte("\n"+frontMatterDelimiter)) {
+				return doc, errFrontMatterUnclosed
+			}
+			end = len(rest) - len(frontMatterDelimiter) - 1
+			fm = rest[:end]
+		} else {
+			fm = rest[:end]
+			body = rest[end+len(frontMatterDelimiter)+2:]
+		}
+	}
+
+	err := yaml.Unmarshal(fm, &doc.frontMatter)
+	if err != nil {
+		return doc, fmt.Errorf("%w: %s", errFrontMatterMalformed, err.Error())
+	}
+
+	doc.Summary = strings.TrimSpace(doc.Summary)
+
+	if strings.TrimSpace(string(body)) != "" {
+		c := string(body)
+		doc.context = &c
+	}
+
+	return doc, nil
+}
+
+// contentHash returns a hash of the parts of a task that can be changed on
+// either side of a sync.
+func contentHash(summary string, active bool, context *string) string {
+	var ctx string
+	if context != nil {
+		ctx = strings.TrimSpace(*context)
+	}
+
+	h := sha256.New()
+	h.Write([]byte(strings.TrimSpace(summary)))
+	h.Write([]byte{0})
+	h.Write([]byte(strconv.FormatBool(active)))
+	h.Write([]byte{0})
+	h.Write([]byte(ctx))
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func fileNameForTask(task types.Task) string {
+	_, sc, _ := task.GetPrefixAndSummaryContent()
+	slug := strings.Trim(nonSlugCharsRegex.ReplaceAllString(strings.ToLower(sc), "-"), "-")
+	if len(slug) > slugMaxLen {
+		slug = strings.TrimRight(slug[:slugMaxLen], "-")
+	}
+
+	if slug == "" {
+		return fmt.Sprintf("%04d.md", task.ID)
+	}
+
+	return fmt.Sprintf("%04d-%s.md", task.ID, slug)
+}
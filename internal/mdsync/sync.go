@@ -0,0 +1,731 @@
+package mdsync
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	pers "github.com/dhth/omm/internal/persistence"
+	"github.com/dhth/omm/internal/types"
+)
+
+const (
+	stateFileName = ".omm-sync.json"
+	stateVersion  = 1
+)
+
+var ErrCouldntReadState = errors.New("couldn't read sync state")
+
+// ChangeKind describes what a sync did for a single task.
+type ChangeKind uint8
+
+const (
+	FileCreated ChangeKind = iota
+	FileUpdated
+	FileDeleted
+	TaskCreated
+	TaskUpdated
+	TaskDeleted
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case FileCreated:
+		return "file created"
+	case FileUpdated:
+		return "file updated"
+	case FileDeleted:
+		return "file deleted"
+	case TaskCreated:
+		return "task created"
+	case TaskUpdated:
+		return "task updated"
+	case TaskDeleted:
+		return "task deleted"
+	default:
+		return "unknown"
+	}
+}
+
+type Change struct {
+	Kind     ChangeKind
+	TaskID   uint64
+	FileName string
+}
+
+// Conflict is reported when a task can't be synced without losing changes
+// made on one of the two sides; neither side is modified in that case.
+type Conflict struct {
+	TaskID   uint64
+	FileName string
+	Reason   string
+}
+
+type Report struct {
+	Changes   []Change
+	Conflicts []Conflict
+}
+
+type syncedTask struct {
+	FileName string    `json:"file"`
+	Hash     string    `json:"hash"`
+	Order    int       `json:"order,omitempty"`
+	SyncedAt time.Time `json:"synced_at"`
+}
+
+type state struct {
+	Version int                   `json:"version"`
+	Tasks   map[uint64]syncedTask `json:"tasks"`
+}
+
+type taskFile struct {
+	name string
+	doc  document
+}
+
+type syncer struct {
+	db     *sql.DB
+	dir    string
+	now    time.Time
+	state  state
+	report Report
+
+	tasks      map[uint64]types.Task
+	order      map[uint64]int
+	sequence   []uint64
+	numSnoozed int
+
+	// moves holds the positions (1-indexed) that tasks were moved to in the
+	// directory
+	moves          map[uint64]int
+	newlyActive    []uint64
+	newlyInactive  map[uint64]struct{}
+	deleted        map[uint64]struct{}
+	sequenceDirty  bool
+	inConflict     map[uint64]struct{}
+	filesByTaskID  map[uint64]taskFile
+	unlinkedFiles  []taskFile
+	fileNamesTaken map[string]struct{}
+}
+
+// Sync mirrors omm's tasks to markdown files in dir, and applies changes made
+// to those files back to omm. Changes are detected by comparing each side
+// with the state recorded at the end of the previous sync. When a task has
+// changed on both sides, it's reported as a conflict and left untouched.
+func Sync(db *sql.DB, dir string, now time.Time) (Report, error) {
+	s := syncer{
+		db:             db,
+		dir:            dir,
+		now:            now,
+		moves:          make(map[uint64]int),
+		newlyInactive:  make(map[uint64]struct{}),
+		deleted:        make(map[uint64]struct{}),
+		inConflict:     make(map[uint64]struct{}),
+		filesByTaskID:  make(map[uint64]taskFile),
+		fileNamesTaken: make(map[string]struct{}),
+	}
+
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return s.report, err
+	}
+
+	err = s.loadState()
+	if err != nil {
+		return s.report, err
+	}
+
+	err = s.loadTasks()
+	if err != nil {
+		return s.report, err
+	}
+
+	err = s.loadFiles()
+	if err != nil {
+		return s.report, err
+	}
+
+	err = s.syncKnownTasks()
+	if err != nil {
+		return s.report, err
+	}
+
+	err = s.syncUnlinkedFiles()
+	if err != nil {
+		return s.report, err
+	}
+
+	err = s.syncSequence()
+	if err != nil {
+		return s.report, err
+	}
+
+	err = s.syncOrder()
+	if err != nil {
+		return s.report, err
+	}
+
+	return s.report, s.saveState()
+}
+
+func (s *syncer) loadState() error {
+	s.state = state{Version: stateVersion, Tasks: make(map[uint64]syncedTask)}
+
+	data, err := os.ReadFile(filepath.Join(s.dir, stateFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrCouldntReadState, err.Error())
+	}
+
+	err = json.Unmarshal(data, &s.state)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrCouldntReadState, err.Error())
+	}
+
+	if s.state.Tasks == nil {
+		s.state.Tasks = make(map[uint64]syncedTask)
+	}
+
+	return nil
+}
+
+func (s *syncer) saveState() error {
+	data, err := json.MarshalIndent(s.state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomically(filepath.Join(s.dir, stateFileName), data)
+}
+
+func (s *syncer) loadTasks() error {
+	activeTasks, err := pers.FetchActiveTasks(s.db, pers.TaskNumLimit)
+	if err != nil {
+		return err
+	}
+
+	archivedTasks, err := pers.FetchInActiveTasks(s.db, pers.TaskNumLimit)
+	if err != nil {
+		return err
+	}
+
+	// snoozed tasks aren't in the task list, so they don't have a position
+	// in it, but their files are kept in sync all the same
+	snoozedTasks, err := pers.FetchSnoozedTasks(s.db, pers.TaskNumLimit)
+	if err != nil {
+		return err
+	}
+
+	s.tasks = make(map[uint64]types.Task, len(activeTasks)+len(archivedTasks)+len(snoozedTasks))
+	s.order = make(map[uint64]int, len(activeTasks))
+	s.sequence = make([]uint64, 0, len(activeTasks))
+
+	for i, t := range activeTasks {
+		s.tasks[t.ID] = t
+		s.order[t.ID] = i + 1
+		s.sequence = append(s.sequence, t.ID)
+	}
+
+	for _, t := range archivedTasks {
+		s.tasks[t.ID] = t
+	}
+
+	for _, t := range snoozedTasks {
+		s.tasks[t.ID] = t
+	}
+	s.numSnoozed = len(snoozedTasks)
+
+	return nil
+}
+
+func (s *syncer) loadFiles() error {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".md" {
+			continue
+		}
+
+		s.fileNamesTaken[entry.Name()] = struct{}{}
+
+		data, err := os.ReadFile(filepath.Join(s.dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+
+		doc, err := parseDocument(data)
+		if err != nil {
+			s.conflict(0, entry.Name(), fmt.Sprintf("couldn't parse file: %s", err.Error()))
+			continue
+		}
+
+		if doc.ID == 0 {
+			s.unlinkedFiles = append(s.unlinkedFiles, taskFile{entry.Name(), doc})
+			continue
+		}
+
+		existing, ok := s.filesByTaskID[doc.ID]
+		if ok {
+			s.conflict(doc.ID, entry.Name(), fmt.Sprintf("task ID is also used by %s", existing.name))
+			s.inConflict[doc.ID] = struct{}{}
+			continue
+		}
+
+		s.filesByTaskID[doc.ID] = taskFile{entry.Name(), doc}
+	}
+
+	return nil
+}
+
+func (s *syncer) syncKnownTasks() error {
+	ids := make(map[uint64]struct{})
+	for id := range s.tasks {
+		ids[id] = struct{}{}
+	}
+	for id := range s.filesByTaskID {
+		ids[id] = struct{}{}
+	}
+	for id := range s.state.Tasks {
+		ids[id] = struct{}{}
+	}
+
+	sortedIDs := make([]uint64, 0, len(ids))
+	for id := range ids {
+		sortedIDs = append(sortedIDs, id)
+	}
+	slices.Sort(sortedIDs)
+
+	for _, id := range sortedIDs {
+		if _, ok := s.inConflict[id]; ok {
+			continue
+		}
+
+		err := s.syncTask(id)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *syncer) syncTask(id uint64) error {
+	task, hasTask := s.tasks[id]
+	file, hasFile := s.filesByTaskID[id]
+	base, hasBase := s.state.Tasks[id]
+
+	switch {
+	case !hasTask && !hasFile:
+		delete(s.state.Tasks, id)
+		return nil
+
+	case hasTask && !hasFile:
+		if !hasBase {
+			return s.writeTaskFile(task, "", FileCreated)
+		}
+
+		if s.taskChangedSince(task, base) {
+			s.conflict(id, base.FileName, "file was deleted, but the task was changed in omm")
+			return nil
+		}
+
+		err := pers.DeleteTask(s.db, id)
+		if err != nil {
+			return err
+		}
+		s.deleted[id] = struct{}{}
+		delete(s.state.Tasks, id)
+		s.addChange(TaskDeleted, id, base.FileName)
+		return nil
+
+	case !hasTask && hasFile:
+		if !hasBase {
+			// the file refers to a task that this database has never seen
+			// (eg. it was synced from another database); treat it as new
+			s.unlinkedFiles = append(s.unlinkedFiles, file)
+			return nil
+		}
+
+		if fileHash(file.doc) != base.Hash {
+			s.conflict(id, file.name, "task was deleted in omm, but the file was changed")
+			return nil
+		}
+
+		err := os.Remove(filepath.Join(s.dir, file.name))
+		if err != nil {
+			return err
+		}
+		delete(s.state.Tasks, id)
+		s.addChange(FileDeleted, id, file.name)
+		return nil
+	}
+
+	taskHash := taskHash(task)
+	docHash := fileHash(file.doc)
+
+	if !hasBase {
+		if taskHash != docHash {
+			s.conflict(id, file.name, "task and file differ, and haven't been synced before")
+			return nil
+		}
+		s.record(id, file.name, taskHash, s.order[id])
+		return nil
+	}
+
+	taskChanged := s.taskChangedSince(task, base)
+	fileChanged := docHash != base.Hash
+
+	if taskChanged && fileChanged && taskHash != docHash {
+		s.conflict(id, file.name, "both the task and the file were changed since the last sync")
+		return nil
+	}
+
+	if task.Active && file.doc.active() && file.doc.Order != 0 && file.doc.Order != base.Order {
+		s.moves[id] = file.doc.Order
+	}
+
+	switch {
+	case taskChanged && fileChanged:
+		s.record(id, file.name, taskHash, base.Order)
+		return nil
+
+	case taskChanged:
+		return s.writeTaskFile(task, file.name, FileUpdated)
+
+	case fileChanged:
+		return s.applyFile(task, file)
+	}
+
+	s.record(id, file.name, taskHash, base.Order)
+	return nil
+}
+
+func (s *syncer) taskChangedSince(task types.Task, base syncedTask) bool {
+	if !task.UpdatedAt.After(base.SyncedAt) {
+		return false
+	}
+
+	return taskHash(task) != base.Hash
+}
+
+func (s *syncer) applyFile(task types.Task, file taskFile) error {
+	doc := file.doc
+
+	_, err := types.CheckIfTaskSummaryValid(doc.Summary)
+	if err != nil {
+		s.conflict(task.ID, file.name, fmt.Sprintf("file has an invalid summary: %s", err.Error()))
+		return nil
+	}
+
+	if doc.context != nil && len(*doc.context) > pers.ContextMaxBytes {
+		s.conflict(task.ID, file.name, "file's content is too large to be used as context")
+		return nil
+	}
+
+	if doc.active() && !task.Active && len(s.sequence)+s.numSnoozed+len(s.newlyActive)+1 > pers.TaskNumLimit {
+		s.conflict(task.ID, file.name, "unarchiving this task would exceed omm's task limit")
+		return nil
+	}
+
+	if doc.Summary != task.Summary {
+		err = pers.UpdateTaskSummary(s.db, task.ID, doc.Summary, s.now)
+		if err != nil {
+			return err
+		}
+		task.Summary = doc.Summary
+	}
+
+	if contentHash("", true, doc.context) != contentHash("", true, task.Context) {
+		if doc.context == nil {
+			err = pers.UnsetTaskContext(s.db, task.ID, s.now)
+		} else {
+			err = pers.UpdateTaskContext(s.db, task.ID, *doc.context, s.now)
+		}
+		if err != nil {
+			return err
+		}
+		task.Context = doc.context
+	}
+
+	if doc.active() != task.Active {
+		err = pers.ChangeTaskStatus(s.db, task.ID, doc.active(), s.now)
+		if err != nil {
+			return err
+		}
+
+		if doc.active() {
+			s.newlyActive = append(s.newlyActive, task.ID)
+		} else {
+			s.newlyInactive[task.ID] = struct{}{}
+		}
+		s.sequenceDirty = true
+		task.Active = doc.active()
+	}
+
+	task.UpdatedAt = s.now
+	s.tasks[task.ID] = task
+	s.addChange(TaskUpdated, task.ID, file.name)
+
+	return s.writeTaskFile(task, file.name, 0)
+}
+
+func (s *syncer) syncUnlinkedFiles() error {
+	if len(s.unlinkedFiles) == 0 {
+		return nil
+	}
+
+	var tasks []types.Task
+	var files []taskFile
+	for _, f := range s.unlinkedFiles {
+		_, err := types.CheckIfTaskSummaryValid(f.doc.Summary)
+		if err != nil {
+			s.conflict(0, f.name, fmt.Sprintf("file has an invalid summary: %s", err.Error()))
+			continue
+		}
+
+		createdAt := f.doc.CreatedAt
+		if createdAt.IsZero() {
+			createdAt = s.now
+		}
+
+		tasks = append(tasks, types.Task{
+			Summary:   f.doc.Summary,
+			Context:   f.doc.context,
+			Active:    f.doc.active(),
+			CreatedAt: createdAt,
+			UpdatedAt: s.now,
+		})
+		files = append(files, f)
+	}
+
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	numActive := 0
+	for _, t := range tasks {
+		if t.Active {
+			numActive++
+		}
+	}
+	// snoozed tasks return to the task list once they wake up
+	if len(s.sequence)+s.numSnoozed+numActive > pers.TaskNumLimit {
+		for _, f := range files {
+			s.conflict(0, f.name, "adding this task would exceed omm's task limit")
+		}
+		return nil
+	}
+
+	// these tasks are inserted at the end of the list; positions requested via
+	// the "order" key are honored afterwards
+	lastID, err := pers.InsertTasks(s.db, tasks, false)
+	if err != nil {
+		return err
+	}
+
+	firstID := uint64(lastID) - uint64(len(tasks)) + 1
+	for i, t := range tasks {
+		t.ID = firstID + uint64(i)
+		s.tasks[t.ID] = t
+		if t.Active {
+			s.sequence = append(s.sequence, t.ID)
+			s.order[t.ID] = len(s.sequence)
+			if files[i].doc.Order != 0 {
+				s.moves[t.ID] = files[i].doc.Order
+			}
+		}
+		s.addChange(TaskCreated, t.ID, files[i].name)
+
+		// the file is rewritten under its original name, now with the task's ID
+		err = s.writeTaskFile(t, files[i].name, 0)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *syncer) syncSequence() error {
+	if !s.sequenceDirty && len(s.moves) == 0 && len(s.deleted) == 0 {
+		return nil
+	}
+
+	sequence := make([]uint64, 0, len(s.sequence)+len(s.newlyActive))
+	sequence = append(sequence, s.newlyActive...)
+	for _, id := range s.sequence {
+		if _, ok := s.newlyInactive[id]; ok {
+			continue
+		}
+		if _, ok := s.deleted[id]; ok {
+			continue
+		}
+		if slices.Contains(s.newlyActive, id) {
+			continue
+		}
+		sequence = append(sequence, id)
+	}
+
+	movedIDs := make([]uint64, 0, len(s.moves))
+	for id := range s.moves {
+		if _, ok := s.newlyInactive[id]; ok {
+			continue
+		}
+		movedIDs = append(movedIDs, id)
+	}
+	sort.Slice(movedIDs, func(i, j int) bool {
+		return s.moves[movedIDs[i]] < s.moves[movedIDs[j]]
+	})
+
+	for _, id := range movedIDs {
+		index := slices.Index(sequence, id)
+		if index == -1 {
+			continue
+		}
+		sequence = slices.Delete(sequence, index, index+1)
+
+		newIndex := min(max(s.moves[id]-1, 0), len(sequence))
+		sequence = slices.Insert(sequence, newIndex, id)
+	}
+
+	err := pers.UpdateTaskSequence(s.db, sequence)
+	if err != nil {
+		return err
+	}
+
+	s.sequence = sequence
+	s.order = make(map[uint64]int, len(sequence))
+	for i, id := range sequence {
+		s.order[id] = i + 1
+	}
+
+	return nil
+}
+
+// syncOrder rewrites files whose "order" doesn't reflect the task's current
+// position in omm's list.
+func (s *syncer) syncOrder() error {
+	for id, synced := range s.state.Tasks {
+		if _, ok := s.inConflict[id]; ok {
+			continue
+		}
+
+		task, ok := s.tasks[id]
+		if !ok {
+			continue
+		}
+
+		if synced.Order == s.order[id] {
+			continue
+		}
+
+		err := s.writeTaskFile(task, synced.FileName, 0)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *syncer) writeTaskFile(task types.Task, fileName string, kind ChangeKind) error {
+	if fileName == "" {
+		fileName = s.uniqueFileName(fileNameForTask(task))
+	}
+
+	data, err := newDocument(task, s.order[task.ID]).render()
+	if err != nil {
+		return err
+	}
+
+	err = writeFileAtomically(filepath.Join(s.dir, fileName), data)
+	if err != nil {
+		return err
+	}
+
+	s.record(task.ID, fileName, taskHash(task), s.order[task.ID])
+	if kind == FileCreated || kind == FileUpdated {
+		s.addChange(kind, task.ID, fileName)
+	}
+
+	return nil
+}
+
+func (s *syncer) uniqueFileName(name string) string {
+	candidate := name
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for i := 2; ; i++ {
+		if _, taken := s.fileNamesTaken[candidate]; !taken {
+			break
+		}
+		candidate = fmt.Sprintf("%s-%d%s", base, i, ext)
+	}
+	s.fileNamesTaken[candidate] = struct{}{}
+
+	return candidate
+}
+
+func (s *syncer) record(id uint64, fileName, hash string, order int) {
+	s.state.Tasks[id] = syncedTask{
+		FileName: fileName,
+		Hash:     hash,
+		Order:    order,
+		SyncedAt: s.now,
+	}
+}
+
+func (s *syncer) addChange(kind ChangeKind, id uint64, fileName string) {
+	s.report.Changes = append(s.report.Changes, Change{kind, id, fileName})
+}
+
+func (s *syncer) conflict(id uint64, fileName, reason string) {
+	if id != 0 {
+		s.inConflict[id] = struct{}{}
+	}
+	s.report.Conflicts = append(s.report.Conflicts, Conflict{id, fileName, reason})
+}
+
+func taskHash(task types.Task) string {
+	return contentHash(task.Summary, task.Active, task.Context)
+}
+
+func fileHash(doc document) string {
+	return contentHash(doc.Summary, doc.active(), doc.context)
+}
+
+func writeFileAtomically(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".omm-sync-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	_, err = tmp.Write(data)
+	if err != nil {
+		_ = tmp.Close()
+		return err
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,267 @@
+package mdsync
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	pers "github.com/dhth/omm/internal/persistence"
+	"github.com/dhth/omm/internal/testutil"
+	"github.com/dhth/omm/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func seedTasks(t *testing.T, db *sql.DB, createdAt time.Time) {
+	t.Helper()
+
+	context := "some context"
+	tasks := []types.Task{
+		{Summary: "infra: rotate keys", Context: &context, Active: true, CreatedAt: createdAt, UpdatedAt: createdAt},
+		{Summary: "write docs", Active: true, CreatedAt: createdAt, UpdatedAt: createdAt},
+		{Summary: "api: remove v1", Active: false, CreatedAt: createdAt, UpdatedAt: createdAt},
+	}
+	_, err := pers.InsertTasks(db, tasks, false)
+	require.NoError(t, err)
+}
+
+func readDoc(t *testing.T, path string) document {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	doc, err := parseDocument(data)
+	require.NoError(t, err)
+
+	return doc
+}
+
+func TestParseDocumentRoundTrip(t *testing.T) {
+	// GIVEN
+	context := "## notes\n\n- one\n- two\n"
+	now := time.Date(2024, 8, 1, 10, 0, 0, 0, time.UTC)
+	task := types.Task{ID: 7, UUID: "u7", Summary: "infra: rotate: keys", Context: &context, Active: true, CreatedAt: now, UpdatedAt: now}
+
+	// WHEN
+	data, err := newDocument(task, 3).render()
+	require.NoError(t, err)
+	got, err := parseDocument(data)
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, uint64(7), got.ID)
+	assert.Equal(t, "infra: rotate: keys", got.Summary)
+	assert.Equal(t, "infra", got.Prefix)
+	assert.Equal(t, 3, got.Order)
+	assert.True(t, got.active())
+	assert.Equal(t, now, got.CreatedAt)
+	require.NotNil(t, got.context)
+	assert.Equal(t, context, *got.context)
+}
+
+func TestParseDocumentFailsWithoutFrontMatter(t *testing.T) {
+	_, err := parseDocument([]byte("just some notes"))
+
+	assert.ErrorIs(t, err, errFrontMatterMissing)
+}
+
+func TestSyncMirrorsTasksToFiles(t *testing.T) {
+	// GIVEN
+	db := testutil.NewDB(t)
+	dir := t.TempDir()
+	now := time.Now().UTC()
+	seedTasks(t, db, now.Add(-time.Hour))
+
+	// WHEN
+	report, err := Sync(db, dir, now)
+
+	// THEN
+	require.NoError(t, err)
+	assert.Empty(t, report.Conflicts)
+	assert.Len(t, report.Changes, 3)
+
+	doc := readDoc(t, filepath.Join(dir, "0001-rotate-keys.md"))
+	assert.Equal(t, "infra: rotate keys", doc.Summary)
+	assert.Equal(t, 1, doc.Order)
+	require.NotNil(t, doc.context)
+	assert.Equal(t, "some context", *doc.context)
+
+	archived := readDoc(t, filepath.Join(dir, "0003-remove-v1.md"))
+	assert.False(t, archived.active())
+
+	report, err = Sync(db, dir, now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Empty(t, report.Changes, "a second sync shouldn't change anything")
+	assert.Empty(t, report.Conflicts)
+}
+
+func TestSyncAppliesFileChangesToTasks(t *testing.T) {
+	// GIVEN
+	db := testutil.NewDB(t)
+	dir := t.TempDir()
+	now := time.Now().UTC()
+	seedTasks(t, db, now.Add(-time.Hour))
+	_, err := Sync(db, dir, now)
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, "0002-write-docs.md")
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	updated := strings.Replace(string(data), "summary: write docs", "summary: 'docs: write the docs'", 1)
+	updated = strings.Replace(updated, "active: true", "active: false", 1)
+	updated += "context added in the vault\n"
+	require.NoError(t, os.WriteFile(path, []byte(updated), 0o644))
+
+	// WHEN
+	report, err := Sync(db, dir, now.Add(time.Minute))
+
+	// THEN
+	require.NoError(t, err)
+	assert.Empty(t, report.Conflicts)
+
+	archived, err := pers.FetchInActiveTasks(db, 10)
+	require.NoError(t, err)
+	require.Len(t, archived, 2)
+	var found bool
+	for _, task := range archived {
+		if task.ID == 2 {
+			found = true
+			assert.Equal(t, "docs: write the docs", task.Summary)
+			require.NotNil(t, task.Context)
+			assert.Equal(t, "context added in the vault\n", *task.Context)
+		}
+	}
+	assert.True(t, found)
+
+	active, err := pers.FetchActiveTasks(db, 10)
+	require.NoError(t, err)
+	require.Len(t, active, 1)
+	assert.Equal(t, uint64(1), active[0].ID)
+}
+
+func TestSyncReportsConflicts(t *testing.T) {
+	// GIVEN
+	db := testutil.NewDB(t)
+	dir := t.TempDir()
+	now := time.Now().UTC()
+	seedTasks(t, db, now.Add(-time.Hour))
+	_, err := Sync(db, dir, now)
+	require.NoError(t, err)
+
+	require.NoError(t, pers.UpdateTaskSummary(db, 2, "write better docs", now.Add(time.Minute)))
+
+	path := filepath.Join(dir, "0002-write-docs.md")
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	changed := strings.Replace(string(data), "summary: write docs", "summary: write fewer docs", 1)
+	require.NoError(t, os.WriteFile(path, []byte(changed), 0o644))
+
+	// WHEN
+	report, err := Sync(db, dir, now.Add(2*time.Minute))
+
+	// THEN
+	require.NoError(t, err)
+	require.Len(t, report.Conflicts, 1)
+	assert.Equal(t, uint64(2), report.Conflicts[0].TaskID)
+
+	doc := readDoc(t, path)
+	assert.Equal(t, "write fewer docs", doc.Summary, "file shouldn't be overwritten")
+
+	active, err := pers.FetchActiveTasks(db, 10)
+	require.NoError(t, err)
+	assert.Equal(t, "write better docs", active[1].Summary, "task shouldn't be overwritten")
+}
+
+func TestSyncCreatesTasksForNewFiles(t *testing.T) {
+	// GIVEN
+	db := testutil.NewDB(t)
+	dir := t.TempDir()
+	now := time.Now().UTC()
+	seedTasks(t, db, now.Add(-time.Hour))
+	_, err := Sync(db, dir, now)
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, "new-idea.md")
+	require.NoError(t, os.WriteFile(path, []byte("---\nsummary: 'ideas: a new idea'\norder: 1\n---\ndetails\n"), 0o644))
+
+	// WHEN
+	report, err := Sync(db, dir, now.Add(time.Minute))
+
+	// THEN
+	require.NoError(t, err)
+	assert.Empty(t, report.Conflicts)
+
+	active, err := pers.FetchActiveTasks(db, 10)
+	require.NoError(t, err)
+	require.Len(t, active, 3)
+	assert.Equal(t, "ideas: a new idea", active[0].Summary, "new task should be placed as per its order")
+
+	doc := readDoc(t, path)
+	assert.Equal(t, active[0].ID, doc.ID, "file should be linked to the new task")
+	assert.Equal(t, 1, doc.Order)
+
+	second := readDoc(t, filepath.Join(dir, "0001-rotate-keys.md"))
+	assert.Equal(t, 2, second.Order, "order of other files should be updated")
+}
+
+func TestSyncPropagatesDeletions(t *testing.T) {
+	// GIVEN
+	db := testutil.NewDB(t)
+	dir := t.TempDir()
+	now := time.Now().UTC()
+	seedTasks(t, db, now.Add(-time.Hour))
+	_, err := Sync(db, dir, now)
+	require.NoError(t, err)
+
+	require.NoError(t, os.Remove(filepath.Join(dir, "0001-rotate-keys.md")))
+	require.NoError(t, pers.DeleteTask(db, 3))
+
+	// WHEN
+	report, err := Sync(db, dir, now.Add(time.Minute))
+
+	// THEN
+	require.NoError(t, err)
+	assert.Empty(t, report.Conflicts)
+
+	active, err := pers.FetchActiveTasks(db, 10)
+	require.NoError(t, err)
+	require.Len(t, active, 1)
+	assert.Equal(t, uint64(2), active[0].ID)
+
+	numShown, err := pers.FetchNumActiveTasksShown(db)
+	require.NoError(t, err)
+	assert.Equal(t, 1, numShown)
+
+	_, err = os.Stat(filepath.Join(dir, "0003-remove-v1.md"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestSyncKeepsFilesOfSnoozedTasks(t *testing.T) {
+	// GIVEN
+	db := testutil.NewDB(t)
+	dir := t.TempDir()
+	now := time.Now().UTC()
+	seedTasks(t, db, now.Add(-time.Hour))
+	_, err := Sync(db, dir, now)
+	require.NoError(t, err)
+
+	require.NoError(t, pers.SnoozeTask(db, 2, now.Add(time.Hour)))
+
+	// WHEN
+	report, err := Sync(db, dir, now.Add(time.Minute))
+
+	// THEN
+	require.NoError(t, err)
+	assert.Empty(t, report.Conflicts)
+
+	doc := readDoc(t, filepath.Join(dir, "0002-write-docs.md"))
+	assert.Equal(t, "write docs", doc.Summary)
+
+	snoozed, err := pers.FetchSnoozedTasks(db, 10)
+	require.NoError(t, err)
+	require.Len(t, snoozed, 1)
+	assert.Equal(t, uint64(2), snoozed[0].ID)
+}
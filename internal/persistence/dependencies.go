@@ -0,0 +1,205 @@
+package persistence
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/dhth/omm/internal/types"
+)
+
+var (
+	ErrDependencyOnItself      = errors.New("a task can't block itself")
+	ErrDependencyCycle         = errors.New("dependency would create a cycle")
+	ErrDependencyAlreadyExists = errors.New("dependency already exists")
+	ErrDependencyNotFound      = errors.New("dependency not found")
+)
+
+// FetchTaskDependencies returns all links between tasks and the ones blocking
+// them; whether a task is blocked depends on whether its blockers are still
+// active, which is left to callers.
+func FetchTaskDependencies(db *sql.DB) ([]types.TaskDependency, error) {
+	rows, err := db.Query(`
+SELECT task_id, blocker_id
+FROM task_dependency
+ORDER BY task_id, created_at, blocker_id;
+`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var dependencies []types.TaskDependency
+	for rows.Next() {
+		var d types.TaskDependency
+		err = rows.Scan(&d.TaskID, &d.BlockerID)
+		if err != nil {
+			return nil, err
+		}
+		dependencies = append(dependencies, d)
+	}
+
+	return dependencies, rows.Err()
+}
+
+// AddTaskDependency marks a task as blocked by another one. It fails if either
+// task doesn't exist, or if the blocker is (directly or transitively) blocked
+// by the task.
+func AddTaskDependency(db *sql.DB, taskID, blockerID uint64, createdAt time.Time) error {
+	if taskID == blockerID {
+		return ErrDependencyOnItself
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var numTasks int
+	err = tx.QueryRow("SELECT COUNT(*) FROM task WHERE id IN (?, ?);", taskID, blockerID).Scan(&numTasks)
+	if err != nil {
+		return err
+	}
+	if numTasks != 2 {
+		return ErrTaskNotFound
+	}
+
+	var numExisting int
+	err = tx.QueryRow(`
+SELECT COUNT(*)
+FROM task_dependency
+WHERE task_id = ?
+    AND blocker_id = ?;
+`, taskID, blockerID).Scan(&numExisting)
+	if err != nil {
+		return err
+	}
+	if numExisting > 0 {
+		return ErrDependencyAlreadyExists
+	}
+
+	var numCycles int
+	err = tx.QueryRow(`
+WITH RECURSIVE blockers(id) AS (
+    SELECT blocker_id
+    FROM task_dependency
+    WHERE task_id = ?
+    UNION
+    SELECT d.blocker_id
+    FROM task_dependency d
+    JOIN blockers b ON d.task_id = b.id
+)
+SELECT COUNT(*)
+FROM blockers
+WHERE id = ?;
+`, blockerID, taskID).Scan(&numCycles)
+	if err != nil {
+		return err
+	}
+	if numCycles > 0 {
+		return ErrDependencyCycle
+	}
+
+	_, err = tx.Exec(`
+INSERT INTO task_dependency (task_id, blocker_id, created_at)
+VALUES (?, ?, ?);
+`, taskID, blockerID, createdAt.UTC())
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RemoveTaskDependency removes the link between a task and one blocking it.
+func RemoveTaskDependency(db *sql.DB, taskID, blockerID uint64) error {
+	res, err := db.Exec(`
+DELETE FROM task_dependency
+WHERE task_id = ?
+    AND blocker_id = ?;
+`, taskID, blockerID)
+	if err != nil {
+		return err
+	}
+
+	numRows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if numRows == 0 {
+		return ErrDependencyNotFound
+	}
+
+	return nil
+}
+
+// replaceTaskDependenciesTx makes the links between tasks match the ones in
+// blockedBy (keyed by UUIDs, which ids maps to task IDs). Links that already
+// exist keep their creation time.
+func replaceTaskDependenciesTx(tx *sql.Tx, ids map[string]uint64, blockedBy map[string][]string) error {
+	rows, err := tx.Query("SELECT task_id, blocker_id FROM task_dependency;")
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[types.TaskDependency]bool)
+	for rows.Next() {
+		var d types.TaskDependency
+		err = rows.Scan(&d.TaskID, &d.BlockerID)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		existing[d] = true
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return err
+	}
+
+	wanted := make(map[types.TaskDependency]bool)
+	for taskUUID, blockerUUIDs := range blockedBy {
+		taskID, ok := ids[taskUUID]
+		if !ok {
+			continue
+		}
+		for _, blockerUUID := range blockerUUIDs {
+			blockerID, ok := ids[blockerUUID]
+			if !ok || blockerID == taskID {
+				continue
+			}
+			wanted[types.TaskDependency{TaskID: taskID, BlockerID: blockerID}] = true
+		}
+	}
+
+	for d := range existing {
+		if wanted[d] {
+			continue
+		}
+		_, err = tx.Exec("DELETE FROM task_dependency WHERE task_id = ? AND blocker_id = ?;", d.TaskID, d.BlockerID)
+		if err != nil {
+			return err
+		}
+	}
+
+	now := time.Now().UTC()
+	for d := range wanted {
+		if existing[d] {
+			continue
+		}
+		_, err = tx.Exec(`
+INSERT INTO task_dependency (task_id, blocker_id, created_at)
+VALUES (?, ?, ?);
+`, d.TaskID, d.BlockerID, now)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package persistence
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dhth/omm/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddTaskDependency(t *testing.T) {
+	testCases := []struct {
+		name      string
+		taskID    uint64
+		blockerID uint64
+		err       error
+	}{
+		{
+			name:      "new dependency",
+			taskID:    3,
+			blockerID: 1,
+		},
+		{
+			name:      "task blocking itself",
+			taskID:    1,
+			blockerID: 1,
+			err:       ErrDependencyOnItself,
+		},
+		{
+			name:      "task that doesn't exist",
+			taskID:    1,
+			blockerID: 10,
+			err:       ErrTaskNotFound,
+		},
+		{
+			name:      "existing dependency",
+			taskID:    2,
+			blockerID: 1,
+			err:       ErrDependencyAlreadyExists,
+		},
+		{
+			name:      "direct cycle",
+			taskID:    1,
+			blockerID: 2,
+			err:       ErrDependencyCycle,
+		},
+		{
+			name:      "transitive cycle",
+			taskID:    1,
+			blockerID: 4,
+			err:       ErrDependencyCycle,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(func() { cleanupDB(t) })
+
+			// GIVEN
+			insertTaggedTasks(t, "task 1", "task 2", "task 3", "task 4")
+			require.NoError(t, AddTaskDependency(testDB, 2, 1, time.Now()))
+			require.NoError(t, AddTaskDependency(testDB, 4, 2, time.Now()))
+
+			// WHEN
+			err := AddTaskDependency(testDB, tt.taskID, tt.blockerID, time.Now())
+
+			// THEN
+			if tt.err != nil {
+				require.ErrorIs(t, err, tt.err)
+				return
+			}
+
+			require.NoError(t, err)
+			dependencies, err := FetchTaskDependencies(testDB)
+			require.NoError(t, err)
+			assert.Contains(t, dependencies, types.TaskDependency{TaskID: tt.taskID, BlockerID: tt.blockerID})
+		})
+	}
+}
+
+func TestRemoveTaskDependency(t *testing.T) {
+	t.Cleanup(func() { cleanupDB(t) })
+
+	// GIVEN
+	insertTaggedTasks(t, "task 1", "task 2", "task 3")
+	require.NoError(t, AddTaskDependency(testDB, 2, 1, time.Now()))
+	require.NoError(t, AddTaskDependency(testDB, 3, 1, time.Now()))
+
+	// WHEN
+	err := RemoveTaskDependency(testDB, 2, 1)
+
+	// THEN
+	require.NoError(t, err)
+	dependencies, err := FetchTaskDependencies(testDB)
+	require.NoError(t, err)
+	assert.Equal(t, []types.TaskDependency{{TaskID: 3, BlockerID: 1}}, dependencies)
+
+	err = RemoveTaskDependency(testDB, 2, 1)
+	require.ErrorIs(t, err, ErrDependencyNotFound)
+}
+
+func TestDependenciesAreRemovedWithTasks(t *testing.T) {
+	t.Cleanup(func() { cleanupDB(t) })
+
+	// GIVEN
+	insertTaggedTasks(t, "task 1", "task 2", "task 3")
+	require.NoError(t, AddTaskDependency(testDB, 2, 1, time.Now()))
+	require.NoError(t, AddTaskDependency(testDB, 3, 2, time.Now()))
+
+	// WHEN
+	err := DeleteTask(testDB, 1)
+
+	// THEN
+	require.NoError(t, err)
+	dependencies, err := FetchTaskDependencies(testDB)
+	require.NoError(t, err)
+	assert.Equal(t, []types.TaskDependency{{TaskID: 3, BlockerID: 2}}, dependencies)
+}
@@ -0,0 +1,242 @@
+package persistence
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var ErrNotAnOmmDB = errors.New("not an omm database")
+
+// SequenceProblems describes how the task sequence has drifted from the tasks
+// it's supposed to order; every entry in the sequence should be an existing
+// active task that isn't snoozed, and every such task should be in the sequence
+// (exactly once).
+type SequenceProblems struct {
+	Malformed    bool
+	UnknownIDs   []uint64
+	InactiveIDs  []uint64
+	SnoozedIDs   []uint64
+	DuplicateIDs []uint64
+	MissingIDs   []uint64
+}
+
+func (p SequenceProblems) Empty() bool {
+	return !p.Malformed &&
+		len(p.UnknownIDs) == 0 &&
+		len(p.InactiveIDs) == 0 &&
+		len(p.SnoozedIDs) == 0 &&
+		len(p.DuplicateIDs) == 0 &&
+		len(p.MissingIDs) == 0
+}
+
+// Describe returns a human readable line for each problem.
+func (p SequenceProblems) Describe() []string {
+	var lines []string
+	if p.Malformed {
+		lines = append(lines, "task sequence is not a valid list of task IDs")
+	}
+	if len(p.UnknownIDs) > 0 {
+		lines = append(lines, fmt.Sprintf("task sequence refers to tasks that don't exist: %v", p.UnknownIDs))
+	}
+	if len(p.InactiveIDs) > 0 {
+		lines = append(lines, fmt.Sprintf("task sequence refers to archived tasks: %v", p.InactiveIDs))
+	}
+	if len(p.SnoozedIDs) > 0 {
+		lines = append(lines, fmt.Sprintf("task sequence refers to snoozed tasks: %v", p.SnoozedIDs))
+	}
+	if len(p.DuplicateIDs) > 0 {
+		lines = append(lines, fmt.Sprintf("task sequence has duplicate entries: %v", p.DuplicateIDs))
+	}
+	if len(p.MissingIDs) > 0 {
+		lines = append(lines, fmt.Sprintf("active tasks missing from the task sequence: %v", p.MissingIDs))
+	}
+
+	return lines
+}
+
+// BackupDB writes a consistent copy of the database to path (which must not
+// exist), without having to stop other connections from using it.
+func BackupDB(db *sql.DB, path string) error {
+	_, err := db.Exec("VACUUM INTO ?;", path)
+	return err
+}
+
+func VacuumDB(db *sql.DB) error {
+	_, err := db.Exec("VACUUM;")
+	return err
+}
+
+// CheckIntegrity runs SQLite's integrity check, and returns the problems it
+// reports (if any).
+func CheckIntegrity(db *sql.DB) ([]string, error) {
+	rows, err := db.Query("PRAGMA integrity_check;")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var problems []string
+	for rows.Next() {
+		var line string
+		err = rows.Scan(&line)
+		if err != nil {
+			return nil, err
+		}
+		if line != "ok" {
+			problems = append(problems, line)
+		}
+	}
+
+	return problems, rows.Err()
+}
+
+// CheckDBVersion ensures that the database was created by omm, and that this
+// version of omm can work with it.
+func CheckDBVersion(db *sql.DB) (int, error) {
+	version, err := fetchLatestDBVersion(db)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", ErrNotAnOmmDB, err.Error())
+	}
+
+	if version.version > latestDBVersion {
+		return version.version, ErrDBDowngraded
+	}
+
+	return version.version, nil
+}
+
+func FindSequenceProblems(db *sql.DB) (SequenceProblems, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return SequenceProblems{}, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	problems, _, err := findSequenceProblemsTx(tx)
+	return problems, err
+}
+
+// RepairTaskSequence fixes the problems reported by FindSequenceProblems; it
+// drops entries that don't refer to active tasks (or refer to snoozed ones, or
+// are repeated), and adds missing active tasks to the end of the sequence, most
+// recently updated first.
+func RepairTaskSequence(db *sql.DB) (SequenceProblems, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return SequenceProblems{}, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	problems, repaired, err := findSequenceProblemsTx(tx)
+	if err != nil {
+		return problems, err
+	}
+
+	if problems.Empty() {
+		return problems, nil
+	}
+
+	if problems.Malformed {
+		_, err = tx.Exec(`
+INSERT OR REPLACE INTO task_sequence (id, sequence)
+VALUES (1, '[]');
+`)
+		if err != nil {
+			return problems, err
+		}
+	}
+
+	err = updateTaskSequenceTx(tx, repaired)
+	if err != nil {
+		return problems, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return problems, err
+	}
+
+	return problems, nil
+}
+
+// findSequenceProblemsTx returns the problems with the task sequence, along
+// with what the sequence should look like.
+func findSequenceProblemsTx(tx *sql.Tx) (SequenceProblems, []uint64, error) {
+	var problems SequenceProblems
+
+	sequence, err := fetchTaskSequenceTx(tx)
+	if err != nil {
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		if !errors.Is(err, sql.ErrNoRows) && !errors.As(err, &syntaxErr) && !errors.As(err, &typeErr) {
+			return problems, nil, err
+		}
+		problems.Malformed = true
+		sequence = nil
+	}
+
+	rows, err := tx.Query(`
+SELECT id, active, snoozed_until IS NOT NULL
+FROM task
+ORDER BY updated_at DESC, id DESC;
+`)
+	if err != nil {
+		return problems, nil, err
+	}
+	defer rows.Close()
+
+	status := make(map[uint64]bool)
+	snoozed := make(map[uint64]bool)
+	var activeIDs []uint64
+	for rows.Next() {
+		var id uint64
+		var active, isSnoozed bool
+		err = rows.Scan(&id, &active, &isSnoozed)
+		if err != nil {
+			return problems, nil, err
+		}
+		status[id] = active
+		snoozed[id] = active && isSnoozed
+		if active && !isSnoozed {
+			activeIDs = append(activeIDs, id)
+		}
+	}
+	err = rows.Err()
+	if err != nil {
+		return problems, nil, err
+	}
+
+	repaired := make([]uint64, 0, len(activeIDs))
+	seen := make(map[uint64]bool)
+	for _, id := range sequence {
+		active, exists := status[id]
+		switch {
+		case !exists:
+			problems.UnknownIDs = append(problems.UnknownIDs, id)
+		case !active:
+			problems.InactiveIDs = append(problems.InactiveIDs, id)
+		case snoozed[id]:
+			problems.SnoozedIDs = append(problems.SnoozedIDs, id)
+		case seen[id]:
+			problems.DuplicateIDs = append(problems.DuplicateIDs, id)
+		default:
+			repaired = append(repaired, id)
+		}
+		seen[id] = true
+	}
+
+	for _, id := range activeIDs {
+		if !seen[id] {
+			problems.MissingIDs = append(problems.MissingIDs, id)
+			repaired = append(repaired, id)
+		}
+	}
+
+	return problems, repaired, nil
+}
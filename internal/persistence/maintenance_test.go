@@ -0,0 +1,130 @@
+package persistence
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepairTaskSequence(t *testing.T) {
+	testCases := []struct {
+		name             string
+		sequence         string
+		snoozedIDs       []uint64
+		expectedProblems SequenceProblems
+		expectedSequence []uint64
+	}{
+		{
+			name:             "sequence in sync",
+			sequence:         "[3, 1, 2]",
+			expectedSequence: []uint64{3, 1, 2},
+		},
+		{
+			name:     "unknown, archived, duplicate, and missing tasks",
+			sequence: "[2, 100, 4, 2, 1]",
+			expectedProblems: SequenceProblems{
+				UnknownIDs:   []uint64{100},
+				InactiveIDs:  []uint64{4},
+				DuplicateIDs: []uint64{2},
+				MissingIDs:   []uint64{3},
+			},
+			expectedSequence: []uint64{2, 1, 3},
+		},
+		{
+			name:             "snoozed task missing from the sequence",
+			sequence:         "[3, 1]",
+			snoozedIDs:       []uint64{2},
+			expectedSequence: []uint64{3, 1},
+		},
+		{
+			name:       "snoozed task in the sequence",
+			sequence:   "[3, 2, 1]",
+			snoozedIDs: []uint64{2},
+			expectedProblems: SequenceProblems{
+				SnoozedIDs: []uint64{2},
+			},
+			expectedSequence: []uint64{3, 1},
+		},
+		{
+			name:     "malformed sequence",
+			sequence: `{"not": "a list"}`,
+			expectedProblems: SequenceProblems{
+				Malformed:  true,
+				MissingIDs: []uint64{3, 2, 1},
+			},
+			expectedSequence: []uint64{3, 2, 1},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(func() { cleanupDB(t) })
+
+			// GIVEN
+			seedDB(t, testDB)
+			_, err := testDB.Exec("UPDATE task_sequence SET sequence = ? WHERE id = 1;", tt.sequence)
+			require.NoError(t, err)
+			for _, id := range tt.snoozedIDs {
+				_, err = testDB.Exec("UPDATE task SET snoozed_until = ? WHERE id = ?;", time.Now().Add(time.Hour), id)
+				require.NoError(t, err)
+			}
+
+			found, err := FindSequenceProblems(testDB)
+			require.NoError(t, err)
+
+			// WHEN
+			repaired, err := RepairTaskSequence(testDB)
+
+			// THEN
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedProblems, found)
+			assert.Equal(t, tt.expectedProblems, repaired)
+			assert.Equal(t, tt.expectedProblems.Empty(), len(found.Describe()) == 0)
+
+			seq, err := fetchTaskSequence(testDB)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedSequence, seq)
+
+			remaining, err := FindSequenceProblems(testDB)
+			require.NoError(t, err)
+			assert.True(t, remaining.Empty())
+		})
+	}
+}
+
+func TestBackupDBWritesAUsableCopy(t *testing.T) {
+	t.Cleanup(func() { cleanupDB(t) })
+
+	// GIVEN
+	seedDB(t, testDB)
+	backupPath := filepath.Join(t.TempDir(), "backup.db")
+
+	// WHEN
+	err := BackupDB(testDB, backupPath)
+
+	// THEN
+	require.NoError(t, err)
+
+	backup, err := sql.Open("sqlite", backupPath)
+	require.NoError(t, err)
+	defer backup.Close()
+
+	problems, err := CheckIntegrity(backup)
+	require.NoError(t, err)
+	assert.Empty(t, problems)
+
+	version, err := CheckDBVersion(backup)
+	require.NoError(t, err)
+	assert.Equal(t, latestDBVersion, version)
+
+	tasks, err := FetchActiveTasks(backup, 10)
+	require.NoError(t, err)
+	assert.Len(t, tasks, 3)
+
+	err = BackupDB(testDB, backupPath)
+	assert.Error(t, err, "backing up to an existing file should fail")
+}
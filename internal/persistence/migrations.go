@@ -4,17 +4,22 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"os"
 	"time"
 )
 
 const (
-	latestDBVersion = 2 // only upgrade this after adding a migration in getMigrations
+	latestDBVersion = 10 // only upgrade this after adding a migration in getMigrations
+	// compatibility information is recorded for migrations from this version
+	// onwards
+	compatibilityTrackedSince = 4
 )
 
 var (
 	ErrDBDowngraded          = errors.New("database downgraded")
 	ErrDBMigrationFailed     = errors.New("database migration failed")
 	ErrCouldntFetchDBVersion = errors.New("couldn't fetch version")
+	ErrDBVersionInvalid      = errors.New("database version is invalid")
 )
 
 type dbVersionInfo struct {
@@ -31,11 +36,216 @@ func getMigrations() map[int]string {
 	migrations[2] = `
 ALTER TABLE task
 ADD COLUMN context TEXT;
+`
+
+	migrations[3] = `
+ALTER TABLE task
+ADD COLUMN uuid TEXT;
+
+UPDATE task
+SET uuid = lower(
+    hex(randomblob(4)) || '-' ||
+    hex(randomblob(2)) || '-4' ||
+    substr(hex(randomblob(2)), 2) || '-' ||
+    substr('89ab', 1 + (abs(random()) % 4), 1) ||
+    substr(hex(randomblob(2)), 2) || '-' ||
+    hex(randomblob(6))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_task_uuid ON task(uuid);
+`
+
+	migrations[4] = `
+CREATE TABLE IF NOT EXISTS db_compatibility (
+    version INTEGER PRIMARY KEY,
+    min_compatible_version INTEGER NOT NULL
+);
+`
+
+	migrations[5] = `
+ALTER TABLE task
+ADD COLUMN context_updated_at TIMESTAMP;
+
+CREATE TRIGGER IF NOT EXISTS task_context_updated
+AFTER UPDATE OF context ON task
+WHEN OLD.context IS NOT NEW.context
+BEGIN
+    UPDATE task
+    SET context_updated_at = NEW.updated_at
+    WHERE id = NEW.id;
+END;
+`
+
+	migrations[6] = `
+ALTER TABLE task
+ADD COLUMN status TEXT;
+`
+
+	migrations[7] = `
+CREATE TABLE IF NOT EXISTS saved_view (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    definition TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+`
+
+	migrations[8] = `
+CREATE TABLE IF NOT EXISTS tag (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    color TEXT
+);
+
+CREATE TABLE IF NOT EXISTS task_tag (
+    task_id INTEGER NOT NULL,
+    tag_id INTEGER NOT NULL,
+    PRIMARY KEY (task_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_tag_tag_id ON task_tag(tag_id);
+
+CREATE TRIGGER IF NOT EXISTS task_tags_deleted
+AFTER DELETE ON task
+BEGIN
+    DELETE FROM task_tag
+    WHERE task_id = OLD.id;
+END;
+`
+
+	migrations[9] = `
+CREATE TABLE IF NOT EXISTS task_dependency (
+    task_id INTEGER NOT NULL,
+    blocker_id INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (task_id, blocker_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_dependency_blocker_id ON task_dependency(blocker_id);
+
+CREATE TRIGGER IF NOT EXISTS task_dependencies_deleted
+AFTER DELETE ON task
+BEGIN
+    DELETE FROM task_dependency
+    WHERE task_id = OLD.id
+        OR blocker_id = OLD.id;
+END;
+`
+
+	migrations[10] = `
+ALTER TABLE task
+ADD COLUMN snoozed_until TIMESTAMP;
 `
 
 	return migrations
 }
 
+// getPostMigrations returns steps that need to run (in the same transaction)
+// after some migrations, for changes that can't be expressed in SQL alone.
+// Like migrations, this is append-only.
+func getPostMigrations() map[int]func(tx *sql.Tx) error {
+	return map[int]func(tx *sql.Tx) error{
+		// tags in existing summaries need to be indexed
+		8: rebuildTaskTagsTx,
+	}
+}
+
+// getMinCompatibleVersions returns, for each migration, the oldest database
+// version that a release of omm needs to support to be able to read a database
+// at that version. Migrations that only add to the schema (tables, nullable
+// columns, indexes) don't affect what older releases read, and carry over the
+// value of the previous migration; ones that change or remove parts of the
+// schema need to use their own version. Like migrations, this is append-only.
+func getMinCompatibleVersions() map[int]int {
+	return map[int]int{
+		2:  1,
+		3:  1,
+		4:  1,
+		5:  1,
+		6:  1,
+		7:  1,
+		8:  1,
+		9:  1,
+		10: 10, // snoozed tasks aren't in the task sequence; older releases wouldn't show them
+	}
+}
+
+// getDownMigrations returns the queries that undo each migration; these are
+// used to export copies of the database that older releases can work with.
+func getDownMigrations() map[int]string {
+	downMigrations := make(map[int]string)
+
+	downMigrations[2] = `
+ALTER TABLE task
+DROP COLUMN context;
+`
+
+	downMigrations[3] = `
+DROP INDEX IF EXISTS idx_task_uuid;
+
+ALTER TABLE task
+DROP COLUMN uuid;
+`
+
+	downMigrations[4] = `
+DROP TABLE IF EXISTS db_compatibility;
+`
+
+	downMigrations[5] = `
+DROP TRIGGER IF EXISTS task_context_updated;
+
+ALTER TABLE task
+DROP COLUMN context_updated_at;
+`
+
+	downMigrations[6] = `
+ALTER TABLE task
+DROP COLUMN status;
+`
+
+	downMigrations[7] = `
+DROP TABLE IF EXISTS saved_view;
+`
+
+	downMigrations[8] = `
+DROP TRIGGER IF EXISTS task_tags_deleted;
+DROP INDEX IF EXISTS idx_task_tag_tag_id;
+DROP TABLE IF EXISTS task_tag;
+DROP TABLE IF EXISTS tag;
+`
+
+	downMigrations[9] = `
+DROP TRIGGER IF EXISTS task_dependencies_deleted;
+DROP INDEX IF EXISTS idx_task_dependency_blocker_id;
+DROP TABLE IF EXISTS task_dependency;
+`
+
+	// snoozed tasks aren't in the task sequence; they're added back to its end,
+	// so that older releases show them
+	downMigrations[10] = `
+UPDATE task_sequence
+SET sequence = (
+    SELECT json_group_array(seq_id)
+    FROM (
+        SELECT CAST(j.value AS INTEGER) AS seq_id, 0 AS grp, j.key AS pos
+        FROM json_each(task_sequence.sequence) j
+        UNION ALL
+        SELECT id, 1, snoozed_until
+        FROM task
+        WHERE active
+            AND snoozed_until IS NOT NULL
+        ORDER BY grp, pos
+    )
+)
+WHERE id = 1;
+
+ALTER TABLE task
+DROP COLUMN snoozed_until;
+`
+
+	return downMigrations
+}
+
 func fetchLatestDBVersion(db *sql.DB) (dbVersionInfo, error) {
 	row := db.QueryRow(`
 SELECT id, version, created_at
@@ -54,24 +264,56 @@ LIMIT 1;
 	return dbVersion, err
 }
 
-func UpgradeDBIfNeeded(db *sql.DB) error {
+type DBVersion struct {
+	Version   int
+	CreatedAt time.Time
+}
+
+// FetchDBVersionHistory returns the versions the database has been at, oldest
+// first.
+func FetchDBVersionHistory(db *sql.DB) ([]DBVersion, error) {
+	rows, err := db.Query(`
+SELECT version, created_at
+FROM db_versions
+ORDER BY id;
+`)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrCouldntFetchDBVersion, err.Error())
+	}
+	defer rows.Close()
+
+	var versions []DBVersion
+	for rows.Next() {
+		var v DBVersion
+		err = rows.Scan(&v.Version, &v.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		v.CreatedAt = v.CreatedAt.Local()
+		versions = append(versions, v)
+	}
+
+	return versions, rows.Err()
+}
+
+// PendingMigrations returns the current version of the database, along with
+// the versions it needs to be migrated to (in order) to be brought up to date.
+func PendingMigrations(db *sql.DB) (int, []int, error) {
 	latestVersionInDB, err := fetchLatestDBVersion(db)
 	if err != nil {
-		return fmt.Errorf("%w: %s", ErrCouldntFetchDBVersion, err.Error())
+		return 0, nil, fmt.Errorf("%w: %s", ErrCouldntFetchDBVersion, err.Error())
 	}
 
 	if latestVersionInDB.version > latestDBVersion {
-		return ErrDBDowngraded
+		return latestVersionInDB.version, nil, ErrDBDowngraded
 	}
 
-	if latestVersionInDB.version < latestDBVersion {
-		err = UpgradeDB(db, latestVersionInDB.version)
-		if err != nil {
-			return err
-		}
+	var pending []int
+	for i := latestVersionInDB.version + 1; i <= latestDBVersion; i++ {
+		pending = append(pending, i)
 	}
 
-	return nil
+	return latestVersionInDB.version, pending, nil
 }
 
 func UpgradeDB(db *sql.DB, currentVersion int) error {
@@ -106,6 +348,13 @@ func runMigration(db *sql.DB, migrateQuery string, version int) error {
 		return err
 	}
 
+	if postMigrate, ok := getPostMigrations()[version]; ok {
+		err = postMigrate(tx)
+		if err != nil {
+			return err
+		}
+	}
+
 	tStmt, err := tx.Prepare(`
 INSERT INTO db_versions (version, created_at)
 VALUES (?, ?);
@@ -120,6 +369,16 @@ VALUES (?, ?);
 		return err
 	}
 
+	if version >= compatibilityTrackedSince {
+		_, err = tx.Exec(`
+INSERT OR REPLACE INTO db_compatibility (version, min_compatible_version)
+VALUES (?, ?);
+`, version, getMinCompatibleVersions()[version])
+		if err != nil {
+			return err
+		}
+	}
+
 	err = tx.Commit()
 	if err != nil {
 		return err
@@ -127,3 +386,125 @@ VALUES (?, ?);
 
 	return nil
 }
+
+// CanReadNewerDB reports whether this version of omm can read a database that
+// has been migrated beyond what it supports by a newer version of omm (without
+// writing to it); this is the case when the newer migrations are additive.
+func CanReadNewerDB(db *sql.DB) (int, bool, error) {
+	return canReadDBAs(db, latestDBVersion)
+}
+
+// canReadDBAs reports whether a release of omm that supports database
+// versions up to readerVersion can read the database.
+func canReadDBAs(db *sql.DB, readerVersion int) (int, bool, error) {
+	latestVersionInDB, err := fetchLatestDBVersion(db)
+	if err != nil {
+		return 0, false, fmt.Errorf("%w: %s", ErrCouldntFetchDBVersion, err.Error())
+	}
+
+	if latestVersionInDB.version <= readerVersion {
+		return latestVersionInDB.version, true, nil
+	}
+
+	var minCompatibleVersion int
+	err = db.QueryRow(`
+SELECT min_compatible_version
+FROM db_compatibility
+WHERE version = ?;
+`, latestVersionInDB.version).Scan(&minCompatibleVersion)
+	if errors.Is(err, sql.ErrNoRows) {
+		return latestVersionInDB.version, false, nil
+	}
+	if err != nil {
+		return latestVersionInDB.version, false, err
+	}
+
+	return latestVersionInDB.version, minCompatibleVersion <= readerVersion, nil
+}
+
+// ExportCompatibleDB writes a copy of the database to path, with migrations
+// beyond the given version undone, so that releases of omm that only support
+// that version can work with it. Data held in the parts of the schema added by
+// the undone migrations is not part of the copy. It returns the version the
+// copy is at; a database that's older than the given version is copied as is.
+func ExportCompatibleDB(db *sql.DB, path string, version int) (int, error) {
+	if version < 1 || version > latestDBVersion {
+		return 0, fmt.Errorf("%w: %d; needs to be between 1 and %d", ErrDBVersionInvalid, version, latestDBVersion)
+	}
+
+	currentVersion, _, err := PendingMigrations(db)
+	if err != nil {
+		return 0, err
+	}
+
+	err = BackupDB(db, path)
+	if err != nil {
+		return 0, err
+	}
+
+	if version >= currentVersion {
+		return currentVersion, nil
+	}
+
+	err = undoMigrations(path, currentVersion, version)
+	if err != nil {
+		_ = os.Remove(path)
+		return 0, err
+	}
+
+	return version, nil
+}
+
+func undoMigrations(path string, fromVersion, toVersion int) error {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	downMigrations := getDownMigrations()
+	for v := fromVersion; v > toVersion; v-- {
+		err = runDownMigration(db, downMigrations[v], v)
+		if err != nil {
+			return fmt.Errorf("couldn't undo migration (version %d): %s", v, err.Error())
+		}
+	}
+
+	return VacuumDB(db)
+}
+
+func runDownMigration(db *sql.DB, query string, version int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+DELETE FROM db_versions
+WHERE version >= ?;
+`, version)
+	if err != nil {
+		return err
+	}
+
+	if version > compatibilityTrackedSince {
+		_, err = tx.Exec(`
+DELETE FROM db_compatibility
+WHERE version >= ?;
+`, version)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -1,7 +1,14 @@
 package persistence
 
 import (
+	"database/sql"
+	"path/filepath"
 	"testing"
+	"time"
+
+	"github.com/dhth/omm/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestMigrationsAreSetupCorrectly(t *testing.T) {
@@ -16,3 +23,266 @@ func TestMigrationsAreSetupCorrectly(t *testing.T) {
 		}
 	}
 }
+
+func TestPendingMigrations(t *testing.T) {
+	// GIVEN
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	defer db.Close()
+	require.NoError(t, InitDB(db))
+
+	// WHEN
+	version, pending, err := PendingMigrations(db)
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, 1, version)
+	require.Len(t, pending, latestDBVersion-1)
+	assert.Equal(t, 2, pending[0])
+
+	require.NoError(t, UpgradeDB(db, version))
+
+	version, pending, err = PendingMigrations(db)
+	require.NoError(t, err)
+	assert.Equal(t, latestDBVersion, version)
+	assert.Empty(t, pending)
+
+	history, err := FetchDBVersionHistory(db)
+	require.NoError(t, err)
+	require.Len(t, history, latestDBVersion)
+	for i, v := range history {
+		assert.Equal(t, i+1, v.Version)
+	}
+
+	_, err = db.Exec("INSERT INTO db_versions (version, created_at) VALUES (?, ?);", latestDBVersion+1, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	_, _, err = PendingMigrations(db)
+	assert.ErrorIs(t, err, ErrDBDowngraded)
+}
+
+func TestMigrationsHaveCompatibilityInfo(t *testing.T) {
+	minCompatibleVersions := getMinCompatibleVersions()
+	downMigrations := getDownMigrations()
+	for i := 2; i <= latestDBVersion; i++ {
+		v, ok := minCompatibleVersions[i]
+		if !ok {
+			t.Errorf("couldn't get min compatible version for migration %d", i)
+		}
+		if v < 1 || v > i {
+			t.Errorf("min compatible version for migration %d is invalid: %d", i, v)
+		}
+		if downMigrations[i] == "" {
+			t.Errorf("down migration %d is empty", i)
+		}
+	}
+}
+
+func getMigratedTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, InitDB(db))
+	require.NoError(t, UpgradeDB(db, 1))
+
+	return db
+}
+
+func TestCanReadNewerDB(t *testing.T) {
+	newerVersion := latestDBVersion + 1
+
+	testCases := []struct {
+		name                 string
+		minCompatibleVersion *int
+		expected             bool
+	}{
+		{
+			name:                 "additive migration",
+			minCompatibleVersion: &[]int{latestDBVersion}[0],
+			expected:             true,
+		},
+		{
+			name:                 "breaking migration",
+			minCompatibleVersion: &newerVersion,
+			expected:             false,
+		},
+		{
+			name:     "no compatibility info",
+			expected: false,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			db := getMigratedTestDB(t)
+			_, err := db.Exec("INSERT INTO db_versions (version, created_at) VALUES (?, ?);", newerVersion, time.Now().Add(time.Minute).UTC())
+			require.NoError(t, err)
+			if tt.minCompatibleVersion != nil {
+				_, err = db.Exec("INSERT INTO db_compatibility (version, min_compatible_version) VALUES (?, ?);", newerVersion, *tt.minCompatibleVersion)
+				require.NoError(t, err)
+			}
+
+			// WHEN
+			version, ok, err := CanReadNewerDB(db)
+
+			// THEN
+			require.NoError(t, err)
+			assert.Equal(t, newerVersion, version)
+			assert.Equal(t, tt.expected, ok)
+		})
+	}
+}
+
+func TestReleasesBeforeSnoozingCantReadDB(t *testing.T) {
+	// GIVEN
+	db := getMigratedTestDB(t)
+
+	testCases := []struct {
+		readerVersion int
+		expected      bool
+	}{
+		{readerVersion: 9, expected: false},
+		{readerVersion: 10, expected: true},
+	}
+
+	for _, tt := range testCases {
+		// WHEN
+		version, ok, err := canReadDBAs(db, tt.readerVersion)
+
+		// THEN
+		require.NoError(t, err)
+		assert.Equal(t, latestDBVersion, version)
+		assert.Equal(t, tt.expected, ok, "reader at version %d", tt.readerVersion)
+	}
+}
+
+func TestExportCompatibleDB(t *testing.T) {
+	testCases := []struct {
+		name            string
+		version         int
+		expectedColumns []string
+	}{
+		{
+			name:            "latest version",
+			version:         latestDBVersion,
+			expectedColumns: []string{"id", "summary", "active", "created_at", "updated_at", "context", "uuid", "context_updated_at", "status", "snoozed_until"},
+		},
+		{
+			name:            "without snoozing",
+			version:         9,
+			expectedColumns: []string{"id", "summary", "active", "created_at", "updated_at", "context", "uuid", "context_updated_at", "status"},
+		},
+		{
+			name:            "without dependencies",
+			version:         8,
+			expectedColumns: []string{"id", "summary", "active", "created_at", "updated_at", "context", "uuid", "context_updated_at", "status"},
+		},
+		{
+			name:            "without tags",
+			version:         7,
+			expectedColumns: []string{"id", "summary", "active", "created_at", "updated_at", "context", "uuid", "context_updated_at", "status"},
+		},
+		{
+			name:            "without saved views",
+			version:         6,
+			expectedColumns: []string{"id", "summary", "active", "created_at", "updated_at", "context", "uuid", "context_updated_at", "status"},
+		},
+		{
+			name:            "without context update times",
+			version:         4,
+			expectedColumns: []string{"id", "summary", "active", "created_at", "updated_at", "context", "uuid"},
+		},
+		{
+			name:            "without uuids",
+			version:         2,
+			expectedColumns: []string{"id", "summary", "active", "created_at", "updated_at", "context"},
+		},
+		{
+			name:            "initial version",
+			version:         1,
+			expectedColumns: []string{"id", "summary", "active", "created_at", "updated_at"},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			db := getMigratedTestDB(t)
+			_, err := InsertTasks(db, []types.Task{{Summary: "task 1 #tagged", Active: true, CreatedAt: time.Now(), UpdatedAt: time.Now()}}, true)
+			require.NoError(t, err)
+			path := filepath.Join(t.TempDir(), "omm.db")
+
+			// WHEN
+			version, err := ExportCompatibleDB(db, path, tt.version)
+
+			// THEN
+			require.NoError(t, err)
+			assert.Equal(t, tt.version, version)
+
+			exported, err := sql.Open("sqlite", path)
+			require.NoError(t, err)
+			defer exported.Close()
+
+			exportedVersion, pending, err := PendingMigrations(exported)
+			require.NoError(t, err)
+			assert.Equal(t, tt.version, exportedVersion)
+			assert.Len(t, pending, latestDBVersion-tt.version)
+
+			rows, err := exported.Query("SELECT name FROM pragma_table_info('task');")
+			require.NoError(t, err)
+			defer rows.Close()
+			var columns []string
+			for rows.Next() {
+				var c string
+				require.NoError(t, rows.Scan(&c))
+				columns = append(columns, c)
+			}
+			require.NoError(t, rows.Err())
+			assert.Equal(t, tt.expectedColumns, columns)
+
+			// an older version of omm would migrate the copy again
+			require.NoError(t, UpgradeDB(exported, exportedVersion))
+			tasks, err := FetchActiveTasks(exported, 10)
+			require.NoError(t, err)
+			assert.Len(t, tasks, 1)
+			tags, err := FetchTags(exported)
+			require.NoError(t, err)
+			assert.Equal(t, []types.Tag{{Name: "tagged", NumTasks: 1}}, tags)
+		})
+	}
+
+	_, err := ExportCompatibleDB(getMigratedTestDB(t), filepath.Join(t.TempDir(), "omm.db"), 0)
+	assert.ErrorIs(t, err, ErrDBVersionInvalid)
+}
+
+func TestExportCompatibleDBAddsSnoozedTasksBackToSequence(t *testing.T) {
+	// GIVEN
+	db := getMigratedTestDB(t)
+	now := time.Now()
+	tasks := make([]types.Task, 4)
+	for i := range tasks {
+		tasks[i] = types.Task{Summary: "task", Active: true, CreatedAt: now, UpdatedAt: now}
+	}
+	_, err := InsertTasks(db, tasks, false)
+	require.NoError(t, err)
+	require.NoError(t, SnoozeTask(db, 3, now.Add(2*time.Hour)))
+	require.NoError(t, SnoozeTask(db, 1, now.Add(time.Hour)))
+	path := filepath.Join(t.TempDir(), "omm.db")
+
+	// WHEN
+	_, err = ExportCompatibleDB(db, path, 9)
+
+	// THEN
+	require.NoError(t, err)
+	exported, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	defer exported.Close()
+
+	sequence, err := fetchTaskSequence(exported)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{2, 4, 1, 3}, sequence)
+}
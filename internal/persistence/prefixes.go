@@ -0,0 +1,134 @@
+package persistence
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dhth/omm/internal/types"
+)
+
+var (
+	ErrPrefixNotFound      = errors.New("prefix not found")
+	ErrPrefixAlreadyExists = errors.New("prefix already exists")
+)
+
+// FetchPrefixes returns the prefixes of all tasks (active and archived), along
+// with the number of tasks that have them, sorted by prefix.
+func FetchPrefixes(db *sql.DB) ([]types.PrefixCount, error) {
+	rows, err := db.Query("SELECT summary, active FROM task;")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[types.TaskPrefix]*types.PrefixCount)
+	for rows.Next() {
+		var t types.Task
+		err = rows.Scan(&t.Summary, &t.Active)
+		if err != nil {
+			return nil, err
+		}
+
+		prefix, ok := t.Prefix()
+		if !ok {
+			continue
+		}
+
+		c, ok := counts[prefix]
+		if !ok {
+			c = &types.PrefixCount{Prefix: prefix}
+			counts[prefix] = c
+		}
+		if t.Active {
+			c.NumActive++
+		} else {
+			c.NumArchived++
+		}
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	prefixes := make([]types.PrefixCount, 0, len(counts))
+	for _, c := range counts {
+		prefixes = append(prefixes, *c)
+	}
+	slices.SortFunc(prefixes, func(a, b types.PrefixCount) int {
+		return strings.Compare(string(a.Prefix), string(b.Prefix))
+	})
+
+	return prefixes, nil
+}
+
+// RenamePrefix changes a prefix in the summaries of all tasks that have it; it
+// fails if another prefix with the new name is already in use. It returns the
+// number of tasks that were changed.
+func RenamePrefix(db *sql.DB, from, to string, updatedAt time.Time) (int, error) {
+	return replacePrefix(db, from, to, false, updatedAt)
+}
+
+// MergePrefixes replaces a prefix with another one in the summaries of all
+// tasks that have it. It returns the number of tasks that were changed.
+func MergePrefixes(db *sql.DB, from, into string, updatedAt time.Time) (int, error) {
+	return replacePrefix(db, from, into, true, updatedAt)
+}
+
+func replacePrefix(db *sql.DB, from, to string, merge bool, updatedAt time.Time) (int, error) {
+	from = strings.TrimSpace(from)
+	to = strings.TrimSpace(to)
+	err := types.CheckIfTaskPrefixValid(to)
+	if err != nil {
+		return 0, err
+	}
+	if merge && from == to {
+		return 0, fmt.Errorf("%w: can't merge a prefix into itself", types.ErrTaskPrefixInvalid)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	tasks, err := fetchTaskSummariesTx(tx)
+	if err != nil {
+		return 0, err
+	}
+
+	var toChange []types.Task
+	for _, t := range tasks {
+		prefix, ok := t.Prefix()
+		if !ok {
+			continue
+		}
+
+		switch string(prefix) {
+		case from:
+			toChange = append(toChange, t)
+		case to:
+			if !merge {
+				return 0, fmt.Errorf("%w: %q", ErrPrefixAlreadyExists, to)
+			}
+		}
+	}
+
+	if len(toChange) == 0 {
+		return 0, fmt.Errorf("%w: %q", ErrPrefixNotFound, from)
+	}
+
+	for _, t := range toChange {
+		err = rewriteTaskSummaryTx(tx, t.ID, types.SummaryWithPrefix(t.Summary, to), updatedAt)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(toChange), tx.Commit()
+}
@@ -0,0 +1,124 @@
+package persistence
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dhth/omm/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func insertPrefixedTasks(t *testing.T) {
+	t.Helper()
+
+	now := time.Now()
+	tasks := []types.Task{
+		{Summary: "api: add auth", Active: true, CreatedAt: now, UpdatedAt: now},
+		{Summary: "api:fix: pagination", Active: true, CreatedAt: now, UpdatedAt: now},
+		{Summary: "infra: deploy #ops", Active: true, CreatedAt: now, UpdatedAt: now},
+		{Summary: " api : remove v1", Active: false, CreatedAt: now, UpdatedAt: now},
+		{Summary: "no prefix", Active: true, CreatedAt: now, UpdatedAt: now},
+	}
+	_, err := InsertTasks(testDB, tasks, false)
+	require.NoError(t, err)
+}
+
+func getSummaries(t *testing.T) []string {
+	t.Helper()
+
+	tasks, err := FetchActiveTasks(testDB, 100)
+	require.NoError(t, err)
+	archived, err := FetchInActiveTasks(testDB, 100)
+	require.NoError(t, err)
+
+	var summaries []string
+	for _, task := range append(tasks, archived...) {
+		summaries = append(summaries, task.Summary)
+	}
+
+	return summaries
+}
+
+func TestFetchPrefixes(t *testing.T) {
+	t.Cleanup(func() { cleanupTags(t) })
+
+	// GIVEN
+	insertPrefixedTasks(t)
+
+	// WHEN
+	got, err := FetchPrefixes(testDB)
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, []types.PrefixCount{
+		{Prefix: "api", NumActive: 2, NumArchived: 1},
+		{Prefix: "infra", NumActive: 1},
+	}, got)
+}
+
+func TestRenamePrefix(t *testing.T) {
+	t.Cleanup(func() { cleanupTags(t) })
+
+	// GIVEN
+	insertPrefixedTasks(t)
+
+	// WHEN
+	numChanged, err := RenamePrefix(testDB, "api", " backend ", time.Now())
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, 3, numChanged)
+	assert.ElementsMatch(t, []string{
+		"backend: add auth",
+		"backend:fix: pagination",
+		"infra: deploy #ops",
+		"backend: remove v1",
+		"no prefix",
+	}, getSummaries(t))
+
+	_, err = RenamePrefix(testDB, "backend", "infra", time.Now())
+	require.ErrorIs(t, err, ErrPrefixAlreadyExists)
+	_, err = RenamePrefix(testDB, "api", "web", time.Now())
+	require.ErrorIs(t, err, ErrPrefixNotFound)
+	_, err = RenamePrefix(testDB, "backend", "a:b", time.Now())
+	require.ErrorIs(t, err, types.ErrTaskPrefixInvalid)
+	_, err = RenamePrefix(testDB, "backend", " ", time.Now())
+	require.ErrorIs(t, err, types.ErrTaskPrefixEmpty)
+}
+
+func TestMergePrefixes(t *testing.T) {
+	t.Cleanup(func() { cleanupTags(t) })
+
+	// GIVEN
+	insertPrefixedTasks(t)
+
+	// WHEN
+	numChanged, err := MergePrefixes(testDB, "infra", "api #platform", time.Now())
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, 1, numChanged)
+	task, err := FetchTaskByID(testDB, 3)
+	require.NoError(t, err)
+	assert.Equal(t, "api #platform: deploy #ops", task.Summary)
+	assert.Equal(t, map[string]int{"ops": 1, "platform": 1}, getTagCounts(t))
+
+	_, err = MergePrefixes(testDB, "api", "api", time.Now())
+	require.ErrorIs(t, err, types.ErrTaskPrefixInvalid)
+}
+
+func TestRenamePrefixFailsIfASummaryBecomesTooLong(t *testing.T) {
+	t.Cleanup(func() { cleanupTags(t) })
+
+	// GIVEN
+	insertTaggedTasks(t, "ui: fix", "ui: "+strings.Repeat("a", types.TaskSummaryMaxLen-4))
+
+	// WHEN
+	_, err := RenamePrefix(testDB, "ui", "user interface", time.Now())
+
+	// THEN
+	require.ErrorIs(t, err, types.ErrTaskSummaryTooLong)
+	assert.Equal(t, []string{"ui: fix", "ui: " + strings.Repeat("a", types.TaskSummaryMaxLen-4)}, getSummaries(t), "changes should be rolled back")
+}
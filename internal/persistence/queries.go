@@ -3,10 +3,14 @@ package persistence
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/dhth/omm/internal/types"
+	"github.com/google/uuid"
 )
 
 const (
@@ -14,6 +18,18 @@ const (
 	ContextMaxBytes = 1024 * 1024
 )
 
+var (
+	ErrTaskNotFound  = errors.New("task not found")
+	ErrTaskNotActive = errors.New("task is not active")
+	ErrTooManyTasks  = errors.New("too many active tasks")
+)
+
+// NewTaskUUID returns a random identifier that stays with a task for its
+// lifetime, and is used to match tasks across omm instances and other tools.
+func NewTaskUUID() string {
+	return uuid.NewString()
+}
+
 func fetchTaskSequence(db *sql.DB) ([]uint64, error) {
 	var seq []byte
 	seqRow := db.QueryRow("SELECT sequence from task_sequence where id=1;")
@@ -46,20 +62,45 @@ func fetchNumTotalTasks(db *sql.DB) (int, error) {
 func fetchTaskByID(db *sql.DB, ID int64) (types.Task, error) {
 	var entry types.Task
 	row := db.QueryRow(`
-SELECT id, summary, active, context, created_at, updated_at
+SELECT id, COALESCE(uuid, ''), summary, active, context, COALESCE(status, ''), created_at, updated_at
 from task
 WHERE id=?;
 `, ID)
 	err := row.Scan(&entry.ID,
+		&entry.UUID,
 		&entry.Summary,
 		&entry.Active,
 		&entry.Context,
+		&entry.Status,
 		&entry.CreatedAt,
 		&entry.UpdatedAt,
 	)
 	return entry, err
 }
 
+// FetchNumActiveTasks returns the number of active tasks, including snoozed
+// ones. It's what counts towards TaskNumLimit: snoozed tasks end up back in the
+// task list once they wake up, which needs to have space for them.
+func FetchNumActiveTasks(db *sql.DB) (int, error) {
+	row := db.QueryRow(`
+SELECT json_array_length(sequence) + (
+    SELECT COUNT(*)
+    FROM task
+    WHERE active
+        AND snoozed_until IS NOT NULL
+) AS num_tasks
+FROM task_sequence where id=1;
+`)
+
+	var numTasks int
+	err := row.Scan(&numTasks)
+	if err != nil {
+		return -1, err
+	}
+
+	return numTasks, nil
+}
+
 func FetchNumActiveTasksShown(db *sql.DB) (int, error) {
 	row := db.QueryRow(`
 SELECT json_array_length(sequence) AS num_tasks
@@ -99,17 +140,19 @@ WHERE id = 1;
 	return nil
 }
 
-func InsertTask(db *sql.DB, summary string, context *string, createdAt, updatedAt time.Time) (uint64, error) {
-	stmt, err := db.Prepare(`
-INSERT INTO task (summary, context, active, created_at, updated_at)
-VALUES (?, ?, true, ?, ?);
-`)
+func InsertTask(db *sql.DB, uuid, summary string, context *string, createdAt, updatedAt time.Time) (uint64, error) {
+	tx, err := db.Begin()
 	if err != nil {
 		return 0, err
 	}
-	defer stmt.Close()
+	defer func() {
+		_ = tx.Rollback()
+	}()
 
-	res, err := stmt.Exec(summary, context, createdAt.UTC(), updatedAt.UTC())
+	res, err := tx.Exec(`
+INSERT INTO task (uuid, summary, context, active, created_at, updated_at)
+VALUES (?, ?, ?, true, ?, ?);
+`, uuid, summary, context, createdAt.UTC(), updatedAt.UTC())
 	if err != nil {
 		return 0, err
 	}
@@ -119,6 +162,16 @@ VALUES (?, ?, true, ?, ?);
 		return 0, err
 	}
 
+	err = syncTaskTags(tx, uint64(li), summary)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+
 	return uint64(li), nil
 }
 
@@ -132,17 +185,21 @@ func InsertTasks(db *sql.DB, tasks []types.Task, insertAtTop bool) (int64, error
 	}()
 
 	var query strings.Builder
-	query.WriteString(`INSERT INTO task (summary, context, active, created_at, updated_at)
+	query.WriteString(`INSERT INTO task (uuid, summary, context, active, created_at, updated_at)
 VALUES `)
 
-	values := make([]any, 0, len(tasks)*4)
+	values := make([]any, 0, len(tasks)*6)
 
 	for i, t := range tasks {
 		if i > 0 {
 			query.WriteString(",")
 		}
-		query.WriteString("(?, ?, ?, ?, ?)")
-		values = append(values, t.Summary, t.Context, t.Active, t.CreatedAt.UTC(), t.UpdatedAt.UTC())
+		taskUUID := t.UUID
+		if taskUUID == "" {
+			taskUUID = NewTaskUUID()
+		}
+		query.WriteString("(?, ?, ?, ?, ?, ?)")
+		values = append(values, taskUUID, t.Summary, t.Context, t.Active, t.CreatedAt.UTC(), t.UpdatedAt.UTC())
 	}
 
 	query.WriteString(";")
@@ -174,6 +231,10 @@ VALUES `)
 	var newTaskIDs []int
 	taskID := int(lastInsertID) - len(tasks) + 1
 	for _, t := range tasks {
+		err = syncTaskTags(tx, uint64(taskID), t.Summary)
+		if err != nil {
+			return -1, err
+		}
 		if t.Active {
 			newTaskIDs = append(newTaskIDs, taskID)
 		}
@@ -215,22 +276,30 @@ WHERE id = 1;
 }
 
 func UpdateTaskSummary(db *sql.DB, id uint64, summary string, updatedAt time.Time) error {
-	stmt, err := db.Prepare(`
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	_, err = tx.Exec(`
 UPDATE task
 SET summary = ?,
     updated_at = ?
 WHERE id = ?
-`)
+`, summary, updatedAt.UTC(), id)
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
 
-	_, err = stmt.Exec(summary, updatedAt.UTC(), id)
+	err = syncTaskTags(tx, id, summary)
 	if err != nil {
 		return err
 	}
-	return nil
+
+	return tx.Commit()
 }
 
 func UpdateTaskContext(db *sql.DB, id uint64, context string, updatedAt time.Time) error {
@@ -275,6 +344,7 @@ func ChangeTaskStatus(db *sql.DB, id uint64, active bool, updatedAt time.Time) e
 	stmt, err := db.Prepare(`
 UPDATE task
 SET active = ?,
+    snoozed_until = NULL,
     updated_at = ?
 WHERE id = ?
 `)
@@ -290,17 +360,47 @@ WHERE id = ?
 	return nil
 }
 
+// UpdateTaskStatus sets the board status of a task; whether a task is active or
+// not is changed via ChangeTaskStatus.
+func UpdateTaskStatus(db *sql.DB, id uint64, status string, updatedAt time.Time) error {
+	stmt, err := db.Prepare(`
+UPDATE task
+SET status = ?,
+    updated_at = ?
+WHERE id = ?
+`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(status, updatedAt.UTC(), id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
 func FetchActiveTasks(db *sql.DB, limit int) ([]types.Task, error) {
+	return FetchActiveTasksWhere(db, "TRUE", nil, limit)
+}
+
+// FetchActiveTasksWhere fetches active tasks that satisfy a condition on the
+// task table (aliased as t), in the order of the task list. The condition is
+// trusted to be built by omm (eg. by the query package), with values passed via
+// args.
+func FetchActiveTasksWhere(db *sql.DB, condition string, args []any, limit int) ([]types.Task, error) {
 	var tasks []types.Task
 
-	rows, err := db.Query(`
-SELECT t.id, t.summary, t.context, t.created_at, t.updated_at
+	rows, err := db.Query(fmt.Sprintf(`
+SELECT t.id, COALESCE(t.uuid, ''), t.summary, t.context, COALESCE(t.status, ''), t.created_at, t.updated_at
 FROM task_sequence s
 JOIN json_each(s.sequence) j ON CAST(j.value AS INTEGER) = t.id
 JOIN task t ON t.id = j.value
+WHERE %s
 ORDER BY j.key
 LIMIT ?;
-`, limit)
+`, condition), append(slices.Clone(args), limit)...)
 	if err != nil {
 		return nil, err
 	}
@@ -309,8 +409,10 @@ LIMIT ?;
 	for rows.Next() {
 		var entry types.Task
 		err = rows.Scan(&entry.ID,
+			&entry.UUID,
 			&entry.Summary,
 			&entry.Context,
+			&entry.Status,
 			&entry.CreatedAt,
 			&entry.UpdatedAt,
 		)
@@ -335,7 +437,7 @@ func FetchInActiveTasks(db *sql.DB, limit int) ([]types.Task, error) {
 	var tasks []types.Task
 
 	rows, err := db.Query(`
-SELECT id, summary, context, created_at, updated_at
+SELECT id, COALESCE(uuid, ''), summary, context, COALESCE(status, ''), created_at, updated_at
 FROM task where active is false
 ORDER BY updated_at DESC
 LIMIT ?;
@@ -348,8 +450,10 @@ LIMIT ?;
 	for rows.Next() {
 		var entry types.Task
 		err = rows.Scan(&entry.ID,
+			&entry.UUID,
 			&entry.Summary,
 			&entry.Context,
+			&entry.Status,
 			&entry.CreatedAt,
 			&entry.UpdatedAt,
 		)
@@ -370,6 +474,33 @@ LIMIT ?;
 	return tasks, nil
 }
 
+// FetchContextUpdateTimes returns the time each task's context was last
+// changed at, for tasks whose context has changed since they were created.
+func FetchContextUpdateTimes(db *sql.DB) (map[uint64]time.Time, error) {
+	rows, err := db.Query(`
+SELECT id, context_updated_at
+FROM task
+WHERE context_updated_at IS NOT NULL;
+`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	updateTimes := make(map[uint64]time.Time)
+	for rows.Next() {
+		var id uint64
+		var updatedAt time.Time
+		err = rows.Scan(&id, &updatedAt)
+		if err != nil {
+			return nil, err
+		}
+		updateTimes[id] = updatedAt.Local()
+	}
+
+	return updateTimes, rows.Err()
+}
+
 func DeleteTask(db *sql.DB, id uint64) error {
 	stmt, err := db.Prepare(`
 DELETE from task
@@ -386,3 +517,530 @@ WHERE id=?;
 	}
 	return nil
 }
+
+// UpsertTasksByUUID inserts tasks whose UUIDs aren't present in the database
+// yet, and updates the ones that are. Tasks that become active are added to
+// the top of the task sequence (in the order they are provided in), and tasks
+// that become inactive are removed from it.
+func UpsertTasksByUUID(db *sql.DB, tasks []types.Task) (int, int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, 0, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var seq []byte
+	err = tx.QueryRow("SELECT sequence from task_sequence where id=1;").Scan(&seq)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var seqItems []uint64
+	err = json.Unmarshal(seq, &seqItems)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var numInserted, numUpdated int
+	var newlyActive []uint64
+	newlyInactive := make(map[uint64]struct{})
+
+	for _, t := range tasks {
+		taskUUID := t.UUID
+		if taskUUID == "" {
+			taskUUID = NewTaskUUID()
+		}
+
+		var id uint64
+		var wasActive bool
+		err = tx.QueryRow("SELECT id, active FROM task WHERE uuid = ?;", taskUUID).Scan(&id, &wasActive)
+
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			res, insErr := tx.Exec(`
+INSERT INTO task (uuid, summary, context, active, created_at, updated_at)
+VALUES (?, ?, ?, ?, ?, ?);
+`, taskUUID, t.Summary, t.Context, t.Active, t.CreatedAt.UTC(), t.UpdatedAt.UTC())
+			if insErr != nil {
+				return 0, 0, insErr
+			}
+
+			li, liErr := res.LastInsertId()
+			if liErr != nil {
+				return 0, 0, liErr
+			}
+
+			err = syncTaskTags(tx, uint64(li), t.Summary)
+			if err != nil {
+				return 0, 0, err
+			}
+
+			if t.Active {
+				newlyActive = append(newlyActive, uint64(li))
+			}
+			numInserted++
+		case err != nil:
+			return 0, 0, err
+		default:
+			_, err = tx.Exec(`
+UPDATE task
+SET summary = ?,
+    context = ?,
+    active = ?,
+    snoozed_until = CASE WHEN ? THEN snoozed_until END,
+    updated_at = ?
+WHERE id = ?;
+`, t.Summary, t.Context, t.Active, t.Active, t.UpdatedAt.UTC(), id)
+			if err != nil {
+				return 0, 0, err
+			}
+
+			err = syncTaskTags(tx, id, t.Summary)
+			if err != nil {
+				return 0, 0, err
+			}
+
+			if t.Active && !wasActive {
+				newlyActive = append(newlyActive, id)
+			} else if !t.Active && wasActive {
+				newlyInactive[id] = struct{}{}
+			}
+			numUpdated++
+		}
+	}
+
+	updatedSeqItems := make([]uint64, 0, len(newlyActive)+len(seqItems))
+	updatedSeqItems = append(updatedSeqItems, newlyActive...)
+	for _, id := range seqItems {
+		if _, ok := newlyInactive[id]; ok {
+			continue
+		}
+		updatedSeqItems = append(updatedSeqItems, id)
+	}
+
+	sequenceJSON, err := json.Marshal(updatedSeqItems)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	_, err = tx.Exec(`
+UPDATE task_sequence
+SET sequence = ?
+WHERE id = 1;
+`, sequenceJSON)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return numInserted, numUpdated, nil
+}
+
+// ReplaceTasks makes the database hold exactly the tasks provided, matching
+// existing tasks by their UUIDs. Tasks missing from the list are deleted. The
+// task sequence is set to the active tasks referenced by sequence (a list of
+// UUIDs), in that order. Snoozing isn't part of the tasks provided, so active
+// tasks that are snoozed stay that way (and out of the sequence). Dependencies
+// are set to the ones in blockedBy, which maps UUIDs of tasks to the UUIDs of
+// the tasks blocking them. Nothing is changed if more than TaskNumLimit of the
+// tasks provided are active.
+func ReplaceTasks(db *sql.DB, tasks []types.Task, sequence []string, blockedBy map[string][]string) error {
+	numActive := 0
+	for _, t := range tasks {
+		if t.Active {
+			numActive++
+		}
+	}
+	if numActive > TaskNumLimit {
+		return fmt.Errorf("%w: %d (the limit is %d)", ErrTooManyTasks, numActive, TaskNumLimit)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	rows, err := tx.Query("SELECT id, uuid FROM task WHERE uuid IS NOT NULL;")
+	if err != nil {
+		return err
+	}
+
+	existingIDs := make(map[string]uint64)
+	for rows.Next() {
+		var id uint64
+		var taskUUID string
+		err = rows.Scan(&id, &taskUUID)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		existingIDs[taskUUID] = id
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return err
+	}
+
+	ids := make(map[string]uint64, len(tasks))
+	for _, t := range tasks {
+		id, ok := existingIDs[t.UUID]
+		if ok {
+			_, err = tx.Exec(`
+UPDATE task
+SET summary = ?,
+    context = ?,
+    active = ?,
+    status = NULLIF(?, ''),
+    snoozed_until = CASE WHEN ? THEN snoozed_until END,
+    created_at = ?,
+    updated_at = ?
+WHERE id = ?;
+`, t.Summary, t.Context, t.Active, t.Status, t.Active, t.CreatedAt.UTC(), t.UpdatedAt.UTC(), id)
+			if err != nil {
+				return err
+			}
+			err = syncTaskTags(tx, id, t.Summary)
+			if err != nil {
+				return err
+			}
+			ids[t.UUID] = id
+			delete(existingIDs, t.UUID)
+			continue
+		}
+
+		res, err := tx.Exec(`
+INSERT INTO task (uuid, summary, context, active, status, created_at, updated_at)
+VALUES (?, ?, ?, ?, NULLIF(?, ''), ?, ?);
+`, t.UUID, t.Summary, t.Context, t.Active, t.Status, t.CreatedAt.UTC(), t.UpdatedAt.UTC())
+		if err != nil {
+			return err
+		}
+
+		li, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+		err = syncTaskTags(tx, uint64(li), t.Summary)
+		if err != nil {
+			return err
+		}
+		ids[t.UUID] = uint64(li)
+	}
+
+	for _, id := range existingIDs {
+		_, err = tx.Exec("DELETE FROM task WHERE id = ?;", id)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = replaceTaskDependenciesTx(tx, ids, blockedBy)
+	if err != nil {
+		return err
+	}
+
+	active := make(map[string]bool, len(tasks))
+	for _, t := range tasks {
+		active[t.UUID] = t.Active
+	}
+
+	snoozed, err := fetchSnoozedTasksTx(tx, TaskNumLimit)
+	if err != nil {
+		return err
+	}
+
+	seqItems := make([]uint64, 0, len(sequence))
+	for _, taskUUID := range sequence {
+		id, ok := ids[taskUUID]
+		if !ok || !active[taskUUID] {
+			continue
+		}
+		if slices.ContainsFunc(snoozed, func(t types.Task) bool { return t.ID == id }) {
+			continue
+		}
+		seqItems = append(seqItems, id)
+	}
+
+	sequenceJSON, err := json.Marshal(seqItems)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+UPDATE task_sequence
+SET sequence = ?
+WHERE id = 1;
+`, sequenceJSON)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// EnsureTaskUUIDs assigns UUIDs to tasks that don't have one (eg. tasks
+// created by a version of omm that predates them).
+func EnsureTaskUUIDs(db *sql.DB) error {
+	rows, err := db.Query("SELECT id FROM task WHERE uuid IS NULL;")
+	if err != nil {
+		return err
+	}
+
+	var ids []uint64
+	for rows.Next() {
+		var id uint64
+		err = rows.Scan(&id)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		_, err = db.Exec("UPDATE task SET uuid = ? WHERE id = ?;", NewTaskUUID(), id)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// FetchTaskByID fetches a single task; it returns ErrTaskNotFound if there's no
+// task with the given ID.
+func FetchTaskByID(db *sql.DB, id uint64) (types.Task, error) {
+	task, err := fetchTaskByID(db, int64(id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return task, ErrTaskNotFound
+	}
+	if err != nil {
+		return task, err
+	}
+
+	task.CreatedAt = task.CreatedAt.Local()
+	task.UpdatedAt = task.UpdatedAt.Local()
+
+	return task, nil
+}
+
+// SetTaskActive changes a task's status (which also wakes it up, if it's
+// snoozed), and keeps the task sequence in line with it: a task that becomes
+// active is added to the top of the sequence, and one that becomes inactive is
+// removed from it.
+func SetTaskActive(db *sql.DB, id uint64, active bool, updatedAt time.Time) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	res, err := tx.Exec(`
+UPDATE task
+SET active = ?,
+    snoozed_until = NULL,
+    updated_at = ?
+WHERE id = ?;
+`, active, updatedAt.UTC(), id)
+	if err != nil {
+		return err
+	}
+
+	err = checkTaskAffected(res)
+	if err != nil {
+		return err
+	}
+
+	seqItems, err := fetchTaskSequenceTx(tx)
+	if err != nil {
+		return err
+	}
+
+	seqItems = slices.DeleteFunc(seqItems, func(seqID uint64) bool { return seqID == id })
+	if active {
+		seqItems = slices.Insert(seqItems, 0, id)
+	}
+
+	err = updateTaskSequenceTx(tx, seqItems)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RemoveTask deletes a task, and removes it from the task sequence.
+func RemoveTask(db *sql.DB, id uint64) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	res, err := tx.Exec("DELETE FROM task WHERE id = ?;", id)
+	if err != nil {
+		return err
+	}
+
+	err = checkTaskAffected(res)
+	if err != nil {
+		return err
+	}
+
+	seqItems, err := fetchTaskSequenceTx(tx)
+	if err != nil {
+		return err
+	}
+
+	seqItems = slices.DeleteFunc(seqItems, func(seqID uint64) bool { return seqID == id })
+
+	err = updateTaskSequenceTx(tx, seqItems)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// MoveTask moves an active task to the given (zero based) position in the task
+// sequence. Positions past the end of the sequence move the task to the end.
+func MoveTask(db *sql.DB, id uint64, position int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	seqItems, err := fetchTaskSequenceTx(tx)
+	if err != nil {
+		return err
+	}
+
+	index := slices.Index(seqItems, id)
+	if index == -1 {
+		return ErrTaskNotActive
+	}
+
+	seqItems = slices.Delete(seqItems, index, index+1)
+	position = max(0, min(position, len(seqItems)))
+	seqItems = slices.Insert(seqItems, position, id)
+
+	err = updateTaskSequenceTx(tx, seqItems)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func checkTaskAffected(res sql.Result) error {
+	numRows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if numRows == 0 {
+		return ErrTaskNotFound
+	}
+
+	return nil
+}
+
+func fetchTaskSequenceTx(tx *sql.Tx) ([]uint64, error) {
+	var seq []byte
+	err := tx.QueryRow("SELECT sequence from task_sequence where id=1;").Scan(&seq)
+	if err != nil {
+		return nil, err
+	}
+
+	var seqItems []uint64
+	err = json.Unmarshal(seq, &seqItems)
+	if err != nil {
+		return nil, err
+	}
+
+	return seqItems, nil
+}
+
+func updateTaskSequenceTx(tx *sql.Tx, sequence []uint64) error {
+	if sequence == nil {
+		sequence = []uint64{}
+	}
+
+	sequenceJSON, err := json.Marshal(sequence)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+UPDATE task_sequence
+SET sequence = ?
+WHERE id = 1;
+`, sequenceJSON)
+
+	return err
+}
+
+// fetchTaskSummariesTx returns the ID, summary, and status of all tasks, ordered
+// by ID.
+func fetchTaskSummariesTx(tx *sql.Tx) ([]types.Task, error) {
+	rows, err := tx.Query("SELECT id, summary, active FROM task ORDER BY id;")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []types.Task
+	for rows.Next() {
+		var t types.Task
+		err = rows.Scan(&t.ID, &t.Summary, &t.Active)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, t)
+	}
+
+	return tasks, rows.Err()
+}
+
+// rewriteTaskSummaryTx updates a task's summary as part of a change to several
+// tasks, checking that the new summary is valid.
+func rewriteTaskSummaryTx(tx *sql.Tx, id uint64, summary string, updatedAt time.Time) error {
+	_, err := types.CheckIfTaskSummaryValid(summary)
+	if err != nil {
+		return fmt.Errorf("task %d: %w", id, err)
+	}
+
+	_, err = tx.Exec(`
+UPDATE task
+SET summary = ?,
+    updated_at = ?
+WHERE id = ?;
+`, summary, updatedAt.UTC(), id)
+	if err != nil {
+		return err
+	}
+
+	return syncTaskTags(tx, id, summary)
+}
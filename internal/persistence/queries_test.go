@@ -258,3 +258,137 @@ func TestInsertTasksAddsTasksAtTheEnd(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, seq, []uint64{1, 2, 3, 6, 7}, "task sequence isn't correct")
 }
+
+func TestUpsertTasksByUUIDInsertsAndUpdatesTasks(t *testing.T) {
+	t.Cleanup(func() { cleanupDB(t) })
+
+	// GIVEN
+	now := time.Now().UTC()
+	existing := []types.Task{
+		{UUID: "uuid-1", Summary: "prefix: task 1", Active: true, CreatedAt: now, UpdatedAt: now},
+		{UUID: "uuid-2", Summary: "prefix: task 2", Active: true, CreatedAt: now, UpdatedAt: now},
+		{UUID: "uuid-3", Summary: "prefix: task 3", Active: false, CreatedAt: now, UpdatedAt: now},
+	}
+	_, err := InsertTasks(testDB, existing, true)
+	require.NoError(t, err)
+
+	// WHEN
+	later := now.Add(time.Hour)
+	context := "new context"
+	tasks := []types.Task{
+		{UUID: "uuid-4", Summary: "prefix: task 4", Active: true, CreatedAt: later, UpdatedAt: later},
+		{UUID: "uuid-3", Summary: "prefix: task 3 (reopened)", Active: true, CreatedAt: now, UpdatedAt: later},
+		{UUID: "uuid-1", Summary: "prefix: task 1", Context: &context, Active: false, CreatedAt: now, UpdatedAt: later},
+	}
+	numInserted, numUpdated, err := UpsertTasksByUUID(testDB, tasks)
+	require.NoError(t, err)
+
+	// THEN
+	assert.Equal(t, 1, numInserted)
+	assert.Equal(t, 2, numUpdated)
+
+	numTotalRes, err := fetchNumTotalTasks(testDB)
+	require.NoError(t, err)
+	assert.Equal(t, 4, numTotalRes)
+
+	seq, err := fetchTaskSequence(testDB)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{4, 3, 2}, seq, "task sequence isn't correct")
+
+	reopened, err := fetchTaskByID(testDB, 3)
+	require.NoError(t, err)
+	assert.Equal(t, "prefix: task 3 (reopened)", reopened.Summary)
+	assert.True(t, reopened.Active)
+
+	archived, err := fetchTaskByID(testDB, 1)
+	require.NoError(t, err)
+	assert.False(t, archived.Active)
+	assert.Equal(t, &context, archived.Context)
+}
+
+func TestInsertTasksAssignsUUIDs(t *testing.T) {
+	t.Cleanup(func() { cleanupDB(t) })
+
+	// GIVEN
+	now := time.Now().UTC()
+	tasks := []types.Task{
+		{Summary: "prefix: task 1", Active: true, CreatedAt: now, UpdatedAt: now},
+		{UUID: "provided-uuid", Summary: "prefix: task 2", Active: true, CreatedAt: now, UpdatedAt: now},
+	}
+
+	// WHEN
+	_, err := InsertTasks(testDB, tasks, true)
+	require.NoError(t, err)
+
+	// THEN
+	first, err := fetchTaskByID(testDB, 1)
+	require.NoError(t, err)
+	assert.NotEmpty(t, first.UUID)
+
+	second, err := fetchTaskByID(testDB, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "provided-uuid", second.UUID)
+}
+
+func TestChangesToTasksKeepTheSequenceInSync(t *testing.T) {
+	t.Cleanup(func() { cleanupDB(t) })
+
+	// GIVEN
+	seedDB(t, testDB)
+	now := time.Now()
+
+	// WHEN
+	require.NoError(t, SetTaskActive(testDB, 2, false, now))
+	require.NoError(t, SetTaskActive(testDB, 4, true, now))
+	require.NoError(t, RemoveTask(testDB, 1))
+	require.NoError(t, MoveTask(testDB, 4, 10))
+
+	// THEN
+	seq, err := fetchTaskSequence(testDB)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{3, 4}, seq, "task sequence isn't correct")
+
+	assert.ErrorIs(t, MoveTask(testDB, 2, 0), ErrTaskNotActive)
+	assert.ErrorIs(t, RemoveTask(testDB, 1), ErrTaskNotFound)
+	_, err = FetchTaskByID(testDB, 1)
+	assert.ErrorIs(t, err, ErrTaskNotFound)
+}
+
+func TestContextUpdateTimesAreRecorded(t *testing.T) {
+	t.Cleanup(func() { cleanupDB(t) })
+
+	// GIVEN
+	seedDB(t, testDB)
+	updatedAt := time.Date(2026, 3, 11, 12, 0, 0, 0, time.UTC)
+
+	// WHEN
+	require.NoError(t, UpdateTaskContext(testDB, 1, "new context", updatedAt))
+	require.NoError(t, UnsetTaskContext(testDB, 2, updatedAt))
+	require.NoError(t, UpdateTaskSummary(testDB, 3, "prefix: updated", updatedAt))
+
+	// THEN
+	updateTimes, err := FetchContextUpdateTimes(testDB)
+	require.NoError(t, err)
+	// task 2 didn't have any context to begin with
+	require.Len(t, updateTimes, 1)
+	assert.True(t, updatedAt.Equal(updateTimes[1]))
+}
+
+func TestUpdateTaskStatus(t *testing.T) {
+	t.Cleanup(func() { cleanupDB(t) })
+
+	// GIVEN
+	seedDB(t, testDB)
+	updatedAt := time.Date(2026, 3, 11, 12, 0, 0, 0, time.UTC)
+
+	// WHEN
+	require.NoError(t, UpdateTaskStatus(testDB, 2, "doing", updatedAt))
+
+	// THEN
+	tasks, err := FetchActiveTasks(testDB, 10)
+	require.NoError(t, err)
+	require.Len(t, tasks, 3)
+	assert.Equal(t, "", tasks[0].Status)
+	assert.Equal(t, "doing", tasks[1].Status)
+	assert.True(t, updatedAt.Equal(tasks[1].UpdatedAt))
+}
@@ -0,0 +1,261 @@
+package persistence
+
+import (
+	"database/sql"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/dhth/omm/internal/types"
+)
+
+var (
+	ErrTaskNotSnoozed = errors.New("task is not snoozed")
+	ErrTaskListFull   = errors.New("task list is at capacity")
+)
+
+// Snoozed tasks are active tasks that are hidden until a point in time; they're
+// kept out of the task sequence while they're snoozed (like archived tasks
+// are), and are added back to its top when they wake up.
+
+// SnoozeTask hides an active task until a point in time; snoozing a task that's
+// already snoozed changes the time it wakes up at.
+func SnoozeTask(db *sql.DB, id uint64, until time.Time) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var active bool
+	err = tx.QueryRow("SELECT active FROM task WHERE id = ?;", id).Scan(&active)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrTaskNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if !active {
+		return ErrTaskNotActive
+	}
+
+	_, err = tx.Exec("UPDATE task SET snoozed_until = ? WHERE id = ?;", until.UTC(), id)
+	if err != nil {
+		return err
+	}
+
+	seqItems, err := fetchTaskSequenceTx(tx)
+	if err != nil {
+		return err
+	}
+
+	seqItems = slices.DeleteFunc(seqItems, func(seqID uint64) bool { return seqID == id })
+
+	err = updateTaskSequenceTx(tx, seqItems)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UnsnoozeTask wakes up a snoozed task right away, adding it to the top of the
+// task sequence. This fails if the task list is at capacity.
+func UnsnoozeTask(db *sql.DB, id uint64) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	res, err := tx.Exec(`
+UPDATE task
+SET snoozed_until = NULL
+WHERE id = ?
+    AND active
+    AND snoozed_until IS NOT NULL;
+`, id)
+	if err != nil {
+		return err
+	}
+
+	numRows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if numRows == 0 {
+		return ErrTaskNotSnoozed
+	}
+
+	seqItems, err := fetchTaskSequenceTx(tx)
+	if err != nil {
+		return err
+	}
+	if len(seqItems) >= TaskNumLimit {
+		return ErrTaskListFull
+	}
+
+	err = addToTopOfSequenceTx(tx, []uint64{id})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// WakeSnoozedTasks wakes up the tasks snoozed until now (or earlier), adding
+// them to the top of the task sequence, the one that was due first at the very
+// top. It returns the tasks that were woken up, in that order. Snoozed tasks
+// count towards the task limit, so there's usually space for them; if there
+// isn't (eg. after a sync added tasks), tasks that don't fit stay snoozed until
+// there is.
+func WakeSnoozedTasks(db *sql.DB, now time.Time) ([]types.Task, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	snoozed, err := fetchSnoozedTasksTx(tx, TaskNumLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	seqItems, err := fetchTaskSequenceTx(tx)
+	if err != nil {
+		return nil, err
+	}
+	space := TaskNumLimit - len(seqItems)
+
+	var woken []types.Task
+	var ids []uint64
+	for _, t := range snoozed {
+		if len(woken) >= space {
+			break
+		}
+		if t.SnoozedUntil.After(now) {
+			continue
+		}
+
+		_, err = tx.Exec("UPDATE task SET snoozed_until = NULL WHERE id = ?;", t.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		t.SnoozedUntil = nil
+		woken = append(woken, t)
+		ids = append(ids, t.ID)
+	}
+
+	if len(woken) == 0 {
+		return nil, nil
+	}
+
+	err = addToTopOfSequenceTx(tx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	return woken, tx.Commit()
+}
+
+// FetchAllActiveTasks returns the tasks in the active task list (in order),
+// followed by snoozed ones; snoozed tasks are still active, they're just not in
+// the list.
+func FetchAllActiveTasks(db *sql.DB) ([]types.Task, error) {
+	tasks, err := FetchActiveTasks(db, TaskNumLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	snoozed, err := FetchSnoozedTasks(db, TaskNumLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(tasks, snoozed...), nil
+}
+
+// FetchSnoozedTasks returns snoozed tasks, the ones that wake up first first.
+func FetchSnoozedTasks(db *sql.DB, limit int) ([]types.Task, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	return fetchSnoozedTasksTx(tx, limit)
+}
+
+func fetchSnoozedTasksTx(tx *sql.Tx, limit int) ([]types.Task, error) {
+	rows, err := tx.Query(`
+SELECT id, COALESCE(uuid, ''), summary, context, COALESCE(status, ''), created_at, updated_at, snoozed_until
+FROM task
+WHERE active
+    AND snoozed_until IS NOT NULL
+ORDER BY snoozed_until, id
+LIMIT ?;
+`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []types.Task
+	for rows.Next() {
+		var t types.Task
+		var snoozedUntil time.Time
+		err = rows.Scan(&t.ID,
+			&t.UUID,
+			&t.Summary,
+			&t.Context,
+			&t.Status,
+			&t.CreatedAt,
+			&t.UpdatedAt,
+			&snoozedUntil,
+		)
+		if err != nil {
+			return nil, err
+		}
+		t.CreatedAt = t.CreatedAt.Local()
+		t.UpdatedAt = t.UpdatedAt.Local()
+		snoozedUntil = snoozedUntil.Local()
+		t.SnoozedUntil = &snoozedUntil
+		t.Active = true
+		tasks = append(tasks, t)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	// timestamps are stored as text, which doesn't always sort chronologically
+	slices.SortStableFunc(tasks, func(a, b types.Task) int {
+		return a.SnoozedUntil.Compare(*b.SnoozedUntil)
+	})
+
+	return tasks, nil
+}
+
+// addToTopOfSequenceTx adds tasks to the top of the task sequence (in the order
+// they're provided in), moving them there if they're already in it.
+func addToTopOfSequenceTx(tx *sql.Tx, ids []uint64) error {
+	seqItems, err := fetchTaskSequenceTx(tx)
+	if err != nil {
+		return err
+	}
+
+	seqItems = slices.DeleteFunc(seqItems, func(seqID uint64) bool { return slices.Contains(ids, seqID) })
+	seqItems = slices.Insert(seqItems, 0, ids...)
+
+	return updateTaskSequenceTx(tx, seqItems)
+}
@@ -0,0 +1,210 @@
+package persistence
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/dhth/omm/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnoozeTask(t *testing.T) {
+	testCases := []struct {
+		name             string
+		id               uint64
+		expectedSequence []uint64
+		err              error
+	}{
+		{
+			name:             "active task",
+			id:               2,
+			expectedSequence: []uint64{1},
+		},
+		{
+			name: "archived task",
+			id:   3,
+			err:  ErrTaskNotActive,
+		},
+		{
+			name: "task that doesn't exist",
+			id:   10,
+			err:  ErrTaskNotFound,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(func() { cleanupDB(t) })
+
+			// GIVEN
+			insertTaggedTasks(t, "task 1", "task 2", "task 3")
+			require.NoError(t, SetTaskActive(testDB, 3, false, time.Now()))
+
+			// WHEN
+			err := SnoozeTask(testDB, tt.id, time.Now().Add(time.Hour))
+
+			// THEN
+			if tt.err != nil {
+				require.ErrorIs(t, err, tt.err)
+				return
+			}
+
+			require.NoError(t, err)
+			sequence, err := fetchTaskSequence(testDB)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedSequence, sequence)
+
+			snoozed, err := FetchSnoozedTasks(testDB, 10)
+			require.NoError(t, err)
+			require.Len(t, snoozed, 1)
+			assert.Equal(t, tt.id, snoozed[0].ID)
+		})
+	}
+}
+
+func TestUnsnoozeTask(t *testing.T) {
+	t.Cleanup(func() { cleanupDB(t) })
+
+	// GIVEN
+	insertTaggedTasks(t, "task 1", "task 2", "task 3")
+	require.NoError(t, SnoozeTask(testDB, 3, time.Now().Add(time.Hour)))
+
+	// WHEN
+	err := UnsnoozeTask(testDB, 3)
+
+	// THEN
+	require.NoError(t, err)
+	sequence, err := fetchTaskSequence(testDB)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{3, 1, 2}, sequence)
+
+	snoozed, err := FetchSnoozedTasks(testDB, 10)
+	require.NoError(t, err)
+	assert.Empty(t, snoozed)
+
+	err = UnsnoozeTask(testDB, 3)
+	require.ErrorIs(t, err, ErrTaskNotSnoozed)
+}
+
+func TestWakeSnoozedTasks(t *testing.T) {
+	t.Cleanup(func() { cleanupDB(t) })
+
+	// GIVEN
+	now := time.Now()
+	insertTaggedTasks(t, "task 1", "task 2", "task 3", "task 4")
+	require.NoError(t, SnoozeTask(testDB, 1, now.Add(-time.Minute)))
+	require.NoError(t, SnoozeTask(testDB, 2, now.Add(time.Hour)))
+	require.NoError(t, SnoozeTask(testDB, 3, now.Add(-time.Hour)))
+
+	// WHEN
+	woken, err := WakeSnoozedTasks(testDB, now)
+
+	// THEN
+	require.NoError(t, err)
+	require.Len(t, woken, 2)
+	assert.Equal(t, uint64(3), woken[0].ID)
+	assert.Equal(t, uint64(1), woken[1].ID)
+	assert.Nil(t, woken[0].SnoozedUntil)
+
+	sequence, err := fetchTaskSequence(testDB)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{3, 1, 4}, sequence)
+
+	snoozed, err := FetchSnoozedTasks(testDB, 10)
+	require.NoError(t, err)
+	require.Len(t, snoozed, 1)
+	assert.Equal(t, uint64(2), snoozed[0].ID)
+}
+
+func TestArchivingTaskEndsSnoozing(t *testing.T) {
+	t.Cleanup(func() { cleanupDB(t) })
+
+	// GIVEN
+	insertTaggedTasks(t, "task 1", "task 2")
+	require.NoError(t, SnoozeTask(testDB, 1, time.Now().Add(time.Hour)))
+
+	// WHEN
+	err := SetTaskActive(testDB, 1, false, time.Now())
+
+	// THEN
+	require.NoError(t, err)
+	require.NoError(t, SetTaskActive(testDB, 1, true, time.Now()))
+	snoozed, err := FetchSnoozedTasks(testDB, 10)
+	require.NoError(t, err)
+	assert.Empty(t, snoozed)
+
+	sequence, err := fetchTaskSequence(testDB)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{1, 2}, sequence)
+}
+
+func TestSnoozedTasksCountTowardsCapacity(t *testing.T) {
+	t.Cleanup(func() { cleanupDB(t) })
+
+	// GIVEN
+	insertTaggedTasks(t, "task 1", "task 2", "task 3")
+	require.NoError(t, SnoozeTask(testDB, 2, time.Now().Add(time.Hour)))
+
+	// WHEN
+	numTasks, err := FetchNumActiveTasks(testDB)
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, 3, numTasks)
+
+	numShown, err := FetchNumActiveTasksShown(testDB)
+	require.NoError(t, err)
+	assert.Equal(t, 2, numShown)
+}
+
+func TestWakingSnoozedTasksIntoAFullList(t *testing.T) {
+	t.Cleanup(func() { cleanupDB(t) })
+
+	// GIVEN
+	insertNumTasks := func(num int) {
+		t.Helper()
+		now := time.Now()
+		tasks := make([]types.Task, num)
+		for i := range tasks {
+			tasks[i] = types.Task{Summary: fmt.Sprintf("task %d", i), Active: true, CreatedAt: now, UpdatedAt: now}
+		}
+		for batch := range slices.Chunk(tasks, 1000) {
+			_, err := InsertTasks(testDB, batch, false)
+			require.NoError(t, err)
+		}
+	}
+
+	now := time.Now()
+	insertNumTasks(TaskNumLimit)
+	require.NoError(t, SnoozeTask(testDB, 1, now.Add(-2*time.Minute)))
+	require.NoError(t, SnoozeTask(testDB, 2, now.Add(-time.Minute)))
+	// the list filling up regardless (eg. via a sync)
+	insertNumTasks(2)
+
+	// WHEN
+	woken, err := WakeSnoozedTasks(testDB, now)
+
+	// THEN
+	require.NoError(t, err)
+	assert.Empty(t, woken, "tasks that don't fit in the list should stay snoozed")
+	require.ErrorIs(t, UnsnoozeTask(testDB, 2), ErrTaskListFull)
+
+	require.NoError(t, SetTaskActive(testDB, 3, false, now))
+	woken, err = WakeSnoozedTasks(testDB, now)
+	require.NoError(t, err)
+	require.Len(t, woken, 1)
+	assert.Equal(t, uint64(1), woken[0].ID)
+
+	active, err := FetchActiveTasks(testDB, TaskNumLimit)
+	require.NoError(t, err)
+	assert.Len(t, active, TaskNumLimit)
+	assert.Equal(t, uint64(1), active[0].ID)
+
+	snoozed, err := FetchSnoozedTasks(testDB, 10)
+	require.NoError(t, err)
+	require.Len(t, snoozed, 1)
+	assert.Equal(t, uint64(2), snoozed[0].ID)
+}
@@ -0,0 +1,353 @@
+package persistence
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dhth/omm/internal/types"
+)
+
+var (
+	ErrTagNotFound      = errors.New("tag not found")
+	ErrTagAlreadyExists = errors.New("tag already exists")
+	ErrTagNameInvalid   = errors.New("tag name is invalid")
+)
+
+// Tags live in task summaries (as "#name"), which remain the source of truth;
+// the tag and task_tag tables index them, and hold what can't be expressed in
+// a summary (like colors).
+
+// syncTaskTags links a task to the tags in its summary (and only those).
+func syncTaskTags(tx *sql.Tx, id uint64, summary string) error {
+	_, err := tx.Exec("DELETE FROM task_tag WHERE task_id = ?;", id)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range types.ParseTags(summary) {
+		_, err = tx.Exec(`
+INSERT INTO tag (name)
+VALUES (?)
+ON CONFLICT (name) DO NOTHING;
+`, name)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`
+INSERT INTO task_tag (task_id, tag_id)
+SELECT ?, id
+FROM tag
+WHERE name = ?;
+`, id, name)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// rebuildTaskTagsTx links every task to the tags in its summary.
+func rebuildTaskTagsTx(tx *sql.Tx) error {
+	rows, err := tx.Query("SELECT id, summary FROM task;")
+	if err != nil {
+		return err
+	}
+
+	summaries := make(map[uint64]string)
+	for rows.Next() {
+		var id uint64
+		var summary string
+		err = rows.Scan(&id, &summary)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		summaries[id] = summary
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM task_tag;")
+	if err != nil {
+		return err
+	}
+
+	for id, summary := range summaries {
+		err = syncTaskTags(tx, id, summary)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// FetchTags returns the tags in use, along with the ones that have a color set,
+// sorted by name.
+func FetchTags(db *sql.DB) ([]types.Tag, error) {
+	rows, err := db.Query(`
+SELECT g.name, COALESCE(g.color, ''), COUNT(tt.task_id)
+FROM tag g
+LEFT JOIN task_tag tt ON tt.tag_id = g.id
+GROUP BY g.id
+HAVING COUNT(tt.task_id) > 0 OR g.color IS NOT NULL
+ORDER BY g.name;
+`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []types.Tag
+	for rows.Next() {
+		var t types.Tag
+		err = rows.Scan(&t.Name, &t.Color, &t.NumTasks)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, t)
+	}
+
+	return tags, rows.Err()
+}
+
+// SetTagColor sets the color a tag is shown in; an empty color unsets it.
+func SetTagColor(db *sql.DB, name, color string) error {
+	res, err := db.Exec(`
+UPDATE tag
+SET color = NULLIF(?, '')
+WHERE name = ?;
+`, color, strings.ToLower(name))
+	if err != nil {
+		return err
+	}
+
+	numRows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if numRows == 0 {
+		return ErrTagNotFound
+	}
+
+	return nil
+}
+
+// RenameTag renames a tag in the summaries of all tasks that have it, keeping
+// its color. It returns the number of tasks that were changed.
+func RenameTag(db *sql.DB, from, to string, updatedAt time.Time) (int, error) {
+	from = strings.ToLower(from)
+	to = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(to), types.TagPrefix))
+	if !types.IsTagNameValid(to) {
+		return 0, fmt.Errorf("%w: %q", ErrTagNameInvalid, to)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	err = pruneTagsTx(tx)
+	if err != nil {
+		return 0, err
+	}
+
+	var numExisting int
+	err = tx.QueryRow("SELECT COUNT(*) FROM tag WHERE name = ?;", to).Scan(&numExisting)
+	if err != nil {
+		return 0, err
+	}
+	if numExisting > 0 {
+		return 0, fmt.Errorf("%w: %q", ErrTagAlreadyExists, to)
+	}
+
+	summaries, err := fetchTaggedSummariesTx(tx, from)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.Exec("UPDATE tag SET name = ? WHERE name = ?;", to, from)
+	if err != nil {
+		return 0, err
+	}
+
+	err = replaceTagInSummariesTx(tx, summaries, from, to, updatedAt)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(summaries), tx.Commit()
+}
+
+// MergeTags replaces a tag with another one in the summaries of all tasks that
+// have it, and removes it. It returns the number of tasks that were changed.
+func MergeTags(db *sql.DB, from, into string, updatedAt time.Time) (int, error) {
+	from = strings.ToLower(from)
+	into = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(into), types.TagPrefix))
+	if !types.IsTagNameValid(into) {
+		return 0, fmt.Errorf("%w: %q", ErrTagNameInvalid, into)
+	}
+	if from == into {
+		return 0, fmt.Errorf("%w: can't merge a tag into itself", ErrTagNameInvalid)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	err = pruneTagsTx(tx)
+	if err != nil {
+		return 0, err
+	}
+
+	summaries, err := fetchTaggedSummariesTx(tx, from)
+	if err != nil {
+		return 0, err
+	}
+
+	err = replaceTagInSummariesTx(tx, summaries, from, into, updatedAt)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.Exec("DELETE FROM tag WHERE name = ?;", from)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(summaries), tx.Commit()
+}
+
+// AddTagsFromPrefixes adds a tag corresponding to the prefix of each task that
+// has one (eg. "side project: task" -> "side project: task #side-project"),
+// optionally stripping the prefix. It returns the number of tasks that were
+// changed.
+func AddTagsFromPrefixes(db *sql.DB, stripPrefix bool, updatedAt time.Time) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	tasks, err := fetchTaskSummariesTx(tx)
+	if err != nil {
+		return 0, err
+	}
+
+	var numChanged int
+	for _, t := range tasks {
+		prefix, ok := t.Prefix()
+		if !ok {
+			continue
+		}
+
+		name, ok := types.TagFromPrefix(prefix)
+		if !ok {
+			continue
+		}
+
+		summary := t.Summary
+		if stripPrefix {
+			_, summary, _ = t.GetPrefixAndSummaryContent()
+		}
+		if !slices.Contains(types.ParseTags(summary), name) {
+			summary = fmt.Sprintf("%s %s%s", summary, types.TagPrefix, name)
+		}
+		if summary == t.Summary {
+			continue
+		}
+
+		err = rewriteTaskSummaryTx(tx, t.ID, summary, updatedAt)
+		if err != nil {
+			return 0, err
+		}
+		numChanged++
+	}
+
+	return numChanged, tx.Commit()
+}
+
+// pruneTagsTx removes tags that are no longer used by any task, unless they
+// have a color set.
+func pruneTagsTx(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+DELETE FROM tag
+WHERE color IS NULL
+    AND id NOT IN (SELECT tag_id FROM task_tag);
+`)
+	return err
+}
+
+// fetchTaggedSummariesTx returns the summaries of the tasks that have a tag,
+// keyed by task ID.
+func fetchTaggedSummariesTx(tx *sql.Tx, name string) (map[uint64]string, error) {
+	rows, err := tx.Query(`
+SELECT t.id, t.summary
+FROM task t
+JOIN task_tag tt ON tt.task_id = t.id
+JOIN tag g ON g.id = tt.tag_id
+WHERE g.name = ?;
+`, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summaries := make(map[uint64]string)
+	for rows.Next() {
+		var id uint64
+		var summary string
+		err = rows.Scan(&id, &summary)
+		if err != nil {
+			return nil, err
+		}
+		summaries[id] = summary
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(summaries) == 0 {
+		var numTags int
+		err = tx.QueryRow("SELECT COUNT(*) FROM tag WHERE name = ?;", name).Scan(&numTags)
+		if err != nil {
+			return nil, err
+		}
+		if numTags == 0 {
+			return nil, fmt.Errorf("%w: %q", ErrTagNotFound, name)
+		}
+	}
+
+	return summaries, nil
+}
+
+func replaceTagInSummariesTx(tx *sql.Tx, summaries map[uint64]string, from, to string, updatedAt time.Time) error {
+	for id, summary := range summaries {
+		err := rewriteTaskSummaryTx(tx, id, types.ReplaceTag(summary, from, to), updatedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,164 @@
+package persistence
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dhth/omm/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func cleanupTags(t *testing.T) {
+	t.Helper()
+
+	cleanupDB(t)
+	_, err := testDB.Exec("DELETE FROM tag;")
+	require.NoError(t, err)
+}
+
+func insertTaggedTasks(t *testing.T, summaries ...string) {
+	t.Helper()
+
+	now := time.Now()
+	tasks := make([]types.Task, len(summaries))
+	for i, s := range summaries {
+		tasks[i] = types.Task{Summary: s, Active: true, CreatedAt: now, UpdatedAt: now}
+	}
+	_, err := InsertTasks(testDB, tasks, false)
+	require.NoError(t, err)
+}
+
+func getTagCounts(t *testing.T) map[string]int {
+	t.Helper()
+
+	tags, err := FetchTags(testDB)
+	require.NoError(t, err)
+	counts := make(map[string]int, len(tags))
+	for _, tag := range tags {
+		counts[tag.Name] = tag.NumTasks
+	}
+
+	return counts
+}
+
+func TestTagsAreKeptInSyncWithSummaries(t *testing.T) {
+	t.Cleanup(func() { cleanupTags(t) })
+
+	// GIVEN
+	insertTaggedTasks(t, "fix login #auth #Urgent", "deploy #infra", "read #auth docs")
+	id, err := InsertTask(testDB, NewTaskUUID(), "rotate keys #infra #auth", nil, time.Now(), time.Now())
+	require.NoError(t, err)
+
+	// WHEN
+	require.NoError(t, UpdateTaskSummary(testDB, 2, "deploy #ops", time.Now()))
+	require.NoError(t, DeleteTask(testDB, id))
+
+	// THEN
+	assert.Equal(t, map[string]int{"auth": 2, "urgent": 1, "ops": 1}, getTagCounts(t))
+}
+
+func TestRenameTag(t *testing.T) {
+	t.Cleanup(func() { cleanupTags(t) })
+
+	// GIVEN
+	insertTaggedTasks(t, "fix login #auth", "read #auth docs", "deploy #infra")
+	require.NoError(t, SetTagColor(testDB, "auth", "#ff0000"))
+
+	// WHEN
+	numChanged, err := RenameTag(testDB, "auth", "#security", time.Now())
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, 2, numChanged)
+	tags, err := FetchTags(testDB)
+	require.NoError(t, err)
+	assert.Equal(t, []types.Tag{
+		{Name: "infra", NumTasks: 1},
+		{Name: "security", Color: "#ff0000", NumTasks: 2},
+	}, tags)
+	task, err := FetchTaskByID(testDB, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "read #security docs", task.Summary)
+
+	_, err = RenameTag(testDB, "security", "infra", time.Now())
+	require.ErrorIs(t, err, ErrTagAlreadyExists)
+	_, err = RenameTag(testDB, "security", "1st", time.Now())
+	require.ErrorIs(t, err, ErrTagNameInvalid)
+	_, err = RenameTag(testDB, "auth", "login", time.Now())
+	require.ErrorIs(t, err, ErrTagNotFound)
+}
+
+func TestMergeTags(t *testing.T) {
+	t.Cleanup(func() { cleanupTags(t) })
+
+	// GIVEN
+	insertTaggedTasks(t, "fix login #auth #security", "read #auth docs", "deploy #infra")
+
+	// WHEN
+	numChanged, err := MergeTags(testDB, "auth", "security", time.Now())
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, 2, numChanged)
+	assert.Equal(t, map[string]int{"security": 2, "infra": 1}, getTagCounts(t))
+	task, err := FetchTaskByID(testDB, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "fix login #security", task.Summary)
+}
+
+func TestRenameTagFailsIfASummaryBecomesTooLong(t *testing.T) {
+	t.Cleanup(func() { cleanupTags(t) })
+
+	// GIVEN
+	insertTaggedTasks(t, "fix #ui", strings.Repeat("a", types.TaskSummaryMaxLen-4)+" #ui")
+
+	// WHEN
+	_, err := RenameTag(testDB, "ui", "user-interface", time.Now())
+
+	// THEN
+	require.ErrorIs(t, err, types.ErrTaskSummaryTooLong)
+	assert.Equal(t, map[string]int{"ui": 2}, getTagCounts(t), "changes should be rolled back")
+}
+
+func TestAddTagsFromPrefixes(t *testing.T) {
+	testCases := []struct {
+		name              string
+		stripPrefix       bool
+		expectedSummaries []string
+	}{
+		{
+			name:              "keeping prefixes",
+			expectedSummaries: []string{"Side Project: add readme #side-project", "infra: deploy #infra", "no prefix"},
+		},
+		{
+			name:              "stripping prefixes",
+			stripPrefix:       true,
+			expectedSummaries: []string{"add readme #side-project", "deploy #infra", "no prefix"},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(func() { cleanupTags(t) })
+
+			// GIVEN
+			insertTaggedTasks(t, "Side Project: add readme", "infra: deploy #infra", "no prefix")
+
+			// WHEN
+			_, err := AddTagsFromPrefixes(testDB, tt.stripPrefix, time.Now())
+
+			// THEN
+			require.NoError(t, err)
+			summaries := make([]string, len(tt.expectedSummaries))
+			for i := range tt.expectedSummaries {
+				task, err := FetchTaskByID(testDB, uint64(i+1))
+				require.NoError(t, err)
+				summaries[i] = task.Summary
+			}
+			assert.Equal(t, tt.expectedSummaries, summaries)
+			assert.Equal(t, map[string]int{"side-project": 1, "infra": 1}, getTagCounts(t))
+		})
+	}
+}
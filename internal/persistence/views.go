@@ -0,0 +1,77 @@
+package persistence
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/dhth/omm/internal/types"
+)
+
+var ErrSavedViewNotFound = errors.New("saved view not found")
+
+func FetchSavedViews(db *sql.DB) ([]types.SavedView, error) {
+	rows, err := db.Query(`
+SELECT name, definition, created_at
+FROM saved_view
+ORDER BY name;
+`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var views []types.SavedView
+	for rows.Next() {
+		var v types.SavedView
+		err = rows.Scan(&v.Name, &v.Definition, &v.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		v.CreatedAt = v.CreatedAt.Local()
+		views = append(views, v)
+	}
+
+	return views, rows.Err()
+}
+
+func InsertSavedView(db *sql.DB, name, definition string, createdAt time.Time) error {
+	stmt, err := db.Prepare(`
+INSERT INTO saved_view (name, definition, created_at)
+VALUES (?, ?, ?);
+`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(name, definition, createdAt.UTC())
+	return err
+}
+
+func DeleteSavedView(db *sql.DB, name string) error {
+	stmt, err := db.Prepare(`
+DELETE FROM saved_view
+WHERE name = ?;
+`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	res, err := stmt.Exec(name)
+	if err != nil {
+		return err
+	}
+
+	numRows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if numRows == 0 {
+		return ErrSavedViewNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,36 @@
+package persistence
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSavedViews(t *testing.T) {
+	t.Cleanup(func() {
+		_, err := testDB.Exec("DELETE FROM saved_view;")
+		require.NoError(t, err)
+	})
+
+	// GIVEN
+	now := time.Now()
+	require.NoError(t, InsertSavedView(testDB, "work", "prefix:api|infra", now))
+	require.NoError(t, InsertSavedView(testDB, "stale", "stale:14d", now))
+
+	// WHEN
+	err := InsertSavedView(testDB, "work", "prefix:api", now)
+
+	// THEN
+	require.Error(t, err, "view names need to be unique")
+
+	require.NoError(t, DeleteSavedView(testDB, "stale"))
+	assert.ErrorIs(t, DeleteSavedView(testDB, "stale"), ErrSavedViewNotFound)
+
+	views, err := FetchSavedViews(testDB)
+	require.NoError(t, err)
+	require.Len(t, views, 1)
+	assert.Equal(t, "work", views[0].Name)
+	assert.Equal(t, "prefix:api|infra", views[0].Definition)
+}
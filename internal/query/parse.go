@@ -0,0 +1,254 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind uint
+
+const (
+	tokTerm tokenKind = iota
+	tokAnd
+	tokOr
+	tokNot
+	tokLParen
+	tokRParen
+)
+
+type token struct {
+	kind     tokenKind
+	text     string
+	pos      int
+	field    string
+	op       string
+	opPos    int
+	value    string
+	valuePos int
+}
+
+func isOpChar(r rune) bool {
+	return strings.ContainsRune(":~<>=", r)
+}
+
+func isWordChar(r rune) bool {
+	return !unicode.IsSpace(r) && r != '(' && r != ')' && r != '"' && !isOpChar(r)
+}
+
+// lex splits a query into tokens; positions in tokens are 1-indexed, and count
+// characters (rather than bytes).
+func lex(raw string) ([]token, error) {
+	runes := []rune(raw)
+	var tokens []token
+
+	i := 0
+	for i < len(runes) {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+			continue
+		case r == '(':
+			tokens = append(tokens, token{kind: tokLParen, text: "(", pos: i + 1})
+			i++
+			continue
+		case r == ')':
+			tokens = append(tokens, token{kind: tokRParen, text: ")", pos: i + 1})
+			i++
+			continue
+		}
+
+		start := i
+		for i < len(runes) && isWordChar(runes[i]) {
+			i++
+		}
+		word := string(runes[start:i])
+
+		if i == len(runes) || !isOpChar(runes[i]) {
+			switch strings.ToUpper(word) {
+			case "AND":
+				tokens = append(tokens, token{kind: tokAnd, text: word, pos: start + 1})
+				continue
+			case "OR":
+				tokens = append(tokens, token{kind: tokOr, text: word, pos: start + 1})
+				continue
+			case "NOT":
+				tokens = append(tokens, token{kind: tokNot, text: word, pos: start + 1})
+				continue
+			}
+
+			text := word
+			if text == "" {
+				text = string(r)
+			}
+			return nil, &ParseError{start + 1, fmt.Errorf("%w, got %q", ErrTermInvalid, text)}
+		}
+
+		if word == "" {
+			return nil, &ParseError{start + 1, fmt.Errorf("%w, got %q", ErrTermInvalid, string(r))}
+		}
+
+		opStart := i
+		i++
+		if i < len(runes) && (runes[opStart] == '<' || runes[opStart] == '>') && runes[i] == '=' {
+			i++
+		}
+		op := string(runes[opStart:i])
+
+		valueStart := i
+		var value string
+		if i < len(runes) && runes[i] == '"' {
+			var sb strings.Builder
+			closed := false
+			for i++; i < len(runes); i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+					sb.WriteRune(runes[i])
+					continue
+				}
+				if runes[i] == '"' {
+					closed = true
+					i++
+					break
+				}
+				sb.WriteRune(runes[i])
+			}
+			if !closed {
+				return nil, &ParseError{valueStart + 1, ErrQuoteUnclosed}
+			}
+			value = sb.String()
+		} else {
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && runes[i] != '(' && runes[i] != ')' {
+				i++
+			}
+			value = string(runes[valueStart:i])
+		}
+
+		if value == "" {
+			return nil, &ParseError{valueStart + 1, fmt.Errorf("%w: %q", ErrValueMissing, word+op)}
+		}
+
+		tokens = append(tokens, token{
+			kind:     tokTerm,
+			text:     string(runes[start:i]),
+			pos:      start + 1,
+			field:    strings.ToLower(word),
+			op:       op,
+			opPos:    opStart + 1,
+			value:    value,
+			valuePos: valueStart + 1,
+		})
+	}
+
+	return tokens, nil
+}
+
+// parser is a recursive descent parser for the grammar:
+//
+//	or      = and { "OR" and }
+//	and     = unary { ["AND"] unary }
+//	unary   = "NOT" unary | "(" or ")" | term
+type parser struct {
+	tokens []token
+	pos    int
+	end    int
+}
+
+func (p *parser) peek() (token, bool) {
+	if p.pos >= len(p.tokens) {
+		return token{}, false
+	}
+
+	return p.tokens[p.pos], true
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		t, ok := p.peek()
+		if !ok || t.kind != tokOr {
+			return left, nil
+		}
+		p.pos++
+
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		t, ok := p.peek()
+		if !ok {
+			return left, nil
+		}
+
+		switch t.kind {
+		case tokAnd:
+			p.pos++
+		case tokTerm, tokNot, tokLParen:
+			// terms separated only by spaces are ANDed together
+		default:
+			return left, nil
+		}
+
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+}
+
+func (p *parser) parseUnary() (node, error) {
+	t, ok := p.peek()
+	if !ok {
+		if p.pos == 0 {
+			return nil, &ParseError{p.end, ErrTermExpected}
+		}
+		return nil, &ParseError{p.end, fmt.Errorf("%w after %q", ErrTermExpected, p.tokens[p.pos-1].text)}
+	}
+
+	switch t.kind {
+	case tokNot:
+		p.pos++
+		n, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{n}, nil
+
+	case tokLParen:
+		p.pos++
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		closing, ok := p.peek()
+		if !ok || closing.kind != tokRParen {
+			return nil, &ParseError{t.pos, ErrParenUnclosed}
+		}
+		p.pos++
+		return n, nil
+
+	case tokTerm:
+		p.pos++
+		return newTerm(t)
+	}
+
+	return nil, &ParseError{t.pos, fmt.Errorf("%w: %q", ErrTokenUnexpected, t.text)}
+}
@@ -0,0 +1,316 @@
+// Package query implements a small language for filtering tasks, eg.
+//
+//	prefix:infra AND tag:urgent AND age>7d AND has:context AND text~"deploy"
+//
+// A query is made up of terms, which can be combined via AND, OR, NOT, and
+// parentheses. Terms separated only by spaces are ANDed together.
+package query
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dhth/omm/internal/types"
+	"github.com/dhth/omm/internal/utils"
+)
+
+var (
+	ErrQueryEmpty      = errors.New("query is empty")
+	ErrTermInvalid     = errors.New("expected a term like field:value (eg. prefix:api)")
+	ErrFieldUnknown    = errors.New("unknown field; valid fields: prefix, tag, has, text, age, updated, stale")
+	ErrOperatorInvalid = errors.New("operator is not valid for field")
+	ErrValueMissing    = errors.New("value is missing")
+	ErrPrefixEmpty     = errors.New("prefix is empty")
+	ErrTagEmpty        = errors.New("tag is empty")
+	ErrHasValueInvalid = errors.New("has can be one of: links, context")
+	ErrPeriodInvalid   = errors.New("period needs to be a number of days/weeks (eg. 14d, 2w)")
+	ErrQuoteUnclosed   = errors.New("quote is not closed")
+	ErrParenUnclosed   = errors.New("parenthesis is not closed")
+	ErrTokenUnexpected = errors.New("unexpected token")
+	ErrTermExpected    = errors.New("expected a term")
+)
+
+var uriRegex = utils.GetURIRegex()
+
+// ParseError is an error in a query, along with the position (1-indexed) of
+// the character it's at.
+type ParseError struct {
+	Pos int
+	Err error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s (at position %d)", e.Err, e.Pos)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Query is a parsed query.
+type Query struct {
+	raw  string
+	root node
+}
+
+// Parse parses a query.
+func Parse(raw string) (Query, error) {
+	tokens, err := lex(raw)
+	if err != nil {
+		return Query{}, err
+	}
+
+	if len(tokens) == 0 {
+		return Query{}, ErrQueryEmpty
+	}
+
+	p := parser{tokens: tokens, end: utf8.RuneCountInString(raw) + 1}
+	root, err := p.parseOr()
+	if err != nil {
+		return Query{}, err
+	}
+
+	if p.pos < len(tokens) {
+		t := tokens[p.pos]
+		return Query{}, &ParseError{t.pos, fmt.Errorf("%w: %q", ErrTokenUnexpected, t.text)}
+	}
+
+	return Query{strings.TrimSpace(raw), root}, nil
+}
+
+// String returns the query as it was entered.
+func (q Query) String() string {
+	return q.raw
+}
+
+// Matches reports whether a task matches the query.
+func (q Query) Matches(t types.Task, now time.Time) bool {
+	if q.root == nil {
+		return true
+	}
+
+	return q.root.matches(t, now)
+}
+
+// Filter returns the tasks that match the query, in the order they're passed
+// in.
+func (q Query) Filter(tasks []types.Task, now time.Time) []types.Task {
+	var matching []types.Task
+	for _, t := range tasks {
+		if q.Matches(t, now) {
+			matching = append(matching, t)
+		}
+	}
+
+	return matching
+}
+
+// ParsePeriod parses a number of days or weeks (eg. 14d, 2w).
+func ParsePeriod(value string) (time.Duration, error) {
+	if len(value) < 2 {
+		return 0, ErrPeriodInvalid
+	}
+
+	num, err := strconv.Atoi(value[:len(value)-1])
+	if err != nil || num <= 0 {
+		return 0, ErrPeriodInvalid
+	}
+
+	switch strings.ToLower(value[len(value)-1:]) {
+	case "d":
+		return time.Duration(num) * 24 * time.Hour, nil
+	case "w":
+		return time.Duration(num) * 7 * 24 * time.Hour, nil
+	default:
+		return 0, ErrPeriodInvalid
+	}
+}
+
+type node interface {
+	matches(t types.Task, now time.Time) bool
+	// sql returns a condition satisfied by (at least) the tasks that match the
+	// node, and whether it's satisfied by exactly those.
+	sql(now time.Time) (string, []any, bool)
+}
+
+type andNode struct {
+	left, right node
+}
+
+func (n andNode) matches(t types.Task, now time.Time) bool {
+	return n.left.matches(t, now) && n.right.matches(t, now)
+}
+
+type orNode struct {
+	left, right node
+}
+
+func (n orNode) matches(t types.Task, now time.Time) bool {
+	return n.left.matches(t, now) || n.right.matches(t, now)
+}
+
+type notNode struct {
+	node node
+}
+
+func (n notNode) matches(t types.Task, now time.Time) bool {
+	return !n.node.matches(t, now)
+}
+
+type prefixTerm struct {
+	prefixes []string
+}
+
+func (n prefixTerm) matches(t types.Task, _ time.Time) bool {
+	prefix, ok := t.Prefix()
+	return ok && slices.Contains(n.prefixes, string(prefix))
+}
+
+type tagTerm struct {
+	tags []string
+}
+
+func (n tagTerm) matches(t types.Task, _ time.Time) bool {
+	for _, tag := range t.Tags() {
+		if slices.Contains(n.tags, tag) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type hasContextTerm struct{}
+
+func (hasContextTerm) matches(t types.Task, _ time.Time) bool {
+	return t.Context != nil
+}
+
+type hasLinksTerm struct{}
+
+func (hasLinksTerm) matches(t types.Task, _ time.Time) bool {
+	return len(utils.ExtractURIs(uriRegex, taskText(t))) > 0
+}
+
+type textTerm struct {
+	value string
+}
+
+func (n textTerm) matches(t types.Task, _ time.Time) bool {
+	return strings.Contains(strings.ToLower(taskText(t)), strings.ToLower(n.value))
+}
+
+// periodTerm compares the time since a task was created/updated with a period.
+type periodTerm struct {
+	updated bool
+	op      string
+	period  time.Duration
+}
+
+func (n periodTerm) matches(t types.Task, now time.Time) bool {
+	since := now.Sub(t.CreatedAt)
+	if n.updated {
+		since = now.Sub(t.UpdatedAt)
+	}
+
+	switch n.op {
+	case ">":
+		return since > n.period
+	case ">=":
+		return since >= n.period
+	case "<":
+		return since < n.period
+	default:
+		return since <= n.period
+	}
+}
+
+func taskText(t types.Task) string {
+	if t.Context == nil {
+		return t.Summary
+	}
+
+	return t.Summary + "\n" + *t.Context
+}
+
+func newTerm(tok token) (node, error) {
+	invalidOp := &ParseError{tok.opPos, fmt.Errorf("%w: %q doesn't support %q", ErrOperatorInvalid, tok.field, tok.op)}
+
+	switch tok.field {
+	case "prefix":
+		if tok.op != ":" {
+			return nil, invalidOp
+		}
+
+		var prefixes []string
+		for p := range strings.SplitSeq(tok.value, "|") {
+			if strings.TrimSpace(p) == "" {
+				return nil, &ParseError{tok.valuePos, fmt.Errorf("%w: %q", ErrPrefixEmpty, tok.text)}
+			}
+			prefixes = append(prefixes, strings.TrimSpace(p))
+		}
+		return prefixTerm{prefixes}, nil
+
+	case "tag":
+		if tok.op != ":" {
+			return nil, invalidOp
+		}
+
+		var tags []string
+		for tag := range strings.SplitSeq(tok.value, "|") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), types.TagPrefix)
+			if tag == "" {
+				return nil, &ParseError{tok.valuePos, fmt.Errorf("%w: %q", ErrTagEmpty, tok.text)}
+			}
+			tags = append(tags, strings.ToLower(tag))
+		}
+		return tagTerm{tags}, nil
+
+	case "has":
+		if tok.op != ":" {
+			return nil, invalidOp
+		}
+
+		switch tok.value {
+		case "links":
+			return hasLinksTerm{}, nil
+		case "context":
+			return hasContextTerm{}, nil
+		default:
+			return nil, &ParseError{tok.valuePos, fmt.Errorf("%w: %q", ErrHasValueInvalid, tok.text)}
+		}
+
+	case "text":
+		if tok.op != "~" {
+			return nil, invalidOp
+		}
+		return textTerm{tok.value}, nil
+
+	case "stale", "age", "updated":
+		validOps := []string{">", ">=", "<", "<="}
+		if tok.field == "stale" {
+			validOps = []string{":"}
+		}
+		if !slices.Contains(validOps, tok.op) {
+			return nil, invalidOp
+		}
+
+		period, err := ParsePeriod(tok.value)
+		if err != nil {
+			return nil, &ParseError{tok.valuePos, fmt.Errorf("%w: %q", err, tok.text)}
+		}
+
+		// stale:<period> is shorthand for updated>=<period>
+		if tok.field == "stale" {
+			return periodTerm{true, ">=", period}, nil
+		}
+		return periodTerm{tok.field == "updated", tok.op, period}, nil
+	}
+
+	return nil, &ParseError{tok.pos, fmt.Errorf("%w: %q", ErrFieldUnknown, tok.field)}
+}
@@ -0,0 +1,122 @@
+package query
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dhth/omm/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var referenceTime = time.Date(2026, 3, 20, 12, 0, 0, 0, time.UTC)
+
+func getTestTasks() []types.Task {
+	withLink := "see https://example.com"
+	withoutLink := "Deploy notes"
+	now := referenceTime
+
+	return []types.Task{
+		{ID: 1, Summary: "api: add endpoint", CreatedAt: now.AddDate(0, 0, -30), UpdatedAt: now.AddDate(0, 0, -20), Context: &withLink},
+		{ID: 2, Summary: "infra: resize cluster #urgent", CreatedAt: now.AddDate(0, 0, -10), UpdatedAt: now.AddDate(0, 0, -1)},
+		{ID: 3, Summary: "infra: deploy v2 #release #Urgent", CreatedAt: now.AddDate(0, 0, -3), UpdatedAt: now.AddDate(0, 0, -3), Context: &withoutLink},
+		{ID: 4, Summary: "read https://example.com/post", CreatedAt: now, UpdatedAt: now},
+	}
+}
+
+func getIDs(tasks []types.Task) []uint64 {
+	ids := make([]uint64, len(tasks))
+	for i, t := range tasks {
+		ids[i] = t.ID
+	}
+
+	return ids
+}
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name        string
+		query       string
+		expectedErr error
+		expectedPos int
+	}{
+		{name: "single term", query: "prefix:api"},
+		{name: "implicit and", query: "prefix:api|infra stale:14d has:links has:context"},
+		{name: "operators", query: `prefix:infra AND age>7d AND has:context AND text~"deploy"`},
+		{name: "lower case operators", query: "prefix:infra or not has:links"},
+		{name: "parentheses", query: `(prefix:api OR prefix:infra) AND NOT (updated<=2w OR text~"a \"quoted\" word")`},
+		{name: "empty query", query: "  ", expectedErr: ErrQueryEmpty},
+		{name: "word without an operator", query: "prefix:api deploy", expectedErr: ErrTermInvalid, expectedPos: 12},
+		{name: "unknown field", query: "owner:me", expectedErr: ErrFieldUnknown, expectedPos: 1},
+		{name: "invalid operator", query: "prefix:api age:7d", expectedErr: ErrOperatorInvalid, expectedPos: 15},
+		{name: "missing value", query: "age> AND has:links", expectedErr: ErrValueMissing, expectedPos: 5},
+		{name: "empty prefix", query: "prefix:api|", expectedErr: ErrPrefixEmpty, expectedPos: 8},
+		{name: "tags", query: "tag:urgent|#release"},
+		{name: "empty tag", query: "tag:#", expectedErr: ErrTagEmpty, expectedPos: 5},
+		{name: "invalid has value", query: "has:tags", expectedErr: ErrHasValueInvalid, expectedPos: 5},
+		{name: "invalid period", query: "age>7", expectedErr: ErrPeriodInvalid, expectedPos: 5},
+		{name: "unclosed quote", query: `text~"deploy`, expectedErr: ErrQuoteUnclosed, expectedPos: 6},
+		{name: "unclosed parenthesis", query: "(prefix:api OR has:links", expectedErr: ErrParenUnclosed, expectedPos: 1},
+		{name: "unexpected parenthesis", query: "prefix:api)", expectedErr: ErrTokenUnexpected, expectedPos: 11},
+		{name: "dangling operator", query: "prefix:api AND", expectedErr: ErrTermExpected, expectedPos: 15},
+		{name: "consecutive operators", query: "prefix:api AND OR has:links", expectedErr: ErrTokenUnexpected, expectedPos: 16},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			// WHEN
+			_, err := Parse(tt.query)
+
+			// THEN
+			if tt.expectedErr == nil {
+				require.NoError(t, err)
+				return
+			}
+
+			require.ErrorIs(t, err, tt.expectedErr)
+			if tt.expectedPos > 0 {
+				var parseErr *ParseError
+				require.ErrorAs(t, err, &parseErr)
+				assert.Equal(t, tt.expectedPos, parseErr.Pos)
+			}
+		})
+	}
+}
+
+func TestFilter(t *testing.T) {
+	testCases := []struct {
+		name        string
+		query       string
+		expectedIDs []uint64
+	}{
+		{name: "prefixes", query: "prefix:api|infra", expectedIDs: []uint64{1, 2, 3}},
+		{name: "tags", query: "tag:urgent|#docs", expectedIDs: []uint64{2, 3}},
+		{name: "tags are matched case-insensitively", query: "tag:RELEASE", expectedIDs: []uint64{3}},
+		{name: "age", query: "age>7d", expectedIDs: []uint64{1, 2}},
+		{name: "time since update", query: "updated<1w", expectedIDs: []uint64{2, 3, 4}},
+		{name: "stale", query: "stale:3d", expectedIDs: []uint64{1, 3}},
+		{name: "links", query: "has:links", expectedIDs: []uint64{1, 4}},
+		{name: "text is matched case-insensitively", query: `text~"DEPLOY"`, expectedIDs: []uint64{3}},
+		{name: "and", query: "prefix:infra AND age>7d", expectedIDs: []uint64{2}},
+		{name: "or", query: "prefix:api OR has:context", expectedIDs: []uint64{1, 3}},
+		{name: "not", query: "NOT prefix:infra", expectedIDs: []uint64{1, 4}},
+		{name: "and binds tighter than or", query: "prefix:api OR prefix:infra has:context", expectedIDs: []uint64{1, 3}},
+		{name: "parentheses", query: "(prefix:api OR prefix:infra) has:context", expectedIDs: []uint64{1, 3}},
+		{name: "no matches", query: "prefix:home", expectedIDs: []uint64{}},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			q, err := Parse(tt.query)
+			require.NoError(t, err)
+
+			// WHEN
+			got := q.Filter(getTestTasks(), referenceTime)
+
+			// THEN
+			assert.Equal(t, tt.expectedIDs, getIDs(got))
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// sqlTimeFormat is the part of the stored (UTC) timestamps of tasks that can be
+// compared lexically.
+const sqlTimeFormat = "2006-01-02 15:04:05"
+
+// SQL returns a condition on the task table (aliased as t), along with its
+// arguments. The condition is satisfied by at least the tasks that match the
+// query; terms that can't be expressed in SQL (eg. has:links) are left out, so
+// results still need to be filtered via Matches.
+func (q Query) SQL(now time.Time) (string, []any) {
+	if q.root == nil {
+		return "TRUE", nil
+	}
+
+	cond, args, _ := q.root.sql(now)
+	return cond, args
+}
+
+func (n andNode) sql(now time.Time) (string, []any, bool) {
+	left, leftArgs, leftExact := n.left.sql(now)
+	right, rightArgs, rightExact := n.right.sql(now)
+
+	return fmt.Sprintf("(%s AND %s)", left, right), append(leftArgs, rightArgs...), leftExact && rightExact
+}
+
+func (n orNode) sql(now time.Time) (string, []any, bool) {
+	left, leftArgs, leftExact := n.left.sql(now)
+	right, rightArgs, rightExact := n.right.sql(now)
+
+	return fmt.Sprintf("(%s OR %s)", left, right), append(leftArgs, rightArgs...), leftExact && rightExact
+}
+
+func (n notNode) sql(now time.Time) (string, []any, bool) {
+	cond, args, exact := n.node.sql(now)
+	// negating a condition that's satisfied by more tasks than the ones that
+	// match would leave out tasks that do match
+	if !exact {
+		return "TRUE", nil, false
+	}
+
+	return fmt.Sprintf("NOT %s", cond), args, true
+}
+
+func (n prefixTerm) sql(_ time.Time) (string, []any, bool) {
+	placeholders := make([]string, len(n.prefixes))
+	args := make([]any, len(n.prefixes))
+	for i, p := range n.prefixes {
+		placeholders[i] = "?"
+		args[i] = p
+	}
+
+	cond := fmt.Sprintf("(instr(t.summary, ':') > 0 AND trim(substr(t.summary, 1, instr(t.summary, ':') - 1), char(32, 9, 10, 11, 12, 13)) IN (%s))",
+		strings.Join(placeholders, ", "))
+
+	return cond, args, true
+}
+
+func (n tagTerm) sql(_ time.Time) (string, []any, bool) {
+	placeholders := make([]string, len(n.tags))
+	args := make([]any, len(n.tags))
+	for i, tag := range n.tags {
+		placeholders[i] = "?"
+		args[i] = tag
+	}
+
+	cond := fmt.Sprintf(`(EXISTS (SELECT 1 FROM task_tag tt JOIN tag g ON g.id = tt.tag_id WHERE tt.task_id = t.id AND g.name IN (%s)))`,
+		strings.Join(placeholders, ", "))
+
+	return cond, args, true
+}
+
+func (hasContextTerm) sql(_ time.Time) (string, []any, bool) {
+	return "(t.context IS NOT NULL)", nil, true
+}
+
+func (hasLinksTerm) sql(_ time.Time) (string, []any, bool) {
+	return "TRUE", nil, false
+}
+
+func (n textTerm) sql(_ time.Time) (string, []any, bool) {
+	// sqlite's lower() only handles ASCII characters
+	for _, r := range n.value {
+		if r > unicode.MaxASCII {
+			return "TRUE", nil, false
+		}
+	}
+
+	return "(instr(lower(t.summary || char(10) || COALESCE(t.context, '')), ?) > 0)", []any{strings.ToLower(n.value)}, false
+}
+
+func (n periodTerm) sql(now time.Time) (string, []any, bool) {
+	column := "t.created_at"
+	if n.updated {
+		column = "t.updated_at"
+	}
+
+	// timestamps are compared at the precision of seconds, which makes the
+	// comparison inclusive either way
+	cutoff := now.Add(-n.period).UTC().Format(sqlTimeFormat)
+	op := "<="
+	if n.op == "<" || n.op == "<=" {
+		op = ">="
+	}
+
+	return fmt.Sprintf("(replace(substr(%s, 1, 19), 'T', ' ') %s ?)", column, op), []any{cutoff}, false
+}
@@ -0,0 +1,74 @@
+package query
+
+import (
+	"database/sql"
+	"testing"
+
+	pers "github.com/dhth/omm/internal/persistence"
+	"github.com/dhth/omm/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db := testutil.NewDB(t)
+
+	tasks := getTestTasks()
+	for i := range tasks {
+		tasks[i].Active = true
+	}
+	_, err := pers.InsertTasks(db, tasks, false)
+	require.NoError(t, err)
+
+	return db
+}
+
+func TestSQLSelectsTasksThatMatch(t *testing.T) {
+	db := getTestDB(t)
+
+	testCases := []struct {
+		name  string
+		query string
+		// exact is whether the condition selects only the tasks that match
+		exact bool
+	}{
+		{name: "prefixes", query: "prefix:api|infra", exact: true},
+		{name: "tags", query: "tag:urgent|release", exact: true},
+		{name: "context", query: "has:context", exact: true},
+		{name: "negated exact terms", query: "NOT (prefix:infra OR has:context)", exact: true},
+		{name: "negated tags", query: "NOT tag:release", exact: true},
+		{name: "age", query: "age>7d"},
+		{name: "time since update", query: "updated<=1w"},
+		{name: "stale", query: "stale:3d"},
+		{name: "text", query: `text~"DEPLOY"`},
+		{name: "non ascii text", query: `text~"déploy"`},
+		{name: "links", query: "has:links"},
+		{name: "negated links", query: "NOT has:links"},
+		{name: "negated text", query: `prefix:infra NOT text~"deploy"`},
+		{name: "combination", query: `(prefix:api OR age<7d) AND NOT has:context`},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			q, err := Parse(tt.query)
+			require.NoError(t, err)
+
+			// WHEN
+			cond, args := q.SQL(referenceTime)
+			selected, err := pers.FetchActiveTasksWhere(db, cond, args, 10)
+
+			// THEN
+			require.NoError(t, err)
+			expected := getIDs(q.Filter(getTestTasks(), referenceTime))
+			if tt.exact {
+				assert.ElementsMatch(t, expected, getIDs(selected))
+			} else {
+				assert.Subset(t, getIDs(selected), expected)
+			}
+			assert.Equal(t, expected, getIDs(q.Filter(selected, referenceTime)))
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dhth/omm/internal/types"
+)
+
+const (
+	dateFormat     = "2006-01-02"
+	noPrefixTitle  = "no prefix"
+	noTasksMessage = "_none_"
+)
+
+// Markdown renders a report as markdown, with the tasks in each section
+// grouped by prefix.
+func Markdown(r Report) string {
+	var sb strings.Builder
+
+	since := r.Since.Format(dateFormat)
+	// the end of the period is exclusive
+	until := r.Until.Add(-1).Format(dateFormat)
+	if since == until {
+		fmt.Fprintf(&sb, "# omm report (%s)\n", since)
+	} else {
+		fmt.Fprintf(&sb, "# omm report (%s to %s)\n", since, until)
+	}
+
+	writeSection(&sb, "Archived", r.Archived)
+	writeSection(&sb, "Created", r.Created)
+	writeSection(&sb, "Context updated", r.ContextUpdated)
+	writeSection(&sb, fmt.Sprintf("Top %d", len(r.Top)), r.Top)
+
+	return sb.String()
+}
+
+func writeSection(sb *strings.Builder, title string, tasks []types.Task) {
+	fmt.Fprintf(sb, "\n## %s\n\n", title)
+
+	if len(tasks) == 0 {
+		fmt.Fprintf(sb, "%s\n", noTasksMessage)
+		return
+	}
+
+	var prefixes []string
+	tasksByPrefix := make(map[string][]types.Task)
+	for _, t := range tasks {
+		prefix := noPrefixTitle
+		p, ok := t.Prefix()
+		if ok {
+			prefix = string(p)
+		}
+
+		if _, ok := tasksByPrefix[prefix]; !ok {
+			prefixes = append(prefixes, prefix)
+		}
+		tasksByPrefix[prefix] = append(tasksByPrefix[prefix], t)
+	}
+
+	for i, prefix := range prefixes {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		fmt.Fprintf(sb, "**%s**\n\n", prefix)
+		for _, t := range tasksByPrefix[prefix] {
+			fmt.Fprintf(sb, "- %s\n", summaryWithoutPrefix(t))
+		}
+	}
+}
+
+func summaryWithoutPrefix(t types.Task) string {
+	if _, ok := t.Prefix(); !ok {
+		return t.Summary
+	}
+
+	_, body, _ := strings.Cut(t.Summary, types.PrefixDelimiter)
+	return strings.TrimSpace(body)
+}
@@ -0,0 +1,135 @@
+package report
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	pers "github.com/dhth/omm/internal/persistence"
+	"github.com/dhth/omm/internal/types"
+)
+
+type Period string
+
+const (
+	Today  Period = "today"
+	Week   Period = "week"
+	Custom Period = "custom"
+
+	DefaultNumTop = 5
+)
+
+var ErrPeriodInvalid = errors.New("period is invalid; valid values: today/week/custom")
+
+func ParsePeriod(value string) (Period, error) {
+	switch Period(value) {
+	case Today:
+		return Today, nil
+	case Week:
+		return Week, nil
+	case Custom:
+		return Custom, nil
+	default:
+		return "", ErrPeriodInvalid
+	}
+}
+
+// Start returns the time a period (other than a custom one) starts at; weeks
+// start on mondays.
+func (p Period) Start(now time.Time) time.Time {
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if p == Week {
+		daysSinceMonday := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -daysSinceMonday)
+	}
+
+	return day
+}
+
+type Options struct {
+	Since  time.Time
+	Until  time.Time
+	NumTop int
+}
+
+type Report struct {
+	Since          time.Time
+	Until          time.Time
+	Archived       []types.Task
+	Created        []types.Task
+	ContextUpdated []types.Task
+	Top            []types.Task
+}
+
+// Load fetches all tasks from the database, and builds a report for them.
+func Load(db *sql.DB, opts Options) (Report, error) {
+	activeTasks, err := pers.FetchAllActiveTasks(db)
+	if err != nil {
+		return Report{}, err
+	}
+
+	archivedTasks, err := pers.FetchInActiveTasks(db, pers.TaskNumLimit)
+	if err != nil {
+		return Report{}, err
+	}
+
+	contextUpdateTimes, err := pers.FetchContextUpdateTimes(db)
+	if err != nil {
+		return Report{}, err
+	}
+
+	return Build(activeTasks, archivedTasks, contextUpdateTimes, opts), nil
+}
+
+// Build builds a report from active tasks (in the order of their priority, with
+// snoozed ones, which aren't considered for the top tasks, at the end), archived
+// tasks, and the times the context of tasks was last updated at.
+// Since omm doesn't record when a task was archived, the time an archived task
+// was last updated stands in for it. Tasks created in the period aren't
+// reported as having their context updated.
+func Build(activeTasks, archivedTasks []types.Task, contextUpdateTimes map[uint64]time.Time, opts Options) Report {
+	numTop := opts.NumTop
+	if numTop == 0 {
+		numTop = DefaultNumTop
+	}
+
+	report := Report{
+		Since: opts.Since,
+		Until: opts.Until,
+	}
+
+	for _, t := range activeTasks {
+		if len(report.Top) >= numTop {
+			break
+		}
+		if t.SnoozedUntil == nil {
+			report.Top = append(report.Top, t)
+		}
+	}
+
+	inPeriod := func(t time.Time) bool {
+		return !t.Before(opts.Since) && t.Before(opts.Until)
+	}
+
+	for _, t := range archivedTasks {
+		if inPeriod(t.UpdatedAt) {
+			report.Archived = append(report.Archived, t)
+		}
+	}
+
+	for _, tasks := range [][]types.Task{activeTasks, archivedTasks} {
+		for _, t := range tasks {
+			if inPeriod(t.CreatedAt) {
+				report.Created = append(report.Created, t)
+				continue
+			}
+
+			contextUpdatedAt, ok := contextUpdateTimes[t.ID]
+			if ok && inPeriod(contextUpdatedAt) {
+				report.ContextUpdated = append(report.ContextUpdated, t)
+			}
+		}
+	}
+
+	return report
+}
@@ -0,0 +1,121 @@
+package report
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dhth/omm/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuild(t *testing.T) {
+	// GIVEN
+	since := time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2026, 3, 11, 0, 0, 0, 0, time.UTC)
+	before := since.Add(-time.Hour)
+	during := since.Add(time.Hour)
+	after := until.Add(time.Hour)
+
+	activeTasks := []types.Task{
+		{ID: 1, Summary: "work: created", Active: true, CreatedAt: during, UpdatedAt: during},
+		{ID: 2, Summary: "work: context updated", Active: true, CreatedAt: before, UpdatedAt: during},
+		{ID: 3, Summary: "untouched", Active: true, CreatedAt: before, UpdatedAt: before},
+	}
+	archivedTasks := []types.Task{
+		{ID: 4, Summary: "home: archived", Active: false, CreatedAt: before, UpdatedAt: during},
+		{ID: 5, Summary: "archived later", Active: false, CreatedAt: before, UpdatedAt: after},
+	}
+	contextUpdateTimes := map[uint64]time.Time{
+		1: during,
+		2: during,
+		3: before,
+	}
+	opts := Options{
+		Since:  since,
+		Until:  until,
+		NumTop: 2,
+	}
+
+	// WHEN
+	got := Build(activeTasks, archivedTasks, contextUpdateTimes, opts)
+
+	// THEN
+	assert.Equal(t, []types.Task{archivedTasks[0]}, got.Archived)
+	assert.Equal(t, []types.Task{activeTasks[0]}, got.Created)
+	assert.Equal(t, []types.Task{activeTasks[1]}, got.ContextUpdated)
+	assert.Equal(t, activeTasks[:2], got.Top)
+}
+
+func TestMarkdown(t *testing.T) {
+	// GIVEN
+	r := Report{
+		Since: time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC),
+		Until: time.Date(2026, 3, 11, 0, 0, 0, 0, time.UTC),
+		Archived: []types.Task{
+			{Summary: "work: write the report"},
+			{Summary: "water the plants"},
+			{Summary: "work: review PRs"},
+		},
+		Top: []types.Task{
+			{Summary: "home: fix the sink"},
+		},
+	}
+
+	// WHEN
+	got := Markdown(r)
+
+	// THEN
+	expected := `# omm report (2026-03-09 to 2026-03-10)
+
+## Archived
+
+**work**
+
+- write the report
+- review PRs
+
+**no prefix**
+
+- water the plants
+
+## Created
+
+_none_
+
+## Context updated
+
+_none_
+
+## Top 1
+
+**home**
+
+- fix the sink
+`
+	assert.Equal(t, expected, got)
+}
+
+func TestBuildLeavesSnoozedTasksOutOfTopTasks(t *testing.T) {
+	// GIVEN
+	since := time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2026, 3, 11, 0, 0, 0, 0, time.UTC)
+	during := since.Add(time.Hour)
+	wakesUpAt := until.Add(24 * time.Hour)
+
+	activeTasks := []types.Task{
+		{ID: 1, Summary: "task 1", Active: true, CreatedAt: during, UpdatedAt: during},
+		{ID: 2, Summary: "snoozed", Active: true, CreatedAt: during, UpdatedAt: during, SnoozedUntil: &wakesUpAt},
+	}
+	opts := Options{
+		Since:  since,
+		Until:  until,
+		NumTop: 2,
+	}
+
+	// WHEN
+	got := Build(activeTasks, nil, nil, opts)
+
+	// THEN
+	assert.Equal(t, activeTasks, got.Created)
+	assert.Equal(t, activeTasks[:1], got.Top)
+}
@@ -0,0 +1,508 @@
+package server
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dhth/omm/internal/hooks"
+	pers "github.com/dhth/omm/internal/persistence"
+	"github.com/dhth/omm/internal/types"
+)
+
+const maxRequestBodyBytes = 2 * pers.ContextMaxBytes
+
+var (
+	errInvalidTaskID       = errors.New("invalid task ID")
+	errInvalidStatus       = errors.New(`invalid status; possible values: [active, archived]`)
+	errInvalidRequestBody  = errors.New("invalid request body")
+	errNothingToUpdate     = errors.New("nothing to update; provide summary and/or context")
+	errContextTooLarge     = errors.New("task context is too large")
+	errPositionMissing     = errors.New("position is missing")
+	errWillExceedCapacity  = errors.New("adding a task will exceed the active task limit")
+	errUnauthorized        = errors.New("missing or incorrect bearer token")
+	errTaskAlreadyArchived = errors.New("task is already archived")
+	errTaskAlreadyActive   = errors.New("task is already active")
+	errSomethingWentWrong  = errors.New("something went wrong")
+	errNotFound            = errors.New("not found")
+	errMethodNotAllowed    = errors.New("method not allowed")
+)
+
+// apiMethods are the methods the API's routes are registered with.
+var apiMethods = []string{http.MethodGet, http.MethodPost, http.MethodPatch, http.MethodDelete}
+
+// Task is the JSON representation of a task.
+type Task struct {
+	ID        uint64    `json:"id"`
+	UUID      string    `json:"uuid"`
+	Summary   string    `json:"summary"`
+	Prefix    *string   `json:"prefix"`
+	Context   *string   `json:"context"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type createTaskRequest struct {
+	Summary string  `json:"summary"`
+	Context *string `json:"context"`
+}
+
+// updateTaskRequest holds the fields of a task that can be updated. A context
+// set to an empty string removes the task's context.
+type updateTaskRequest struct {
+	Summary *string `json:"summary"`
+	Context *string `json:"context"`
+}
+
+type moveTaskRequest struct {
+	Position *int `json:"position"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+type server struct {
+	db     *sql.DB
+	token  string
+	hooks  hooks.Config
+	errLog io.Writer
+	now    func() time.Time
+}
+
+// New returns an http.Handler that serves omm's JSON API. If token is
+// non-empty, every request needs to carry it as a bearer token. Hooks are run
+// after tasks are changed. Unexpected errors, and hook failures are written to
+// errLog.
+//
+//	GET    /api/tasks?status=active|archived&prefix=<prefix>
+//	POST   /api/tasks                  {"summary": "...", "context": "..."}
+//	GET    /api/tasks/{id}
+//	PATCH  /api/tasks/{id}             {"summary": "...", "context": "..."}
+//	DELETE /api/tasks/{id}
+//	POST   /api/tasks/{id}/archive
+//	POST   /api/tasks/{id}/unarchive
+//	POST   /api/tasks/{id}/move        {"position": 0}
+func New(db *sql.DB, token string, hooksCfg hooks.Config, errLog io.Writer) http.Handler {
+	s := server{db: db, token: token, hooks: hooksCfg, errLog: errLog, now: time.Now}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/tasks", s.listTasks)
+	mux.HandleFunc("POST /api/tasks", s.createTask)
+	mux.HandleFunc("GET /api/tasks/{id}", s.getTask)
+	mux.HandleFunc("PATCH /api/tasks/{id}", s.updateTask)
+	mux.HandleFunc("DELETE /api/tasks/{id}", s.deleteTask)
+	mux.HandleFunc("POST /api/tasks/{id}/archive", s.archiveTask)
+	mux.HandleFunc("POST /api/tasks/{id}/unarchive", s.unarchiveTask)
+	mux.HandleFunc("POST /api/tasks/{id}/move", s.moveTask)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		allowed := allowedMethods(mux, r)
+		if len(allowed) > 0 {
+			w.Header().Set("Allow", strings.Join(allowed, ", "))
+			writeError(w, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		writeError(w, http.StatusNotFound, errNotFound)
+	})
+
+	return s.authenticate(mux)
+}
+
+// allowedMethods returns the methods that routes matching a request's path are
+// registered with; the catch-all route that serves errors doesn't count.
+func allowedMethods(mux *http.ServeMux, r *http.Request) []string {
+	probe := r.Clone(r.Context())
+
+	var allowed []string
+	for _, method := range apiMethods {
+		probe.Method = method
+		_, pattern := mux.Handler(probe)
+		if pattern != "/" {
+			allowed = append(allowed, method)
+		}
+	}
+
+	return allowed
+}
+
+func (s server) authenticate(next http.Handler) http.Handler {
+	if s.token == "" {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, errUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s server) listTasks(w http.ResponseWriter, r *http.Request) {
+	var tasks []types.Task
+	var err error
+
+	switch r.URL.Query().Get("status") {
+	case "", "active":
+		tasks, err = pers.FetchAllActiveTasks(s.db)
+	case "archived":
+		tasks, err = pers.FetchInActiveTasks(s.db, pers.TaskNumLimit)
+	default:
+		writeError(w, http.StatusBadRequest, errInvalidStatus)
+		return
+	}
+	if err != nil {
+		s.writeInternalError(w, err)
+		return
+	}
+
+	prefix := strings.TrimSpace(r.URL.Query().Get("prefix"))
+
+	result := make([]Task, 0, len(tasks))
+	for _, t := range tasks {
+		if prefix != "" {
+			p, ok := t.Prefix()
+			if !ok || string(p) != prefix {
+				continue
+			}
+		}
+		result = append(result, toTask(t))
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s server) createTask(w http.ResponseWriter, r *http.Request) {
+	var req createTaskRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	summary := strings.TrimSpace(req.Summary)
+	_, err := types.CheckIfTaskSummaryValid(summary)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	var context *string
+	if req.Context != nil && *req.Context != "" {
+		if len(*req.Context) > pers.ContextMaxBytes {
+			writeError(w, http.StatusUnprocessableEntity, errContextTooLarge)
+			return
+		}
+		context = req.Context
+	}
+
+	numTasks, err := pers.FetchNumActiveTasks(s.db)
+	if err != nil {
+		s.writeInternalError(w, err)
+		return
+	}
+
+	if numTasks+1 > pers.TaskNumLimit {
+		writeError(w, http.StatusConflict, errWillExceedCapacity)
+		return
+	}
+
+	now := s.now()
+	lastID, err := pers.InsertTasks(s.db, []types.Task{{
+		Summary:   summary,
+		Context:   context,
+		Active:    true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}}, true)
+	if err != nil {
+		s.writeInternalError(w, err)
+		return
+	}
+
+	task, err := pers.FetchTaskByID(s.db, uint64(lastID))
+	if err != nil {
+		s.writeInternalError(w, err)
+		return
+	}
+
+	s.runHook(hooks.EventCreate, task)
+	writeJSON(w, http.StatusCreated, toTask(task))
+}
+
+func (s server) getTask(w http.ResponseWriter, r *http.Request) {
+	task, ok := s.fetchTask(w, r)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toTask(task))
+}
+
+func (s server) updateTask(w http.ResponseWriter, r *http.Request) {
+	task, ok := s.fetchTask(w, r)
+	if !ok {
+		return
+	}
+
+	var req updateTaskRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	if req.Summary == nil && req.Context == nil {
+		writeError(w, http.StatusBadRequest, errNothingToUpdate)
+		return
+	}
+
+	var summary string
+	if req.Summary != nil {
+		summary = strings.TrimSpace(*req.Summary)
+		_, err := types.CheckIfTaskSummaryValid(summary)
+		if err != nil {
+			writeError(w, http.StatusUnprocessableEntity, err)
+			return
+		}
+	}
+
+	if req.Context != nil && len(*req.Context) > pers.ContextMaxBytes {
+		writeError(w, http.StatusUnprocessableEntity, errContextTooLarge)
+		return
+	}
+
+	now := s.now()
+	if req.Summary != nil {
+		err := pers.UpdateTaskSummary(s.db, task.ID, summary, now)
+		if err != nil {
+			s.writeInternalError(w, err)
+			return
+		}
+	}
+
+	if req.Context != nil {
+		var err error
+		if *req.Context == "" {
+			err = pers.UnsetTaskContext(s.db, task.ID, now)
+		} else {
+			err = pers.UpdateTaskContext(s.db, task.ID, *req.Context, now)
+		}
+		if err != nil {
+			s.writeInternalError(w, err)
+			return
+		}
+	}
+
+	s.writeTask(w, task.ID, hooks.EventUpdate)
+}
+
+func (s server) deleteTask(w http.ResponseWriter, r *http.Request) {
+	task, ok := s.fetchTask(w, r)
+	if !ok {
+		return
+	}
+
+	err := pers.RemoveTask(s.db, task.ID)
+	if errors.Is(err, pers.ErrTaskNotFound) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		s.writeInternalError(w, err)
+		return
+	}
+
+	s.runHook(hooks.EventDelete, task)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s server) archiveTask(w http.ResponseWriter, r *http.Request) {
+	s.changeTaskStatus(w, r, false)
+}
+
+func (s server) unarchiveTask(w http.ResponseWriter, r *http.Request) {
+	s.changeTaskStatus(w, r, true)
+}
+
+func (s server) changeTaskStatus(w http.ResponseWriter, r *http.Request, active bool) {
+	task, ok := s.fetchTask(w, r)
+	if !ok {
+		return
+	}
+
+	if task.Active == active {
+		err := errTaskAlreadyArchived
+		if active {
+			err = errTaskAlreadyActive
+		}
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+
+	if active {
+		numTasks, err := pers.FetchNumActiveTasks(s.db)
+		if err != nil {
+			s.writeInternalError(w, err)
+			return
+		}
+
+		if numTasks+1 > pers.TaskNumLimit {
+			writeError(w, http.StatusConflict, errWillExceedCapacity)
+			return
+		}
+	}
+
+	err := pers.SetTaskActive(s.db, task.ID, active, s.now())
+	if err != nil {
+		s.writeInternalError(w, err)
+		return
+	}
+
+	event := hooks.EventArchive
+	if active {
+		event = hooks.EventUnarchive
+	}
+	s.writeTask(w, task.ID, event)
+}
+
+func (s server) moveTask(w http.ResponseWriter, r *http.Request) {
+	task, ok := s.fetchTask(w, r)
+	if !ok {
+		return
+	}
+
+	var req moveTaskRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	if req.Position == nil {
+		writeError(w, http.StatusBadRequest, errPositionMissing)
+		return
+	}
+
+	err := pers.MoveTask(s.db, task.ID, *req.Position)
+	if errors.Is(err, pers.ErrTaskNotActive) {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	if err != nil {
+		s.writeInternalError(w, err)
+		return
+	}
+
+	s.writeTask(w, task.ID, "")
+}
+
+func (s server) fetchTask(w http.ResponseWriter, r *http.Request) (types.Task, bool) {
+	id, ok := parseTaskID(w, r)
+	if !ok {
+		return types.Task{}, false
+	}
+
+	task, err := pers.FetchTaskByID(s.db, id)
+	if errors.Is(err, pers.ErrTaskNotFound) {
+		writeError(w, http.StatusNotFound, err)
+		return task, false
+	}
+	if err != nil {
+		s.writeInternalError(w, err)
+		return task, false
+	}
+
+	return task, true
+}
+
+// writeTask responds with the current state of a task, after running the hook
+// for event (if any).
+func (s server) writeTask(w http.ResponseWriter, id uint64, event hooks.Event) {
+	task, err := pers.FetchTaskByID(s.db, id)
+	if err != nil {
+		s.writeInternalError(w, err)
+		return
+	}
+
+	if event != "" {
+		s.runHook(event, task)
+	}
+	writeJSON(w, http.StatusOK, toTask(task))
+}
+
+// runHook runs the hook for an event; failures don't affect the response, as
+// the change has been saved already.
+func (s server) runHook(event hooks.Event, task types.Task) {
+	err := s.hooks.Run(event, task)
+	if err != nil {
+		fmt.Fprintf(s.errLog, "Warning: %s\n", err.Error())
+	}
+}
+
+func parseTaskID(w http.ResponseWriter, r *http.Request) (uint64, bool) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil || id == 0 {
+		writeError(w, http.StatusBadRequest, errInvalidTaskID)
+		return 0, false
+	}
+
+	return id, true
+}
+
+func decodeBody(w http.ResponseWriter, r *http.Request, v any) bool {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBodyBytes))
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(v)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("%w: %s", errInvalidRequestBody, err.Error()))
+		return false
+	}
+
+	return true
+}
+
+func toTask(t types.Task) Task {
+	var prefix *string
+	p, ok := t.Prefix()
+	if ok {
+		ps := string(p)
+		prefix = &ps
+	}
+
+	return Task{
+		ID:        t.ID,
+		UUID:      t.UUID,
+		Summary:   t.Summary,
+		Prefix:    prefix,
+		Context:   t.Context,
+		Active:    t.Active,
+		CreatedAt: t.CreatedAt,
+		UpdatedAt: t.UpdatedAt,
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
+
+// writeInternalError logs the underlying error, and doesn't expose it to the
+// client.
+func (s server) writeInternalError(w http.ResponseWriter, err error) {
+	fmt.Fprintf(s.errLog, "Error: %s\n", err.Error())
+	writeError(w, http.StatusInternalServerError, errSomethingWentWrong)
+}
@@ -0,0 +1,354 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dhth/omm/internal/hooks"
+	pers "github.com/dhth/omm/internal/persistence"
+	"github.com/dhth/omm/internal/testutil"
+	"github.com/dhth/omm/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db := testutil.NewDB(t)
+
+	now := time.Now()
+	_, err := pers.InsertTasks(db, []types.Task{
+		{Summary: "home: task 1", Active: true, CreatedAt: now, UpdatedAt: now},
+		{Summary: "work: task 2", Active: true, CreatedAt: now, UpdatedAt: now},
+		{Summary: "home: task 3", Active: true, CreatedAt: now, UpdatedAt: now},
+		{Summary: "task 4", Active: false, CreatedAt: now, UpdatedAt: now},
+	}, false)
+	require.NoError(t, err)
+
+	return db
+}
+
+func doRequest(t *testing.T, handler http.Handler, method, target, body string) (int, string) {
+	t.Helper()
+
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+
+	req := httptest.NewRequest(method, target, reader)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	return rec.Code, rec.Body.String()
+}
+
+func decodeTasks(t *testing.T, body string) []string {
+	t.Helper()
+
+	var tasks []Task
+	require.NoError(t, json.Unmarshal([]byte(body), &tasks))
+
+	summaries := make([]string, len(tasks))
+	for i, task := range tasks {
+		summaries[i] = task.Summary
+	}
+
+	return summaries
+}
+
+func TestListingTasks(t *testing.T) {
+	testCases := []struct {
+		name     string
+		target   string
+		expected []string
+	}{
+		{
+			name:     "active tasks by default",
+			target:   "/api/tasks",
+			expected: []string{"home: task 1", "work: task 2", "home: task 3"},
+		},
+		{
+			name:     "archived tasks",
+			target:   "/api/tasks?status=archived",
+			expected: []string{"task 4"},
+		},
+		{
+			name:     "filtered by prefix",
+			target:   "/api/tasks?prefix=home",
+			expected: []string{"home: task 1", "home: task 3"},
+		},
+		{
+			name:     "filtered by a prefix that matches nothing",
+			target:   "/api/tasks?prefix=absent",
+			expected: []string{},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			handler := New(getTestDB(t), "", hooks.Config{}, io.Discard)
+
+			// WHEN
+			code, body := doRequest(t, handler, http.MethodGet, tt.target, "")
+
+			// THEN
+			require.Equal(t, http.StatusOK, code)
+			assert.Equal(t, tt.expected, decodeTasks(t, body))
+		})
+	}
+}
+
+func TestListingTasksIncludesSnoozedOnes(t *testing.T) {
+	// GIVEN
+	db := getTestDB(t)
+	require.NoError(t, pers.SnoozeTask(db, 1, time.Now().Add(time.Hour)))
+	handler := New(db, "", hooks.Config{}, io.Discard)
+
+	// WHEN
+	code, body := doRequest(t, handler, http.MethodGet, "/api/tasks?prefix=home", "")
+
+	// THEN
+	require.Equal(t, http.StatusOK, code)
+	// snoozed tasks come after the ones in the task list
+	assert.Equal(t, []string{"home: task 3", "home: task 1"}, decodeTasks(t, body))
+}
+
+func TestCreatingATask(t *testing.T) {
+	// GIVEN
+	handler := New(getTestDB(t), "", hooks.Config{}, io.Discard)
+
+	// WHEN
+	code, body := doRequest(t, handler, http.MethodPost, "/api/tasks", `{"summary": "work: new task", "context": "some context"}`)
+
+	// THEN
+	require.Equal(t, http.StatusCreated, code, body)
+	var task Task
+	require.NoError(t, json.Unmarshal([]byte(body), &task))
+	assert.Equal(t, uint64(5), task.ID)
+	assert.NotEmpty(t, task.UUID)
+	require.NotNil(t, task.Prefix)
+	assert.Equal(t, "work", *task.Prefix)
+	require.NotNil(t, task.Context)
+	assert.Equal(t, "some context", *task.Context)
+
+	_, body = doRequest(t, handler, http.MethodGet, "/api/tasks", "")
+	assert.Equal(t, []string{"work: new task", "home: task 1", "work: task 2", "home: task 3"}, decodeTasks(t, body))
+}
+
+func TestInvalidRequestsGetJSONErrors(t *testing.T) {
+	testCases := []struct {
+		name         string
+		method       string
+		target       string
+		body         string
+		expectedCode int
+		expectedErr  string
+	}{
+		{
+			name:         "invalid summary",
+			method:       http.MethodPost,
+			target:       "/api/tasks",
+			body:         `{"summary": " : task"}`,
+			expectedCode: http.StatusUnprocessableEntity,
+			expectedErr:  types.ErrTaskPrefixEmpty.Error(),
+		},
+		{
+			name:         "malformed body",
+			method:       http.MethodPost,
+			target:       "/api/tasks",
+			body:         `{"summary": `,
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  errInvalidRequestBody.Error(),
+		},
+		{
+			name:         "non-existent task",
+			method:       http.MethodGet,
+			target:       "/api/tasks/100",
+			expectedCode: http.StatusNotFound,
+			expectedErr:  pers.ErrTaskNotFound.Error(),
+		},
+		{
+			name:         "invalid task ID",
+			method:       http.MethodDelete,
+			target:       "/api/tasks/abc",
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  errInvalidTaskID.Error(),
+		},
+		{
+			name:         "moving an archived task",
+			method:       http.MethodPost,
+			target:       "/api/tasks/4/move",
+			body:         `{"position": 0}`,
+			expectedCode: http.StatusConflict,
+			expectedErr:  pers.ErrTaskNotActive.Error(),
+		},
+		{
+			name:         "archiving an archived task",
+			method:       http.MethodPost,
+			target:       "/api/tasks/4/archive",
+			expectedCode: http.StatusConflict,
+			expectedErr:  errTaskAlreadyArchived.Error(),
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			handler := New(getTestDB(t), "", hooks.Config{}, io.Discard)
+
+			// WHEN
+			code, body := doRequest(t, handler, tt.method, tt.target, tt.body)
+
+			// THEN
+			assert.Equal(t, tt.expectedCode, code)
+			var resp errorResponse
+			require.NoError(t, json.Unmarshal([]byte(body), &resp))
+			assert.Contains(t, resp.Error, tt.expectedErr)
+		})
+	}
+}
+
+func TestUnsupportedMethodsGetJSONErrors(t *testing.T) {
+	testCases := []struct {
+		name          string
+		method        string
+		target        string
+		expectedCode  int
+		expectedAllow string
+		expectedErr   string
+	}{
+		{
+			name:          "tasks",
+			method:        http.MethodPut,
+			target:        "/api/tasks",
+			expectedCode:  http.StatusMethodNotAllowed,
+			expectedAllow: "GET, POST",
+			expectedErr:   errMethodNotAllowed.Error(),
+		},
+		{
+			name:          "task",
+			method:        http.MethodPost,
+			target:        "/api/tasks/1",
+			expectedCode:  http.StatusMethodNotAllowed,
+			expectedAllow: "GET, PATCH, DELETE",
+			expectedErr:   errMethodNotAllowed.Error(),
+		},
+		{
+			name:          "task action",
+			method:        http.MethodGet,
+			target:        "/api/tasks/1/archive",
+			expectedCode:  http.StatusMethodNotAllowed,
+			expectedAllow: "POST",
+			expectedErr:   errMethodNotAllowed.Error(),
+		},
+		{
+			name:         "unknown path",
+			method:       http.MethodPut,
+			target:       "/api/unknown",
+			expectedCode: http.StatusNotFound,
+			expectedErr:  errNotFound.Error(),
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			handler := New(getTestDB(t), "", hooks.Config{}, io.Discard)
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			rec := httptest.NewRecorder()
+
+			// WHEN
+			handler.ServeHTTP(rec, req)
+
+			// THEN
+			assert.Equal(t, tt.expectedCode, rec.Code)
+			assert.Equal(t, tt.expectedAllow, rec.Header().Get("Allow"))
+			var resp errorResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			assert.Equal(t, tt.expectedErr, resp.Error)
+		})
+	}
+}
+
+func TestUpdatingArchivingDeletingAndMovingTasks(t *testing.T) {
+	// GIVEN
+	db := getTestDB(t)
+	handler := New(db, "", hooks.Config{}, io.Discard)
+
+	// WHEN
+	code, body := doRequest(t, handler, http.MethodPatch, "/api/tasks/2", `{"summary": "work: task 2 (updated)", "context": "new context"}`)
+
+	// THEN
+	require.Equal(t, http.StatusOK, code, body)
+	task, err := pers.FetchTaskByID(db, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "work: task 2 (updated)", task.Summary)
+	require.NotNil(t, task.Context)
+	assert.Equal(t, "new context", *task.Context)
+
+	// WHEN
+	code, _ = doRequest(t, handler, http.MethodPost, "/api/tasks/1/archive", "")
+	require.Equal(t, http.StatusOK, code)
+	code, _ = doRequest(t, handler, http.MethodPost, "/api/tasks/4/unarchive", "")
+	require.Equal(t, http.StatusOK, code)
+	code, _ = doRequest(t, handler, http.MethodDelete, "/api/tasks/3", "")
+	require.Equal(t, http.StatusNoContent, code)
+	code, _ = doRequest(t, handler, http.MethodPost, "/api/tasks/4/move", `{"position": 5}`)
+	require.Equal(t, http.StatusOK, code)
+
+	// THEN
+	_, body = doRequest(t, handler, http.MethodGet, "/api/tasks", "")
+	assert.Equal(t, []string{"work: task 2 (updated)", "task 4"}, decodeTasks(t, body))
+	_, body = doRequest(t, handler, http.MethodGet, "/api/tasks?status=archived", "")
+	assert.Equal(t, []string{"home: task 1"}, decodeTasks(t, body))
+}
+
+func TestBearerTokenAuth(t *testing.T) {
+	testCases := []struct {
+		name         string
+		header       string
+		expectedCode int
+	}{
+		{
+			name:         "correct token",
+			header:       "Bearer secret",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "incorrect token",
+			header:       "Bearer incorrect",
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "no token",
+			expectedCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			handler := New(getTestDB(t), "secret", hooks.Config{}, io.Discard)
+			req := httptest.NewRequest(http.MethodGet, "/api/tasks", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+
+			// WHEN
+			handler.ServeHTTP(rec, req)
+
+			// THEN
+			assert.Equal(t, tt.expectedCode, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package stats
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+)
+
+const noPrefix = "(none)"
+
+var sparklineChars = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline renders values as a line of block characters, scaled to the
+// largest value.
+func Sparkline(values []int) string {
+	maxVal := 0
+	for _, v := range values {
+		maxVal = max(maxVal, v)
+	}
+
+	var sb strings.Builder
+	for _, v := range values {
+		if maxVal == 0 {
+			sb.WriteRune(sparklineChars[0])
+			continue
+		}
+		sb.WriteRune(sparklineChars[v*(len(sparklineChars)-1)/maxVal])
+	}
+
+	return sb.String()
+}
+
+// Render returns a plain text report of stats.
+func Render(s Stats) string {
+	var sb strings.Builder
+
+	header := fmt.Sprintf("since %s, by %s", s.Since.Format("2006-01-02"), s.Interval)
+	if s.Prefix != "" {
+		header += fmt.Sprintf(", for prefix %q", s.Prefix)
+	}
+	fmt.Fprintf(&sb, "%s\n\n", header)
+
+	tw := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "active tasks\t%d\n", s.NumActive)
+	fmt.Fprintf(tw, "archived tasks\t%d\n", s.NumArchived)
+	fmt.Fprintf(tw, "average age of active tasks\t%s\n", formatDays(s.AverageActiveAgeDays))
+	_ = tw.Flush()
+
+	created := make([]int, len(s.Trend))
+	archived := make([]int, len(s.Trend))
+	for i, b := range s.Trend {
+		created[i] = b.Created
+		archived[i] = b.Archived
+	}
+
+	sb.WriteString("\ntrend\n")
+	tw = tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "  created\t%s\t%d\n", Sparkline(created), s.NumCreatedSince)
+	fmt.Fprintf(tw, "  archived\t%s\t%d\n", Sparkline(archived), s.NumArchivedSince)
+	_ = tw.Flush()
+
+	fmt.Fprintf(&sb, "\n")
+	tw = tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "  %s of\tcreated\tarchived\n", s.Interval)
+	for _, b := range s.Trend {
+		fmt.Fprintf(tw, "  %s\t%d\t%d\n", b.Start.Format("2006-01-02"), b.Created, b.Archived)
+	}
+	_ = tw.Flush()
+
+	if len(s.OldestActiveTasks) > 0 {
+		sb.WriteString("\noldest active tasks\n")
+		tw = tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+		for _, t := range s.OldestActiveTasks {
+			fmt.Fprintf(tw, "  #%d\t%s\t%s\n", t.ID, formatDays(t.AgeDays), t.Summary)
+		}
+		_ = tw.Flush()
+	}
+
+	if len(s.Prefixes) > 0 {
+		sb.WriteString("\nprefixes\n")
+		tw = tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "  prefix\tactive\tarchived\taverage age (active)")
+		for _, p := range s.Prefixes {
+			prefix := p.Prefix
+			if prefix == "" {
+				prefix = noPrefix
+			}
+			age := "-"
+			if p.Active > 0 {
+				age = formatDays(p.AverageActiveAgeDays)
+			}
+			fmt.Fprintf(tw, "  %s\t%d\t%d\t%s\n", prefix, p.Active, p.Archived, age)
+		}
+		_ = tw.Flush()
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,253 @@
+package stats
+
+import (
+	"cmp"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	pers "github.com/dhth/omm/internal/persistence"
+	"github.com/dhth/omm/internal/types"
+)
+
+type Interval string
+
+const (
+	Day  Interval = "day"
+	Week Interval = "week"
+
+	DefaultNumOldest = 5
+)
+
+var ErrIntervalInvalid = errors.New("interval is invalid; valid values: day/week")
+
+func ParseInterval(value string) (Interval, error) {
+	switch Interval(value) {
+	case Day:
+		return Day, nil
+	case Week:
+		return Week, nil
+	default:
+		return "", ErrIntervalInvalid
+	}
+}
+
+type Options struct {
+	// Since is the start of the period the trend is computed for
+	Since time.Time
+	// Prefix limits stats to tasks with this prefix, if set
+	Prefix    string
+	Interval  Interval
+	NumOldest int
+}
+
+// Bucket holds the number of tasks created and archived in an interval.
+type Bucket struct {
+	Start    time.Time `json:"start"`
+	Created  int       `json:"created"`
+	Archived int       `json:"archived"`
+}
+
+type TaskAge struct {
+	ID      uint64  `json:"id"`
+	Summary string  `json:"summary"`
+	AgeDays float64 `json:"age_days"`
+}
+
+type PrefixStats struct {
+	Prefix               string  `json:"prefix"`
+	Active               int     `json:"active"`
+	Archived             int     `json:"archived"`
+	AverageActiveAgeDays float64 `json:"average_active_age_days"`
+}
+
+type Stats struct {
+	Since                time.Time     `json:"since"`
+	Until                time.Time     `json:"until"`
+	Interval             Interval      `json:"interval"`
+	Prefix               string        `json:"prefix,omitempty"`
+	NumActive            int           `json:"num_active"`
+	NumArchived          int           `json:"num_archived"`
+	NumCreatedSince      int           `json:"num_created_since"`
+	NumArchivedSince     int           `json:"num_archived_since"`
+	AverageActiveAgeDays float64       `json:"average_active_age_days"`
+	Trend                []Bucket      `json:"trend"`
+	OldestActiveTasks    []TaskAge     `json:"oldest_active_tasks"`
+	Prefixes             []PrefixStats `json:"prefixes"`
+}
+
+// Load fetches all tasks from the database, and computes stats for them.
+func Load(db *sql.DB, opts Options, now time.Time) (Stats, error) {
+	activeTasks, err := pers.FetchAllActiveTasks(db)
+	if err != nil {
+		return Stats{}, err
+	}
+
+	archivedTasks, err := pers.FetchInActiveTasks(db, pers.TaskNumLimit)
+	if err != nil {
+		return Stats{}, err
+	}
+
+	return Compute(append(activeTasks, archivedTasks...), opts, now), nil
+}
+
+// Compute derives stats from tasks. Since omm doesn't record when a task was
+// archived, the time an archived task was last updated stands in for it.
+func Compute(tasks []types.Task, opts Options, now time.Time) Stats {
+	if opts.Interval == "" {
+		opts.Interval = Week
+	}
+
+	stats := Stats{
+		Since:             opts.Since,
+		Until:             now,
+		Interval:          opts.Interval,
+		Prefix:            opts.Prefix,
+		Trend:             getBuckets(opts.Since, now, opts.Interval),
+		OldestActiveTasks: []TaskAge{},
+		Prefixes:          []PrefixStats{},
+	}
+
+	var activeTasks []types.Task
+	var totalActiveAge time.Duration
+	prefixStats := make(map[string]*PrefixStats)
+	prefixAges := make(map[string]time.Duration)
+
+	for _, t := range tasks {
+		prefix := ""
+		p, ok := t.Prefix()
+		if ok {
+			prefix = string(p)
+		}
+
+		if opts.Prefix != "" && prefix != opts.Prefix {
+			continue
+		}
+
+		ps, ok := prefixStats[prefix]
+		if !ok {
+			ps = &PrefixStats{Prefix: prefix}
+			prefixStats[prefix] = ps
+		}
+
+		if t.Active {
+			age := now.Sub(t.CreatedAt)
+			stats.NumActive++
+			totalActiveAge += age
+			activeTasks = append(activeTasks, t)
+			ps.Active++
+			prefixAges[prefix] += age
+		} else {
+			stats.NumArchived++
+			ps.Archived++
+		}
+
+		i := bucketIndex(stats.Trend, t.CreatedAt, now)
+		if i >= 0 {
+			stats.Trend[i].Created++
+			stats.NumCreatedSince++
+		}
+
+		if !t.Active {
+			i = bucketIndex(stats.Trend, t.UpdatedAt, now)
+			if i >= 0 {
+				stats.Trend[i].Archived++
+				stats.NumArchivedSince++
+			}
+		}
+	}
+
+	if stats.NumActive > 0 {
+		stats.AverageActiveAgeDays = toDays(totalActiveAge / time.Duration(stats.NumActive))
+	}
+
+	slices.SortStableFunc(activeTasks, func(a, b types.Task) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	numOldest := opts.NumOldest
+	if numOldest == 0 {
+		numOldest = DefaultNumOldest
+	}
+	for _, t := range activeTasks[:min(numOldest, len(activeTasks))] {
+		stats.OldestActiveTasks = append(stats.OldestActiveTasks, TaskAge{
+			ID:      t.ID,
+			Summary: t.Summary,
+			AgeDays: toDays(now.Sub(t.CreatedAt)),
+		})
+	}
+
+	for prefix, ps := range prefixStats {
+		if ps.Active > 0 {
+			ps.AverageActiveAgeDays = toDays(prefixAges[prefix] / time.Duration(ps.Active))
+		}
+		stats.Prefixes = append(stats.Prefixes, *ps)
+	}
+	slices.SortFunc(stats.Prefixes, func(a, b PrefixStats) int {
+		return cmp.Or(
+			cmp.Compare(b.Active+b.Archived, a.Active+a.Archived),
+			cmp.Compare(a.Prefix, b.Prefix),
+		)
+	})
+
+	return stats
+}
+
+// getBuckets returns empty buckets covering the period from since to now; the
+// first one starts at the beginning of the day (or week) since falls in.
+func getBuckets(since, now time.Time, interval Interval) []Bucket {
+	start := startOfInterval(since, interval)
+	var buckets []Bucket
+	for t := start; !t.After(now); t = nextInterval(t, interval) {
+		buckets = append(buckets, Bucket{Start: t})
+	}
+
+	return buckets
+}
+
+func startOfInterval(t time.Time, interval Interval) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	if interval == Week {
+		// weeks start on mondays
+		daysSinceMonday := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -daysSinceMonday)
+	}
+
+	return day
+}
+
+func nextInterval(t time.Time, interval Interval) time.Time {
+	if interval == Week {
+		return t.AddDate(0, 0, 7)
+	}
+
+	return t.AddDate(0, 0, 1)
+}
+
+func bucketIndex(buckets []Bucket, t, now time.Time) int {
+	if len(buckets) == 0 || t.Before(buckets[0].Start) || t.After(now) {
+		return -1
+	}
+
+	for i := len(buckets) - 1; i >= 0; i-- {
+		if !t.Before(buckets[i].Start) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func toDays(d time.Duration) float64 {
+	return math.Round(d.Hours()/24*10) / 10
+}
+
+func formatDays(days float64) string {
+	if days < 1 {
+		return "<1d"
+	}
+
+	return fmt.Sprintf("%.1fd", days)
+}
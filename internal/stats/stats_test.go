@@ -0,0 +1,144 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	pers "github.com/dhth/omm/internal/persistence"
+	"github.com/dhth/omm/internal/testutil"
+	"github.com/dhth/omm/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompute(t *testing.T) {
+	// GIVEN
+	// a wednesday
+	now := time.Date(2026, 3, 11, 12, 0, 0, 0, time.UTC)
+	daysAgo := func(days int) time.Time {
+		return now.AddDate(0, 0, -days)
+	}
+	tasks := []types.Task{
+		{ID: 1, Summary: "work: a", Active: true, CreatedAt: daysAgo(1), UpdatedAt: daysAgo(1)},
+		{ID: 2, Summary: "work: b", Active: true, CreatedAt: daysAgo(9), UpdatedAt: daysAgo(9)},
+		{ID: 3, Summary: "home: c", Active: true, CreatedAt: daysAgo(30), UpdatedAt: daysAgo(2)},
+		{ID: 4, Summary: "work: d", Active: false, CreatedAt: daysAgo(8), UpdatedAt: daysAgo(2)},
+		{ID: 5, Summary: "e", Active: false, CreatedAt: daysAgo(3), UpdatedAt: daysAgo(0)},
+	}
+	opts := Options{
+		Since:     daysAgo(14),
+		Interval:  Week,
+		NumOldest: 2,
+	}
+
+	// WHEN
+	got := Compute(tasks, opts, now)
+
+	// THEN
+	assert.Equal(t, 3, got.NumActive)
+	assert.Equal(t, 2, got.NumArchived)
+	assert.Equal(t, 4, got.NumCreatedSince)
+	assert.Equal(t, 2, got.NumArchivedSince)
+	assert.InDelta(t, 13.3, got.AverageActiveAgeDays, 0.001)
+
+	expectedTrend := []Bucket{
+		{Start: time.Date(2026, 2, 23, 0, 0, 0, 0, time.UTC)},
+		{Start: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), Created: 3},
+		{Start: time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC), Created: 1, Archived: 2},
+	}
+	assert.Equal(t, expectedTrend, got.Trend)
+
+	expectedOldest := []TaskAge{
+		{ID: 3, Summary: "home: c", AgeDays: 30},
+		{ID: 2, Summary: "work: b", AgeDays: 9},
+	}
+	assert.Equal(t, expectedOldest, got.OldestActiveTasks)
+
+	expectedPrefixes := []PrefixStats{
+		{Prefix: "work", Active: 2, Archived: 1, AverageActiveAgeDays: 5},
+		{Prefix: "", Active: 0, Archived: 1},
+		{Prefix: "home", Active: 1, Archived: 0, AverageActiveAgeDays: 30},
+	}
+	assert.Equal(t, expectedPrefixes, got.Prefixes)
+}
+
+func TestComputeForPrefix(t *testing.T) {
+	// GIVEN
+	now := time.Date(2026, 3, 11, 12, 0, 0, 0, time.UTC)
+	tasks := []types.Task{
+		{ID: 1, Summary: "work: a", Active: true, CreatedAt: now.AddDate(0, 0, -2), UpdatedAt: now},
+		{ID: 2, Summary: "home: b", Active: true, CreatedAt: now.AddDate(0, 0, -1), UpdatedAt: now},
+	}
+	opts := Options{
+		Since:    now.AddDate(0, 0, -2),
+		Prefix:   "work",
+		Interval: Day,
+	}
+
+	// WHEN
+	got := Compute(tasks, opts, now)
+
+	// THEN
+	assert.Equal(t, 1, got.NumActive)
+	require.Len(t, got.Trend, 3)
+	assert.Equal(t, []int{1, 0, 0}, []int{got.Trend[0].Created, got.Trend[1].Created, got.Trend[2].Created})
+	require.Len(t, got.Prefixes, 1)
+	assert.Equal(t, "work", got.Prefixes[0].Prefix)
+}
+
+func TestSparkline(t *testing.T) {
+	testCases := []struct {
+		name     string
+		values   []int
+		expected string
+	}{
+		{
+			name:     "no values",
+			values:   nil,
+			expected: "",
+		},
+		{
+			name:     "all zeroes",
+			values:   []int{0, 0, 0},
+			expected: "▁▁▁",
+		},
+		{
+			name:     "scaled to the largest value",
+			values:   []int{0, 1, 2, 7, 14},
+			expected: "▁▁▂▄█",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Sparkline(tt.values)
+
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestLoadCountsSnoozedTasksAsActive(t *testing.T) {
+	// GIVEN
+	db := testutil.NewDB(t)
+
+	now := time.Now()
+	_, err := pers.InsertTasks(db, []types.Task{
+		{Summary: "work: task 1", Active: true, CreatedAt: now, UpdatedAt: now},
+		{Summary: "work: task 2", Active: true, CreatedAt: now, UpdatedAt: now},
+		{Summary: "task 3", Active: false, CreatedAt: now, UpdatedAt: now},
+	}, false)
+	require.NoError(t, err)
+	require.NoError(t, pers.SnoozeTask(db, 2, now.Add(time.Hour)))
+
+	// WHEN
+	got, err := Load(db, Options{Since: now.AddDate(0, 0, -7)}, now)
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, 2, got.NumActive)
+	assert.Equal(t, 1, got.NumArchived)
+	require.NotEmpty(t, got.Prefixes)
+	assert.Equal(t, "work", got.Prefixes[0].Prefix)
+	assert.Equal(t, 2, got.Prefixes[0].Active)
+}
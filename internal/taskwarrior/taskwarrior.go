@@ -0,0 +1,280 @@
+package taskwarrior
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	pers "github.com/dhth/omm/internal/persistence"
+	"github.com/dhth/omm/internal/types"
+)
+
+const (
+	timeFormat = "20060102T150405Z"
+
+	statusPending   = "pending"
+	statusWaiting   = "waiting"
+	statusCompleted = "completed"
+	statusDeleted   = "deleted"
+	statusRecurring = "recurring"
+
+	annotationSeparator = "\n\n"
+)
+
+var (
+	ErrCouldntParseExport = errors.New("couldn't parse taskwarrior export")
+	ErrInvalidTimestamp   = errors.New("invalid taskwarrior timestamp")
+	ErrContextTooLarge    = errors.New("annotations are too large to be used as context")
+)
+
+type Annotation struct {
+	Entry       string `json:"entry"`
+	Description string `json:"description"`
+}
+
+// Task is the subset of taskwarrior's JSON task format that omm understands.
+type Task struct {
+	UUID        string       `json:"uuid"`
+	Description string       `json:"description"`
+	Project     string       `json:"project,omitempty"`
+	Status      string       `json:"status"`
+	Entry       string       `json:"entry"`
+	Modified    string       `json:"modified,omitempty"`
+	End         string       `json:"end,omitempty"`
+	Urgency     float64      `json:"urgency,omitempty"`
+	Annotations []Annotation `json:"annotations,omitempty"`
+}
+
+// SkippedTask holds a taskwarrior task that couldn't be converted to an omm
+// task, along with the reason for it.
+type SkippedTask struct {
+	Task   Task
+	Reason error
+}
+
+// Parse reads the output of "task export". Both a JSON array (the default
+// since taskwarrior 2.4) and one JSON object per line are supported.
+func Parse(r io.Reader) ([]Task, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return nil, nil
+	}
+
+	var tasks []Task
+	if trimmed[0] == '[' {
+		err = json.Unmarshal(trimmed, &tasks)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrCouldntParseExport, err.Error())
+		}
+		return tasks, nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(trimmed))
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSuffix(strings.TrimSpace(scanner.Text()), ",")
+		if line == "" {
+			continue
+		}
+
+		var t Task
+		err = json.Unmarshal([]byte(line), &t)
+		if err != nil {
+			return nil, fmt.Errorf("%w (line %d): %s", ErrCouldntParseExport, lineNum, err.Error())
+		}
+		tasks = append(tasks, t)
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+// ToOmmTasks converts taskwarrior tasks to omm tasks. Projects become
+// prefixes, annotations become context, and completed tasks are archived.
+// Active tasks are ordered by urgency (highest first), so that inserting
+// them at the top of omm's list retains taskwarrior's priorities. Deleted
+// and recurring (template) tasks are skipped, as are ones that can't be
+// converted (eg. because of an invalid summary, or too many annotations).
+func ToOmmTasks(twTasks []Task) ([]types.Task, []SkippedTask) {
+	type ranked struct {
+		task    types.Task
+		urgency float64
+	}
+
+	var active, inactive []ranked
+	var skipped []SkippedTask
+
+	for _, twt := range twTasks {
+		if twt.Status == statusDeleted || twt.Status == statusRecurring {
+			continue
+		}
+
+		t, err := toOmmTask(twt)
+		if err != nil {
+			skipped = append(skipped, SkippedTask{twt, err})
+			continue
+		}
+
+		if t.Active {
+			active = append(active, ranked{t, twt.Urgency})
+		} else {
+			inactive = append(inactive, ranked{t, twt.Urgency})
+		}
+	}
+
+	sort.SliceStable(active, func(i, j int) bool {
+		return active[i].urgency > active[j].urgency
+	})
+
+	tasks := make([]types.Task, 0, len(active)+len(inactive))
+	for _, r := range active {
+		tasks = append(tasks, r.task)
+	}
+	for _, r := range inactive {
+		tasks = append(tasks, r.task)
+	}
+
+	return tasks, skipped
+}
+
+func toOmmTask(twt Task) (types.Task, error) {
+	var t types.Task
+
+	description := strings.TrimSpace(twt.Description)
+	project := strings.TrimSpace(twt.Project)
+
+	summary := description
+	if project != "" {
+		summary = fmt.Sprintf("%s%s %s", project, types.PrefixDelimiter, description)
+	}
+
+	_, err := types.CheckIfTaskSummaryValid(summary)
+	if err != nil {
+		return t, err
+	}
+
+	createdAt := time.Now()
+	if twt.Entry != "" {
+		createdAt, err = parseTime(twt.Entry)
+		if err != nil {
+			return t, err
+		}
+	}
+
+	updatedAt := createdAt
+	for _, ts := range []string{twt.Modified, twt.End} {
+		if ts == "" {
+			continue
+		}
+		updatedAt, err = parseTime(ts)
+		if err != nil {
+			return t, err
+		}
+		break
+	}
+
+	var context *string
+	if len(twt.Annotations) > 0 {
+		descriptions := make([]string, 0, len(twt.Annotations))
+		for _, a := range twt.Annotations {
+			descriptions = append(descriptions, a.Description)
+		}
+		c := strings.Join(descriptions, annotationSeparator)
+		if len(c) > pers.ContextMaxBytes {
+			return t, ErrContextTooLarge
+		}
+		context = &c
+	}
+
+	return types.Task{
+		UUID:      twt.UUID,
+		Summary:   summary,
+		Context:   context,
+		Active:    twt.Status == statusPending || twt.Status == statusWaiting || twt.Status == "",
+		CreatedAt: createdAt,
+		UpdatedAt: updatedAt,
+	}, nil
+}
+
+// FromOmmTasks converts omm tasks to taskwarrior tasks. Active tasks are
+// expected to be in omm's priority order; their urgency is set in decreasing
+// order so that importing the output back into omm retains the same order.
+// A task's context is exported as a single annotation.
+func FromOmmTasks(tasks []types.Task) []Task {
+	twTasks := make([]Task, 0, len(tasks))
+
+	numActive := 0
+	for _, t := range tasks {
+		if t.Active {
+			numActive++
+		}
+	}
+
+	activeIndex := 0
+	for _, t := range tasks {
+		prefix, description, hasPrefix := t.GetPrefixAndSummaryContent()
+
+		twt := Task{
+			UUID:        t.UUID,
+			Description: description,
+			Entry:       formatTime(t.CreatedAt),
+			Modified:    formatTime(t.UpdatedAt),
+		}
+
+		if hasPrefix {
+			twt.Project = prefix
+		}
+
+		if t.Active {
+			twt.Status = statusPending
+			twt.Urgency = float64(numActive - activeIndex)
+			activeIndex++
+		} else {
+			twt.Status = statusCompleted
+			twt.End = formatTime(t.UpdatedAt)
+		}
+
+		if t.Context != nil && strings.TrimSpace(*t.Context) != "" {
+			twt.Annotations = []Annotation{
+				{
+					Entry:       formatTime(t.UpdatedAt),
+					Description: *t.Context,
+				},
+			}
+		}
+
+		twTasks = append(twTasks, twt)
+	}
+
+	return twTasks
+}
+
+func parseTime(value string) (time.Time, error) {
+	t, err := time.Parse(timeFormat, value)
+	if err != nil {
+		return t, fmt.Errorf("%w: %q", ErrInvalidTimestamp, value)
+	}
+
+	return t.Local(), nil
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeFormat)
+}
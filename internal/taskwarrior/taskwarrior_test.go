@@ -0,0 +1,128 @@
+package taskwarrior
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	pers "github.com/dhth/omm/internal/persistence"
+	"github.com/dhth/omm/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sampleExport = `[
+{"id":1,"description":"write release notes","entry":"20240801T100000Z","modified":"20240802T100000Z","project":"docs","status":"pending","uuid":"6f1e9d0e-5b9c-4c2f-9a51-3d41a1b5d001","urgency":2.1},
+{"id":2,"description":"fix flaky test","entry":"20240801T110000Z","modified":"20240801T110000Z","status":"pending","uuid":"6f1e9d0e-5b9c-4c2f-9a51-3d41a1b5d002","urgency":8.4,"annotations":[{"entry":"20240801T110500Z","description":"fails on CI only"},{"entry":"20240801T111000Z","description":"see run 123"}]},
+{"id":0,"description":"old task","end":"20240730T090000Z","entry":"20240701T090000Z","project":"misc","status":"completed","uuid":"6f1e9d0e-5b9c-4c2f-9a51-3d41a1b5d003","urgency":0},
+{"id":0,"description":"removed task","entry":"20240701T090000Z","status":"deleted","uuid":"6f1e9d0e-5b9c-4c2f-9a51-3d41a1b5d004"}
+]`
+
+func TestParseSupportsArraysAndLines(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input string
+	}{
+		{
+			name:  "json array",
+			input: `[{"description":"a","status":"pending","uuid":"u1"},{"description":"b","status":"pending","uuid":"u2"}]`,
+		},
+		{
+			name: "one object per line",
+			input: `{"description":"a","status":"pending","uuid":"u1"},
+{"description":"b","status":"pending","uuid":"u2"}`,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(strings.NewReader(tt.input))
+
+			require.NoError(t, err)
+			require.Len(t, got, 2)
+			assert.Equal(t, "u1", got[0].UUID)
+			assert.Equal(t, "b", got[1].Description)
+		})
+	}
+}
+
+func TestParseReturnsErrorForInvalidInput(t *testing.T) {
+	_, err := Parse(strings.NewReader(`[{"description":`))
+
+	assert.ErrorIs(t, err, ErrCouldntParseExport)
+}
+
+func TestToOmmTasks(t *testing.T) {
+	// GIVEN
+	twTasks, err := Parse(strings.NewReader(sampleExport))
+	require.NoError(t, err)
+
+	// WHEN
+	got, skipped := ToOmmTasks(twTasks)
+
+	// THEN
+	assert.Empty(t, skipped)
+	require.Len(t, got, 3)
+
+	assert.Equal(t, "fix flaky test", got[0].Summary, "tasks with higher urgency should come first")
+	assert.True(t, got[0].Active)
+	require.NotNil(t, got[0].Context)
+	assert.Equal(t, "fails on CI only\n\nsee run 123", *got[0].Context)
+
+	assert.Equal(t, "docs: write release notes", got[1].Summary)
+	assert.Equal(t, "6f1e9d0e-5b9c-4c2f-9a51-3d41a1b5d001", got[1].UUID)
+	assert.Nil(t, got[1].Context)
+	assert.Equal(t, time.Date(2024, 8, 2, 10, 0, 0, 0, time.UTC), got[1].UpdatedAt.UTC())
+
+	assert.Equal(t, "misc: old task", got[2].Summary)
+	assert.False(t, got[2].Active)
+	assert.Equal(t, time.Date(2024, 7, 30, 9, 0, 0, 0, time.UTC), got[2].UpdatedAt.UTC())
+}
+
+func TestToOmmTasksSkipsInvalidTasks(t *testing.T) {
+	twTasks := []Task{
+		{UUID: "u1", Description: "   ", Status: statusPending},
+		{UUID: "u2", Description: strings.Repeat("a", types.TaskSummaryMaxLen+1), Status: statusPending},
+		{UUID: "u3", Description: "valid", Status: statusPending, Entry: "not-a-timestamp"},
+		{UUID: "u4", Description: "valid", Status: statusPending, Annotations: []Annotation{
+			{Description: strings.Repeat("a", pers.ContextMaxBytes)},
+			{Description: "one more"},
+		}},
+	}
+
+	got, skipped := ToOmmTasks(twTasks)
+
+	assert.Empty(t, got)
+	require.Len(t, skipped, 4)
+	assert.ErrorIs(t, skipped[0].Reason, types.ErrTaskSummaryEmpty)
+	assert.ErrorIs(t, skipped[1].Reason, types.ErrTaskSummaryTooLong)
+	assert.ErrorIs(t, skipped[2].Reason, ErrInvalidTimestamp)
+	assert.ErrorIs(t, skipped[3].Reason, ErrContextTooLarge)
+}
+
+func TestRoundTripRetainsTaskDetails(t *testing.T) {
+	// GIVEN
+	createdAt := time.Date(2024, 8, 1, 10, 0, 0, 0, time.UTC)
+	updatedAt := time.Date(2024, 8, 3, 12, 30, 0, 0, time.UTC)
+	context := "## notes\n\n- point one\n- point two"
+	tasks := []types.Task{
+		{UUID: "u1", Summary: "infra: rotate keys", Context: &context, Active: true, CreatedAt: createdAt, UpdatedAt: updatedAt},
+		{UUID: "u2", Summary: "write docs", Active: true, CreatedAt: createdAt, UpdatedAt: updatedAt},
+		{UUID: "u3", Summary: "api: remove v1 endpoints", Active: false, CreatedAt: createdAt, UpdatedAt: updatedAt},
+	}
+
+	// WHEN
+	got, skipped := ToOmmTasks(FromOmmTasks(tasks))
+
+	// THEN
+	assert.Empty(t, skipped)
+	require.Len(t, got, len(tasks))
+	for i, task := range tasks {
+		assert.Equal(t, task.UUID, got[i].UUID)
+		assert.Equal(t, task.Summary, got[i].Summary)
+		assert.Equal(t, task.Context, got[i].Context)
+		assert.Equal(t, task.Active, got[i].Active)
+		assert.Equal(t, task.CreatedAt, got[i].CreatedAt.UTC())
+		assert.Equal(t, task.UpdatedAt, got[i].UpdatedAt.UTC())
+	}
+}
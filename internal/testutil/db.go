@@ -0,0 +1,27 @@
+// Package testutil holds helpers shared by the tests of omm's packages.
+package testutil
+
+import (
+	"database/sql"
+	"testing"
+
+	pers "github.com/dhth/omm/internal/persistence"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite" // sqlite driver
+)
+
+// NewDB returns an in-memory database, migrated to the latest version, that's
+// closed once the test finishes.
+func NewDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	require.NoError(t, pers.InitDB(db))
+	require.NoError(t, pers.UpgradeDB(db, 1))
+
+	return db
+}
@@ -0,0 +1,132 @@
+package types
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+const TagPrefix = "#"
+
+var (
+	// a tag needs to be at the start of a summary, or follow whitespace (so that
+	// eg. URL fragments aren't picked up), and start with a letter (so that eg.
+	// issue numbers like #123 aren't either)
+	tagRegex     = regexp.MustCompile(`(^|\s)#(\p{L}[\p{L}\p{N}_-]*)`)
+	tagNameRegex = regexp.MustCompile(`^\p{L}[\p{L}\p{N}_-]*$`)
+)
+
+// Tag is a label added to tasks via "#name" in their summaries.
+type Tag struct {
+	Name string
+	// Color overrides the color the tag is shown in; it's empty if not set
+	Color    string
+	NumTasks int
+}
+
+// TagSpan is the byte range of a tag (including its "#") in a summary.
+type TagSpan struct {
+	Start int
+	End   int
+	Name  string
+}
+
+// GetTagSpans returns the tags in a summary, in the order they appear. Tag
+// names are case-insensitive, and are returned in lower case.
+func GetTagSpans(summary string) []TagSpan {
+	var spans []TagSpan
+	for _, m := range tagRegex.FindAllStringSubmatchIndex(summary, -1) {
+		// m[4]:m[5] is the name; the "#" precedes it
+		spans = append(spans, TagSpan{m[4] - 1, m[5], strings.ToLower(summary[m[4]:m[5]])})
+	}
+
+	return spans
+}
+
+// ParseTags returns the unique tags in a summary, in the order they appear.
+func ParseTags(summary string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, s := range GetTagSpans(summary) {
+		if seen[s.Name] {
+			continue
+		}
+		seen[s.Name] = true
+		tags = append(tags, s.Name)
+	}
+
+	return tags
+}
+
+func (t Task) Tags() []string {
+	return ParseTags(t.Summary)
+}
+
+func IsTagNameValid(name string) bool {
+	return tagNameRegex.MatchString(name)
+}
+
+// ReplaceTag replaces occurrences of a tag in a summary with another one; if
+// the summary already has the other tag, or it's empty, occurrences are removed
+// instead.
+func ReplaceTag(summary, from, to string) string {
+	from = strings.ToLower(from)
+	to = strings.ToLower(to)
+
+	remove := to == ""
+	for _, s := range GetTagSpans(summary) {
+		if s.Name == to {
+			remove = true
+			break
+		}
+	}
+
+	spans := GetTagSpans(summary)
+	var sb strings.Builder
+	last := 0
+	for _, s := range spans {
+		if s.Name != from {
+			continue
+		}
+
+		sb.WriteString(summary[last:s.Start])
+		last = s.End
+		if remove {
+			// drop the whitespace before the tag, or after it if it's at the start
+			trimmed := strings.TrimRightFunc(sb.String(), unicode.IsSpace)
+			sb.Reset()
+			sb.WriteString(trimmed)
+			if trimmed == "" {
+				for last < len(summary) && unicode.IsSpace(rune(summary[last])) {
+					last++
+				}
+			}
+			continue
+		}
+		sb.WriteString(TagPrefix + to)
+	}
+	sb.WriteString(summary[last:])
+
+	return sb.String()
+}
+
+// TagFromPrefix returns the tag a task prefix corresponds to (eg. "Side
+// Project" -> "side-project"), or false if it can't be turned into one.
+func TagFromPrefix(prefix TaskPrefix) (string, bool) {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(string(prefix))) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsNumber(r) || r == '_' || r == '-':
+			sb.WriteRune(r)
+		case unicode.IsSpace(r) || r == '/' || r == '.':
+			sb.WriteRune('-')
+		}
+	}
+
+	name := sb.String()
+	if !IsTagNameValid(name) {
+		return "", false
+	}
+
+	return name, true
+}
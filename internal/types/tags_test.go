@@ -0,0 +1,87 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTags(t *testing.T) {
+	testCases := []struct {
+		name     string
+		summary  string
+		expected []string
+	}{
+		{name: "no tags", summary: "api: add endpoint"},
+		{name: "tags anywhere in the summary", summary: "#api add endpoint #v2-release #api", expected: []string{"api", "v2-release"}},
+		{name: "tags are case-insensitive", summary: "fix #Auth #AUTH", expected: []string{"auth"}},
+		{name: "non ascii tags", summary: "écrire #café_notes", expected: []string{"café_notes"}},
+		{name: "numbers aren't tags", summary: "fix issue #123", expected: nil},
+		{name: "url fragments aren't tags", summary: "read https://example.com/#section", expected: nil},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			// WHEN
+			got := ParseTags(tt.summary)
+
+			// THEN
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestReplaceTag(t *testing.T) {
+	testCases := []struct {
+		name     string
+		summary  string
+		from     string
+		to       string
+		expected string
+	}{
+		{name: "rename", summary: "fix #auth flow #AUTH", from: "auth", to: "security", expected: "fix #security flow #security"},
+		{name: "other tags are left alone", summary: "fix #auth #authz", from: "auth", to: "security", expected: "fix #security #authz"},
+		{name: "removal", summary: "fix #auth flow", from: "auth", expected: "fix flow"},
+		{name: "removal at the start", summary: "#auth fix flow", from: "auth", expected: "fix flow"},
+		{name: "tag already present", summary: "fix #auth #security", from: "auth", to: "security", expected: "fix #security"},
+		{name: "tag absent", summary: "fix flow", from: "auth", to: "security", expected: "fix flow"},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			// WHEN
+			got := ReplaceTag(tt.summary, tt.from, tt.to)
+
+			// THEN
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestTagFromPrefix(t *testing.T) {
+	testCases := []struct {
+		prefix   TaskPrefix
+		expected string
+		ok       bool
+	}{
+		{prefix: "api", expected: "api", ok: true},
+		{prefix: "Side Project", expected: "side-project", ok: true},
+		{prefix: "team/infra.k8s", expected: "team-infra-k8s", ok: true},
+		{prefix: "2024", ok: false},
+		{prefix: "!!", ok: false},
+	}
+
+	for _, tt := range testCases {
+		t.Run(string(tt.prefix), func(t *testing.T) {
+			// GIVEN
+			// WHEN
+			got, ok := TagFromPrefix(tt.prefix)
+
+			// THEN
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
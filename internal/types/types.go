@@ -2,6 +2,7 @@ package types
 
 import (
 	"errors"
+	"fmt"
 	"strings"
 	"time"
 )
@@ -17,6 +18,7 @@ var (
 	ErrTaskPrefixEmpty      = errors.New("task prefix is empty")
 	ErrTaskSummaryBodyEmpty = errors.New("task summary body is empty")
 	ErrTaskSummaryTooLong   = errors.New("task summary is too long")
+	ErrTaskPrefixInvalid    = errors.New("task prefix is invalid")
 )
 
 type TaskDetails struct {
@@ -25,12 +27,18 @@ type TaskDetails struct {
 }
 
 type Task struct {
-	ID        uint64
-	Summary   string
-	Context   *string
-	Active    bool
+	ID      uint64
+	UUID    string
+	Summary string
+	Context *string
+	Active  bool
+	// Status is the column of the board an active task is in
+	Status    string
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	// SnoozedUntil is when a snoozed task wakes up; it's only set for tasks
+	// fetched as snoozed ones
+	SnoozedUntil *time.Time
 }
 
 func (t Task) GetDetails() TaskDetails {
@@ -46,6 +54,13 @@ func (t Task) GetDetails() TaskDetails {
 	}
 }
 
+// SavedView is a view created via the TUI.
+type SavedView struct {
+	Name       string
+	Definition string
+	CreatedAt  time.Time
+}
+
 type ContextBookmark string
 
 type TaskPrefix string
@@ -63,6 +78,54 @@ func (t Task) Prefix() (TaskPrefix, bool) {
 	return "", false
 }
 
+// PrefixCount is the number of active and archived tasks that have a prefix.
+type PrefixCount struct {
+	Prefix      TaskPrefix
+	NumActive   int
+	NumArchived int
+}
+
+// TaskDependency is a link between a task and one that needs to be done before
+// it can be worked on.
+type TaskDependency struct {
+	TaskID    uint64
+	BlockerID uint64
+}
+
+// CheckIfTaskPrefixValid checks whether a prefix can be added to a summary; it
+// can't be empty, or contain the prefix delimiter.
+func CheckIfTaskPrefixValid(prefix string) error {
+	if strings.TrimSpace(prefix) == "" {
+		return ErrTaskPrefixEmpty
+	}
+
+	if strings.Contains(prefix, PrefixDelimiter) {
+		return fmt.Errorf("%w: it can't contain %q", ErrTaskPrefixInvalid, PrefixDelimiter)
+	}
+
+	return nil
+}
+
+// SummaryWithPrefix returns a summary with its prefix replaced by (or, if it
+// doesn't have one, prepended with) another one.
+func SummaryWithPrefix(summary, prefix string) string {
+	if strings.TrimSpace(summary) == "" {
+		return fmt.Sprintf("%s: ", prefix)
+	}
+
+	summEls := strings.Split(summary, PrefixDelimiter)
+
+	if len(summEls) == 1 {
+		return fmt.Sprintf("%s: %s", prefix, summary)
+	}
+
+	if summEls[1] == "" {
+		return fmt.Sprintf("%s: ", prefix)
+	}
+
+	return fmt.Sprintf("%s:%s", prefix, strings.Join(summEls[1:], PrefixDelimiter))
+}
+
 func CheckIfTaskSummaryValid(summary string) (bool, error) {
 	if strings.TrimSpace(summary) == "" {
 		return false, ErrTaskSummaryEmpty
@@ -106,12 +169,11 @@ func (t Task) Description() string {
 	return ""
 }
 
+// FilterValue returns the task's summary. It's called for every task each time
+// a list's filter changes, so it doesn't include the context; the task lists
+// read whatever else they filter on from the tasks themselves.
 func (t Task) FilterValue() string {
-	p, ok := t.Prefix()
-	if ok {
-		return string(p)
-	}
-	return ""
+	return t.Summary
 }
 
 func (c ContextBookmark) Title() string {
@@ -0,0 +1,73 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummaryWithPrefix(t *testing.T) {
+	testCases := []struct {
+		name     string
+		summary  string
+		prefix   string
+		expected string
+	}{
+		{
+			name:     "empty summary",
+			summary:  "",
+			prefix:   "new",
+			expected: "new: ",
+		},
+		{
+			name:     "just whitespace",
+			summary:  "  ",
+			prefix:   "new",
+			expected: "new: ",
+		},
+		{
+			name:     "just a colon",
+			summary:  ":",
+			prefix:   "new",
+			expected: "new: ",
+		},
+		{
+			name:     "just summary content",
+			summary:  "this is a task",
+			prefix:   "new",
+			expected: "new: this is a task",
+		},
+		{
+			name:     "just a prefix",
+			summary:  "old:",
+			prefix:   "new",
+			expected: "new: ",
+		},
+		{
+			name:     "prefix and summary content",
+			summary:  "old: this is a task",
+			prefix:   "new",
+			expected: "new: this is a task",
+		},
+		{
+			name:     "prefix and summary content without space",
+			summary:  "old:this is a task",
+			prefix:   "new",
+			expected: "new:this is a task",
+		},
+		{
+			name:     "summary with two colons",
+			summary:  "old: this: is a task",
+			prefix:   "new",
+			expected: "new: this: is a task",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SummaryWithPrefix(tt.summary, tt.prefix)
+
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// TaskURIPrefix starts URIs that reference omm tasks (eg. "omm://task/12");
+// these can be added to summaries and contexts like any other URI, and are
+// resolved within omm instead of being opened.
+const TaskURIPrefix = "omm://task/"
+
+// TaskURI returns the URI that references a task.
+func TaskURI(id uint64) string {
+	return fmt.Sprintf("%s%d", TaskURIPrefix, id)
+}
+
+// ParseTaskURI returns the ID of the task a URI references, or false if it
+// doesn't reference one.
+func ParseTaskURI(uri string) (uint64, bool) {
+	idStr, ok := strings.CutPrefix(uri, TaskURIPrefix)
+	if !ok {
+		return 0, false
+	}
+
+	id, err := strconv.ParseUint(strings.TrimSuffix(idStr, "/"), 10, 64)
+	if err != nil || id == 0 {
+		return 0, false
+	}
+
+	return id, true
+}
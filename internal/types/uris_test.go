@@ -0,0 +1,35 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTaskURI(t *testing.T) {
+	testCases := []struct {
+		uri      string
+		expected uint64
+		ok       bool
+	}{
+		{uri: "omm://task/12", expected: 12, ok: true},
+		{uri: "omm://task/12/", expected: 12, ok: true},
+		{uri: TaskURI(7), expected: 7, ok: true},
+		{uri: "omm://task/0", ok: false},
+		{uri: "omm://task/abc", ok: false},
+		{uri: "omm://task/", ok: false},
+		{uri: "https://example.com/task/12", ok: false},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.uri, func(t *testing.T) {
+			// GIVEN
+			// WHEN
+			got, ok := ParseTaskURI(tt.uri)
+
+			// THEN
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
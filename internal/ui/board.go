@@ -0,0 +1,262 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"charm.land/bubbles/v2/list"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/dhth/omm/internal/types"
+	"github.com/dhth/omm/internal/utils"
+)
+
+const (
+	boardColumnMinWidth = 20
+	boardCardHeight     = 2
+	boardTitleSuffix    = " (board)"
+)
+
+// boardColumn holds the tasks shown in a column of the board; the last column
+// holds archived tasks.
+type boardColumn struct {
+	name  string
+	tasks []types.Task
+	done  bool
+}
+
+// getBoardColumns places active tasks in the column matching their status (in
+// the order of the task list), and archived tasks in the last column. Tasks
+// with a status that doesn't match any column are placed in the first one.
+func getBoardColumns(names []string, activeTasks, archivedTasks []types.Task) []boardColumn {
+	if len(names) == 0 {
+		return nil
+	}
+
+	columns := make([]boardColumn, len(names))
+	columnIndex := make(map[string]int)
+	for i, name := range names {
+		columns[i].name = name
+		if i < len(names)-1 {
+			columnIndex[name] = i
+		}
+	}
+
+	for _, t := range activeTasks {
+		i := columnIndex[t.Status]
+		columns[i].tasks = append(columns[i].tasks, t)
+	}
+
+	last := len(columns) - 1
+	columns[last].done = true
+	columns[last].tasks = archivedTasks
+
+	return columns
+}
+
+func (m Model) getBoard() []boardColumn {
+	return getBoardColumns(m.cfg.BoardColumns, getTasks(m.taskList.Items()), getTasks(m.archivedTaskList.Items()))
+}
+
+func getTasks(items []list.Item) []types.Task {
+	tasks := make([]types.Task, 0, len(items))
+	for _, item := range items {
+		t, ok := item.(types.Task)
+		if ok {
+			tasks = append(tasks, t)
+		}
+	}
+
+	return tasks
+}
+
+// clampBoardCursor keeps the cursor within the bounds of the board.
+func (m *Model) clampBoardCursor(board []boardColumn) {
+	if len(m.boardRows) != len(board) {
+		m.boardRows = make([]int, len(board))
+	}
+
+	m.boardColumnIndex = min(max(m.boardColumnIndex, 0), len(board)-1)
+	for i, c := range board {
+		m.boardRows[i] = min(max(m.boardRows[i], 0), max(len(c.tasks)-1, 0))
+	}
+}
+
+func (m Model) selectedBoardTask(board []boardColumn) (types.Task, bool) {
+	if m.boardColumnIndex < 0 || m.boardColumnIndex >= len(board) {
+		return types.Task{}, false
+	}
+
+	tasks := board[m.boardColumnIndex].tasks
+	row := m.boardRows[m.boardColumnIndex]
+	if row >= len(tasks) {
+		return types.Task{}, false
+	}
+
+	return tasks[row], true
+}
+
+// focusBoardTask moves the cursor to a task on the board.
+func (m *Model) focusBoardTask(id uint64) {
+	board := m.getBoard()
+	m.clampBoardCursor(board)
+
+	for i, c := range board {
+		for j, t := range c.tasks {
+			if t.ID == id {
+				m.boardColumnIndex = i
+				m.boardRows[i] = j
+				return
+			}
+		}
+	}
+}
+
+// moveBoardTask moves the selected task offset columns to the right (or left,
+// if offset is negative). Moving a task to the last column archives it, and
+// moving it out of there unarchives it.
+func (m *Model) moveBoardTask(offset int) tea.Cmd {
+	board := m.getBoard()
+	t, ok := m.selectedBoardTask(board)
+	if !ok {
+		return nil
+	}
+
+	target := m.boardColumnIndex + offset
+	if target < 0 || target >= len(board) {
+		return nil
+	}
+
+	now := time.Now()
+	current := board[m.boardColumnIndex]
+	switch {
+	case board[target].done:
+		return changeTaskStatus(m.db, m.tlIndexMap[t.ID], t.ID, false, now)
+
+	case current.done:
+		if !m.isSpaceAvailable() {
+			m.errorMsg = noSpaceAvailableMsg
+			return nil
+		}
+
+		cmd := changeTaskStatus(m.db, m.atlIndexMap[t.ID], t.ID, true, now)
+		if t.Status == board[target].name {
+			return cmd
+		}
+		return tea.Sequence(cmd, updateTaskStatus(m.db, t.ID, board[target].name, now))
+
+	default:
+		return updateTaskStatus(m.db, t.ID, board[target].name, now)
+	}
+}
+
+// reorderBoardTask swaps the selected task with the one offset positions below
+// it (or above it, if offset is negative) in its column; tasks in other columns
+// keep their positions in the task list.
+func (m *Model) reorderBoardTask(offset int) tea.Cmd {
+	board := m.getBoard()
+	column := board[m.boardColumnIndex]
+	if column.done {
+		m.errorMsg = "Archived tasks can't be reordered"
+		return nil
+	}
+
+	row := m.boardRows[m.boardColumnIndex]
+	target := row + offset
+	if row >= len(column.tasks) || target < 0 || target >= len(column.tasks) {
+		return nil
+	}
+
+	i := m.tlIndexMap[column.tasks[row].ID]
+	j := m.tlIndexMap[column.tasks[target].ID]
+	items := m.taskList.Items()
+	itemI, itemJ := items[i], items[j]
+	m.taskList.SetItem(i, itemJ)
+	m.taskList.SetItem(j, itemI)
+	m.boardRows[m.boardColumnIndex] = target
+
+	return m.updateActiveTasksSequence()
+}
+
+func (m Model) getBoardView() string {
+	board := m.getBoard()
+	if len(board) == 0 {
+		return ""
+	}
+
+	columnWidth := max((m.terminalWidth-2)/len(board), boardColumnMinWidth)
+	maxCards := max((m.terminalHeight-8)/boardCardHeight, 1)
+
+	selectionStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(m.theme.Primary))
+	archivedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(m.theme.Secondary))
+
+	columns := make([]string, len(board))
+	for i, c := range board {
+		focused := i == m.boardColumnIndex
+		titleStyle := m.styles.mutedText.Bold(true)
+		if focused {
+			titleStyle = selectionStyle.Bold(true)
+		}
+		if c.done && focused {
+			titleStyle = archivedStyle.Bold(true)
+		}
+
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "%s %s\n\n",
+			titleStyle.Render(utils.Trim(c.name, columnWidth-8)),
+			m.styles.mutedText.Render(fmt.Sprintf("(%d)", len(c.tasks))),
+		)
+
+		row := 0
+		if i < len(m.boardRows) {
+			row = m.boardRows[i]
+		}
+		start := max(row-maxCards+1, 0)
+		end := min(start+maxCards, len(c.tasks))
+
+		for j := start; j < end; j++ {
+			sel := focused && j == row
+			cardStyle := lipgloss.NewStyle()
+			if sel && c.done {
+				cardStyle = archivedStyle
+			} else if sel {
+				cardStyle = selectionStyle
+			}
+			sb.WriteString(m.getBoardCard(c.tasks[j], columnWidth-4, sel, cardStyle))
+			sb.WriteString("\n\n")
+		}
+
+		if end < len(c.tasks) {
+			sb.WriteString(m.styles.mutedText.Render(fmt.Sprintf("  +%d more", len(c.tasks)-end)))
+		}
+
+		columns[i] = lipgloss.NewStyle().Width(columnWidth).Render(sb.String())
+	}
+
+	header := m.styles.activeListTitleBar.Render(m.cfg.TaskListTitle + boardTitleSuffix)
+
+	return fmt.Sprintf("\n  %s\n\n%s", header, lipgloss.JoinHorizontal(lipgloss.Top, columns...))
+}
+
+func (m Model) getBoardCard(t types.Task, width int, selected bool, style lipgloss.Style) string {
+	prefix, summary, hasPrefix := t.GetPrefixAndSummaryContent()
+
+	marker := "  "
+	if selected {
+		marker = style.Render("▎ ")
+	}
+
+	if !hasPrefix {
+		return marker + renderTaskSummary(summary, nil, width, style, m.tagColors, m.theme.PrefixColors)
+	}
+
+	prefixColor := getPrefixColor(prefix, m.theme.PinnedPrefixColors, m.theme.PrefixColors)
+	prefix = utils.Trim(prefix, width/3)
+	prefixStr := lipgloss.NewStyle().
+		Foreground(prefixColor).
+		Bold(true).
+		Render(prefix)
+
+	return fmt.Sprintf("%s%s %s", marker, prefixStr, renderTaskSummary(summary, nil, max(width-len(prefix)-1, 1), style, m.tagColors, m.theme.PrefixColors))
+}
@@ -0,0 +1,84 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/dhth/omm/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetBoardColumns(t *testing.T) {
+	// GIVEN
+	active := getTasksWithSummaries("a", "b", "c", "d")
+	active[0].Status = "doing"
+	active[2].Status = "unknown"
+	active[3].Status = "done"
+	archived := []types.Task{{ID: 10, Summary: "e"}}
+
+	// WHEN
+	got := getBoardColumns([]string{"todo", "doing", "done"}, active, archived)
+
+	// THEN
+	require.Len(t, got, 3)
+	summaries := make(map[string][]string)
+	for _, c := range got {
+		for _, t := range c.tasks {
+			summaries[c.name] = append(summaries[c.name], t.Summary)
+		}
+	}
+	// active tasks never end up in the last column, even if their status says so
+	assert.Equal(t, map[string][]string{
+		"todo":  {"b", "c", "d"},
+		"doing": {"a"},
+		"done":  {"e"},
+	}, summaries)
+	assert.False(t, got[1].done)
+	assert.True(t, got[2].done)
+}
+
+func TestParseBoardColumns(t *testing.T) {
+	testCases := []struct {
+		name        string
+		value       string
+		expected    []string
+		expectedErr error
+	}{
+		{
+			name:     "valid columns",
+			value:    "todo, doing ,done",
+			expected: []string{"todo", "doing", "done"},
+		},
+		{
+			name:        "a single column",
+			value:       "todo",
+			expectedErr: ErrBoardColumnsTooFew,
+		},
+		{
+			name:        "an empty column",
+			value:       "todo,,done",
+			expectedErr: ErrBoardColumnEmpty,
+		},
+		{
+			name:        "a repeated column",
+			value:       "todo,done,todo",
+			expectedErr: ErrBoardColumnDuplicate,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			// WHEN
+			got, err := ParseBoardColumns(tt.value)
+
+			// THEN
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
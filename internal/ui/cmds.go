@@ -8,7 +8,9 @@ import (
 
 	tea "charm.land/bubbletea/v2"
 	"github.com/atotto/clipboard"
+	"github.com/dhth/omm/internal/hooks"
 	pers "github.com/dhth/omm/internal/persistence"
+	"github.com/dhth/omm/internal/stats"
 	"github.com/dhth/omm/internal/types"
 	_ "modernc.org/sqlite" // sqlite driver
 )
@@ -28,9 +30,11 @@ func updateTaskSequence(db *sql.DB, sequence []uint64) tea.Cmd {
 
 func createTask(db *sql.DB, index int, summary string, context *string, createdAt, updatedAt time.Time) tea.Cmd {
 	return func() tea.Msg {
-		id, err := pers.InsertTask(db, summary, context, createdAt, updatedAt)
+		taskUUID := pers.NewTaskUUID()
+		id, err := pers.InsertTask(db, taskUUID, summary, context, createdAt, updatedAt)
 		task := types.Task{
 			ID:        id,
+			UUID:      taskUUID,
 			Summary:   summary,
 			Context:   context,
 			Active:    true,
@@ -41,6 +45,24 @@ func createTask(db *sql.DB, index int, summary string, context *string, createdA
 	}
 }
 
+func updateTaskStatus(db *sql.DB, id uint64, status string, updatedAt time.Time) tea.Cmd {
+	return func() tea.Msg {
+		err := pers.UpdateTaskStatus(db, id, status, updatedAt)
+		return taskBoardStatusUpdatedMsg{id, status, updatedAt, err}
+	}
+}
+
+func fetchStats(db *sql.DB, now time.Time) tea.Cmd {
+	return func() tea.Msg {
+		opts := stats.Options{
+			Since:    now.AddDate(0, 0, -7*statsNumWeeks),
+			Interval: stats.Week,
+		}
+		s, err := stats.Load(db, opts, now)
+		return statsFetchedMsg{s, err}
+	}
+}
+
 func deleteTask(db *sql.DB, id uint64, index int, active bool) tea.Cmd {
 	return func() tea.Msg {
 		err := pers.DeleteTask(db, id)
@@ -76,6 +98,13 @@ func changeTaskStatus(db *sql.DB, listIndex int, id uint64, active bool, updated
 	}
 }
 
+func runHook(hooksCfg hooks.Config, event hooks.Event, task types.Task) tea.Cmd {
+	return func() tea.Msg {
+		err := hooksCfg.Run(event, task)
+		return hookRanMsg{event, err}
+	}
+}
+
 func fetchTasks(db *sql.DB, active bool, limit int) tea.Cmd {
 	return func() tea.Msg {
 		var tasks []types.Task
@@ -90,6 +119,143 @@ func fetchTasks(db *sql.DB, active bool, limit int) tea.Cmd {
 	}
 }
 
+func fetchSavedViews(db *sql.DB) tea.Cmd {
+	return func() tea.Msg {
+		views, err := pers.FetchSavedViews(db)
+		return savedViewsFetchedMsg{views, err}
+	}
+}
+
+func createSavedView(db *sql.DB, name, definition string, createdAt time.Time) tea.Cmd {
+	return func() tea.Msg {
+		err := pers.InsertSavedView(db, name, definition, createdAt)
+		return savedViewCreatedMsg{name, err}
+	}
+}
+
+func deleteSavedView(db *sql.DB, name string) tea.Cmd {
+	return func() tea.Msg {
+		err := pers.DeleteSavedView(db, name)
+		return savedViewDeletedMsg{name, err}
+	}
+}
+
+func fetchTags(db *sql.DB, show bool) tea.Cmd {
+	return func() tea.Msg {
+		tags, err := pers.FetchTags(db)
+		return tagsFetchedMsg{tags, show, err}
+	}
+}
+
+func renameTag(db *sql.DB, from, to string, updatedAt time.Time) tea.Cmd {
+	return func() tea.Msg {
+		_, err := pers.RenameTag(db, from, to, updatedAt)
+		return tagChangedMsg{err}
+	}
+}
+
+func mergeTags(db *sql.DB, from, into string, updatedAt time.Time) tea.Cmd {
+	return func() tea.Msg {
+		_, err := pers.MergeTags(db, from, into, updatedAt)
+		return tagChangedMsg{err}
+	}
+}
+
+func setTagColor(db *sql.DB, name, color string) tea.Cmd {
+	return func() tea.Msg {
+		err := pers.SetTagColor(db, name, color)
+		return tagChangedMsg{err}
+	}
+}
+
+func fetchPrefixes(db *sql.DB, selected types.TaskPrefix, show bool) tea.Cmd {
+	return func() tea.Msg {
+		prefixes, err := pers.FetchPrefixes(db)
+		return prefixesFetchedMsg{prefixes, selected, show, err}
+	}
+}
+
+func renamePrefix(db *sql.DB, from, to string, updatedAt time.Time) tea.Cmd {
+	return func() tea.Msg {
+		numChanged, err := pers.RenamePrefix(db, from, to, updatedAt)
+		return prefixChangedMsg{numChanged, err}
+	}
+}
+
+func mergePrefixes(db *sql.DB, from, into string, updatedAt time.Time) tea.Cmd {
+	return func() tea.Msg {
+		numChanged, err := pers.MergePrefixes(db, from, into, updatedAt)
+		return prefixChangedMsg{numChanged, err}
+	}
+}
+
+func fetchTaskDependencies(db *sql.DB) tea.Cmd {
+	return func() tea.Msg {
+		dependencies, err := pers.FetchTaskDependencies(db)
+		return taskDependenciesFetchedMsg{dependencies, err}
+	}
+}
+
+func addTaskDependency(db *sql.DB, taskID, blockerID uint64, createdAt time.Time) tea.Cmd {
+	return func() tea.Msg {
+		err := pers.AddTaskDependency(db, taskID, blockerID, createdAt)
+		return taskDependencyChangedMsg{err}
+	}
+}
+
+func removeTaskDependency(db *sql.DB, taskID, blockerID uint64) tea.Cmd {
+	return func() tea.Msg {
+		err := pers.RemoveTaskDependency(db, taskID, blockerID)
+		return taskDependencyChangedMsg{err}
+	}
+}
+
+func fetchSnoozedTasks(db *sql.DB, show bool) tea.Cmd {
+	return func() tea.Msg {
+		tasks, err := pers.FetchSnoozedTasks(db, pers.TaskNumLimit)
+		return snoozedTasksFetchedMsg{tasks, show, err}
+	}
+}
+
+func snoozeTask(db *sql.DB, id uint64, until time.Time) tea.Cmd {
+	return func() tea.Msg {
+		err := pers.SnoozeTask(db, id, until)
+		return taskSnoozedMsg{id, err}
+	}
+}
+
+func unsnoozeTask(db *sql.DB, task types.Task) tea.Cmd {
+	return func() tea.Msg {
+		err := pers.UnsnoozeTask(db, task.ID)
+		return taskUnsnoozedMsg{task, err}
+	}
+}
+
+func scheduleSnapshot(delay time.Duration, gen int) tea.Cmd {
+	return tea.Tick(delay, func(time.Time) tea.Msg {
+		return snapshotDueMsg{gen}
+	})
+}
+
+func takeSnapshot(snapshot func() error) tea.Cmd {
+	return func() tea.Msg {
+		return snapshotTakenMsg{snapshot()}
+	}
+}
+
+func scheduleSnoozedTasksWakeUp(interval time.Duration) tea.Cmd {
+	return tea.Tick(interval, func(time.Time) tea.Msg {
+		return snoozedTasksDueMsg{}
+	})
+}
+
+func wakeSnoozedTasks(db *sql.DB, now time.Time) tea.Cmd {
+	return func() tea.Msg {
+		tasks, err := pers.WakeSnoozedTasks(db, now)
+		return snoozedTasksWokenMsg{tasks, err}
+	}
+}
+
 func openTextEditor(fPath string, editorCmd []string, taskIndex int, taskID uint64, oldContext *string) tea.Cmd {
 	c := exec.Command(editorCmd[0], append(editorCmd[1:], fPath)...)
 
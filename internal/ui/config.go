@@ -1,5 +1,16 @@
 package ui
 
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/dhth/omm/internal/hooks"
+	"github.com/dhth/omm/internal/ui/theme"
+	"github.com/dhth/omm/internal/views"
+)
+
 type ListDensityType uint8
 
 const (
@@ -12,8 +23,55 @@ const (
 	SpaciousDensityVal = "spacious"
 )
 
+// FilterModeType determines what the task lists' filter matches against.
+type FilterModeType uint8
+
+const (
+	FilterPrefix FilterModeType = iota
+	FilterSummary
+	FilterSummaryAndContext
+)
+
+const (
+	FilterPrefixVal  = "prefix"
+	FilterSummaryVal = "summary"
+	FilterContextVal = "context"
+)
+
+// DefaultBoardColumns are the columns of the board; the last one holds archived
+// tasks.
+const DefaultBoardColumns = "todo,doing,blocked,done"
+
+var (
+	ErrBoardColumnsTooFew   = errors.New("board needs at least 2 columns")
+	ErrBoardColumnEmpty     = errors.New("board column name is empty")
+	ErrBoardColumnDuplicate = errors.New("board column is repeated")
+)
+
+// ParseBoardColumns parses a comma separated list of board columns.
+func ParseBoardColumns(value string) ([]string, error) {
+	var columns []string
+	for c := range strings.SplitSeq(value, ",") {
+		c = strings.TrimSpace(c)
+		if c == "" {
+			return nil, ErrBoardColumnEmpty
+		}
+		if slices.Contains(columns, c) {
+			return nil, fmt.Errorf("%w: %q", ErrBoardColumnDuplicate, c)
+		}
+		columns = append(columns, c)
+	}
+
+	if len(columns) < 2 {
+		return nil, ErrBoardColumnsTooFew
+	}
+
+	return columns, nil
+}
+
 type Config struct {
 	ListDensity           ListDensityType
+	FilterMode            FilterModeType
 	TaskListTitle         string
 	TextEditorCmd         []string
 	Guide                 bool
@@ -21,4 +79,12 @@ type Config struct {
 	ShowContext           bool
 	ConfirmBeforeDeletion bool
 	CircularNav           bool
+	BoardColumns          []string
+	Hooks                 hooks.Config
+	Views                 []views.View
+	PrefixColors          theme.PrefixColors
+	ReadOnly              bool
+	// Snapshot, if set, is called (in the background) shortly after tasks are
+	// changed; it's used to commit snapshots of tasks to a git repository
+	Snapshot func() error
 }
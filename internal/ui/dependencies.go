@@ -0,0 +1,164 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/dhth/omm/internal/types"
+)
+
+const blockerPickTitle = "blocked by ?"
+
+// getLinkedTaskIDs returns the tasks blocking a task, and the ones it blocks.
+func getLinkedTaskIDs(dependencies []types.TaskDependency, id uint64) ([]uint64, []uint64) {
+	var blockers, dependents []uint64
+	for _, d := range dependencies {
+		switch id {
+		case d.TaskID:
+			blockers = append(blockers, d.BlockerID)
+		case d.BlockerID:
+			dependents = append(dependents, d.TaskID)
+		}
+	}
+
+	return blockers, dependents
+}
+
+// updateBlockedTasks works out which tasks are blocked by an active task
+// (snoozed ones included); a task stops being blocked once all of its blockers
+// are archived.
+func (m *Model) updateBlockedTasks() {
+	// the map is shared with list delegates, so it's updated in place
+	for id := range m.blockedTasks {
+		delete(m.blockedTasks, id)
+	}
+
+	for _, d := range m.dependencies {
+		if m.isTaskOpen(d.BlockerID) {
+			m.blockedTasks[d.TaskID] = true
+		}
+	}
+}
+
+// getOpenDependents returns the active tasks (snoozed ones included) blocked by
+// a task.
+func (m Model) getOpenDependents(id uint64) []uint64 {
+	_, dependents := getLinkedTaskIDs(m.dependencies, id)
+
+	return slices.DeleteFunc(dependents, func(d uint64) bool {
+		return !m.isTaskOpen(d)
+	})
+}
+
+// getTaskByID returns a task from the active, the archived, or the snoozed task
+// list.
+func (m Model) getTaskByID(id uint64) (types.Task, bool) {
+	if index, ok := m.tlIndexMap[id]; ok && index < len(m.taskList.Items()) {
+		t, ok := m.taskList.Items()[index].(types.Task)
+		return t, ok
+	}
+
+	if index, ok := m.atlIndexMap[id]; ok && index < len(m.archivedTaskList.Items()) {
+		t, ok := m.archivedTaskList.Items()[index].(types.Task)
+		return t, ok
+	}
+
+	if index, ok := m.stlIndexMap[id]; ok && index < len(m.snoozedTaskList.Items()) {
+		t, ok := m.snoozedTaskList.Items()[index].(types.Task)
+		return t, ok
+	}
+
+	return types.Task{}, false
+}
+
+// pickBlocker is called for the task selected in the active task list; the
+// first call starts picking a blocker for it, and the next one (on another
+// task) links the two, or unlinks them if they already are.
+func (m *Model) pickBlocker(t types.Task) tea.Cmd {
+	if m.dependencyTaskID == 0 {
+		m.dependencyTaskID = t.ID
+		m.resetTaskListTitle()
+		return nil
+	}
+
+	taskID := m.dependencyTaskID
+	m.stopPickingBlocker()
+	if t.ID == taskID {
+		return nil
+	}
+
+	if slices.Contains(m.dependencies, types.TaskDependency{TaskID: taskID, BlockerID: t.ID}) {
+		return removeTaskDependency(m.db, taskID, t.ID)
+	}
+
+	return addTaskDependency(m.db, taskID, t.ID, time.Now())
+}
+
+func (m *Model) stopPickingBlocker() {
+	m.dependencyTaskID = 0
+	m.resetTaskListTitle()
+}
+
+// resetTaskListTitle sets the title of the active task list back to what it
+// is when no prompt is shown.
+func (m *Model) resetTaskListTitle() {
+	if m.dependencyTaskID != 0 {
+		m.taskList.Title = blockerPickTitle
+		m.taskList.Styles.Title = m.styles.bookmarksListTitleBar
+		return
+	}
+
+	m.taskList.Title = m.cfg.TaskListTitle
+	m.taskList.Styles.Title = m.styles.activeListTitleBar
+}
+
+// getLinkedTasksDetails returns the section of the task details pane that
+// lists the tasks linked to a task (via dependencies, or by referencing it via
+// its URI), and records the order they're numbered in.
+func (m *Model) getLinkedTasksDetails(task types.Task) string {
+	blockers, dependents := getLinkedTaskIDs(m.dependencies, task.ID)
+	m.linkedTaskIDs = nil
+
+	var sb strings.Builder
+	writeSection := func(title string, ids []uint64) {
+		var lines []string
+		for _, id := range ids {
+			t, ok := m.getTaskByID(id)
+			if !ok {
+				continue
+			}
+
+			m.linkedTaskIDs = append(m.linkedTaskIDs, id)
+			line := fmt.Sprintf("- [%d] %s", len(m.linkedTaskIDs), t.Summary)
+			if _, snoozed := m.stlIndexMap[id]; snoozed {
+				line += " (snoozed)"
+			} else if _, active := m.tlIndexMap[id]; !active {
+				line += " (archived)"
+			}
+			lines = append(lines, line)
+		}
+
+		if len(lines) > 0 {
+			fmt.Fprintf(&sb, "### %s\n\n%s\n\n", title, strings.Join(lines, "\n"))
+		}
+	}
+
+	writeSection("blocked by", blockers)
+	writeSection("blocks", dependents)
+	writeSection("referenced by", getBacklinkIDs(m.uriRegex, m.getAllTasks(), task.ID))
+
+	return sb.String()
+}
+
+// showLinkedTask shows the nth task listed in the task details pane's linked
+// tasks section in the pane, selecting it in the list it's in.
+func (m *Model) showLinkedTask(n int) {
+	if n < 1 || n > len(m.linkedTaskIDs) {
+		return
+	}
+
+	m.jumpToTask(m.linkedTaskIDs[n-1])
+}
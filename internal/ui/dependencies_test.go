@@ -0,0 +1,77 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/dhth/omm/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetLinkedTaskIDs(t *testing.T) {
+	dependencies := []types.TaskDependency{
+		{TaskID: 2, BlockerID: 1},
+		{TaskID: 3, BlockerID: 1},
+		{TaskID: 3, BlockerID: 2},
+	}
+
+	testCases := []struct {
+		name               string
+		id                 uint64
+		expectedBlockers   []uint64
+		expectedDependents []uint64
+	}{
+		{
+			name:               "task blocking others",
+			id:                 1,
+			expectedDependents: []uint64{2, 3},
+		},
+		{
+			name:               "task both blocked and blocking",
+			id:                 2,
+			expectedBlockers:   []uint64{1},
+			expectedDependents: []uint64{3},
+		},
+		{
+			name:             "blocked task",
+			id:               3,
+			expectedBlockers: []uint64{1, 2},
+		},
+		{
+			name: "task without links",
+			id:   4,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			// WHEN
+			blockers, dependents := getLinkedTaskIDs(dependencies, tt.id)
+
+			// THEN
+			assert.Equal(t, tt.expectedBlockers, blockers)
+			assert.Equal(t, tt.expectedDependents, dependents)
+		})
+	}
+}
+
+func TestUpdateBlockedTasksOnlyCountsActiveBlockers(t *testing.T) {
+	// GIVEN
+	blockedTasks := map[uint64]bool{5: true}
+	m := Model{
+		blockedTasks: blockedTasks,
+		dependencies: []types.TaskDependency{
+			{TaskID: 2, BlockerID: 1},
+			{TaskID: 3, BlockerID: 4},
+			{TaskID: 6, BlockerID: 7},
+		},
+		tlIndexMap:  map[uint64]int{1: 0, 2: 1, 3: 2, 6: 3},
+		stlIndexMap: map[uint64]int{7: 0},
+	}
+
+	// WHEN
+	m.updateBlockedTasks()
+
+	// THEN
+	assert.Equal(t, map[uint64]bool{2: true, 6: true}, blockedTasks, "the map shared with list delegates should be updated in place")
+}
@@ -0,0 +1,260 @@
+package ui
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"charm.land/bubbles/v2/list"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/dhth/omm/internal/types"
+)
+
+// getFilterTarget returns the part of a task that the filter matches against:
+// its prefix, its summary, or its summary followed by its context on the next
+// line. Since the summary comes first, the positions of matches in it are the
+// same as in the target.
+func getFilterTarget(t types.Task, mode FilterModeType) string {
+	switch mode {
+	case FilterSummary:
+		return t.Summary
+	case FilterSummaryAndContext:
+		if t.Context == nil {
+			return t.Summary
+		}
+		return t.Summary + "\n" + *t.Context
+	default:
+		prefix, _, found := strings.Cut(t.Summary, types.PrefixDelimiter)
+		if !found || strings.TrimSpace(prefix) == "" {
+			return ""
+		}
+		return prefix
+	}
+}
+
+// taskFilter fuzzy matches the tasks of a task list based on mode, ranking the
+// best matches first. Lists only hand their filters the tasks' filter values
+// (summaries), so when the context is needed as well, tasks are looked up
+// among the list's items as of the model's last update (see setItems).
+type taskFilter struct {
+	mode  FilterModeType
+	mu    sync.Mutex
+	items []list.Item
+}
+
+func newTaskFilter(mode FilterModeType) *taskFilter {
+	return &taskFilter{mode: mode}
+}
+
+// setItems records the items of the list the filter belongs to. Filtering
+// happens in a command, which can run after the list's items have changed
+// again; filter values that don't line up with these items are only matched
+// by their summaries.
+func (f *taskFilter) setItems(items []list.Item) {
+	if f == nil || f.mode != FilterSummaryAndContext {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.items = items
+}
+
+func (f *taskFilter) filter(term string, targets []string) []list.Rank {
+	var items []list.Item
+	if f.mode == FilterSummaryAndContext {
+		f.mu.Lock()
+		items = f.items
+		f.mu.Unlock()
+	}
+
+	filterTargets := make([]string, len(targets))
+	for i, summary := range targets {
+		t := types.Task{Summary: summary}
+		if i < len(items) {
+			item, ok := items[i].(types.Task)
+			if ok && item.Summary == summary {
+				t = item
+			}
+		}
+		filterTargets[i] = getFilterTarget(t, f.mode)
+	}
+
+	return list.DefaultFilter(term, filterTargets)
+}
+
+// getSummaryMatches splits the byte offsets of matches in a task's summary into
+// those in its prefix and those in the rest of the summary, relative to the
+// (trimmed) strings returned by GetPrefixAndSummaryContent.
+func getSummaryMatches(summary string, matches []int) ([]int, []int) {
+	if len(matches) == 0 {
+		return nil, nil
+	}
+
+	bodyStart := 0
+	var prefixMatches []int
+	rawPrefix, rawBody, found := strings.Cut(summary, types.PrefixDelimiter)
+	if found {
+		prefixStart := len(rawPrefix) - len(strings.TrimLeft(rawPrefix, " \t"))
+		prefixEnd := len(strings.TrimRight(rawPrefix, " \t"))
+		bodyStart = len(rawPrefix) + len(types.PrefixDelimiter) + len(rawBody) - len(strings.TrimLeft(rawBody, " \t"))
+
+		for _, i := range matches {
+			if i >= prefixStart && i < prefixEnd {
+				prefixMatches = append(prefixMatches, i-prefixStart)
+			}
+		}
+	}
+
+	var bodyMatches []int
+	for _, i := range matches {
+		if i >= bodyStart && i < len(summary) {
+			bodyMatches = append(bodyMatches, i-bodyStart)
+		}
+	}
+
+	return prefixMatches, bodyMatches
+}
+
+// highlightMatches renders s with the characters at the given byte offsets
+// highlighted; offsets that don't fall within the first limit bytes of s (which
+// is where it might have been truncated) are ignored.
+func highlightMatches(s string, matches []int, limit int, style lipgloss.Style) string {
+	if len(matches) == 0 {
+		return style.Render(s)
+	}
+
+	var runeIndexes []int
+	for _, i := range matches {
+		if i >= limit || i >= len(s) {
+			continue
+		}
+		runeIndexes = append(runeIndexes, utf8.RuneCountInString(s[:i]))
+	}
+
+	return lipgloss.StyleRunes(s, runeIndexes, style.Underline(true), style)
+}
+
+// selectedTaskIndex returns the selected task of a task list, and its index
+// among all of the list's items (which is different from the cursor's index
+// when the list is filtered).
+func (m Model) selectedTaskIndex(listType taskListType) (types.Task, int, bool) {
+	taskList := &m.taskList
+	indexMap := m.tlIndexMap
+	if listType == archivedTasks {
+		taskList = &m.archivedTaskList
+		indexMap = m.atlIndexMap
+	}
+
+	t, ok := taskList.SelectedItem().(types.Task)
+	if !ok {
+		return t, 0, false
+	}
+
+	if !taskList.IsFiltered() {
+		return t, taskList.Index(), true
+	}
+
+	index, ok := indexMap[t.ID]
+	return t, index, ok
+}
+
+// refilterList applies a list's filter again after its items change, keeping
+// the cursor at the same position among the filtered items; the filter is reset
+// if nothing matches it anymore.
+func refilterList(l *list.Model) {
+	if l.FilterState() == list.Unfiltered {
+		return
+	}
+
+	index := l.Index()
+	l.SetFilterText(l.FilterValue())
+
+	numVisible := len(l.VisibleItems())
+	if numVisible == 0 {
+		l.ResetFilter()
+		return
+	}
+
+	l.Select(min(index, numVisible-1))
+}
+
+// selectVisibleTask moves the cursor of a list to a task, if it's visible.
+func selectVisibleTask(l *list.Model, id uint64) {
+	for i, item := range l.VisibleItems() {
+		t, ok := item.(types.Task)
+		if ok && t.ID == id {
+			l.Select(i)
+			return
+		}
+	}
+}
+
+// getMoveIndex returns the index a task at index from needs to be inserted at
+// (once it's been removed) to end up right below the task at index neighbour
+// (or right above it, if below is false).
+func getMoveIndex(from, neighbour int, below bool) int {
+	if neighbour > from {
+		neighbour--
+	}
+
+	if below {
+		return neighbour + 1
+	}
+
+	return neighbour
+}
+
+// getFilteredNeighbour returns the ID of the visible task offset positions
+// away from the task with the ID id, going by the order of the task list; the
+// visible items themselves are ranked by how well they match the filter.
+func getFilteredNeighbour(visible []list.Item, tlIndexMap map[uint64]int, id uint64, offset int) (uint64, bool) {
+	ids := make([]uint64, 0, len(visible))
+	for _, item := range visible {
+		t, ok := item.(types.Task)
+		if ok {
+			ids = append(ids, t.ID)
+		}
+	}
+	slices.SortFunc(ids, func(a, b uint64) int {
+		return cmp.Compare(tlIndexMap[a], tlIndexMap[b])
+	})
+
+	i := slices.Index(ids, id)
+	ni := i + offset
+	if i < 0 || ni < 0 || ni >= len(ids) {
+		return 0, false
+	}
+
+	return ids[ni], true
+}
+
+// moveFilteredTask moves the selected task of the filtered task list past the
+// visible task below it in the task list (or above it, if offset is negative);
+// ie, the task is placed right after (or before) that task.
+func (m *Model) moveFilteredTask(offset int) tea.Cmd {
+	t, ok := m.taskList.SelectedItem().(types.Task)
+	if !ok {
+		return nil
+	}
+
+	neighbourID, ok := getFilteredNeighbour(m.taskList.VisibleItems(), m.tlIndexMap, t.ID, offset)
+	if !ok {
+		return nil
+	}
+
+	from := m.tlIndexMap[t.ID]
+	to := getMoveIndex(from, m.tlIndexMap[neighbourID], offset > 0)
+
+	m.taskList.RemoveItem(from)
+	m.taskList.InsertItem(to, t)
+	cmd := m.updateActiveTasksSequence()
+
+	refilterList(&m.taskList)
+	selectVisibleTask(&m.taskList, t.ID)
+
+	return cmd
+}
@@ -0,0 +1,253 @@
+package ui
+
+import (
+	"slices"
+	"testing"
+
+	"charm.land/bubbles/v2/list"
+	"github.com/dhth/omm/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetFilterTarget(t *testing.T) {
+	context := "some context"
+	withContext := types.Task{Summary: "work: fix the build", Context: &context}
+	withoutPrefix := types.Task{Summary: "fix the build"}
+
+	testCases := []struct {
+		name     string
+		task     types.Task
+		mode     FilterModeType
+		expected string
+	}{
+		{
+			name:     "prefix mode",
+			task:     withContext,
+			mode:     FilterPrefix,
+			expected: "work",
+		},
+		{
+			name:     "prefix mode for a task without a prefix",
+			task:     withoutPrefix,
+			mode:     FilterPrefix,
+			expected: "",
+		},
+		{
+			name:     "summary mode",
+			task:     withContext,
+			mode:     FilterSummary,
+			expected: "work: fix the build",
+		},
+		{
+			name:     "summary mode for a task without a prefix",
+			task:     withoutPrefix,
+			mode:     FilterSummary,
+			expected: "fix the build",
+		},
+		{
+			name:     "context mode",
+			task:     withContext,
+			mode:     FilterSummaryAndContext,
+			expected: "work: fix the build\nsome context",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			// WHEN
+			got := getFilterTarget(tt.task, tt.mode)
+
+			// THEN
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestTaskFilterRanksBetterMatchesFirst(t *testing.T) {
+	// GIVEN
+	tasks := getTasksWithSummaries("home: bake bread", "work: release build", "build: fix flaky test")
+	targets := make([]string, len(tasks))
+	for i, t := range tasks {
+		targets[i] = t.FilterValue()
+	}
+
+	// WHEN
+	got := newTaskFilter(FilterSummary).filter("build", targets)
+
+	// THEN
+	indexes := make([]int, len(got))
+	for i, r := range got {
+		indexes[i] = r.Index
+	}
+	assert.Equal(t, []int{2, 1}, indexes)
+}
+
+func TestTaskFilterMatchesContextsOfTheListsItems(t *testing.T) {
+	// GIVEN
+	tasks := getTasksWithSummaries("task 1", "task 2", "task 3")
+	context := "deploy to staging"
+	tasks[1].Context = &context
+	tasks[2].Context = &context
+
+	items := make([]list.Item, len(tasks))
+	targets := make([]string, len(tasks))
+	for i, t := range tasks {
+		items[i] = t
+		targets[i] = t.FilterValue()
+	}
+
+	f := newTaskFilter(FilterSummaryAndContext)
+	// the third item has changed since the filter values were computed
+	items[2] = getTasksWithSummaries("task 3 (edited)")[0]
+	f.setItems(items)
+
+	// WHEN
+	got := f.filter("staging", targets)
+
+	// THEN
+	require.Len(t, got, 1)
+	assert.Equal(t, 1, got[0].Index)
+}
+
+func TestGetSummaryMatches(t *testing.T) {
+	// GIVEN
+	summary := "work:  fix it"
+	// matches for "wfi", and one in the context following the summary
+	matches := []int{0, 7, 8, 20}
+
+	// WHEN
+	prefixMatches, bodyMatches := getSummaryMatches(summary, matches)
+
+	// THEN
+	assert.Equal(t, []int{0}, prefixMatches)
+	assert.Equal(t, []int{0, 1}, bodyMatches)
+}
+
+func TestGetMoveIndex(t *testing.T) {
+	testCases := []struct {
+		name      string
+		from      int
+		neighbour int
+		below     bool
+		expected  int
+	}{
+		{
+			name:      "moving below a task further down",
+			from:      1,
+			neighbour: 4,
+			below:     true,
+			expected:  4,
+		},
+		{
+			name:      "moving below a task further up",
+			from:      4,
+			neighbour: 1,
+			below:     true,
+			expected:  2,
+		},
+		{
+			name:      "moving above a task further up",
+			from:      4,
+			neighbour: 1,
+			expected:  1,
+		},
+		{
+			name:      "moving above a task further down",
+			from:      1,
+			neighbour: 4,
+			expected:  3,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			items := []int{0, 1, 2, 3, 4, 5}
+			moved := items[tt.from]
+			neighbour := items[tt.neighbour]
+
+			// WHEN
+			got := getMoveIndex(tt.from, tt.neighbour, tt.below)
+
+			// THEN
+			assert.Equal(t, tt.expected, got)
+			rest := append(append([]int{}, items[:tt.from]...), items[tt.from+1:]...)
+			result := append(append(append([]int{}, rest[:got]...), moved), rest[got:]...)
+			neighbourIndex := slices.Index(result, neighbour)
+			if tt.below {
+				assert.Equal(t, neighbourIndex+1, got)
+			} else {
+				assert.Equal(t, neighbourIndex-1, got)
+			}
+		})
+	}
+}
+
+func TestGetFilteredNeighbourGoesByTaskListOrder(t *testing.T) {
+	tasks := getTasksWithSummaries("home: bake bread", "work: release build", "build: fix flaky test", "misc: build docs")
+	tlIndexMap := make(map[uint64]int)
+	targets := make([]string, len(tasks))
+	for i, t := range tasks {
+		tlIndexMap[t.ID] = i
+		targets[i] = t.FilterValue()
+	}
+
+	// the filter ranks the best matches first, which isn't the task list's order
+	var visible []list.Item
+	for _, r := range newTaskFilter(FilterSummary).filter("build", targets) {
+		visible = append(visible, tasks[r.Index])
+	}
+	require.Equal(t, tasks[2], visible[0])
+
+	testCases := []struct {
+		name     string
+		id       uint64
+		offset   int
+		expected uint64
+		ok       bool
+	}{
+		{
+			name:     "moving up",
+			id:       3,
+			offset:   -1,
+			expected: 2,
+			ok:       true,
+		},
+		{
+			name:     "moving down",
+			id:       3,
+			offset:   1,
+			expected: 4,
+			ok:       true,
+		},
+		{
+			name:   "moving up from the top",
+			id:     2,
+			offset: -1,
+		},
+		{
+			name:   "moving down from the bottom",
+			id:     4,
+			offset: 1,
+		},
+		{
+			name:   "task that isn't visible",
+			id:     1,
+			offset: 1,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			// WHEN
+			got, ok := getFilteredNeighbour(visible, tlIndexMap, tt.id, tt.offset)
+
+			// THEN
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
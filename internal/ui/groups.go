@@ -0,0 +1,304 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+
+	"charm.land/bubbles/v2/list"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/dhth/omm/internal/types"
+	"github.com/dhth/omm/internal/ui/theme"
+)
+
+const (
+	noPrefixGroupTitle = "no prefix"
+	groupedListSuffix  = " (by prefix)"
+)
+
+// taskGroup holds the active tasks that share a prefix, in the order they
+// appear in the task list.
+type taskGroup struct {
+	prefix string
+	tasks  []types.Task
+}
+
+// groupHeader is the list item shown at the top of a group in the grouped task
+// list.
+type groupHeader struct {
+	prefix    string
+	numTasks  int
+	collapsed bool
+}
+
+func (h groupHeader) FilterValue() string { return h.prefix }
+
+// getTaskGroups groups tasks by prefix; groups are ordered by where their first
+// task appears.
+func getTaskGroups(tasks []types.Task) []taskGroup {
+	var groups []taskGroup
+	groupIndex := make(map[string]int)
+
+	for _, t := range tasks {
+		prefix := ""
+		p, ok := t.Prefix()
+		if ok {
+			prefix = string(p)
+		}
+
+		i, ok := groupIndex[prefix]
+		if !ok {
+			i = len(groups)
+			groupIndex[prefix] = i
+			groups = append(groups, taskGroup{prefix: prefix})
+		}
+		groups[i].tasks = append(groups[i].tasks, t)
+	}
+
+	return groups
+}
+
+// getGroupedItems returns the items to show in the grouped task list; tasks in
+// collapsed groups are left out.
+func getGroupedItems(groups []taskGroup, collapsed map[string]bool) []list.Item {
+	var items []list.Item
+	for _, g := range groups {
+		items = append(items, groupHeader{g.prefix, len(g.tasks), collapsed[g.prefix]})
+		if collapsed[g.prefix] {
+			continue
+		}
+		for _, t := range g.tasks {
+			items = append(items, t)
+		}
+	}
+
+	return items
+}
+
+// moveGroup moves the group at index by offset positions; it returns false if
+// the group can't be moved that far.
+func moveGroup(groups []taskGroup, index, offset int) bool {
+	newIndex := index + offset
+	if index < 0 || index >= len(groups) || newIndex < 0 || newIndex >= len(groups) {
+		return false
+	}
+
+	g := groups[index]
+	if offset > 0 {
+		copy(groups[index:newIndex], groups[index+1:newIndex+1])
+	} else {
+		copy(groups[newIndex+1:index+1], groups[newIndex:index])
+	}
+	groups[newIndex] = g
+
+	return true
+}
+
+// flattenGroups returns the tasks in groups, one group after the other.
+func flattenGroups(groups []taskGroup) []types.Task {
+	var tasks []types.Task
+	for _, g := range groups {
+		tasks = append(tasks, g.tasks...)
+	}
+
+	return tasks
+}
+
+type groupedItemDelegate struct {
+	selStyle           lipgloss.Style
+	mutedStyle         lipgloss.Style
+	prefixColors       []string
+	pinnedPrefixColors map[string]string
+	tagColors          map[string]string
+	blockedTasks       map[uint64]bool
+}
+
+func (d groupedItemDelegate) Height() int { return 1 }
+
+func (d groupedItemDelegate) Spacing() int { return 1 }
+
+func (d groupedItemDelegate) Update(_ tea.Msg, _ *list.Model) tea.Cmd { return nil }
+
+func (d groupedItemDelegate) Render(w io.Writer, m list.Model, index int, listItem list.Item) {
+	sr := d.selStyle.Render
+	marker := "  "
+	if index == m.Index() {
+		marker = sr("▎ ")
+	}
+
+	switch item := listItem.(type) {
+	case groupHeader:
+		indicator := "▾"
+		if item.collapsed {
+			indicator = "▸"
+		}
+
+		title := d.mutedStyle.Render(noPrefixGroupTitle)
+		if item.prefix != "" {
+			title = lipgloss.NewStyle().
+				Foreground(getPrefixColor(item.prefix, d.pinnedPrefixColors, d.prefixColors)).
+				Bold(true).
+				Render(item.prefix)
+		}
+
+		fmt.Fprintf(w, "%s%s %s %s", marker, indicator, title, d.mutedStyle.Render(fmt.Sprintf("(%d)", item.numTasks)))
+
+	case types.Task:
+		_, sc, _ := item.GetPrefixAndSummaryContent()
+		summaryWidth := taskSummaryWidth - prefixPadding
+
+		indicators := getTaskIndicators(item, d.blockedTasks)
+
+		if index == m.Index() {
+			fmt.Fprintf(w, "%s    %s%s", marker, renderTaskSummary(sc, nil, summaryWidth, d.selStyle, d.tagColors, d.prefixColors), sr(indicators))
+			return
+		}
+		fmt.Fprintf(w, "%s    %s%s", marker, renderTaskSummary(sc, nil, summaryWidth, lipgloss.NewStyle(), d.tagColors, d.prefixColors), indicators)
+	}
+}
+
+func newGroupedTaskListDelegate(thm theme.Theme, tagColors map[string]string, blockedTasks map[uint64]bool) list.ItemDelegate {
+	return groupedItemDelegate{
+		selStyle:           lipgloss.NewStyle().Foreground(lipgloss.Color(thm.Primary)),
+		mutedStyle:         lipgloss.NewStyle().Foreground(lipgloss.Color(thm.Muted)),
+		prefixColors:       thm.PrefixColors,
+		pinnedPrefixColors: thm.PinnedPrefixColors,
+		tagColors:          tagColors,
+		blockedTasks:       blockedTasks,
+	}
+}
+
+// refreshGroupedTaskList rebuilds the grouped task list from the active task
+// list, keeping the cursor on the item it was on (if it's still present).
+func (m *Model) refreshGroupedTaskList() {
+	selected := m.groupedTaskList.SelectedItem()
+
+	items := getGroupedItems(m.getActiveTaskGroups(), m.collapsedGroups)
+	m.groupedTaskList.SetItems(items)
+
+	for i, item := range items {
+		if sameGroupedItem(item, selected) {
+			m.groupedTaskList.Select(i)
+			return
+		}
+	}
+
+	if m.groupedTaskList.Index() >= len(items) {
+		m.groupedTaskList.Select(max(len(items)-1, 0))
+	}
+}
+
+func (m Model) getActiveTaskGroups() []taskGroup {
+	return getTaskGroups(getTasks(m.taskList.Items()))
+}
+
+// selectedGroupPrefix returns the prefix of the group the cursor is in, in the
+// grouped task list.
+func (m Model) selectedGroupPrefix() (string, bool) {
+	switch item := m.groupedTaskList.SelectedItem().(type) {
+	case groupHeader:
+		return item.prefix, true
+	case types.Task:
+		p, _ := item.Prefix()
+		return string(p), true
+	}
+
+	return "", false
+}
+
+func sameGroupedItem(a, b list.Item) bool {
+	switch a := a.(type) {
+	case groupHeader:
+		b, ok := b.(groupHeader)
+		return ok && a.prefix == b.prefix
+	case types.Task:
+		b, ok := b.(types.Task)
+		return ok && a.ID == b.ID
+	}
+
+	return false
+}
+
+// selectGroupedTask moves the cursor in the grouped task list to a task, or to
+// the header of its group if the group is collapsed.
+func (m *Model) selectGroupedTask(task types.Task) {
+	for i, item := range m.groupedTaskList.Items() {
+		t, ok := item.(types.Task)
+		if ok && t.ID == task.ID {
+			m.groupedTaskList.Select(i)
+			return
+		}
+	}
+
+	p, _ := task.Prefix()
+	m.selectGroupHeader(string(p))
+}
+
+func (m *Model) selectGroupHeader(prefix string) {
+	for i, item := range m.groupedTaskList.Items() {
+		h, ok := item.(groupHeader)
+		if ok && h.prefix == prefix {
+			m.groupedTaskList.Select(i)
+			return
+		}
+	}
+}
+
+// moveSelectedGroup moves the group the cursor is in by offset positions, which
+// places all of its tasks together in the task list.
+func (m *Model) moveSelectedGroup(offset int) tea.Cmd {
+	prefix, ok := m.selectedGroupPrefix()
+	if !ok {
+		return nil
+	}
+
+	groups := m.getActiveTaskGroups()
+	index := -1
+	for i, g := range groups {
+		if g.prefix == prefix {
+			index = i
+			break
+		}
+	}
+
+	if !moveGroup(groups, index, offset) {
+		return nil
+	}
+
+	selected, hasSelection := m.taskList.SelectedItem().(types.Task)
+
+	tasks := flattenGroups(groups)
+	items := make([]list.Item, len(tasks))
+	for i, t := range tasks {
+		items[i] = t
+	}
+	m.taskList.SetItems(items)
+	cmd := m.updateActiveTasksSequence()
+
+	if hasSelection {
+		m.taskList.Select(m.tlIndexMap[selected.ID])
+	}
+
+	m.refreshGroupedTaskList()
+
+	return cmd
+}
+
+// showSelectedGroupedTask switches to the active task list, with the cursor on
+// the task selected in the grouped task list (or the first task of the
+// selected group).
+func (m *Model) showSelectedGroupedTask() {
+	m.activeView = taskListView
+
+	switch item := m.groupedTaskList.SelectedItem().(type) {
+	case types.Task:
+		m.taskList.Select(m.tlIndexMap[item.ID])
+	case groupHeader:
+		for _, g := range m.getActiveTaskGroups() {
+			if g.prefix == item.prefix && len(g.tasks) > 0 {
+				m.taskList.Select(m.tlIndexMap[g.tasks[0].ID])
+				return
+			}
+		}
+	}
+}
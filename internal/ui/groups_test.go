@@ -0,0 +1,122 @@
+package ui
+
+import (
+	"testing"
+
+	"charm.land/bubbles/v2/list"
+	"github.com/dhth/omm/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func getTasksWithSummaries(summaries ...string) []types.Task {
+	tasks := make([]types.Task, len(summaries))
+	for i, s := range summaries {
+		tasks[i] = types.Task{ID: uint64(i + 1), Summary: s, Active: true}
+	}
+
+	return tasks
+}
+
+func getGroupSummaries(groups []taskGroup) map[string][]string {
+	summaries := make(map[string][]string)
+	for _, g := range groups {
+		for _, t := range g.tasks {
+			summaries[g.prefix] = append(summaries[g.prefix], t.Summary)
+		}
+	}
+
+	return summaries
+}
+
+func TestGetTaskGroups(t *testing.T) {
+	// GIVEN
+	tasks := getTasksWithSummaries("work: a", "home: b", "c", "work: d", "home: e")
+
+	// WHEN
+	got := getTaskGroups(tasks)
+
+	// THEN
+	prefixes := make([]string, len(got))
+	for i, g := range got {
+		prefixes[i] = g.prefix
+	}
+	assert.Equal(t, []string{"work", "home", ""}, prefixes)
+	assert.Equal(t, map[string][]string{
+		"work": {"work: a", "work: d"},
+		"home": {"home: b", "home: e"},
+		"":     {"c"},
+	}, getGroupSummaries(got))
+}
+
+func TestGetGroupedItemsLeavesOutCollapsedGroups(t *testing.T) {
+	// GIVEN
+	tasks := getTasksWithSummaries("work: a", "home: b", "work: c")
+	groups := getTaskGroups(tasks)
+
+	// WHEN
+	got := getGroupedItems(groups, map[string]bool{"work": true})
+
+	// THEN
+	expected := []list.Item{
+		groupHeader{prefix: "work", numTasks: 2, collapsed: true},
+		groupHeader{prefix: "home", numTasks: 1},
+		tasks[1],
+	}
+	assert.Equal(t, expected, got)
+}
+
+func TestMoveGroup(t *testing.T) {
+	testCases := []struct {
+		name             string
+		index            int
+		offset           int
+		expectedMoved    bool
+		expectedPrefixes []string
+	}{
+		{
+			name:             "moving down",
+			index:            0,
+			offset:           1,
+			expectedMoved:    true,
+			expectedPrefixes: []string{"home", "work", "misc"},
+		},
+		{
+			name:             "moving up",
+			index:            2,
+			offset:           -2,
+			expectedMoved:    true,
+			expectedPrefixes: []string{"misc", "work", "home"},
+		},
+		{
+			name:             "moving beyond the end",
+			index:            2,
+			offset:           1,
+			expectedPrefixes: []string{"work", "home", "misc"},
+		},
+		{
+			name:             "moving beyond the start",
+			index:            0,
+			offset:           -1,
+			expectedPrefixes: []string{"work", "home", "misc"},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			groups := getTaskGroups(getTasksWithSummaries("work: a", "home: b", "work: c", "misc: d"))
+
+			// WHEN
+			moved := moveGroup(groups, tt.index, tt.offset)
+
+			// THEN
+			assert.Equal(t, tt.expectedMoved, moved)
+			prefixes := make([]string, len(groups))
+			for i, g := range groups {
+				prefixes[i] = g.prefix
+			}
+			assert.Equal(t, tt.expectedPrefixes, prefixes)
+			assert.Len(t, flattenGroups(groups), 4)
+		})
+	}
+}
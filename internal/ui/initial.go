@@ -12,17 +12,22 @@ import (
 )
 
 func InitialModel(db *sql.DB, config Config, thm theme.Theme) Model {
+	thm.PinnedPrefixColors = config.PrefixColors.For(thm.Name)
 	styles := newStyles(thm)
+	tagColors := make(map[string]string)
+	blockedTasks := make(map[uint64]bool)
 
 	taskItems := make([]list.Item, 0)
 
 	taskList := list.New(taskItems,
-		newTaskListDelegate(thm, config.ListDensity, activeTasks),
+		newTaskListDelegate(thm, config.ListDensity, activeTasks, tagColors, blockedTasks),
 		taskSummaryWidth,
 		defaultListHeight,
 	)
 	taskList.Title = config.TaskListTitle
 	taskList.SetFilteringEnabled(true)
+	tlFilter := newTaskFilter(config.FilterMode)
+	taskList.Filter = tlFilter.filter
 	taskList.SetStatusBarItemName("task", "tasks")
 	taskList.SetShowStatusBar(true)
 	taskList.SetShowHelp(false)
@@ -36,7 +41,7 @@ func InitialModel(db *sql.DB, config Config, thm theme.Theme) Model {
 	archivedTaskItems := make([]list.Item, 0)
 
 	archivedTaskList := list.New(archivedTaskItems,
-		newTaskListDelegate(thm, config.ListDensity, archivedTasks),
+		newTaskListDelegate(thm, config.ListDensity, archivedTasks, tagColors, blockedTasks),
 		taskSummaryWidth,
 		defaultListHeight,
 	)
@@ -44,6 +49,8 @@ func InitialModel(db *sql.DB, config Config, thm theme.Theme) Model {
 	archivedTaskList.SetShowStatusBar(true)
 	archivedTaskList.SetStatusBarItemName("task", "tasks")
 	archivedTaskList.SetFilteringEnabled(true)
+	atlFilter := newTaskFilter(config.FilterMode)
+	archivedTaskList.Filter = atlFilter.filter
 	archivedTaskList.SetShowHelp(false)
 	archivedTaskList.DisableQuitKeybindings()
 	archivedTaskList.KeyMap.PrevPage.SetKeys("left", "h", "pgup")
@@ -81,6 +88,103 @@ func InitialModel(db *sql.DB, config Config, thm theme.Theme) Model {
 
 	prefixSearchList.Styles.Title = styles.prefixListTitleBar
 
+	groupedTaskList := list.New(nil, newGroupedTaskListDelegate(thm, tagColors, blockedTasks), taskSummaryWidth, defaultListHeight)
+
+	groupedTaskList.Title = config.TaskListTitle + groupedListSuffix
+	groupedTaskList.SetShowHelp(false)
+	groupedTaskList.SetShowStatusBar(false)
+	groupedTaskList.SetFilteringEnabled(false)
+	groupedTaskList.DisableQuitKeybindings()
+	groupedTaskList.KeyMap.PrevPage.SetKeys("left", "h", "pgup")
+	groupedTaskList.KeyMap.NextPage.SetKeys("right", "l", "pgdown")
+
+	groupedTaskList.Styles.Title = styles.activeListTitleBar
+
+	savedViewsList := list.New(nil, newSavedViewsListDelegate(thm), taskSummaryWidth, defaultListHeight)
+
+	savedViewsList.Title = savedViewsTitle
+	savedViewsList.SetShowHelp(false)
+	savedViewsList.SetStatusBarItemName("view", "views")
+	savedViewsList.SetFilteringEnabled(false)
+	savedViewsList.DisableQuitKeybindings()
+	savedViewsList.KeyMap.PrevPage.SetKeys("left", "h", "pgup")
+	savedViewsList.KeyMap.NextPage.SetKeys("right", "l", "pgdown")
+
+	savedViewsList.Styles.Title = styles.prefixListTitleBar
+
+	queryResultsList := list.New(nil,
+		newTaskListDelegate(thm, config.ListDensity, activeTasks, tagColors, blockedTasks),
+		taskSummaryWidth,
+		defaultListHeight,
+	)
+	queryResultsList.SetShowHelp(false)
+	queryResultsList.SetStatusBarItemName("task", "tasks")
+	queryResultsList.SetFilteringEnabled(false)
+	queryResultsList.DisableQuitKeybindings()
+	queryResultsList.KeyMap.PrevPage.SetKeys("left", "h", "pgup")
+	queryResultsList.KeyMap.NextPage.SetKeys("right", "l", "pgdown")
+
+	queryResultsList.Styles.Title = styles.activeListTitleBar
+
+	tagsList := list.New(nil, newTagsListDelegate(thm, tagColors), taskSummaryWidth, defaultListHeight)
+
+	tagsList.Title = tagsTitle
+	tagsList.SetShowHelp(false)
+	tagsList.SetStatusBarItemName("tag", "tags")
+	tagsList.SetFilteringEnabled(false)
+	tagsList.DisableQuitKeybindings()
+	tagsList.KeyMap.PrevPage.SetKeys("left", "h", "pgup")
+	tagsList.KeyMap.NextPage.SetKeys("right", "l", "pgdown")
+
+	tagsList.Styles.Title = styles.prefixListTitleBar
+
+	prefixesList := list.New(nil, newPrefixesListDelegate(thm), taskSummaryWidth, defaultListHeight)
+
+	prefixesList.Title = prefixesTitle
+	prefixesList.SetShowHelp(false)
+	prefixesList.SetStatusBarItemName("prefix", "prefixes")
+	prefixesList.SetFilteringEnabled(false)
+	prefixesList.DisableQuitKeybindings()
+	prefixesList.KeyMap.PrevPage.SetKeys("left", "h", "pgup")
+	prefixesList.KeyMap.NextPage.SetKeys("right", "l", "pgdown")
+
+	prefixesList.Styles.Title = styles.prefixListTitleBar
+
+	snoozedTaskList := list.New(nil, newSnoozedTaskListDelegate(thm, tagColors), taskSummaryWidth, defaultListHeight)
+
+	snoozedTaskList.Title = snoozedTitle
+	snoozedTaskList.SetShowHelp(false)
+	snoozedTaskList.SetStatusBarItemName("task", "tasks")
+	snoozedTaskList.SetFilteringEnabled(false)
+	snoozedTaskList.DisableQuitKeybindings()
+	snoozedTaskList.KeyMap.PrevPage.SetKeys("left", "h", "pgup")
+	snoozedTaskList.KeyMap.NextPage.SetKeys("right", "l", "pgdown")
+
+	snoozedTaskList.Styles.Title = styles.bookmarksListTitleBar
+
+	savedViewInput := textinput.New()
+	savedViewInput.Placeholder = "name = prefix:api|infra stale:14d"
+	savedViewInput.CharLimit = savedViewInputMaxLen
+	savedViewInput.SetWidth(taskSummaryWidth)
+
+	queryInput := textinput.New()
+	queryInput.Placeholder = `prefix:infra AND age>7d AND text~"deploy"`
+	queryInput.CharLimit = queryInputMaxLen
+	queryInput.SetWidth(taskSummaryWidth)
+
+	tagInput := textinput.New()
+	tagInput.CharLimit = tagInputMaxLen
+	tagInput.SetWidth(taskSummaryWidth)
+
+	prefixInput := textinput.New()
+	prefixInput.CharLimit = prefixInputMaxLen
+	prefixInput.SetWidth(taskSummaryWidth)
+
+	snoozeInput := textinput.New()
+	snoozeInput.Placeholder = "3h, 2d, 1w, tomorrow, friday, 2026-05-04, 2026-05-04 09:00"
+	snoozeInput.CharLimit = snoozeInputMaxLen
+	snoozeInput.SetWidth(taskSummaryWidth)
+
 	m := Model{
 		db:                db,
 		cfg:               config,
@@ -88,8 +192,24 @@ func InitialModel(db *sql.DB, config Config, thm theme.Theme) Model {
 		styles:            styles,
 		taskList:          taskList,
 		archivedTaskList:  archivedTaskList,
+		tlFilter:          tlFilter,
+		atlFilter:         atlFilter,
 		taskBMList:        contextBMList,
 		prefixSearchList:  prefixSearchList,
+		groupedTaskList:   groupedTaskList,
+		savedViewsList:    savedViewsList,
+		queryResultsList:  queryResultsList,
+		queryInput:        queryInput,
+		savedViewInput:    savedViewInput,
+		tagsList:          tagsList,
+		tagInput:          tagInput,
+		tagColors:         tagColors,
+		blockedTasks:      blockedTasks,
+		prefixesList:      prefixesList,
+		prefixInput:       prefixInput,
+		snoozedTaskList:   snoozedTaskList,
+		snoozeInput:       snoozeInput,
+		collapsedGroups:   make(map[string]bool),
 		taskInput:         taskInput,
 		showHelpIndicator: true,
 		contextVPTaskID:   0,
@@ -0,0 +1,125 @@
+package ui
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"charm.land/bubbles/v2/list"
+	tea "charm.land/bubbletea/v2"
+	"github.com/dhth/omm/internal/types"
+	"github.com/dhth/omm/internal/utils"
+)
+
+const linkedTaskNotFoundMsg = "Linked task not found"
+
+// splitTaskURIs separates URIs that reference omm tasks from the ones that need
+// to be opened outside of omm.
+func splitTaskURIs(uris []string) ([]uint64, []string) {
+	var taskIDs []uint64
+	var others []string
+	for _, uri := range uris {
+		if id, ok := types.ParseTaskURI(uri); ok {
+			taskIDs = append(taskIDs, id)
+			continue
+		}
+		others = append(others, uri)
+	}
+
+	return taskIDs, others
+}
+
+// getBacklinkIDs returns the tasks whose summary or context reference a task
+// via its URI, in the order they're provided in.
+func getBacklinkIDs(rg *regexp.Regexp, tasks []types.Task, id uint64) []uint64 {
+	var ids []uint64
+	for _, t := range tasks {
+		if t.ID == id {
+			continue
+		}
+
+		text := t.Summary
+		if t.Context != nil {
+			text += "\n" + *t.Context
+		}
+		// most tasks don't reference any, so the (comparatively slow) regex is
+		// only run when needed
+		if !strings.Contains(text, types.TaskURIPrefix) {
+			continue
+		}
+
+		taskIDs, _ := splitTaskURIs(utils.ExtractURIs(rg, text))
+		if slices.Contains(taskIDs, id) {
+			ids = append(ids, t.ID)
+		}
+	}
+
+	return ids
+}
+
+func (m Model) getAllTasks() []types.Task {
+	var tasks []types.Task
+	for _, items := range [][]list.Item{m.taskList.Items(), m.archivedTaskList.Items(), m.snoozedTaskList.Items()} {
+		for _, item := range items {
+			if t, ok := item.(types.Task); ok {
+				tasks = append(tasks, t)
+			}
+		}
+	}
+
+	return tasks
+}
+
+// openBookmark opens a URI, or jumps to the task it references.
+func (m *Model) openBookmark(uri string) tea.Cmd {
+	if id, ok := types.ParseTaskURI(uri); ok {
+		m.jumpToTask(id)
+		return nil
+	}
+
+	return openURI(uri)
+}
+
+// jumpToTask selects a task in the list it's in; the task details pane shows
+// it if it's open (and the task isn't snoozed), otherwise the list is shown.
+func (m *Model) jumpToTask(id uint64) {
+	t, ok := m.getTaskByID(id)
+	if !ok {
+		m.errorMsg = linkedTaskNotFoundMsg
+		return
+	}
+
+	if index, snoozed := m.stlIndexMap[id]; snoozed {
+		m.snoozedTaskList.Select(index)
+		m.activeView = snoozedTaskListView
+		return
+	}
+
+	listView := taskListView
+	if _, active := m.tlIndexMap[id]; active {
+		if m.taskList.IsFiltered() {
+			m.taskList.ResetFilter()
+		}
+		m.taskList.Select(m.tlIndexMap[id])
+		m.activeTaskList = activeTasks
+	} else {
+		if m.archivedTaskList.IsFiltered() {
+			m.archivedTaskList.ResetFilter()
+		}
+		m.archivedTaskList.Select(m.atlIndexMap[id])
+		m.activeTaskList = archivedTasks
+		listView = archivedTaskListView
+	}
+
+	if m.activeView == taskDetailsView {
+		m.lastActiveView = listView
+		m.taskDetailsVP.GotoTop()
+		m.setContextFSContent(t)
+		return
+	}
+
+	if m.activeView != listView {
+		m.lastActiveView = m.activeView
+	}
+	m.activeView = listView
+}
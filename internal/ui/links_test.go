@@ -0,0 +1,39 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/dhth/omm/internal/types"
+	"github.com/dhth/omm/internal/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitTaskURIs(t *testing.T) {
+	// GIVEN
+	uris := []string{"https://example.com", "omm://task/12", "mailto:a@example.com", "omm://task/3", "omm://task/abc"}
+
+	// WHEN
+	taskIDs, others := splitTaskURIs(uris)
+
+	// THEN
+	assert.Equal(t, []uint64{12, 3}, taskIDs)
+	assert.Equal(t, []string{"https://example.com", "mailto:a@example.com", "omm://task/abc"}, others)
+}
+
+func TestGetBacklinkIDs(t *testing.T) {
+	// GIVEN
+	context := "depends on the migration: omm://task/1"
+	tasks := []types.Task{
+		{ID: 1, Summary: "write migration, see omm://task/1"},
+		{ID: 2, Summary: "deploy api", Context: &context},
+		{ID: 3, Summary: "release notes for omm://task/12"},
+		{ID: 4, Summary: "review omm://task/1, https://example.com"},
+		{ID: 5, Summary: "read https://example.com/omm://task/1"},
+	}
+
+	// WHEN
+	got := getBacklinkIDs(utils.GetURIRegex(), tasks, 1)
+
+	// THEN
+	assert.Equal(t, []uint64{2, 4}, got)
+}
@@ -22,14 +22,20 @@ const (
 )
 
 type compactItemDelegate struct {
-	selStyle     lipgloss.Style
-	prefixColors []string
+	selStyle           lipgloss.Style
+	prefixColors       []string
+	pinnedPrefixColors map[string]string
+	tagColors          map[string]string
+	blockedTasks       map[uint64]bool
 }
 
 type spaciousTaskItemDelegate struct {
 	selStyle           lipgloss.Style
 	secondaryTextStyle lipgloss.Style
 	prefixColors       []string
+	pinnedPrefixColors map[string]string
+	tagColors          map[string]string
+	blockedTasks       map[uint64]bool
 }
 
 func (d compactItemDelegate) Height() int { return 1 }
@@ -51,24 +57,24 @@ func (d compactItemDelegate) Render(w io.Writer, m list.Model, index int, listIt
 	}
 
 	prefix, sc, hp := t.GetPrefixAndSummaryContent()
+	prefixMatches, summaryMatches := getSummaryMatches(t.Summary, m.MatchesForItem(index))
 
 	if hp {
-		prefix = lipgloss.NewStyle().
-			Foreground(getColorForString(prefix, d.prefixColors)).
-			Bold(true).
-			Render(utils.RightPadTrim(prefix, prefixPadding, true))
-	}
-	var hasContext string
-	if t.Context != nil {
-		hasContext = "(c)"
+		prefixStyle := lipgloss.NewStyle().
+			Foreground(getPrefixColor(prefix, d.pinnedPrefixColors, d.prefixColors)).
+			Bold(true)
+		prefix = highlightMatches(utils.RightPadTrim(prefix, prefixPadding, true), prefixMatches, prefixPadding-3, prefixStyle)
 	}
+	indicators := getTaskIndicators(t, d.blockedTasks)
+
+	summaryWidth := taskSummaryWidth - prefixPadding
 
 	sr := d.selStyle.Render
 	var str string
 	if index == m.Index() {
-		str = fmt.Sprintf("%s%s%s%s", sr("▎ "), prefix, sr(utils.RightPadTrim(sc, taskSummaryWidth-prefixPadding, true)), sr(hasContext))
+		str = fmt.Sprintf("%s%s%s%s", sr("▎ "), prefix, renderTaskSummary(sc, summaryMatches, summaryWidth, d.selStyle, d.tagColors, d.prefixColors), sr(indicators))
 	} else {
-		str = fmt.Sprintf("%s%s%s%s", "  ", prefix, utils.RightPadTrim(sc, taskSummaryWidth-prefixPadding, true), hasContext)
+		str = fmt.Sprintf("%s%s%s%s", "  ", prefix, renderTaskSummary(sc, summaryMatches, summaryWidth, lipgloss.NewStyle(), d.tagColors, d.prefixColors), indicators)
 	}
 
 	fmt.Fprint(w, str)
@@ -81,11 +87,12 @@ func (d spaciousTaskItemDelegate) Render(w io.Writer, m list.Model, index int, l
 	}
 
 	prefix, sc, hasPrefix := t.GetPrefixAndSummaryContent()
+	prefixMatches, summaryMatches := getSummaryMatches(t.Summary, m.MatchesForItem(index))
 	if hasPrefix {
-		prefix = lipgloss.NewStyle().
-			Foreground(getColorForString(prefix, d.prefixColors)).
-			Bold(true).
-			Render(utils.RightPadTrim(prefix, spaciousPrefixPadding, true))
+		prefixStyle := lipgloss.NewStyle().
+			Foreground(getPrefixColor(prefix, d.pinnedPrefixColors, d.prefixColors)).
+			Bold(true)
+		prefix = highlightMatches(utils.RightPadTrim(prefix, spaciousPrefixPadding, true), prefixMatches, spaciousPrefixPadding-3, prefixStyle)
 	} else {
 		prefix = strings.Repeat(" ", spaciousPrefixPadding)
 	}
@@ -97,25 +104,40 @@ func (d spaciousTaskItemDelegate) Render(w io.Writer, m list.Model, index int, l
 
 	createdAt := d.secondaryTextStyle.Render(utils.RightPadTrim(fmt.Sprintf("created %s", createdAtTs), createdAtPadding, true))
 
-	hasContext := ""
-	if t.Context != nil {
-		hasContext = d.secondaryTextStyle.Render("(c)")
+	var indicators string
+	if i := getTaskIndicators(t, d.blockedTasks); i != "" {
+		indicators = d.secondaryTextStyle.Render(i)
 	}
 
-	desc := fmt.Sprintf("%s%s%s", prefix, createdAt, hasContext)
-	title := utils.RightPadTrim(sc, taskSummaryWidth-2, true)
-	desc = utils.RightPadTrim(desc, taskSummaryWidth-2, true)
+	desc := fmt.Sprintf("%s%s%s", prefix, createdAt, indicators)
+	// desc is styled, so it needs to be truncated in a way that keeps its escape
+	// sequences intact
+	desc = lipgloss.NewStyle().MaxWidth(taskSummaryWidth - 2).Render(desc)
 
 	sr := d.selStyle.Render
 	if index == m.Index() {
-		fmt.Fprintf(w, "%s%s\n%s%s", sr("▎ "), sr(title), sr("▎ "), sr(desc))
+		fmt.Fprintf(w, "%s%s\n%s%s", sr("▎ "), renderTaskSummary(sc, summaryMatches, taskSummaryWidth-2, d.selStyle, d.tagColors, d.prefixColors), sr("▎ "), sr(desc))
 		return
 	}
 
-	fmt.Fprintf(w, "  %s\n  %s", title, desc)
+	fmt.Fprintf(w, "  %s\n  %s", renderTaskSummary(sc, summaryMatches, taskSummaryWidth-2, lipgloss.NewStyle(), d.tagColors, d.prefixColors), desc)
 }
 
-func newTaskListDelegate(thm theme.Theme, density ListDensityType, listType taskListType) list.ItemDelegate {
+// getTaskIndicators returns the markers shown next to a task in lists: "(c)" if
+// it has context, and "(b)" if it's blocked by an active task.
+func getTaskIndicators(t types.Task, blockedTasks map[uint64]bool) string {
+	var indicators string
+	if t.Context != nil {
+		indicators += "(c)"
+	}
+	if blockedTasks[t.ID] {
+		indicators += "(b)"
+	}
+
+	return indicators
+}
+
+func newTaskListDelegate(thm theme.Theme, density ListDensityType, listType taskListType, tagColors map[string]string, blockedTasks map[uint64]bool) list.ItemDelegate {
 	selectionColor := lipgloss.Color(thm.Primary)
 	if listType == archivedTasks {
 		selectionColor = lipgloss.Color(thm.Secondary)
@@ -126,9 +148,9 @@ func newTaskListDelegate(thm theme.Theme, density ListDensityType, listType task
 	switch density {
 	case Spacious:
 		secondaryTextStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(thm.Muted))
-		return spaciousTaskItemDelegate{selectionStyle, secondaryTextStyle, thm.PrefixColors}
+		return spaciousTaskItemDelegate{selectionStyle, secondaryTextStyle, thm.PrefixColors, thm.PinnedPrefixColors, tagColors, blockedTasks}
 	default:
-		return compactItemDelegate{selectionStyle, thm.PrefixColors}
+		return compactItemDelegate{selectionStyle, thm.PrefixColors, thm.PinnedPrefixColors, tagColors, blockedTasks}
 	}
 }
 
@@ -136,8 +158,58 @@ func newBookmarksListDelegate(thm theme.Theme) list.ItemDelegate {
 	return newSpaciousListDelegate(lipgloss.Color(thm.Tertiary), lipgloss.Color(thm.Muted), false, 1)
 }
 
+// prefixSearchItemDelegate renders prefixes in the prefix selection list, in
+// the color they're shown in elsewhere, along with that color.
+type prefixSearchItemDelegate struct {
+	selStyle           lipgloss.Style
+	mutedStyle         lipgloss.Style
+	prefixColors       []string
+	pinnedPrefixColors map[string]string
+}
+
+func (d prefixSearchItemDelegate) Height() int { return 1 }
+
+func (d prefixSearchItemDelegate) Spacing() int { return 0 }
+
+func (d prefixSearchItemDelegate) Update(_ tea.Msg, _ *list.Model) tea.Cmd { return nil }
+
+func (d prefixSearchItemDelegate) Render(w io.Writer, m list.Model, index int, listItem list.Item) {
+	p, ok := listItem.(types.TaskPrefix)
+	if !ok {
+		return
+	}
+
+	marker := "  "
+	if index == m.Index() {
+		marker = d.selStyle.Render("▎ ")
+	}
+
+	prefix := string(p)
+	prefixColor, pinned := resolvePrefixColor(prefix, d.pinnedPrefixColors, d.prefixColors)
+	name := lipgloss.NewStyle().
+		Foreground(toPrefixColor(prefixColor)).
+		Bold(true).
+		Render(utils.RightPadTrim(prefix, prefixPadding, true))
+
+	details := prefixColor
+	if pinned {
+		details += " (pinned)"
+	}
+
+	fmt.Fprintf(w, "%s%s%s", marker, name, d.mutedStyle.Render(details))
+}
+
 func newPrefixSearchListDelegate(thm theme.Theme) list.ItemDelegate {
-	return newSpaciousListDelegate(lipgloss.Color(thm.Quinary), lipgloss.Color(thm.Muted), false, 0)
+	return prefixSearchItemDelegate{
+		selStyle:           lipgloss.NewStyle().Foreground(lipgloss.Color(thm.Quinary)),
+		mutedStyle:         lipgloss.NewStyle().Foreground(lipgloss.Color(thm.Muted)),
+		prefixColors:       thm.PrefixColors,
+		pinnedPrefixColors: thm.PinnedPrefixColors,
+	}
+}
+
+func newSavedViewsListDelegate(thm theme.Theme) list.ItemDelegate {
+	return newSpaciousListDelegate(lipgloss.Color(thm.Quinary), lipgloss.Color(thm.Muted), true, 1)
 }
 
 func newSpaciousListDelegate(selectionColor color.Color, normalTitleColor color.Color, showDesc bool, spacing int) list.DefaultDelegate {
@@ -41,6 +41,19 @@ const (
 	contextBookmarksView
 	prefixSelectionView
 	helpView
+	statsView
+	groupedTaskListView
+	boardView
+	savedViewSelectionView
+	savedViewEntryView
+	queryResultsView
+	queryEntryView
+	tagSelectionView
+	tagEntryView
+	prefixManagementView
+	prefixEntryView
+	snoozedTaskListView
+	snoozeEntryView
 )
 
 type taskListType uint
@@ -58,16 +71,53 @@ const (
 )
 
 type Model struct {
-	db                    *sql.DB
-	cfg                   Config
-	theme                 theme.Theme
-	styles                styles
-	taskList              list.Model
-	archivedTaskList      list.Model
-	taskBMList            list.Model
-	prefixSearchList      list.Model
+	db                 *sql.DB
+	cfg                Config
+	theme              theme.Theme
+	styles             styles
+	taskList           list.Model
+	archivedTaskList   list.Model
+	taskBMList         list.Model
+	prefixSearchList   list.Model
+	groupedTaskList    list.Model
+	savedViewsList     list.Model
+	queryResultsList   list.Model
+	queryResultsParent activeView
+	queryInput         textinput.Model
+	savedViewInput     textinput.Model
+	tagsList           list.Model
+	tagInput           textinput.Model
+	tagChange          tagChangeType
+	prefixesList       list.Model
+	prefixInput        textinput.Model
+	prefixChange       prefixChangeType
+	snoozedTaskList    list.Model
+	snoozeInput        textinput.Model
+	// snoozeTaskID is the task being snoozed via the snooze entry pane
+	snoozeTaskID      uint64
+	snoozeEntryParent activeView
+	// snapshotGen identifies the latest snapshot scheduled; only that one is
+	// taken, so that a series of changes results in a single snapshot
+	snapshotGen int
+	// tagColors holds the colors set for tags; it's shared with list delegates
+	tagColors map[string]string
+	// blockedTasks holds the IDs of tasks blocked by an active task; it's shared
+	// with list delegates
+	blockedTasks map[uint64]bool
+	dependencies []types.TaskDependency
+	// dependencyTaskID is the task a blocker is being picked for, if any
+	dependencyTaskID uint64
+	// linkedTaskIDs are the tasks linked to the one in the task details pane,
+	// in the order they're numbered in
+	linkedTaskIDs         []uint64
+	collapsedGroups       map[string]bool
+	boardColumnIndex      int
+	boardRows             []int
 	tlIndexMap            map[uint64]int
 	atlIndexMap           map[uint64]int
+	tlFilter              *taskFilter
+	atlFilter             *taskFilter
+	stlIndexMap           map[uint64]int
 	taskIndex             int
 	taskID                uint64
 	taskChange            taskChangeType
@@ -77,6 +127,8 @@ type Model struct {
 	taskDetailsVPReady    bool
 	helpVP                viewport.Model
 	helpVPReady           bool
+	statsVP               viewport.Model
+	statsVPReady          bool
 	quitting              bool
 	showHelpIndicator     bool
 	successMsg            string
@@ -95,13 +147,22 @@ type Model struct {
 	taskDetailsMdRenderer *glamour.TermRenderer
 	prefixSearchUse       prefixUse
 	showDeletePrompt      bool
+	showArchivePrompt     bool
 	yankedTaskDetails     *types.TaskDetails
 }
 
 func (m Model) Init() tea.Cmd {
-	return tea.Batch(
+	cmds := []tea.Cmd{
 		fetchTasks(m.db, true, pers.TaskNumLimit),
 		fetchTasks(m.db, false, pers.TaskNumLimit),
-		hideHelp(time.Minute*1),
-	)
+		fetchSnoozedTasks(m.db, false),
+		fetchTags(m.db, false),
+		fetchTaskDependencies(m.db),
+		hideHelp(time.Minute * 1),
+	}
+	if !m.cfg.ReadOnly {
+		cmds = append(cmds, scheduleSnoozedTasksWakeUp(wakeSnoozedTasksInterval))
+	}
+
+	return tea.Batch(cmds...)
 }
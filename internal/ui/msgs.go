@@ -3,6 +3,8 @@ package ui
 import (
 	"time"
 
+	"github.com/dhth/omm/internal/hooks"
+	"github.com/dhth/omm/internal/stats"
 	"github.com/dhth/omm/internal/types"
 )
 
@@ -50,12 +52,29 @@ type taskStatusChangedMsg struct {
 	err       error
 }
 
+type taskBoardStatusUpdatedMsg struct {
+	id        uint64
+	status    string
+	updatedAt time.Time
+	err       error
+}
+
+type hookRanMsg struct {
+	event hooks.Event
+	err   error
+}
+
 type tasksFetched struct {
 	tasks  []types.Task
 	active bool
 	err    error
 }
 
+type statsFetchedMsg struct {
+	stats stats.Stats
+	err   error
+}
+
 type textEditorClosed struct {
 	fPath      string
 	taskIndex  int
@@ -77,3 +96,83 @@ type urisOpenedDarwinMsg struct {
 type contextWrittenToCBMsg struct {
 	err error
 }
+
+type savedViewsFetchedMsg struct {
+	views []types.SavedView
+	err   error
+}
+
+type savedViewCreatedMsg struct {
+	name string
+	err  error
+}
+
+type savedViewDeletedMsg struct {
+	name string
+	err  error
+}
+
+type tagsFetchedMsg struct {
+	tags []types.Tag
+	// show is whether the tag selection list needs to be shown
+	show bool
+	err  error
+}
+
+type tagChangedMsg struct {
+	err error
+}
+
+type prefixesFetchedMsg struct {
+	prefixes []types.PrefixCount
+	// selected is the prefix the cursor needs to be moved to, if any
+	selected types.TaskPrefix
+	show     bool
+	err      error
+}
+
+type prefixChangedMsg struct {
+	numChanged int
+	err        error
+}
+
+type taskDependenciesFetchedMsg struct {
+	dependencies []types.TaskDependency
+	err          error
+}
+
+type taskDependencyChangedMsg struct {
+	err error
+}
+
+type snoozedTasksFetchedMsg struct {
+	tasks []types.Task
+	// show is whether the snoozed task list needs to be shown
+	show bool
+	err  error
+}
+
+type taskSnoozedMsg struct {
+	id  uint64
+	err error
+}
+
+type taskUnsnoozedMsg struct {
+	task types.Task
+	err  error
+}
+
+type snoozedTasksDueMsg struct{}
+
+type snapshotDueMsg struct {
+	gen int
+}
+
+type snapshotTakenMsg struct {
+	err error
+}
+
+type snoozedTasksWokenMsg struct {
+	tasks []types.Task
+	err   error
+}
@@ -0,0 +1,140 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+
+	"charm.land/bubbles/v2/list"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/dhth/omm/internal/types"
+	"github.com/dhth/omm/internal/ui/theme"
+	"github.com/dhth/omm/internal/utils"
+)
+
+const (
+	prefixesTitle     = "prefixes"
+	prefixInputMaxLen = 100
+)
+
+type prefixChangeType uint
+
+const (
+	prefixRename prefixChangeType = iota
+	prefixMerge
+)
+
+// prefixCountItem is a prefix in the prefix management list.
+type prefixCountItem struct {
+	count types.PrefixCount
+}
+
+func (i prefixCountItem) Title() string {
+	return string(i.count.Prefix)
+}
+
+func (i prefixCountItem) Description() string {
+	return ""
+}
+
+func (i prefixCountItem) FilterValue() string {
+	return string(i.count.Prefix)
+}
+
+type prefixCountItemDelegate struct {
+	selStyle           lipgloss.Style
+	mutedStyle         lipgloss.Style
+	prefixColors       []string
+	pinnedPrefixColors map[string]string
+}
+
+func (d prefixCountItemDelegate) Height() int { return 1 }
+
+func (d prefixCountItemDelegate) Spacing() int { return 1 }
+
+func (d prefixCountItemDelegate) Update(_ tea.Msg, _ *list.Model) tea.Cmd { return nil }
+
+func (d prefixCountItemDelegate) Render(w io.Writer, m list.Model, index int, listItem list.Item) {
+	item, ok := listItem.(prefixCountItem)
+	if !ok {
+		return
+	}
+
+	marker := "  "
+	if index == m.Index() {
+		marker = d.selStyle.Render("▎ ")
+	}
+
+	prefix := item.Title()
+	name := lipgloss.NewStyle().
+		Foreground(getPrefixColor(prefix, d.pinnedPrefixColors, d.prefixColors)).
+		Bold(true).
+		Render(utils.RightPadTrim(prefix, prefixPadding, true))
+
+	details := fmt.Sprintf("%d active, %d archived", item.count.NumActive, item.count.NumArchived)
+
+	fmt.Fprintf(w, "%s%s%s", marker, name, d.mutedStyle.Render(details))
+}
+
+func newPrefixesListDelegate(thm theme.Theme) list.ItemDelegate {
+	return prefixCountItemDelegate{
+		selStyle:           lipgloss.NewStyle().Foreground(lipgloss.Color(thm.Quinary)),
+		mutedStyle:         lipgloss.NewStyle().Foreground(lipgloss.Color(thm.Muted)),
+		prefixColors:       thm.PrefixColors,
+		pinnedPrefixColors: thm.PinnedPrefixColors,
+	}
+}
+
+// setPrefixes populates the prefix management list, keeping the cursor on the
+// prefix it was on (if it's still present).
+func (m *Model) setPrefixes(prefixes []types.PrefixCount) {
+	var selected types.TaskPrefix
+	if i, ok := m.prefixesList.SelectedItem().(prefixCountItem); ok {
+		selected = i.count.Prefix
+	}
+
+	items := make([]list.Item, len(prefixes))
+	for i, p := range prefixes {
+		items[i] = prefixCountItem{p}
+	}
+	m.prefixesList.SetItems(items)
+
+	m.selectPrefix(selected)
+}
+
+// selectPrefix moves the cursor of the prefix management list to a prefix,
+// keeping it within bounds if the prefix isn't present.
+func (m *Model) selectPrefix(prefix types.TaskPrefix) {
+	for i, item := range m.prefixesList.Items() {
+		if p, ok := item.(prefixCountItem); ok && p.count.Prefix == prefix {
+			m.prefixesList.Select(i)
+			return
+		}
+	}
+
+	if m.prefixesList.Index() >= len(m.prefixesList.Items()) {
+		m.prefixesList.Select(max(len(m.prefixesList.Items())-1, 0))
+	}
+}
+
+// startPrefixChange switches to the prefix entry pane, for a change to the
+// selected prefix.
+func (m *Model) startPrefixChange(change prefixChangeType) {
+	item, ok := m.prefixesList.SelectedItem().(prefixCountItem)
+	if !ok {
+		return
+	}
+
+	m.prefixChange = change
+	m.prefixInput.Reset()
+	switch change {
+	case prefixRename:
+		m.prefixInput.Placeholder = "new prefix"
+		m.prefixInput.SetValue(item.Title())
+	case prefixMerge:
+		m.prefixInput.Placeholder = "prefix to merge into"
+	}
+	m.prefixInput.CursorEnd()
+	m.prefixInput.Focus()
+	m.activeView = prefixEntryView
+}
@@ -0,0 +1,64 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"charm.land/bubbles/v2/list"
+	"github.com/dhth/omm/internal/query"
+	"github.com/dhth/omm/internal/types"
+)
+
+const (
+	queryTitle       = "query"
+	queryInputMaxLen = 500
+)
+
+// parseQueryInput parses the input of the query entry pane; errors are meant
+// to be shown in the status bar.
+func parseQueryInput(input string) (query.Query, error) {
+	q, err := query.Parse(input)
+	if err != nil {
+		return query.Query{}, fmt.Errorf("invalid query: %w", err)
+	}
+
+	return q, nil
+}
+
+// showQueryResults switches to a list of the active tasks that match a query;
+// going back from it leads to the parent view.
+func (m *Model) showQueryResults(q query.Query, title string, parent activeView) {
+	tasks := q.Filter(getTasks(m.taskList.Items()), time.Now())
+	items := make([]list.Item, len(tasks))
+	for i, t := range tasks {
+		items[i] = t
+	}
+
+	m.queryResultsList.SetItems(items)
+	m.queryResultsList.Select(0)
+	m.queryResultsList.Title = title
+	m.queryResultsParent = parent
+	m.activeView = queryResultsView
+}
+
+// showSelectedQueryResult switches to the active task list, with the cursor
+// on the task selected in the query results list.
+func (m *Model) showSelectedQueryResult() {
+	t, ok := m.queryResultsList.SelectedItem().(types.Task)
+	if !ok {
+		return
+	}
+
+	listIndex, ok := m.tlIndexMap[t.ID]
+	if !ok {
+		m.errorMsg = somethingWentWrongMsg
+		return
+	}
+
+	if m.taskList.IsFiltered() {
+		m.taskList.ResetFilter()
+	}
+	m.taskList.Select(listIndex)
+	m.activeView = taskListView
+	m.activeTaskList = activeTasks
+}
@@ -0,0 +1,92 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"charm.land/bubbles/v2/list"
+	"github.com/dhth/omm/internal/types"
+	"github.com/dhth/omm/internal/views"
+)
+
+const (
+	savedViewsTitle      = "views"
+	savedViewInputMaxLen = 500
+)
+
+var errSavedViewInputInvalid = errors.New("enter a view as 'name = definition'")
+
+// savedViewItem is a view in the saved view selection list.
+type savedViewItem struct {
+	view views.View
+}
+
+func (i savedViewItem) Title() string {
+	return i.view.Name
+}
+
+func (i savedViewItem) Description() string {
+	return fmt.Sprintf("%s  (%s)", i.view.Definition, i.view.Source)
+}
+
+func (i savedViewItem) FilterValue() string {
+	return i.view.Name
+}
+
+// parseSavedViewInput parses the input of the saved view entry pane, which
+// looks like "name = definition".
+func parseSavedViewInput(input string) (views.View, error) {
+	name, definition, ok := strings.Cut(input, "=")
+	if !ok {
+		return views.View{}, errSavedViewInputInvalid
+	}
+
+	return views.Parse(name, definition, views.FromDB)
+}
+
+func (m Model) getSavedViews() []views.View {
+	items := m.savedViewsList.Items()
+	result := make([]views.View, 0, len(items))
+	for _, item := range items {
+		i, ok := item.(savedViewItem)
+		if ok {
+			result = append(result, i.view)
+		}
+	}
+
+	return result
+}
+
+// setSavedViews populates the saved view selection list with views defined in
+// the config file and the ones saved in the database, keeping the cursor on the
+// view it was on (if it's still present).
+func (m *Model) setSavedViews(saved []types.SavedView) {
+	var selectedName string
+	if i, ok := m.savedViewsList.SelectedItem().(savedViewItem); ok {
+		selectedName = i.view.Name
+	}
+
+	merged := views.Merge(m.cfg.Views, saved)
+	items := make([]list.Item, len(merged))
+	for i, v := range merged {
+		items[i] = savedViewItem{v}
+	}
+	m.savedViewsList.SetItems(items)
+
+	for i, v := range merged {
+		if v.Name == selectedName {
+			m.savedViewsList.Select(i)
+			return
+		}
+	}
+
+	if m.savedViewsList.Index() >= len(items) {
+		m.savedViewsList.Select(max(len(items)-1, 0))
+	}
+}
+
+// showSavedViewTasks switches to a list of the active tasks that match a view.
+func (m *Model) showSavedViewTasks(v views.View) {
+	m.showQueryResults(v.Query(), fmt.Sprintf("%s: %s", savedViewsTitle, v.Name), savedViewSelectionView)
+}
@@ -0,0 +1,61 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/dhth/omm/internal/query"
+	"github.com/dhth/omm/internal/views"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSavedViewInput(t *testing.T) {
+	testCases := []struct {
+		name               string
+		input              string
+		expectedName       string
+		expectedDefinition string
+		err                error
+	}{
+		{
+			name:               "valid input",
+			input:              "Work = prefix:api|infra stale:14d",
+			expectedName:       "work",
+			expectedDefinition: "prefix:api|infra stale:14d",
+		},
+		{
+			name:  "no separator",
+			input: "work prefix:api",
+			err:   errSavedViewInputInvalid,
+		},
+		{
+			name:  "empty name",
+			input: " = has:links",
+			err:   views.ErrNameEmpty,
+		},
+		{
+			name:  "invalid definition",
+			input: "work = age:14d",
+			err:   query.ErrOperatorInvalid,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			// WHEN
+			got, err := parseSavedViewInput(tt.input)
+
+			// THEN
+			if tt.err != nil {
+				require.ErrorIs(t, err, tt.err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedName, got.Name)
+			assert.Equal(t, tt.expectedDefinition, got.Definition)
+			assert.Equal(t, views.FromDB, got.Source)
+		})
+	}
+}
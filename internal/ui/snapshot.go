@@ -0,0 +1,44 @@
+package ui
+
+import (
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+// snapshotDelay is how long after a change a snapshot is taken; changes made in
+// the meantime end up in the same snapshot.
+const snapshotDelay = time.Second
+
+// changedTasks reports whether a message is the result of tasks (or their
+// order) being changed in the database.
+func changedTasks(msg tea.Msg) bool {
+	switch msg := msg.(type) {
+	case taskSequenceUpdatedMsg:
+		return msg.err == nil
+	case taskCreatedMsg:
+		return msg.err == nil
+	case taskDeletedMsg:
+		return msg.err == nil
+	case taskSummaryUpdatedMsg:
+		return msg.err == nil
+	case taskContextUpdatedMsg:
+		return msg.err == nil
+	case taskStatusChangedMsg:
+		return msg.err == nil
+	case taskBoardStatusUpdatedMsg:
+		return msg.err == nil
+	case tagChangedMsg:
+		return msg.err == nil
+	case prefixChangedMsg:
+		return msg.err == nil
+	case taskSnoozedMsg:
+		return msg.err == nil
+	case taskUnsnoozedMsg:
+		return msg.err == nil
+	case snoozedTasksWokenMsg:
+		return msg.err == nil && len(msg.tasks) > 0
+	}
+
+	return false
+}
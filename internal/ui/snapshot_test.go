@@ -0,0 +1,79 @@
+package ui
+
+import (
+	"errors"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/dhth/omm/internal/ui/theme"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangedTasks(t *testing.T) {
+	testCases := []struct {
+		name     string
+		msg      tea.Msg
+		expected bool
+	}{
+		{
+			name:     "task created",
+			msg:      taskCreatedMsg{},
+			expected: true,
+		},
+		{
+			name:     "task sequence updated",
+			msg:      taskSequenceUpdatedMsg{},
+			expected: true,
+		},
+		{
+			name: "failed change",
+			msg:  taskStatusChangedMsg{err: errors.New("failed")},
+		},
+		{
+			name: "no snoozed tasks woken up",
+			msg:  snoozedTasksWokenMsg{},
+		},
+		{
+			name: "tasks fetched",
+			msg:  tasksFetched{},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			// WHEN
+			got := changedTasks(tt.msg)
+
+			// THEN
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestOnlyLatestScheduledSnapshotIsTaken(t *testing.T) {
+	// GIVEN
+	thm, err := theme.Get(theme.DefaultThemeName)
+	require.NoError(t, err)
+	var numSnapshots int
+	m := InitialModel(nil, Config{Snapshot: func() error {
+		numSnapshots++
+		return nil
+	}}, thm)
+
+	// WHEN
+	for range 2 {
+		updated, _ := m.Update(taskSequenceUpdatedMsg{})
+		m = updated.(Model)
+	}
+	_, staleCmd := m.Update(snapshotDueMsg{gen: 1})
+	_, latestCmd := m.Update(snapshotDueMsg{gen: 2})
+
+	// THEN
+	assert.Equal(t, 2, m.snapshotGen)
+	assert.Nil(t, staleCmd)
+	require.NotNil(t, latestCmd)
+	assert.Equal(t, snapshotTakenMsg{}, latestCmd())
+	assert.Equal(t, 1, numSnapshots)
+}
@@ -0,0 +1,266 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"charm.land/bubbles/v2/list"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/dhth/omm/internal/query"
+	"github.com/dhth/omm/internal/types"
+	"github.com/dhth/omm/internal/ui/theme"
+	"github.com/dhth/omm/internal/utils"
+	"github.com/dustin/go-humanize"
+)
+
+const (
+	snoozedTitle             = "snoozed"
+	snoozeInputMaxLen        = 50
+	snoozedUntilPadding      = 44
+	wakeSnoozedTasksInterval = time.Minute
+)
+
+var (
+	errSnoozeInputInvalid = errors.New("enter a period (eg. 3h, 2d, 1w), a day (eg. tomorrow, friday), or a date (eg. 2026-05-04, 2026-05-04 09:00)")
+	errSnoozeTimeInPast   = errors.New("tasks can only be snoozed until a time in the future")
+)
+
+// parseSnoozeInput parses the input of the snooze entry pane into the time a
+// task needs to wake up at. Days and dates without a time refer to their start.
+func parseSnoozeInput(input string, now time.Time) (time.Time, error) {
+	value := strings.ToLower(strings.TrimSpace(input))
+	if value == "" {
+		return time.Time{}, errSnoozeInputInvalid
+	}
+
+	until, ok := parseSnoozeDay(value, now)
+	if !ok {
+		var err error
+		until, err = parseSnoozePeriodOrDate(value, now)
+		if err != nil {
+			return time.Time{}, err
+		}
+	}
+
+	if !until.After(now) {
+		return time.Time{}, errSnoozeTimeInPast
+	}
+
+	return until, nil
+}
+
+// parseSnoozeDay parses "tomorrow", or the name of a weekday (which refers to
+// its next occurrence, a week from now if it's today).
+func parseSnoozeDay(value string, now time.Time) (time.Time, bool) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if value == "tomorrow" {
+		return today.AddDate(0, 0, 1), true
+	}
+
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		name := strings.ToLower(d.String())
+		if value != name && value != name[:3] {
+			continue
+		}
+
+		days := (int(d)-int(now.Weekday())+6)%7 + 1
+		return today.AddDate(0, 0, days), true
+	}
+
+	return time.Time{}, false
+}
+
+func parseSnoozePeriodOrDate(value string, now time.Time) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02 15:04", "2006-01-02"} {
+		until, err := time.ParseInLocation(layout, value, now.Location())
+		if err == nil {
+			return until, nil
+		}
+	}
+
+	if period, err := query.ParsePeriod(value); err == nil {
+		return now.Add(period), nil
+	}
+
+	unit := time.Minute
+	switch {
+	case strings.HasSuffix(value, "h"):
+		unit = time.Hour
+	case !strings.HasSuffix(value, "m"):
+		return time.Time{}, errSnoozeInputInvalid
+	}
+
+	num, err := strconv.Atoi(value[:len(value)-1])
+	if err != nil || num <= 0 {
+		return time.Time{}, errSnoozeInputInvalid
+	}
+
+	return now.Add(time.Duration(num) * unit), nil
+}
+
+// snoozedTaskItemDelegate renders tasks in the snoozed task list, along with
+// when they wake up.
+type snoozedTaskItemDelegate struct {
+	selStyle           lipgloss.Style
+	mutedStyle         lipgloss.Style
+	prefixColors       []string
+	pinnedPrefixColors map[string]string
+	tagColors          map[string]string
+}
+
+func (d snoozedTaskItemDelegate) Height() int { return 1 }
+
+func (d snoozedTaskItemDelegate) Spacing() int { return 1 }
+
+func (d snoozedTaskItemDelegate) Update(_ tea.Msg, _ *list.Model) tea.Cmd { return nil }
+
+func (d snoozedTaskItemDelegate) Render(w io.Writer, m list.Model, index int, listItem list.Item) {
+	t, ok := listItem.(types.Task)
+	if !ok {
+		return
+	}
+
+	prefix, sc, hasPrefix := t.GetPrefixAndSummaryContent()
+	if hasPrefix {
+		prefix = lipgloss.NewStyle().
+			Foreground(getPrefixColor(prefix, d.pinnedPrefixColors, d.prefixColors)).
+			Bold(true).
+			Render(utils.RightPadTrim(prefix, prefixPadding, true))
+	}
+
+	var until string
+	if t.SnoozedUntil != nil {
+		until = fmt.Sprintf("until %s (%s)", t.SnoozedUntil.Format(timeFormat), humanize.Time(*t.SnoozedUntil))
+	}
+	until = d.mutedStyle.Render(utils.RightPadTrim(until, snoozedUntilPadding, true))
+
+	summaryWidth := taskSummaryWidth - prefixPadding - snoozedUntilPadding
+	if index == m.Index() {
+		fmt.Fprintf(w, "%s%s%s%s", d.selStyle.Render("▎ "), prefix, renderTaskSummary(sc, nil, summaryWidth, d.selStyle, d.tagColors, d.prefixColors), until)
+		return
+	}
+
+	fmt.Fprintf(w, "  %s%s%s", prefix, renderTaskSummary(sc, nil, summaryWidth, lipgloss.NewStyle(), d.tagColors, d.prefixColors), until)
+}
+
+func newSnoozedTaskListDelegate(thm theme.Theme, tagColors map[string]string) list.ItemDelegate {
+	return snoozedTaskItemDelegate{
+		selStyle:           lipgloss.NewStyle().Foreground(lipgloss.Color(thm.Tertiary)),
+		mutedStyle:         lipgloss.NewStyle().Foreground(lipgloss.Color(thm.Muted)),
+		prefixColors:       thm.PrefixColors,
+		pinnedPrefixColors: thm.PinnedPrefixColors,
+		tagColors:          tagColors,
+	}
+}
+
+// setSnoozedTasks populates the snoozed task list, keeping the cursor on the
+// task it was on (if it's still present).
+func (m *Model) setSnoozedTasks(tasks []types.Task) {
+	var selected uint64
+	if t, ok := m.snoozedTaskList.SelectedItem().(types.Task); ok {
+		selected = t.ID
+	}
+
+	items := make([]list.Item, len(tasks))
+	for i, t := range tasks {
+		items[i] = t
+	}
+	m.snoozedTaskList.SetItems(items)
+	m.updateSnoozedTasksIndex()
+
+	if index, ok := m.stlIndexMap[selected]; ok {
+		m.snoozedTaskList.Select(index)
+	} else if m.snoozedTaskList.Index() >= len(items) {
+		m.snoozedTaskList.Select(max(len(items)-1, 0))
+	}
+}
+
+func (m *Model) updateSnoozedTasksIndex() {
+	stlIndexMap := make(map[uint64]int)
+	for i, ti := range m.snoozedTaskList.Items() {
+		if t, ok := ti.(types.Task); ok {
+			stlIndexMap[t.ID] = i
+		}
+	}
+
+	m.stlIndexMap = stlIndexMap
+}
+
+// isTaskOpen reports whether a task is active, ie. whether it's either in the
+// active task list, or snoozed.
+func (m Model) isTaskOpen(id uint64) bool {
+	if _, ok := m.tlIndexMap[id]; ok {
+		return true
+	}
+
+	_, ok := m.stlIndexMap[id]
+	return ok
+}
+
+// startSnooze switches to the snooze entry pane, for a task; going back from
+// it leads to the parent view.
+func (m *Model) startSnooze(t types.Task, parent activeView) {
+	m.snoozeTaskID = t.ID
+	m.snoozeEntryParent = parent
+	m.snoozeInput.Reset()
+	m.snoozeInput.Focus()
+	m.activeView = snoozeEntryView
+}
+
+// showWokenTasks adds tasks that are no longer snoozed to the top of the active
+// task list (the one that was due first at the very top), like unarchived
+// tasks are, and removes them from the snoozed task list.
+func (m *Model) showWokenTasks(tasks []types.Task) tea.Cmd {
+	oldIndex := m.taskList.Index()
+	var numAdded int
+	for i := len(tasks) - 1; i >= 0; i-- {
+		t := tasks[i]
+		if _, ok := m.tlIndexMap[t.ID]; ok {
+			continue
+		}
+
+		t.SnoozedUntil = nil
+		m.taskList.InsertItem(0, list.Item(t))
+		numAdded++
+	}
+
+	var remaining []types.Task
+	for _, item := range m.snoozedTaskList.Items() {
+		t, ok := item.(types.Task)
+		if !ok {
+			continue
+		}
+		woken := slices.ContainsFunc(tasks, func(w types.Task) bool { return w.ID == t.ID })
+		if !woken {
+			remaining = append(remaining, t)
+		}
+	}
+	m.setSnoozedTasks(remaining)
+
+	if numAdded == 0 {
+		return nil
+	}
+
+	if len(m.taskList.Items()) > numAdded {
+		m.taskList.Select(oldIndex + numAdded)
+	}
+	refilterList(&m.taskList)
+	cmd := m.updateActiveTasksSequence()
+	m.updateBlockedTasks()
+
+	if m.activeView == groupedTaskListView {
+		m.refreshGroupedTaskList()
+	}
+
+	if m.activeView == boardView {
+		m.clampBoardCursor(m.getBoard())
+	}
+
+	return cmd
+}
@@ -0,0 +1,114 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSnoozeInput(t *testing.T) {
+	// a wednesday
+	now := time.Date(2026, 5, 6, 15, 30, 0, 0, time.Local)
+
+	testCases := []struct {
+		name     string
+		input    string
+		expected time.Time
+		err      error
+	}{
+		{
+			name:     "minutes",
+			input:    "45m",
+			expected: now.Add(45 * time.Minute),
+		},
+		{
+			name:     "hours",
+			input:    "3H",
+			expected: now.Add(3 * time.Hour),
+		},
+		{
+			name:     "days",
+			input:    "2d",
+			expected: now.Add(48 * time.Hour),
+		},
+		{
+			name:     "weeks",
+			input:    "1w",
+			expected: now.Add(7 * 24 * time.Hour),
+		},
+		{
+			name:     "tomorrow",
+			input:    " tomorrow ",
+			expected: time.Date(2026, 5, 7, 0, 0, 0, 0, time.Local),
+		},
+		{
+			name:     "weekday later this week",
+			input:    "Friday",
+			expected: time.Date(2026, 5, 8, 0, 0, 0, 0, time.Local),
+		},
+		{
+			name:     "weekday next week",
+			input:    "mon",
+			expected: time.Date(2026, 5, 11, 0, 0, 0, 0, time.Local),
+		},
+		{
+			name:     "today's weekday",
+			input:    "wednesday",
+			expected: time.Date(2026, 5, 13, 0, 0, 0, 0, time.Local),
+		},
+		{
+			name:     "date",
+			input:    "2026-06-01",
+			expected: time.Date(2026, 6, 1, 0, 0, 0, 0, time.Local),
+		},
+		{
+			name:     "date and time",
+			input:    "2026-05-06 17:00",
+			expected: time.Date(2026, 5, 6, 17, 0, 0, 0, time.Local),
+		},
+		{
+			name:  "time in the past",
+			input: "2026-05-06 09:00",
+			err:   errSnoozeTimeInPast,
+		},
+		{
+			name:  "empty input",
+			input: " ",
+			err:   errSnoozeInputInvalid,
+		},
+		{
+			name:  "zero period",
+			input: "0h",
+			err:   errSnoozeInputInvalid,
+		},
+		{
+			name:  "unknown unit",
+			input: "3y",
+			err:   errSnoozeInputInvalid,
+		},
+		{
+			name:  "invalid input",
+			input: "later",
+			err:   errSnoozeInputInvalid,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			// WHEN
+			got, err := parseSnoozeInput(tt.input, now)
+
+			// THEN
+			if tt.err != nil {
+				require.ErrorIs(t, err, tt.err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.True(t, tt.expected.Equal(got), "expected %s, got %s", tt.expected, got)
+		})
+	}
+}
@@ -0,0 +1,260 @@
+package ui
+
+import (
+	"fmt"
+	"image/color"
+	"io"
+	"strings"
+	"unicode"
+
+	"charm.land/bubbles/v2/list"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/dhth/omm/internal/query"
+	"github.com/dhth/omm/internal/types"
+	"github.com/dhth/omm/internal/ui/theme"
+	"github.com/dhth/omm/internal/utils"
+)
+
+const (
+	tagsTitle      = "tags"
+	tagInputMaxLen = 100
+)
+
+type tagChangeType uint
+
+const (
+	tagRename tagChangeType = iota
+	tagMerge
+	tagRecolor
+)
+
+// tagItem is a tag in the tag selection list.
+type tagItem struct {
+	tag types.Tag
+}
+
+func (i tagItem) Title() string {
+	return types.TagPrefix + i.tag.Name
+}
+
+func (i tagItem) Description() string {
+	return ""
+}
+
+func (i tagItem) FilterValue() string {
+	return i.tag.Name
+}
+
+type tagItemDelegate struct {
+	selStyle     lipgloss.Style
+	mutedStyle   lipgloss.Style
+	prefixColors []string
+	tagColors    map[string]string
+}
+
+func (d tagItemDelegate) Height() int { return 1 }
+
+func (d tagItemDelegate) Spacing() int { return 1 }
+
+func (d tagItemDelegate) Update(_ tea.Msg, _ *list.Model) tea.Cmd { return nil }
+
+func (d tagItemDelegate) Render(w io.Writer, m list.Model, index int, listItem list.Item) {
+	item, ok := listItem.(tagItem)
+	if !ok {
+		return
+	}
+
+	marker := "  "
+	if index == m.Index() {
+		marker = d.selStyle.Render("▎ ")
+	}
+
+	name := lipgloss.NewStyle().
+		Foreground(getTagColor(item.tag.Name, d.tagColors, d.prefixColors)).
+		Bold(true).
+		Render(utils.RightPadTrim(item.Title(), prefixPadding, true))
+
+	numTasks := fmt.Sprintf("%d tasks", item.tag.NumTasks)
+	if item.tag.NumTasks == 1 {
+		numTasks = "1 task"
+	}
+	details := utils.RightPadTrim(numTasks, 12, false)
+	if item.tag.Color != "" {
+		details += fmt.Sprintf("color: %s", item.tag.Color)
+	}
+
+	fmt.Fprintf(w, "%s%s%s", marker, name, d.mutedStyle.Render(details))
+}
+
+func newTagsListDelegate(thm theme.Theme, tagColors map[string]string) list.ItemDelegate {
+	return tagItemDelegate{
+		selStyle:     lipgloss.NewStyle().Foreground(lipgloss.Color(thm.Quinary)),
+		mutedStyle:   lipgloss.NewStyle().Foreground(lipgloss.Color(thm.Muted)),
+		prefixColors: thm.PrefixColors,
+		tagColors:    tagColors,
+	}
+}
+
+// getTagColor returns the color set for a tag, falling back to one picked from
+// the theme's prefix colors.
+func getTagColor(name string, tagColors map[string]string, prefixColors []string) color.Color {
+	if c, ok := tagColors[name]; ok {
+		return lipgloss.Color(c)
+	}
+
+	return getColorForString(name, prefixColors)
+}
+
+// stripTags removes tags from a summary (along with the whitespace separating
+// them from the rest of it), and returns what's left, the byte offsets of
+// matches relative to it, and the tags.
+func stripTags(summary string, matches []int) (string, []int, []string) {
+	spans := types.GetTagSpans(summary)
+	if len(spans) == 0 {
+		return summary, matches, nil
+	}
+
+	removed := make([]bool, len(summary))
+	tags := make([]string, 0, len(spans))
+	for _, s := range spans {
+		start, end := s.Start, s.End
+		for start > 0 && unicode.IsSpace(rune(summary[start-1])) {
+			start--
+		}
+		if start == 0 {
+			for end < len(summary) && unicode.IsSpace(rune(summary[end])) {
+				end++
+			}
+		}
+		for i := start; i < end; i++ {
+			removed[i] = true
+		}
+		tags = append(tags, s.Name)
+	}
+
+	var sb strings.Builder
+	newOffsets := make([]int, len(summary))
+	for i := range len(summary) {
+		newOffsets[i] = sb.Len()
+		if !removed[i] {
+			sb.WriteByte(summary[i])
+		}
+	}
+
+	var newMatches []int
+	for _, i := range matches {
+		if i < len(summary) && !removed[i] {
+			newMatches = append(newMatches, newOffsets[i])
+		}
+	}
+
+	return sb.String(), newMatches, tags
+}
+
+// renderTaskSummary renders a summary (without its prefix) in width columns,
+// with its tags shown as colored chips at the end; matches are highlighted the
+// same way highlightMatches does.
+func renderTaskSummary(summary string, matches []int, width int, style lipgloss.Style, tagColors map[string]string, prefixColors []string) string {
+	text, textMatches, tags := stripTags(summary, matches)
+
+	// chips take up to half of the available width; the ones that don't fit
+	// are left out
+	var chips []string
+	var chipsWidth int
+	for _, t := range tags {
+		chip := types.TagPrefix + t
+		if chipsWidth+len(chip)+1 > width/2 {
+			break
+		}
+		chips = append(chips, lipgloss.NewStyle().Foreground(getTagColor(t, tagColors, prefixColors)).Render(chip))
+		chipsWidth += len(chip) + 1
+	}
+
+	if len(chips) == 0 {
+		return highlightMatches(utils.RightPadTrim(text, width, true), textMatches, width-3, style)
+	}
+
+	textWidth := width - chipsWidth
+	trimmed := utils.Trim(text, textWidth)
+	limit := len(trimmed)
+	if len(text) > len(trimmed) {
+		limit = textWidth - 3
+	}
+
+	return fmt.Sprintf("%s %s%s",
+		highlightMatches(trimmed, textMatches, limit, style),
+		strings.Join(chips, " "),
+		strings.Repeat(" ", textWidth-len(trimmed)),
+	)
+}
+
+// setTags populates the tag selection list, keeping the cursor on the tag it
+// was on (if it's still present), and updates the colors tags are shown in.
+func (m *Model) setTags(tags []types.Tag) {
+	var selectedName string
+	if i, ok := m.tagsList.SelectedItem().(tagItem); ok {
+		selectedName = i.tag.Name
+	}
+
+	// the map is shared with list delegates, so it's updated in place
+	for name := range m.tagColors {
+		delete(m.tagColors, name)
+	}
+
+	items := make([]list.Item, len(tags))
+	for i, t := range tags {
+		items[i] = tagItem{t}
+		if t.Color != "" {
+			m.tagColors[t.Name] = t.Color
+		}
+	}
+	m.tagsList.SetItems(items)
+
+	for i, t := range tags {
+		if t.Name == selectedName {
+			m.tagsList.Select(i)
+			return
+		}
+	}
+
+	if m.tagsList.Index() >= len(items) {
+		m.tagsList.Select(max(len(items)-1, 0))
+	}
+}
+
+// showTagTasks switches to a list of the active tasks that have a tag.
+func (m *Model) showTagTasks(tag types.Tag) {
+	q, err := query.Parse("tag:" + tag.Name)
+	if err != nil {
+		m.errorMsg = somethingWentWrongMsg
+		return
+	}
+
+	m.showQueryResults(q, fmt.Sprintf("%s: %s%s", tagsTitle, types.TagPrefix, tag.Name), tagSelectionView)
+}
+
+// startTagChange switches to the tag entry pane, for a change to the selected
+// tag.
+func (m *Model) startTagChange(change tagChangeType) {
+	item, ok := m.tagsList.SelectedItem().(tagItem)
+	if !ok {
+		return
+	}
+
+	m.tagChange = change
+	m.tagInput.Reset()
+	switch change {
+	case tagRename:
+		m.tagInput.Placeholder = "new name"
+		m.tagInput.SetValue(item.tag.Name)
+	case tagMerge:
+		m.tagInput.Placeholder = "tag to merge into"
+	case tagRecolor:
+		m.tagInput.Placeholder = "#fabd2f, or an ANSI color number; leave empty to unset"
+		m.tagInput.SetValue(item.tag.Color)
+	}
+	m.tagInput.CursorEnd()
+	m.tagInput.Focus()
+	m.activeView = tagEntryView
+}
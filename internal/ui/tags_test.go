@@ -0,0 +1,61 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStripTags(t *testing.T) {
+	testCases := []struct {
+		name            string
+		summary         string
+		matches         []int
+		expectedText    string
+		expectedMatches []int
+		expectedTags    []string
+	}{
+		{
+			name:            "no tags",
+			summary:         "fix the build",
+			matches:         []int{0, 4},
+			expectedText:    "fix the build",
+			expectedMatches: []int{0, 4},
+		},
+		{
+			name:            "tags at the end",
+			summary:         "fix the build #ci #Urgent",
+			matches:         []int{4, 15},
+			expectedText:    "fix the build",
+			expectedMatches: []int{4},
+			expectedTags:    []string{"ci", "urgent"},
+		},
+		{
+			name:            "tags at the start and in the middle",
+			summary:         "#ci fix #urgent the build",
+			matches:         []int{4, 16},
+			expectedText:    "fix the build",
+			expectedMatches: []int{0, 4},
+			expectedTags:    []string{"ci", "urgent"},
+		},
+		{
+			name:         "only tags",
+			summary:      "#ci #urgent",
+			expectedText: "",
+			expectedTags: []string{"ci", "urgent"},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			// WHEN
+			text, matches, tags := stripTags(tt.summary, tt.matches)
+
+			// THEN
+			assert.Equal(t, tt.expectedText, text)
+			assert.Equal(t, tt.expectedMatches, matches)
+			assert.Equal(t, tt.expectedTags, tags)
+		})
+	}
+}
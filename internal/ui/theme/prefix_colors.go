@@ -0,0 +1,83 @@
+package theme
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+var ErrPrefixColorInvalid = errors.New("prefix color is invalid; it needs to be either a hex color (eg. #fabd2f), or an ANSI color number (0-255)")
+
+// PrefixColors are colors pinned to prefixes, either for all themes, or for
+// specific ones. Prefixes are stored (and matched) in lower case.
+type PrefixColors struct {
+	All     map[string]string
+	ByTheme map[string]map[string]string
+}
+
+// ParsePrefixColors parses the prefix colors set in the config file, eg.
+//
+//	[prefix_colors]
+//	work = "#fabd2f"
+//
+//	[prefix_colors.dracula]
+//	work = "212"
+//
+// String (or number) values pin a color for all themes, and tables named after
+// themes pin colors for just that theme.
+func ParsePrefixColors(values map[string]any) (PrefixColors, error) {
+	colors := PrefixColors{
+		All:     make(map[string]string),
+		ByTheme: make(map[string]map[string]string),
+	}
+
+	for key, value := range values {
+		themeColors, ok := value.(map[string]any)
+		if !ok {
+			color, err := parsePrefixColor(key, value)
+			if err != nil {
+				return colors, err
+			}
+			colors.All[strings.ToLower(key)] = color
+			continue
+		}
+
+		if !slices.Contains(All(), key) {
+			return colors, fmt.Errorf("%w: %q", ErrInvalidThemeName, key)
+		}
+
+		colors.ByTheme[key] = make(map[string]string)
+		for prefix, v := range themeColors {
+			color, err := parsePrefixColor(prefix, v)
+			if err != nil {
+				return colors, err
+			}
+			colors.ByTheme[key][strings.ToLower(prefix)] = color
+		}
+	}
+
+	return colors, nil
+}
+
+func parsePrefixColor(prefix string, value any) (string, error) {
+	color := strings.TrimSpace(fmt.Sprintf("%v", value))
+	if !IsColorValid(color) {
+		return "", fmt.Errorf("%w: %q (for %q)", ErrPrefixColorInvalid, color, prefix)
+	}
+
+	return color, nil
+}
+
+// For returns the colors pinned for a theme; ones set for the theme take
+// precedence over ones set for all themes.
+func (p PrefixColors) For(themeName string) map[string]string {
+	colors := maps.Clone(p.All)
+	if colors == nil {
+		colors = make(map[string]string)
+	}
+	maps.Copy(colors, p.ByTheme[themeName])
+
+	return colors
+}
@@ -0,0 +1,80 @@
+package theme
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePrefixColors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		values   map[string]any
+		expected PrefixColors
+		err      error
+	}{
+		{
+			name: "colors for all themes, and for a single one",
+			values: map[string]any{
+				"Work":    "#fabd2f",
+				"infra":   int64(4),
+				"dracula": map[string]any{"work": " 212 "},
+			},
+			expected: PrefixColors{
+				All:     map[string]string{"work": "#fabd2f", "infra": "4"},
+				ByTheme: map[string]map[string]string{"dracula": {"work": "212"}},
+			},
+		},
+		{
+			name:   "invalid color",
+			values: map[string]any{"work": "yellow"},
+			err:    ErrPrefixColorInvalid,
+		},
+		{
+			name:   "invalid color for a theme",
+			values: map[string]any{"dracula": map[string]any{"work": "256"}},
+			err:    ErrPrefixColorInvalid,
+		},
+		{
+			name:   "unknown theme",
+			values: map[string]any{"solarized": map[string]any{"work": "4"}},
+			err:    ErrInvalidThemeName,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			// WHEN
+			got, err := ParsePrefixColors(tt.values)
+
+			// THEN
+			if tt.err != nil {
+				require.ErrorIs(t, err, tt.err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestPrefixColorsForTheme(t *testing.T) {
+	// GIVEN
+	colors := PrefixColors{
+		All:     map[string]string{"work": "#fabd2f", "infra": "4"},
+		ByTheme: map[string]map[string]string{"dracula": {"work": "212"}},
+	}
+
+	// WHEN
+	forDracula := colors.For("dracula")
+	forOneDark := colors.For("onedark")
+
+	// THEN
+	assert.Equal(t, map[string]string{"work": "212", "infra": "4"}, forDracula)
+	assert.Equal(t, map[string]string{"work": "#fabd2f", "infra": "4"}, forOneDark)
+	assert.Equal(t, "#fabd2f", colors.All["work"], "pinned colors shouldn't be modified")
+	assert.Empty(t, PrefixColors{}.For("dracula"))
+}
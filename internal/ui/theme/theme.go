@@ -3,6 +3,9 @@ package theme
 import (
 	"errors"
 	"fmt"
+	"maps"
+	"regexp"
+	"strconv"
 	"strings"
 )
 
@@ -12,6 +15,8 @@ const (
 
 var ErrInvalidThemeName = errors.New("invalid theme name provided")
 
+var hexColorRegex = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
 var themes = []Theme{
 	catppuccinMocha(),
 	dracula(),
@@ -37,6 +42,9 @@ type Theme struct {
 	Text         string
 	Background   string
 	PrefixColors []string
+	// PinnedPrefixColors are colors pinned to (lower-cased) prefixes via the
+	// config file; they take precedence over PrefixColors
+	PinnedPrefixColors map[string]string
 }
 
 func All() []string {
@@ -90,6 +98,18 @@ func themeByOffset(name string, offset int) (Theme, error) {
 func cloneTheme(thm Theme) Theme {
 	cp := thm
 	cp.PrefixColors = append([]string(nil), thm.PrefixColors...)
+	cp.PinnedPrefixColors = maps.Clone(thm.PinnedPrefixColors)
 
 	return cp
 }
+
+// IsColorValid reports whether a color is either a hex color (eg. #fabd2f), or
+// an ANSI color number (0-255).
+func IsColorValid(color string) bool {
+	if hexColorRegex.MatchString(color) {
+		return true
+	}
+
+	num, err := strconv.Atoi(color)
+	return err == nil && num >= 0 && num <= 255 && !strings.HasPrefix(color, "+")
+}
@@ -137,3 +137,31 @@ func TestPreviousTheme(t *testing.T) {
 		})
 	}
 }
+
+func TestIsColorValid(t *testing.T) {
+	testCases := []struct {
+		color    string
+		expected bool
+	}{
+		{color: "#fabd2f", expected: true},
+		{color: "#FFF", expected: true},
+		{color: "214", expected: true},
+		{color: "0", expected: true},
+		{color: "256", expected: false},
+		{color: "-1", expected: false},
+		{color: "#fabd2", expected: false},
+		{color: "yellow", expected: false},
+		{color: "", expected: false},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.color, func(t *testing.T) {
+			// GIVEN
+			// WHEN
+			got := IsColorValid(tt.color)
+
+			// THEN
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
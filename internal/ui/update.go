@@ -5,37 +5,90 @@ import (
 	"fmt"
 	"os"
 	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"charm.land/bubbles/v2/list"
 	"charm.land/bubbles/v2/viewport"
 	tea "charm.land/bubbletea/v2"
+	"github.com/dhth/omm/internal/hooks"
 	pers "github.com/dhth/omm/internal/persistence"
+	"github.com/dhth/omm/internal/stats"
 	"github.com/dhth/omm/internal/types"
 	"github.com/dhth/omm/internal/ui/theme"
 	"github.com/dhth/omm/internal/utils"
+	"github.com/dhth/omm/internal/views"
 )
 
 const (
 	noSpaceAvailableMsg         = "Task list is at capacity. Archive/delete tasks using ctrl+d/ctrl+x."
 	noContextMsg                = "  ∅"
 	viewPortMoveLineCount       = 3
-	cannotMoveWhenFilteredMsg   = "Can't move items when the task list is filtered"
-	cannotAddWhenFilteredMsg    = "Can't add items when the task list is filtered"
-	cannotDeleteWhenFilteredMsg = "Can't delete items when the task list is filtered"
+	statsNumWeeks               = 8
+	cannotGroupWhenFilteredMsg  = "Can't group items when the task list is filtered"
+	cannotShowBoardWhenFiltered = "Can't show the board when the task list is filtered"
 	somethingWentWrongMsg       = "Something went wrong"
+	cannotDeleteConfigViewMsg   = "Views defined in the config file can't be deleted from omm"
+	readOnlyMsg                 = "Tasks can't be changed; the database was migrated by a newer version of omm"
+	invalidTagNameMsg           = "Tag names need to start with a letter, and can only contain letters, numbers, _ and -"
+	invalidColorMsg             = "Colors need to be either hex colors (eg. #fabd2f), or ANSI color numbers (0-255)"
 )
 
 //go:embed assets/help.md
 var helpStr string
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	m, cmd := m.update(msg)
+
+	m.tlFilter.setItems(m.taskList.Items())
+	m.atlFilter.setItems(m.archivedTaskList.Items())
+
+	return m, cmd
+}
+
+func (m Model) update(msg tea.Msg) (Model, tea.Cmd) {
 	var cmd tea.Cmd
 	var cmds []tea.Cmd
 	m.successMsg = ""
 	m.errorMsg = ""
 
+	// snoozed tasks are woken up periodically, which needs to carry on
+	// regardless of the view that's active
+	switch msg := msg.(type) {
+	case snoozedTasksDueMsg:
+		// a task being entered refers to its position in the task list, which
+		// can't change under it
+		if m.activeView == taskEntryView || (m.activeView == prefixSelectionView && m.prefixSearchUse == prefixChoose) {
+			cmds = append(cmds, scheduleSnoozedTasksWakeUp(wakeSnoozedTasksInterval))
+			break
+		}
+		cmds = append(cmds, wakeSnoozedTasks(m.db, time.Now()))
+
+	case snoozedTasksWokenMsg:
+		if msg.err != nil {
+			m.errorMsg = fmt.Sprintf("Error waking up snoozed tasks: %s", msg.err)
+		} else if len(msg.tasks) > 0 {
+			cmds = append(cmds, m.showWokenTasks(msg.tasks))
+		}
+		cmds = append(cmds, scheduleSnoozedTasksWakeUp(wakeSnoozedTasksInterval))
+
+	case snapshotDueMsg:
+		if msg.gen == m.snapshotGen {
+			cmds = append(cmds, takeSnapshot(m.cfg.Snapshot))
+		}
+
+	case snapshotTakenMsg:
+		if msg.err != nil {
+			m.errorMsg = fmt.Sprintf("Error snapshotting tasks: %s", msg.err)
+		}
+	}
+
+	if m.cfg.Snapshot != nil && changedTasks(msg) {
+		m.snapshotGen++
+		cmds = append(cmds, scheduleSnapshot(snapshotDelay, m.snapshotGen))
+	}
+
 	if m.activeView == taskListView || m.activeView == archivedTaskListView {
 		switch msg := msg.(type) {
 		case tea.KeyPressMsg:
@@ -165,6 +218,184 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, tea.Batch(cmds...)
 	}
 
+	if m.activeView == savedViewEntryView {
+		switch msg := msg.(type) {
+		case tea.KeyPressMsg:
+			switch msg.String() {
+			case "esc", "ctrl+c":
+				m.activeView = savedViewSelectionView
+				return m, tea.Batch(cmds...)
+			case "enter":
+				v, err := parseSavedViewInput(m.savedViewInput.Value())
+				if err != nil {
+					m.errorMsg = err.Error()
+					return m, tea.Batch(cmds...)
+				}
+
+				_, exists := views.Find(m.getSavedViews(), v.Name)
+				if exists {
+					m.errorMsg = views.ErrViewAlreadyExists.Error()
+					return m, tea.Batch(cmds...)
+				}
+
+				cmds = append(cmds, createSavedView(m.db, v.Name, v.Definition, time.Now()))
+				m.savedViewInput.Reset()
+				m.activeView = savedViewSelectionView
+				return m, tea.Batch(cmds...)
+			}
+		}
+
+		m.savedViewInput, cmd = m.savedViewInput.Update(msg)
+		cmds = append(cmds, cmd)
+		return m, tea.Batch(cmds...)
+	}
+
+	if m.activeView == queryEntryView {
+		switch msg := msg.(type) {
+		case tea.KeyPressMsg:
+			switch msg.String() {
+			case "esc", "ctrl+c":
+				m.activeView = taskListView
+				return m, tea.Batch(cmds...)
+			case "enter":
+				if strings.TrimSpace(m.queryInput.Value()) == "" {
+					m.activeView = taskListView
+					return m, tea.Batch(cmds...)
+				}
+
+				q, err := parseQueryInput(m.queryInput.Value())
+				if err != nil {
+					m.errorMsg = err.Error()
+					return m, tea.Batch(cmds...)
+				}
+
+				m.showQueryResults(q, fmt.Sprintf("%s: %s", queryTitle, q), queryEntryView)
+				return m, tea.Batch(cmds...)
+			}
+		}
+
+		m.queryInput, cmd = m.queryInput.Update(msg)
+		cmds = append(cmds, cmd)
+		return m, tea.Batch(cmds...)
+	}
+
+	if m.activeView == tagEntryView {
+		switch msg := msg.(type) {
+		case tea.KeyPressMsg:
+			switch msg.String() {
+			case "esc", "ctrl+c":
+				m.activeView = tagSelectionView
+				return m, tea.Batch(cmds...)
+			case "enter":
+				item, ok := m.tagsList.SelectedItem().(tagItem)
+				if !ok {
+					m.activeView = tagSelectionView
+					return m, tea.Batch(cmds...)
+				}
+
+				value := strings.TrimSpace(m.tagInput.Value())
+				switch m.tagChange {
+				case tagRename, tagMerge:
+					name := strings.ToLower(strings.TrimPrefix(value, types.TagPrefix))
+					if !types.IsTagNameValid(name) {
+						m.errorMsg = invalidTagNameMsg
+						return m, tea.Batch(cmds...)
+					}
+					if name == item.tag.Name {
+						m.activeView = tagSelectionView
+						return m, tea.Batch(cmds...)
+					}
+
+					if m.tagChange == tagRename {
+						cmds = append(cmds, renameTag(m.db, item.tag.Name, name, time.Now()))
+					} else {
+						cmds = append(cmds, mergeTags(m.db, item.tag.Name, name, time.Now()))
+					}
+				case tagRecolor:
+					if value != "" && !theme.IsColorValid(value) {
+						m.errorMsg = invalidColorMsg
+						return m, tea.Batch(cmds...)
+					}
+					cmds = append(cmds, setTagColor(m.db, item.tag.Name, value))
+				}
+
+				m.tagInput.Reset()
+				m.activeView = tagSelectionView
+				return m, tea.Batch(cmds...)
+			}
+		}
+
+		m.tagInput, cmd = m.tagInput.Update(msg)
+		cmds = append(cmds, cmd)
+		return m, tea.Batch(cmds...)
+	}
+
+	if m.activeView == prefixEntryView {
+		switch msg := msg.(type) {
+		case tea.KeyPressMsg:
+			switch msg.String() {
+			case "esc", "ctrl+c":
+				m.activeView = prefixManagementView
+				return m, tea.Batch(cmds...)
+			case "enter":
+				item, ok := m.prefixesList.SelectedItem().(prefixCountItem)
+				if !ok {
+					m.activeView = prefixManagementView
+					return m, tea.Batch(cmds...)
+				}
+
+				prefix := strings.TrimSpace(m.prefixInput.Value())
+				err := types.CheckIfTaskPrefixValid(prefix)
+				if err != nil {
+					m.errorMsg = err.Error()
+					return m, tea.Batch(cmds...)
+				}
+
+				if prefix != item.Title() {
+					if m.prefixChange == prefixRename {
+						cmds = append(cmds, renamePrefix(m.db, item.Title(), prefix, time.Now()))
+					} else {
+						cmds = append(cmds, mergePrefixes(m.db, item.Title(), prefix, time.Now()))
+					}
+				}
+
+				m.prefixInput.Reset()
+				m.activeView = prefixManagementView
+				return m, tea.Batch(cmds...)
+			}
+		}
+
+		m.prefixInput, cmd = m.prefixInput.Update(msg)
+		cmds = append(cmds, cmd)
+		return m, tea.Batch(cmds...)
+	}
+
+	if m.activeView == snoozeEntryView {
+		switch msg := msg.(type) {
+		case tea.KeyPressMsg:
+			switch msg.String() {
+			case "esc", "ctrl+c":
+				m.activeView = m.snoozeEntryParent
+				return m, tea.Batch(cmds...)
+			case "enter":
+				until, err := parseSnoozeInput(m.snoozeInput.Value(), time.Now())
+				if err != nil {
+					m.errorMsg = err.Error()
+					return m, tea.Batch(cmds...)
+				}
+
+				cmds = append(cmds, snoozeTask(m.db, m.snoozeTaskID, until))
+				m.snoozeInput.Reset()
+				m.activeView = m.snoozeEntryParent
+				return m, tea.Batch(cmds...)
+			}
+		}
+
+		m.snoozeInput, cmd = m.snoozeInput.Update(msg)
+		cmds = append(cmds, cmd)
+		return m, tea.Batch(cmds...)
+	}
+
 	skipListUpdate := false
 
 	switch msg := msg.(type) {
@@ -193,6 +424,18 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		m.taskList.SetHeight(listHeight)
 		m.archivedTaskList.SetHeight(listHeight)
+		m.groupedTaskList.SetWidth(msg.Width - w)
+		m.groupedTaskList.SetHeight(msg.Height - h - h3 - 1)
+		m.savedViewsList.SetWidth(msg.Width - 2)
+		m.savedViewsList.SetHeight(msg.Height - h - h3 - 1)
+		m.queryResultsList.SetWidth(msg.Width - w)
+		m.queryResultsList.SetHeight(msg.Height - h - h3 - 1)
+		m.tagsList.SetWidth(msg.Width - 2)
+		m.tagsList.SetHeight(msg.Height - h - h3 - 1)
+		m.prefixesList.SetWidth(msg.Width - 2)
+		m.prefixesList.SetHeight(msg.Height - h - h3 - 1)
+		m.snoozedTaskList.SetWidth(msg.Width - 2)
+		m.snoozedTaskList.SetHeight(msg.Height - h - h3 - 1)
 		vpWidth := msg.Width - 4
 
 		if !m.contextVPReady {
@@ -246,14 +489,23 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.helpVP.SetHeight(m.terminalHeight - 4)
 		}
 
+		if !m.statsVPReady {
+			m.statsVP = viewport.New(viewport.WithWidth(msg.Width-3), viewport.WithHeight(m.terminalHeight-4))
+			m.statsVP.KeyMap.Up.SetEnabled(false)
+			m.statsVP.KeyMap.Down.SetEnabled(false)
+			m.statsVPReady = true
+		} else {
+			m.statsVP.SetWidth(msg.Width - 3)
+			m.statsVP.SetHeight(m.terminalHeight - 4)
+		}
+
 	case tea.KeyPressMsg:
 		if m.cfg.ConfirmBeforeDeletion && m.showDeletePrompt && msg.String() != "ctrl+x" {
 			m.showDeletePrompt = false
 
 			switch m.activeView {
 			case taskListView:
-				m.taskList.Title = m.cfg.TaskListTitle
-				m.taskList.Styles.Title = m.styles.activeListTitleBar
+				m.resetTaskListTitle()
 			case archivedTaskListView:
 				m.archivedTaskList.Title = archivedTitle
 				m.archivedTaskList.Styles.Title = m.styles.archivedListTitleBar
@@ -261,6 +513,17 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, tea.Batch(cmds...)
 		}
 
+		if m.showArchivePrompt && msg.String() != "ctrl+d" {
+			m.showArchivePrompt = false
+			m.resetTaskListTitle()
+			return m, tea.Batch(cmds...)
+		}
+
+		if m.cfg.ReadOnly && m.changesTasks(msg.String()) {
+			m.errorMsg = readOnlyMsg
+			return m, tea.Batch(cmds...)
+		}
+
 		switch keypress := msg.String(); keypress {
 
 		case "Q":
@@ -273,6 +536,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "esc", "q", "ctrl+c":
 			av := m.activeView
 
+			if m.activeView == taskListView && m.dependencyTaskID != 0 {
+				m.stopPickingBlocker()
+				break
+			}
+
 			if m.activeView == taskListView && m.taskList.IsFiltered() {
 				m.taskList.ResetFilter()
 				break
@@ -290,7 +558,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				break
 			}
 
-			if m.activeView == taskDetailsView || m.activeView == contextBookmarksView || m.activeView == helpView {
+			if m.activeView == taskDetailsView || m.activeView == contextBookmarksView || m.activeView == helpView || m.activeView == statsView || m.activeView == boardView {
 				m.activeView = m.lastActiveView
 				switch m.activeView {
 				case taskListView:
@@ -309,6 +577,21 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				break
 			}
 
+			if m.activeView == prefixManagementView {
+				m.activeView = m.lastActiveView
+				break
+			}
+
+			if m.activeView == groupedTaskListView || m.activeView == savedViewSelectionView || m.activeView == tagSelectionView || m.activeView == snoozedTaskListView {
+				m.activeView = taskListView
+				break
+			}
+
+			if m.activeView == queryResultsView {
+				m.activeView = m.queryResultsParent
+				break
+			}
+
 			m.quitting = true
 			if m.cfg.Guide {
 				_ = os.Remove(m.cfg.DBPath)
@@ -316,7 +599,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, tea.Quit
 
 		case "?":
-			if m.activeView == taskDetailsView || m.activeView == contextBookmarksView || m.activeView == prefixSelectionView {
+			if m.activeView == taskDetailsView || m.activeView == contextBookmarksView || m.activeView == prefixSelectionView || m.activeView == prefixManagementView || m.activeView == statsView || m.activeView == boardView {
 				break
 			}
 
@@ -327,6 +610,193 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.lastActiveView = m.activeView
 			m.activeView = helpView
 
+		case "S":
+			if m.activeView == statsView {
+				m.activeView = m.lastActiveView
+				break
+			}
+
+			if m.activeView != taskListView && m.activeView != archivedTaskListView {
+				break
+			}
+
+			cmds = append(cmds, fetchStats(m.db, time.Now()))
+
+		case "z":
+			switch m.activeView {
+			case taskListView:
+				if len(m.taskList.Items()) == 0 {
+					break
+				}
+
+				if m.taskList.IsFiltered() {
+					m.errorMsg = cannotGroupWhenFilteredMsg
+					break
+				}
+
+				m.refreshGroupedTaskList()
+				if t, ok := m.taskList.SelectedItem().(types.Task); ok {
+					m.selectGroupedTask(t)
+				}
+				m.activeView = groupedTaskListView
+
+			case groupedTaskListView:
+				m.showSelectedGroupedTask()
+			}
+
+		case "w":
+			switch m.activeView {
+			case taskListView, archivedTaskListView:
+				if m.taskList.IsFiltered() || m.archivedTaskList.IsFiltered() {
+					m.errorMsg = cannotShowBoardWhenFiltered
+					break
+				}
+
+				var t types.Task
+				var ok bool
+				if m.activeView == taskListView {
+					t, ok = m.taskList.SelectedItem().(types.Task)
+				} else {
+					t, ok = m.archivedTaskList.SelectedItem().(types.Task)
+				}
+
+				m.clampBoardCursor(m.getBoard())
+				if ok {
+					m.focusBoardTask(t.ID)
+				}
+				m.lastActiveView = m.activeView
+				m.activeView = boardView
+
+			case boardView:
+				m.activeView = m.lastActiveView
+			}
+
+		case ":":
+			if m.activeView != taskListView {
+				break
+			}
+
+			m.queryInput.Focus()
+			m.queryInput.CursorEnd()
+			m.activeView = queryEntryView
+			return m, tea.Batch(cmds...)
+
+		case "V":
+			switch m.activeView {
+			case taskListView:
+				cmds = append(cmds, fetchSavedViews(m.db))
+			case savedViewSelectionView, queryResultsView:
+				m.activeView = taskListView
+			}
+
+		case "T":
+			switch m.activeView {
+			case taskListView:
+				cmds = append(cmds, fetchTags(m.db, true))
+			case tagSelectionView, queryResultsView:
+				m.activeView = taskListView
+			}
+
+		case "r":
+			switch m.activeView {
+			case tagSelectionView:
+				m.startTagChange(tagRename)
+				return m, tea.Batch(cmds...)
+			case prefixManagementView:
+				m.startPrefixChange(prefixRename)
+				return m, tea.Batch(cmds...)
+			}
+
+		case "m":
+			switch m.activeView {
+			case tagSelectionView:
+				m.startTagChange(tagMerge)
+				return m, tea.Batch(cmds...)
+			case prefixManagementView:
+				m.startPrefixChange(prefixMerge)
+				return m, tea.Batch(cmds...)
+			}
+
+		case "M":
+			if m.activeView != prefixSelectionView || m.prefixSearchUse != prefixFilter {
+				break
+			}
+
+			var selected types.TaskPrefix
+			if p, ok := m.prefixSearchList.SelectedItem().(types.TaskPrefix); ok {
+				selected = p
+			}
+			cmds = append(cmds, fetchPrefixes(m.db, selected, true))
+
+		case "H":
+			if m.activeView != boardView {
+				break
+			}
+
+			cmds = append(cmds, m.moveBoardTask(-1))
+
+		case "L":
+			if m.activeView != boardView {
+				break
+			}
+
+			cmds = append(cmds, m.moveBoardTask(1))
+
+		case "space":
+			if m.activeView != groupedTaskListView {
+				break
+			}
+
+			prefix, ok := m.selectedGroupPrefix()
+			if !ok {
+				break
+			}
+
+			m.collapsedGroups[prefix] = !m.collapsedGroups[prefix]
+			m.refreshGroupedTaskList()
+			m.selectGroupHeader(prefix)
+
+		case "Z":
+			switch m.activeView {
+			case taskListView:
+				cmds = append(cmds, fetchSnoozedTasks(m.db, true))
+
+			case snoozedTaskListView:
+				m.activeView = taskListView
+
+			case groupedTaskListView:
+				groups := m.getActiveTaskGroups()
+				collapse := slices.ContainsFunc(groups, func(g taskGroup) bool {
+					return !m.collapsedGroups[g.prefix]
+				})
+
+				prefix, ok := m.selectedGroupPrefix()
+				for _, g := range groups {
+					m.collapsedGroups[g.prefix] = collapse
+				}
+				m.refreshGroupedTaskList()
+				if ok {
+					m.selectGroupHeader(prefix)
+				}
+			}
+
+		case "s":
+			var t types.Task
+			var ok bool
+			switch m.activeView {
+			case taskListView:
+				t, ok = m.taskList.SelectedItem().(types.Task)
+			case snoozedTaskListView:
+				t, ok = m.snoozedTaskList.SelectedItem().(types.Task)
+			}
+
+			if !ok {
+				break
+			}
+
+			m.startSnooze(t, m.activeView)
+			return m, tea.Batch(cmds...)
+
 		case "tab", "shift+tab":
 			switch m.activeView {
 			case taskListView:
@@ -349,11 +819,6 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				break
 			}
 
-			if m.taskList.IsFiltered() {
-				m.errorMsg = cannotAddWhenFilteredMsg
-				break
-			}
-
 			m.taskIndex = 0
 			m.taskInput.Reset()
 			m.taskInput.Focus()
@@ -371,12 +836,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				break
 			}
 
-			if m.taskList.IsFiltered() {
-				m.errorMsg = cannotAddWhenFilteredMsg
-				break
+			_, index, ok := m.selectedTaskIndex(activeTasks)
+			if !ok {
+				index = 0
 			}
 
-			m.taskIndex = m.taskList.Index()
+			m.taskIndex = index
 			m.taskInput.Reset()
 			m.taskInput.Focus()
 			m.taskChange = taskInsert
@@ -384,6 +849,13 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, tea.Batch(cmds...)
 
 		case "a", "o":
+			if m.activeView == savedViewSelectionView && keypress == "a" {
+				m.savedViewInput.Reset()
+				m.savedViewInput.Focus()
+				m.activeView = savedViewEntryView
+				return m, tea.Batch(cmds...)
+			}
+
 			if m.activeView != taskListView {
 				break
 			}
@@ -393,15 +865,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				break
 			}
 
-			if m.taskList.IsFiltered() {
-				m.errorMsg = cannotAddWhenFilteredMsg
-				break
-			}
-
-			if len(m.taskList.Items()) == 0 {
-				m.taskIndex = 0
+			_, index, ok := m.selectedTaskIndex(activeTasks)
+			if ok {
+				m.taskIndex = index + 1
 			} else {
-				m.taskIndex = m.taskList.Index() + 1
+				m.taskIndex = 0
 			}
 			m.taskInput.Reset()
 			m.taskInput.Focus()
@@ -419,11 +887,6 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				break
 			}
 
-			if m.taskList.IsFiltered() {
-				m.errorMsg = cannotAddWhenFilteredMsg
-				break
-			}
-
 			m.taskIndex = len(m.taskList.Items())
 			m.taskInput.Reset()
 			m.taskInput.Focus()
@@ -433,7 +896,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		case "down", "j":
 			switch m.activeView {
-			case taskListView, archivedTaskListView, contextBookmarksView, prefixSelectionView:
+			case taskListView, archivedTaskListView, contextBookmarksView, prefixSelectionView, groupedTaskListView, savedViewSelectionView, queryResultsView, tagSelectionView, prefixManagementView, snoozedTaskListView:
 				if !m.cfg.CircularNav {
 					break
 				}
@@ -449,6 +912,18 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					list = &m.taskBMList
 				case prefixSelectionView:
 					list = &m.prefixSearchList
+				case groupedTaskListView:
+					list = &m.groupedTaskList
+				case savedViewSelectionView:
+					list = &m.savedViewsList
+				case queryResultsView:
+					list = &m.queryResultsList
+				case tagSelectionView:
+					list = &m.tagsList
+				case prefixManagementView:
+					list = &m.prefixesList
+				case snoozedTaskListView:
+					list = &m.snoozedTaskList
 				default:
 					break
 				}
@@ -478,11 +953,21 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					break
 				}
 				m.helpVP.ScrollDown(viewPortMoveLineCount)
+
+			case statsView:
+				if m.statsVP.AtBottom() {
+					break
+				}
+				m.statsVP.ScrollDown(viewPortMoveLineCount)
+
+			case boardView:
+				m.boardRows[m.boardColumnIndex]++
+				m.clampBoardCursor(m.getBoard())
 			}
 
 		case "up", "k":
 			switch m.activeView {
-			case taskListView, archivedTaskListView, contextBookmarksView, prefixSelectionView:
+			case taskListView, archivedTaskListView, contextBookmarksView, prefixSelectionView, groupedTaskListView, savedViewSelectionView, queryResultsView, tagSelectionView, prefixManagementView, snoozedTaskListView:
 				if !m.cfg.CircularNav {
 					break
 				}
@@ -498,6 +983,18 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					list = &m.taskBMList
 				case prefixSelectionView:
 					list = &m.prefixSearchList
+				case groupedTaskListView:
+					list = &m.groupedTaskList
+				case savedViewSelectionView:
+					list = &m.savedViewsList
+				case queryResultsView:
+					list = &m.queryResultsList
+				case tagSelectionView:
+					list = &m.tagsList
+				case prefixManagementView:
+					list = &m.prefixesList
+				case snoozedTaskListView:
+					list = &m.snoozedTaskList
 				default:
 					break
 				}
@@ -527,15 +1024,35 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					break
 				}
 				m.helpVP.ScrollUp(viewPortMoveLineCount)
+
+			case statsView:
+				if m.statsVP.AtTop() {
+					break
+				}
+				m.statsVP.ScrollUp(viewPortMoveLineCount)
+
+			case boardView:
+				m.boardRows[m.boardColumnIndex]--
+				m.clampBoardCursor(m.getBoard())
 			}
 
 		case "J":
+			if m.activeView == groupedTaskListView {
+				cmds = append(cmds, m.moveSelectedGroup(1))
+				break
+			}
+
+			if m.activeView == boardView {
+				cmds = append(cmds, m.reorderBoardTask(1))
+				break
+			}
+
 			if m.activeView != taskListView {
 				break
 			}
 
 			if m.taskList.IsFiltered() {
-				m.errorMsg = cannotMoveWhenFilteredMsg
+				cmds = append(cmds, m.moveFilteredTask(1))
 				break
 			}
 
@@ -558,12 +1075,22 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			cmds = append(cmds, cmd)
 
 		case "K":
+			if m.activeView == groupedTaskListView {
+				cmds = append(cmds, m.moveSelectedGroup(-1))
+				break
+			}
+
+			if m.activeView == boardView {
+				cmds = append(cmds, m.reorderBoardTask(-1))
+				break
+			}
+
 			if m.activeView != taskListView {
 				break
 			}
 
 			if m.taskList.IsFiltered() {
-				m.errorMsg = cannotMoveWhenFilteredMsg
+				cmds = append(cmds, m.moveFilteredTask(-1))
 				break
 			}
 
@@ -590,9 +1117,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				break
 			}
 
-			listItem := m.taskList.SelectedItem()
-			index := m.taskList.Index()
-			t, ok := listItem.(types.Task)
+			t, index, ok := m.selectedTaskIndex(activeTasks)
 			if !ok {
 				m.errorMsg = somethingWentWrongMsg
 				break
@@ -630,6 +1155,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 			cmds = append(cmds, fetchTasks(m.db, true, pers.TaskNumLimit))
 			cmds = append(cmds, fetchTasks(m.db, false, pers.TaskNumLimit))
+			cmds = append(cmds, fetchSnoozedTasks(m.db, false))
+			cmds = append(cmds, fetchTaskDependencies(m.db))
 
 		case "ctrl+d":
 			switch m.activeView {
@@ -638,19 +1165,24 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					break
 				}
 
-				if m.taskList.IsFiltered() {
-					m.errorMsg = "Cannot archive items when the task list is filtered"
+				t, index, ok := m.selectedTaskIndex(activeTasks)
+				if !ok {
+					m.errorMsg = "Something went wrong; cannot archive item"
 					break
 				}
 
-				listItem := m.taskList.SelectedItem()
-				index := m.taskList.Index()
-				t, ok := listItem.(types.Task)
-				if !ok {
-					m.errorMsg = "Something went wrong; cannot archive item"
+				if !m.showArchivePrompt && len(m.getOpenDependents(t.ID)) > 0 {
+					m.showArchivePrompt = true
+					m.taskList.Title = "archive ?"
+					m.taskList.Styles.Title = m.styles.dangerListTitleBar
 					break
 				}
 
+				if m.showArchivePrompt {
+					m.showArchivePrompt = false
+					m.resetTaskListTitle()
+				}
+
 				cmd = changeTaskStatus(m.db, index, t.ID, false, time.Now())
 				cmds = append(cmds, cmd)
 
@@ -659,14 +1191,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					break
 				}
 
-				if m.archivedTaskList.IsFiltered() {
-					m.errorMsg = "Cannot unarchive items when the task list is filtered"
-					break
-				}
-
-				listItem := m.archivedTaskList.SelectedItem()
-				index := m.archivedTaskList.Index()
-				t, ok := listItem.(types.Task)
+				t, index, ok := m.selectedTaskIndex(archivedTasks)
 				if !ok {
 					m.errorMsg = somethingWentWrongMsg
 					break
@@ -677,6 +1202,21 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 		case "ctrl+x":
+			if m.activeView == savedViewSelectionView {
+				item, ok := m.savedViewsList.SelectedItem().(savedViewItem)
+				if !ok {
+					break
+				}
+
+				if item.view.Source == views.FromConfig {
+					m.errorMsg = cannotDeleteConfigViewMsg
+					break
+				}
+
+				cmds = append(cmds, deleteSavedView(m.db, item.view.Name))
+				break
+			}
+
 			if m.activeView != taskListView && m.activeView != archivedTaskListView {
 				break
 			}
@@ -689,22 +1229,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					break
 				}
 
-				if m.taskList.IsFiltered() {
-					m.errorMsg = cannotDeleteWhenFilteredMsg
-					quit = true
-					break
-				}
 			case archivedTaskListView:
 				if len(m.archivedTaskList.Items()) == 0 {
 					quit = true
 					break
 				}
 
-				if m.archivedTaskList.IsFiltered() {
-					m.errorMsg = cannotDeleteWhenFilteredMsg
-					quit = true
-					break
-				}
 			}
 
 			if quit {
@@ -728,8 +1258,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 			switch m.activeView {
 			case taskListView:
-				index := m.taskList.Index()
-				t, ok := m.taskList.SelectedItem().(types.Task)
+				t, index, ok := m.selectedTaskIndex(activeTasks)
 				if !ok {
 					m.errorMsg = somethingWentWrongMsg
 					break
@@ -738,13 +1267,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				cmds = append(cmds, cmd)
 				if m.cfg.ConfirmBeforeDeletion {
 					m.showDeletePrompt = false
-					m.taskList.Title = m.cfg.TaskListTitle
-					m.taskList.Styles.Title = m.styles.activeListTitleBar
+					m.resetTaskListTitle()
 				}
 
 			case archivedTaskListView:
-				index := m.archivedTaskList.Index()
-				task, ok := m.archivedTaskList.SelectedItem().(types.Task)
+				task, index, ok := m.selectedTaskIndex(archivedTasks)
 				if !ok {
 					m.errorMsg = somethingWentWrongMsg
 					break
@@ -798,11 +1325,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				break
 			}
 
-			if len(prefixes) == 1 {
-				m.errorMsg = "Only 1 unique prefix in task list"
-				break
-			}
-
+			// a single prefix isn't of much use for filtering, but the list is
+			// also where prefixes are managed from
 			slices.Sort(prefixes)
 
 			pi := make([]list.Item, len(prefixes))
@@ -817,6 +1341,40 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.prefixSearchUse = prefixFilter
 
 		case "enter":
+			if m.activeView == groupedTaskListView {
+				m.showSelectedGroupedTask()
+				break
+			}
+
+			if m.activeView == savedViewSelectionView {
+				item, ok := m.savedViewsList.SelectedItem().(savedViewItem)
+				if ok {
+					m.showSavedViewTasks(item.view)
+				}
+				break
+			}
+
+			if m.activeView == queryResultsView {
+				m.showSelectedQueryResult()
+				break
+			}
+
+			if m.activeView == tagSelectionView {
+				item, ok := m.tagsList.SelectedItem().(tagItem)
+				if ok {
+					m.showTagTasks(item.tag)
+				}
+				break
+			}
+
+			if m.activeView == snoozedTaskListView {
+				t, ok := m.snoozedTaskList.SelectedItem().(types.Task)
+				if ok {
+					cmds = append(cmds, unsnoozeTask(m.db, t))
+				}
+				break
+			}
+
 			if m.activeView != taskListView && m.activeView != archivedTaskListView && m.activeView != contextBookmarksView && m.activeView != prefixSelectionView {
 				break
 			}
@@ -886,7 +1444,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 			case contextBookmarksView:
 				uri := m.taskBMList.SelectedItem().FilterValue()
-				cmds = append(cmds, openURI(uri))
+				cmds = append(cmds, m.openBookmark(uri))
 			case prefixSelectionView:
 				prefix := m.prefixSearchList.SelectedItem().FilterValue()
 
@@ -934,7 +1492,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					return m, tea.Sequence(cmds...)
 
 				case prefixChoose:
-					m.taskInput.SetValue(getSummaryWithNewPrefix(m.taskInput.Value(), prefix))
+					m.taskInput.SetValue(types.SummaryWithPrefix(m.taskInput.Value(), prefix))
 					m.activeView = taskEntryView
 				}
 
@@ -949,13 +1507,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				break
 			}
 
-			if m.taskList.IsFiltered() {
-				m.errorMsg = cannotMoveWhenFilteredMsg
+			t, index, ok := m.selectedTaskIndex(activeTasks)
+			if !ok {
+				m.errorMsg = somethingWentWrongMsg
 				break
 			}
 
-			index := m.taskList.Index()
-
 			lastIndex := len(m.taskList.Items()) - 1
 
 			if index == lastIndex {
@@ -963,21 +1520,25 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				break
 			}
 
+			m.taskList.RemoveItem(index)
 			if m.taskList.IsFiltered() {
-				m.taskList.ResetFilter()
-				m.taskList.Select(index)
+				m.taskList.InsertItem(lastIndex, t)
+				refilterList(&m.taskList)
+			} else {
+				cmd = m.taskList.InsertItem(lastIndex, t)
+				cmds = append(cmds, cmd)
+				m.taskList.Select(lastIndex)
 			}
 
-			listItem := m.taskList.SelectedItem()
-			m.taskList.RemoveItem(index)
-			cmd = m.taskList.InsertItem(lastIndex, listItem)
-			cmds = append(cmds, cmd)
-			m.taskList.Select(lastIndex)
-
 			cmd = m.updateActiveTasksSequence()
 			cmds = append(cmds, cmd)
 
 		case "c":
+			if m.activeView == tagSelectionView {
+				m.startTagChange(tagRecolor)
+				return m, tea.Batch(cmds...)
+			}
+
 			if m.activeView != taskListView && m.activeView != archivedTaskListView && m.activeView != taskDetailsView {
 				break
 			}
@@ -986,21 +1547,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			var ok bool
 			var index int
 
-			switch m.activeTaskList {
-			case activeTasks:
-				t, ok = m.taskList.SelectedItem().(types.Task)
-				if !ok {
-					m.errorMsg = somethingWentWrongMsg
-					break
-				}
-				index = m.taskList.Index()
-			case archivedTasks:
-				t, ok = m.archivedTaskList.SelectedItem().(types.Task)
-				if !ok {
-					m.errorMsg = somethingWentWrongMsg
-					break
-				}
-				index = m.archivedTaskList.Index()
+			t, index, ok = m.selectedTaskIndex(m.activeTaskList)
+			if !ok {
+				m.errorMsg = somethingWentWrongMsg
+				break
 			}
 
 			if len(m.cfg.TextEditorCmd) == 0 {
@@ -1037,11 +1587,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.cfg.ListDensity = Compact
 			}
 
-			tlDel := newTaskListDelegate(m.theme, m.cfg.ListDensity, activeTasks)
-			atlDel := newTaskListDelegate(m.theme, m.cfg.ListDensity, archivedTasks)
+			tlDel := newTaskListDelegate(m.theme, m.cfg.ListDensity, activeTasks, m.tagColors, m.blockedTasks)
+			atlDel := newTaskListDelegate(m.theme, m.cfg.ListDensity, archivedTasks, m.tagColors, m.blockedTasks)
 
 			m.taskList.SetDelegate(tlDel)
 			m.archivedTaskList.SetDelegate(atlDel)
+			m.queryResultsList.SetDelegate(tlDel)
 
 			if m.cfg.ShowContext {
 				m.taskList.SetHeight(m.shortenedListHt)
@@ -1086,8 +1637,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 			if m.cfg.ListDensity == Compact {
-				tlDel := newTaskListDelegate(m.theme, Compact, activeTasks)
-				atlDel := newTaskListDelegate(m.theme, Compact, archivedTasks)
+				tlDel := newTaskListDelegate(m.theme, Compact, activeTasks, m.tagColors, m.blockedTasks)
+				atlDel := newTaskListDelegate(m.theme, Compact, archivedTasks, m.tagColors, m.blockedTasks)
 				m.taskList.SetDelegate(tlDel)
 				m.archivedTaskList.SetDelegate(atlDel)
 			}
@@ -1131,7 +1682,36 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.lastActiveView = m.activeView
 			m.activeView = taskDetailsView
 
+		case "D":
+			if m.activeView != taskListView {
+				break
+			}
+
+			t, ok := m.taskList.SelectedItem().(types.Task)
+			if !ok {
+				break
+			}
+
+			cmds = append(cmds, m.pickBlocker(t))
+
+		case "1", "2", "3", "4", "5", "6", "7", "8", "9":
+			if m.activeView != taskDetailsView {
+				break
+			}
+
+			n, err := strconv.Atoi(keypress)
+			if err != nil {
+				break
+			}
+			m.showLinkedTask(n)
+
 		case "h":
+			if m.activeView == boardView {
+				m.boardColumnIndex--
+				m.clampBoardCursor(m.getBoard())
+				break
+			}
+
 			if m.activeView != taskDetailsView {
 				break
 			}
@@ -1155,6 +1735,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.setContextFSContent(t)
 
 		case "l":
+			if m.activeView == boardView {
+				m.boardColumnIndex++
+				m.clampBoardCursor(m.getBoard())
+				break
+			}
+
 			if m.activeView != taskDetailsView {
 				break
 			}
@@ -1193,7 +1779,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 			if len(uris) == 1 {
-				cmds = append(cmds, openURI(uris[0]))
+				cmds = append(cmds, m.openBookmark(uris[0]))
 				break
 			}
 
@@ -1226,18 +1812,23 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				break
 			}
 
-			if len(uris) == 1 {
-				cmds = append(cmds, openURI(uris[0]))
-				break
+			// only one task can be jumped to, so links to tasks after the first one
+			// are ignored
+			taskIDs, uris := splitTaskURIs(uris)
+			if len(taskIDs) > 0 {
+				m.jumpToTask(taskIDs[0])
 			}
 
-			if m.rtos == types.GOOSDarwin {
+			switch {
+			case len(uris) == 0:
+			case len(uris) == 1:
+				cmds = append(cmds, openURI(uris[0]))
+			case m.rtos == types.GOOSDarwin:
 				cmds = append(cmds, openURIsDarwin(uris))
-				break
-			}
-
-			for _, uri := range uris {
-				cmds = append(cmds, openURI(uri))
+			default:
+				for _, uri := range uris {
+					cmds = append(cmds, openURI(uri))
+				}
 			}
 
 		case "y":
@@ -1313,13 +1904,14 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				break
 			}
 
-			if m.taskList.FilterState() != list.Unfiltered {
-				m.errorMsg = "you can only paste in an unfiltered state"
-				break
+			index := 0
+			_, selectedIndex, ok := m.selectedTaskIndex(activeTasks)
+			if ok {
+				index = selectedIndex + 1
 			}
 
 			now := time.Now()
-			cmd = createTask(m.db, m.taskList.Index()+1, m.yankedTaskDetails.Summary, m.yankedTaskDetails.Context, now, now)
+			cmd = createTask(m.db, index, m.yankedTaskDetails.Summary, m.yankedTaskDetails.Context, now, now)
 			cmds = append(cmds, cmd)
 
 		case "P":
@@ -1332,13 +1924,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				break
 			}
 
-			if m.taskList.FilterState() != list.Unfiltered {
-				m.errorMsg = "you can only paste in an unfiltered state"
-				break
-			}
+			_, index, _ := m.selectedTaskIndex(activeTasks)
 
 			now := time.Now()
-			cmd = createTask(m.db, m.taskList.Index(), m.yankedTaskDetails.Summary, m.yankedTaskDetails.Context, now, now)
+			cmd = createTask(m.db, index, m.yankedTaskDetails.Summary, m.yankedTaskDetails.Context, now, now)
 			cmds = append(cmds, cmd)
 		}
 
@@ -1353,11 +1942,17 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		entry := list.Item(msg.task)
 		cmd = m.taskList.InsertItem(msg.index, entry)
-		cmds = append(cmds, cmd)
-		m.taskList.Select(msg.index)
+		if m.taskList.IsFiltered() {
+			refilterList(&m.taskList)
+			selectVisibleTask(&m.taskList, msg.task.ID)
+		} else {
+			cmds = append(cmds, cmd)
+			m.taskList.Select(msg.index)
+		}
 
 		cmd = m.updateActiveTasksSequence()
 		cmds = append(cmds, cmd)
+		cmds = append(cmds, m.runHook(hooks.EventCreate, msg.task))
 
 	case taskDeletedMsg:
 		if msg.err != nil {
@@ -1365,21 +1960,38 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			break
 		}
 
+		var deletedItem list.Item
 		switch msg.active {
 		case true:
+			deletedItem = m.taskList.Items()[msg.listIndex]
 			m.taskList.RemoveItem(msg.listIndex)
 			cmd = m.updateActiveTasksSequence()
 			cmds = append(cmds, cmd)
 		case false:
+			deletedItem = m.archivedTaskList.Items()[msg.listIndex]
 			m.archivedTaskList.RemoveItem(msg.listIndex)
 			m.updateArchivedTasksIndex()
 		}
+		refilterList(&m.taskList)
+		refilterList(&m.archivedTaskList)
+
+		if t, ok := deletedItem.(types.Task); ok {
+			cmds = append(cmds, m.runHook(hooks.EventDelete, t))
+		}
+
+		// links to the task are removed along with it
+		cmds = append(cmds, fetchTaskDependencies(m.db))
 
 	case taskSequenceUpdatedMsg:
 		if msg.err != nil {
 			m.errorMsg = fmt.Sprintf("Error updating task sequence: %s", msg.err)
 		}
 
+	case hookRanMsg:
+		if msg.err != nil {
+			m.errorMsg = msg.err.Error()
+		}
+
 	case taskSummaryUpdatedMsg:
 		if msg.err != nil {
 			m.errorMsg = fmt.Sprintf("Error updating task: %s", msg.err)
@@ -1394,6 +2006,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			t.UpdatedAt = msg.updatedAt
 			cmd = m.taskList.SetItem(msg.listIndex, list.Item(t))
 			cmds = append(cmds, cmd)
+			cmds = append(cmds, m.runHook(hooks.EventUpdate, t))
 		}
 
 	case taskContextUpdatedMsg:
@@ -1442,6 +2055,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			// to force refresh
 			m.contextVPTaskID = 0
+
+			if ok {
+				cmds = append(cmds, m.runHook(hooks.EventUpdate, t))
+			}
 		}
 
 	case taskStatusChangedMsg:
@@ -1458,9 +2075,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					break
 				}
 				t.UpdatedAt = msg.updatedAt
+				t.Active = true
 				m.taskList.InsertItem(0, list.Item(t))
 				m.taskList.Select(oldIndex + 1)
 				m.archivedTaskList.RemoveItem(msg.listIndex)
+				cmds = append(cmds, m.runHook(hooks.EventUnarchive, t))
 			case false:
 				item := m.taskList.Items()[msg.listIndex]
 
@@ -1470,12 +2089,46 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 
 				t.UpdatedAt = msg.updatedAt
+				t.Active = false
 				m.archivedTaskList.InsertItem(0, list.Item(t))
 				m.taskList.RemoveItem(msg.listIndex)
+				cmds = append(cmds, m.runHook(hooks.EventArchive, t))
 			}
+			refilterList(&m.taskList)
+			refilterList(&m.archivedTaskList)
 			cmd = m.updateActiveTasksSequence()
 			m.updateArchivedTasksIndex()
+			m.updateBlockedTasks()
 			cmds = append(cmds, cmd)
+
+			if m.activeView == boardView {
+				m.focusBoardTask(msg.id)
+			}
+		}
+
+	case taskBoardStatusUpdatedMsg:
+		if msg.err != nil {
+			m.errorMsg = fmt.Sprintf("Error updating task: %s", msg.err)
+			break
+		}
+
+		index, ok := m.tlIndexMap[msg.id]
+		if !ok {
+			break
+		}
+
+		t, ok := m.taskList.Items()[index].(types.Task)
+		if !ok {
+			break
+		}
+
+		t.Status = msg.status
+		t.UpdatedAt = msg.updatedAt
+		cmds = append(cmds, m.taskList.SetItem(index, list.Item(t)))
+		cmds = append(cmds, m.runHook(hooks.EventUpdate, t))
+
+		if m.activeView == boardView {
+			m.focusBoardTask(msg.id)
 		}
 
 	case tasksFetched:
@@ -1510,7 +2163,159 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.archivedTaskList.Select(0)
 				m.updateArchivedTasksIndex()
 			}
+
+			m.updateBlockedTasks()
+
+			if m.activeView == groupedTaskListView {
+				m.refreshGroupedTaskList()
+			}
+
+			if m.activeView == boardView {
+				m.clampBoardCursor(m.getBoard())
+			}
+		}
+	case taskDependenciesFetchedMsg:
+		if msg.err != nil {
+			m.errorMsg = fmt.Sprintf("Error fetching task dependencies: %s", msg.err)
+			break
+		}
+
+		m.dependencies = msg.dependencies
+		m.updateBlockedTasks()
+
+	case taskDependencyChangedMsg:
+		if msg.err != nil {
+			m.errorMsg = fmt.Sprintf("Error linking tasks: %s", msg.err)
+			break
+		}
+
+		cmds = append(cmds, fetchTaskDependencies(m.db))
+
+	case snoozedTasksFetchedMsg:
+		if msg.err != nil {
+			m.errorMsg = fmt.Sprintf("Error fetching snoozed tasks: %s", msg.err)
+			break
+		}
+
+		m.setSnoozedTasks(msg.tasks)
+		m.updateBlockedTasks()
+		if msg.show && m.activeView == taskListView {
+			m.activeView = snoozedTaskListView
+		}
+
+	case taskSnoozedMsg:
+		if msg.err != nil {
+			m.errorMsg = fmt.Sprintf("Error snoozing task: %s", msg.err)
+			break
+		}
+
+		if index, ok := m.tlIndexMap[msg.id]; ok {
+			m.taskList.RemoveItem(index)
+			refilterList(&m.taskList)
+			cmds = append(cmds, m.updateActiveTasksSequence())
+		}
+		cmds = append(cmds, fetchSnoozedTasks(m.db, false))
+
+	case taskUnsnoozedMsg:
+		if msg.err != nil {
+			m.errorMsg = fmt.Sprintf("Error unsnoozing task: %s", msg.err)
+			break
+		}
+
+		cmds = append(cmds, m.showWokenTasks([]types.Task{msg.task}))
+
+	case savedViewsFetchedMsg:
+		if msg.err != nil {
+			m.errorMsg = fmt.Sprintf("Error fetching views: %s", msg.err)
+			break
+		}
+
+		m.setSavedViews(msg.views)
+		if m.activeView == taskListView {
+			m.activeView = savedViewSelectionView
+		}
+
+	case savedViewCreatedMsg:
+		if msg.err != nil {
+			m.errorMsg = fmt.Sprintf("Error saving view: %s", msg.err)
+			break
+		}
+
+		cmds = append(cmds, fetchSavedViews(m.db))
+
+	case savedViewDeletedMsg:
+		if msg.err != nil {
+			m.errorMsg = fmt.Sprintf("Error deleting view: %s", msg.err)
+			break
 		}
+
+		cmds = append(cmds, fetchSavedViews(m.db))
+
+	case tagsFetchedMsg:
+		if msg.err != nil {
+			m.errorMsg = fmt.Sprintf("Error fetching tags: %s", msg.err)
+			break
+		}
+
+		m.setTags(msg.tags)
+		if msg.show && m.activeView == taskListView {
+			m.activeView = tagSelectionView
+		}
+
+	case tagChangedMsg:
+		if msg.err != nil {
+			m.errorMsg = fmt.Sprintf("Error changing tag: %s", msg.err)
+			break
+		}
+
+		// tags live in summaries, which might have changed
+		cmds = append(cmds,
+			fetchTags(m.db, false),
+			fetchTasks(m.db, true, pers.TaskNumLimit),
+			fetchTasks(m.db, false, pers.TaskNumLimit),
+		)
+
+	case prefixesFetchedMsg:
+		if msg.err != nil {
+			m.errorMsg = fmt.Sprintf("Error fetching prefixes: %s", msg.err)
+			break
+		}
+
+		m.setPrefixes(msg.prefixes)
+		if msg.show && m.activeView == prefixSelectionView {
+			m.selectPrefix(msg.selected)
+			m.activeView = prefixManagementView
+		}
+
+	case prefixChangedMsg:
+		if msg.err != nil {
+			m.errorMsg = fmt.Sprintf("Error changing prefix: %s", msg.err)
+			break
+		}
+
+		cmds = append(cmds,
+			fetchPrefixes(m.db, "", false),
+			fetchTasks(m.db, true, pers.TaskNumLimit),
+			fetchTasks(m.db, false, pers.TaskNumLimit),
+		)
+
+	case statsFetchedMsg:
+		if msg.err != nil {
+			m.errorMsg = fmt.Sprintf("Error fetching stats: %s", msg.err)
+			break
+		}
+
+		statsLines := strings.Split(stats.Render(msg.stats), "\n")
+		for i, line := range statsLines {
+			statsLines[i] = "  " + line
+		}
+		m.statsVP.SetContent(strings.Join(statsLines, "\n"))
+		m.statsVP.GotoTop()
+		if m.activeView != statsView {
+			m.lastActiveView = m.activeView
+			m.activeView = statsView
+		}
+
 	case textEditorClosed:
 		if msg.err != nil {
 			m.errorMsg = fmt.Sprintf("%s: %s", somethingWentWrongMsg, msg.err)
@@ -1649,6 +2454,39 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case helpView:
 		m.helpVP, viewUpdateCmd = m.helpVP.Update(msg)
+
+	case statsView:
+		m.statsVP, viewUpdateCmd = m.statsVP.Update(msg)
+
+	case groupedTaskListView:
+		if !skipListUpdate {
+			m.groupedTaskList, viewUpdateCmd = m.groupedTaskList.Update(msg)
+		}
+
+	case savedViewSelectionView:
+		if !skipListUpdate {
+			m.savedViewsList, viewUpdateCmd = m.savedViewsList.Update(msg)
+		}
+
+	case queryResultsView:
+		if !skipListUpdate {
+			m.queryResultsList, viewUpdateCmd = m.queryResultsList.Update(msg)
+		}
+
+	case tagSelectionView:
+		if !skipListUpdate {
+			m.tagsList, viewUpdateCmd = m.tagsList.Update(msg)
+		}
+
+	case prefixManagementView:
+		if !skipListUpdate {
+			m.prefixesList, viewUpdateCmd = m.prefixesList.Update(msg)
+		}
+
+	case snoozedTaskListView:
+		if !skipListUpdate {
+			m.snoozedTaskList, viewUpdateCmd = m.snoozedTaskList.Update(msg)
+		}
 	}
 
 	cmds = append(cmds, viewUpdateCmd)
@@ -1656,6 +2494,16 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
+// runHook returns a command that runs the hook configured for the event, or
+// nil if there's none.
+func (m *Model) runHook(event hooks.Event, task types.Task) tea.Cmd {
+	if !m.cfg.Hooks.IsSet(event) {
+		return nil
+	}
+
+	return runHook(m.cfg.Hooks, event, task)
+}
+
 func (m *Model) updateActiveTasksSequence() tea.Cmd {
 	sequence := make([]uint64, len(m.taskList.Items()))
 	tlIndexMap := make(map[uint64]int)
@@ -1688,8 +2536,10 @@ func (m *Model) updateArchivedTasksIndex() {
 	m.atlIndexMap = tlIndexMap
 }
 
+// isSpaceAvailable reports whether a task can be added to the task list;
+// snoozed tasks take up space in it, as they return to it when they wake up.
 func (m Model) isSpaceAvailable() bool {
-	return len(m.taskList.Items()) < pers.TaskNumLimit
+	return len(m.taskList.Items())+len(m.snoozedTaskList.Items()) < pers.TaskNumLimit
 }
 
 func (m *Model) setContextFSContent(task types.Task) {
@@ -1699,11 +2549,12 @@ func (m *Model) setContextFSContent(task types.Task) {
 	}
 
 	details := fmt.Sprintf(`- summary          :    %s
+- link             :    %s
 - created at       :    %s
 - last updated at  :    %s
 
-%s
-`, task.Summary, task.CreatedAt.Format(timeFormat), task.UpdatedAt.Format(timeFormat), ctx)
+%s%s
+`, task.Summary, types.TaskURI(task.ID), task.CreatedAt.Format(timeFormat), task.UpdatedAt.Format(timeFormat), m.getLinkedTasksDetails(task), ctx)
 
 	if m.taskDetailsMdRenderer != nil {
 		detailsGl, err := m.taskDetailsMdRenderer.Render(details)
@@ -1746,18 +2597,31 @@ func (m Model) getTaskURIs() ([]string, bool) {
 }
 
 func (m *Model) applyTheme(thm theme.Theme) {
+	thm.PinnedPrefixColors = m.cfg.PrefixColors.For(thm.Name)
 	m.theme = thm
 	m.styles = newStyles(thm)
 
-	m.taskList.SetDelegate(newTaskListDelegate(thm, m.cfg.ListDensity, activeTasks))
-	m.archivedTaskList.SetDelegate(newTaskListDelegate(thm, m.cfg.ListDensity, archivedTasks))
+	m.taskList.SetDelegate(newTaskListDelegate(thm, m.cfg.ListDensity, activeTasks, m.tagColors, m.blockedTasks))
+	m.archivedTaskList.SetDelegate(newTaskListDelegate(thm, m.cfg.ListDensity, archivedTasks, m.tagColors, m.blockedTasks))
 	m.taskBMList.SetDelegate(newBookmarksListDelegate(thm))
 	m.prefixSearchList.SetDelegate(newPrefixSearchListDelegate(thm))
+	m.groupedTaskList.SetDelegate(newGroupedTaskListDelegate(thm, m.tagColors, m.blockedTasks))
+	m.savedViewsList.SetDelegate(newSavedViewsListDelegate(thm))
+	m.tagsList.SetDelegate(newTagsListDelegate(thm, m.tagColors))
+	m.prefixesList.SetDelegate(newPrefixesListDelegate(thm))
+	m.snoozedTaskList.SetDelegate(newSnoozedTaskListDelegate(thm, m.tagColors))
+	m.queryResultsList.SetDelegate(newTaskListDelegate(thm, m.cfg.ListDensity, activeTasks, m.tagColors, m.blockedTasks))
 
 	m.taskList.Styles.Title = m.styles.activeListTitleBar
 	m.archivedTaskList.Styles.Title = m.styles.archivedListTitleBar
 	m.taskBMList.Styles.Title = m.styles.bookmarksListTitleBar
 	m.prefixSearchList.Styles.Title = m.styles.prefixListTitleBar
+	m.groupedTaskList.Styles.Title = m.styles.activeListTitleBar
+	m.savedViewsList.Styles.Title = m.styles.prefixListTitleBar
+	m.tagsList.Styles.Title = m.styles.prefixListTitleBar
+	m.prefixesList.Styles.Title = m.styles.prefixListTitleBar
+	m.snoozedTaskList.Styles.Title = m.styles.bookmarksListTitleBar
+	m.queryResultsList.Styles.Title = m.styles.activeListTitleBar
 
 	vpWidth := m.terminalWidth - 4
 	if vpWidth > 0 {
@@ -1805,3 +2669,34 @@ func (m *Model) applyTheme(thm theme.Theme) {
 	// content for the selected task ID and skip re-rendering under the new theme.
 	m.contextVPTaskID = 0
 }
+
+// changesTasks reports whether a keypress would make changes to tasks in the
+// active view.
+func (m Model) changesTasks(keypress string) bool {
+	switch m.activeView {
+	case taskListView:
+		// in a filtered list, enter only clears the filter
+		if keypress == "enter" {
+			return !m.taskList.IsFiltered()
+		}
+		return slices.Contains([]string{"I", "O", "a", "o", "A", "u", "E", "$", "J", "K", "p", "P", "c", "D", "s", "ctrl+d", "ctrl+x"}, keypress)
+	case archivedTaskListView:
+		return slices.Contains([]string{"c", "ctrl+d", "ctrl+x"}, keypress)
+	case taskDetailsView:
+		return keypress == "c"
+	case groupedTaskListView:
+		return keypress == "J" || keypress == "K"
+	case boardView:
+		return slices.Contains([]string{"H", "L", "J", "K"}, keypress)
+	case savedViewSelectionView:
+		return keypress == "a" || keypress == "ctrl+x"
+	case tagSelectionView:
+		return slices.Contains([]string{"r", "m", "c"}, keypress)
+	case prefixManagementView:
+		return keypress == "r" || keypress == "m"
+	case snoozedTaskListView:
+		return keypress == "s" || keypress == "enter"
+	}
+
+	return false
+}
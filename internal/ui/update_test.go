@@ -0,0 +1,51 @@
+package ui
+
+import (
+	"testing"
+
+	"charm.land/bubbles/v2/list"
+	"github.com/dhth/omm/internal/ui/theme"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnterOnlyChangesTasksInAnUnfilteredTaskList(t *testing.T) {
+	testCases := []struct {
+		name       string
+		filterText string
+		expected   bool
+	}{
+		{
+			name:     "unfiltered",
+			expected: true,
+		},
+		{
+			name:       "filtered",
+			filterText: "task",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			thm, err := theme.Get(theme.DefaultThemeName)
+			require.NoError(t, err)
+			m := InitialModel(nil, Config{}, thm)
+			tasks := getTasksWithSummaries("task 1", "task 2")
+			items := make([]list.Item, len(tasks))
+			for i, task := range tasks {
+				items[i] = task
+			}
+			m.taskList.SetItems(items)
+			if tt.filterText != "" {
+				m.taskList.SetFilterText(tt.filterText)
+			}
+
+			// WHEN
+			got := m.changesTasks("enter")
+
+			// THEN
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
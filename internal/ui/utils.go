@@ -1,7 +1,6 @@
 package ui
 
 import (
-	"fmt"
 	"hash/fnv"
 	"image/color"
 	"strings"
@@ -11,35 +10,52 @@ import (
 )
 
 func getColorForString(str string, colors []string) color.Color {
-	if len(colors) == 0 {
+	c := pickColorForString(str, colors)
+	if c == "" {
 		return lipgloss.NoColor{}
 	}
 
+	return lipgloss.Color(c)
+}
+
+func pickColorForString(str string, colors []string) string {
+	if len(colors) == 0 {
+		return ""
+	}
+
 	h := fnv.New32()
 	h.Write([]byte(str))
 	hash := h.Sum32()
 
-	color := colors[hash%uint32(len(colors))]
+	return colors[hash%uint32(len(colors))]
+}
 
-	return lipgloss.Color(color)
+// getPrefixColor returns the color pinned to a prefix, falling back to one
+// picked from the theme's prefix colors.
+func getPrefixColor(prefix string, pinned map[string]string, colors []string) color.Color {
+	c, _ := resolvePrefixColor(prefix, pinned, colors)
+	return toPrefixColor(c)
 }
 
-func getSummaryWithNewPrefix(summary, newPrefix string) string {
-	if strings.TrimSpace(summary) == "" {
-		return fmt.Sprintf("%s: ", newPrefix)
+// toPrefixColor turns a color returned by resolvePrefixColor into one lipgloss
+// can render; an empty color leaves the prefix uncolored.
+func toPrefixColor(c string) color.Color {
+	if c == "" {
+		return lipgloss.NoColor{}
 	}
 
-	summEls := strings.Split(summary, types.PrefixDelimiter)
-
-	if len(summEls) == 1 {
-		return fmt.Sprintf("%s: %s", newPrefix, summary)
-	}
+	return lipgloss.Color(c)
+}
 
-	if summEls[1] == "" {
-		return fmt.Sprintf("%s: ", newPrefix)
+// resolvePrefixColor returns the color a prefix is shown in, and whether it's
+// pinned (as opposed to picked from the theme's prefix colors).
+func resolvePrefixColor(prefix string, pinned map[string]string, colors []string) (string, bool) {
+	c, ok := pinned[strings.ToLower(prefix)]
+	if ok {
+		return c, true
 	}
 
-	return fmt.Sprintf("%s:%s", newPrefix, strings.Join(summEls[1:], types.PrefixDelimiter))
+	return pickColorForString(prefix, colors), false
 }
 
 func getPrefix(summary string) (string, bool) {
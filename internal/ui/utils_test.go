@@ -28,68 +28,44 @@ func TestGetColorForString(t *testing.T) {
 	assert.Equal(t, gota, gotb)
 }
 
-func TestGetSummaryWithNewPrefix(t *testing.T) {
+func TestResolvePrefixColor(t *testing.T) {
+	pinned := map[string]string{"work": "#fabd2f"}
+	colors := []string{"#ff0000", "#00ff00"}
+
 	testCases := []struct {
-		name      string
-		summary   string
-		newPrefix string
-		expected  string
+		name           string
+		prefix         string
+		expectedPinned bool
 	}{
 		{
-			name:      "empty summary",
-			summary:   "",
-			newPrefix: "new",
-			expected:  "new: ",
-		},
-		{
-			name:      "just whitespace",
-			summary:   "  ",
-			newPrefix: "new",
-			expected:  "new: ",
-		},
-		{
-			name:      "just a colon",
-			summary:   ":",
-			newPrefix: "new",
-			expected:  "new: ",
-		},
-		{
-			name:      "just summary content",
-			summary:   "this is a task",
-			newPrefix: "new",
-			expected:  "new: this is a task",
-		},
-		{
-			name:      "just a prefix",
-			summary:   "old:",
-			newPrefix: "new",
-			expected:  "new: ",
-		},
-		{
-			name:      "prefix and summary content",
-			summary:   "old: this is a task",
-			newPrefix: "new",
-			expected:  "new: this is a task",
+			name:           "pinned prefix",
+			prefix:         "work",
+			expectedPinned: true,
 		},
 		{
-			name:      "prefix and summary content without space",
-			summary:   "old:this is a task",
-			newPrefix: "new",
-			expected:  "new:this is a task",
+			name:           "pinned prefix in a different case",
+			prefix:         "Work",
+			expectedPinned: true,
 		},
 		{
-			name:      "summary with two colons",
-			summary:   "old: this: is a task",
-			newPrefix: "new",
-			expected:  "new: this: is a task",
+			name:   "prefix without a pinned color",
+			prefix: "infra",
 		},
 	}
 
 	for _, tt := range testCases {
 		t.Run(tt.name, func(t *testing.T) {
-			got := getSummaryWithNewPrefix(tt.summary, tt.newPrefix)
+			// GIVEN
+			// WHEN
+			got, gotPinned := resolvePrefixColor(tt.prefix, pinned, colors)
 
-			assert.Equal(t, tt.expected, got)
+			// THEN
+			assert.Equal(t, tt.expectedPinned, gotPinned)
+			if tt.expectedPinned {
+				assert.Equal(t, "#fabd2f", got)
+			} else {
+				assert.Contains(t, colors, got)
+			}
 		})
 	}
 }
@@ -5,6 +5,7 @@ import (
 
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
+	"github.com/dhth/omm/internal/types"
 )
 
 var TaskListDefaultTitle = "omm"
@@ -19,6 +20,10 @@ func (m Model) View() tea.View {
 	var statusBar string
 	var listEmpty bool
 
+	if m.cfg.ReadOnly {
+		statusBar += m.styles.statusError.Render("[read-only]")
+	}
+
 	if m.showHelpIndicator && (m.activeView != helpView) {
 		statusBar += m.styles.statusHint.Render("Press ? for help")
 	}
@@ -27,6 +32,18 @@ func (m Model) View() tea.View {
 		statusBar += m.styles.deletePrompt.Render("press ctrl+x again to delete, any other key to cancel")
 	}
 
+	if m.showArchivePrompt {
+		var numDependents int
+		if t, ok := m.taskList.SelectedItem().(types.Task); ok {
+			numDependents = len(m.getOpenDependents(t.ID))
+		}
+		statusBar += m.styles.deletePrompt.Render(fmt.Sprintf("blocks %d open task(s); press ctrl+d again to archive, any other key to cancel", numDependents))
+	}
+
+	if m.dependencyTaskID != 0 && m.activeView == taskListView {
+		statusBar += m.styles.statusHint.Render("press D on the task that blocks it (or on one of its blockers to unlink them), esc to cancel")
+	}
+
 	if m.errorMsg != "" && m.successMsg != "" {
 		statusBar += fmt.Sprintf("%s%s",
 			m.styles.statusError.Render(m.errorMsg),
@@ -87,7 +104,7 @@ func (m Model) View() tea.View {
   %s`,
 				header,
 				m.styles.mutedText.Render(fmt.Sprintf("task will be added %s", newTaskPosition)),
-				m.styles.mutedText.Render("omm picks up the prefix in a task summary like 'prefix: do something'\n  and highlights it for you in the task list"),
+				m.styles.mutedText.Render("omm picks up the prefix and tags in a task summary like 'prefix: do something #tag'\n  and highlights them for you in the task list"),
 				m.taskInput.View(),
 				m.styles.mutedText.Render("press <esc> to go back, ⏎ to submit"),
 			)
@@ -106,7 +123,7 @@ func (m Model) View() tea.View {
 
   %s`,
 				header,
-				m.styles.mutedText.Render("omm picks up the prefix in a task summary like 'prefix: do something'\n  and highlights it for you in the task list"),
+				m.styles.mutedText.Render("omm picks up the prefix and tags in a task summary like 'prefix: do something #tag'\n  and highlights them for you in the task list"),
 				m.taskInput.View(),
 				m.styles.mutedText.Render("press <esc> to go back, ⏎ to submit"),
 			)
@@ -129,12 +146,189 @@ func (m Model) View() tea.View {
 			content = m.styles.sectionHeader.Render(header) + "\n" + m.taskDetailsVP.View()
 		}
 
+	case groupedTaskListView:
+		content = m.styles.listContainer.Render(m.groupedTaskList.View())
+
+	case savedViewSelectionView:
+		if len(m.savedViewsList.Items()) > 0 {
+			content = m.styles.listContainer.Render(m.savedViewsList.View())
+		} else {
+			content = fmt.Sprintf(`
+  %s
+
+  %s`, m.styles.activeListTitle.Render(savedViewsTitle), m.styles.mutedText.Render("No views. Press a to add one.\n"))
+		}
+
+	case savedViewEntryView:
+		header := m.styles.taskEntryTitle.Render("enter your view")
+		content = fmt.Sprintf(`
+  %s
+
+  %s
+
+  %s
+
+  %s`,
+			header,
+			m.styles.mutedText.Render("a view is a named query (eg. work = prefix:api|infra AND NOT has:links);\n  press : in the task list to try out queries"),
+			m.savedViewInput.View(),
+			m.styles.mutedText.Render("press <esc> to go back, ⏎ to submit"),
+		)
+		for range m.terminalHeight - 10 {
+			content += "\n"
+		}
+
+	case queryEntryView:
+		header := m.styles.taskEntryTitle.Render("query tasks")
+		content = fmt.Sprintf(`
+  %s
+
+  %s
+
+  %s
+
+  %s`,
+			header,
+			m.styles.mutedText.Render("terms (prefix:<a|b>, tag:<a|b>, has:<links|context>, text~<text>, age/updated <op> <period>, stale:<period>)\n  can be combined via AND, OR, NOT, and parentheses; eg. prefix:infra AND age>7d AND text~\"deploy\""),
+			m.queryInput.View(),
+			m.styles.mutedText.Render("press <esc> to go back, ⏎ to submit"),
+		)
+		for range m.terminalHeight - 10 {
+			content += "\n"
+		}
+
+	case queryResultsView:
+		if len(m.queryResultsList.Items()) > 0 {
+			content = m.styles.listContainer.Render(m.queryResultsList.View())
+		} else {
+			content = fmt.Sprintf(`
+  %s
+
+  %s`, m.styles.activeListTitle.Render(m.queryResultsList.Title), m.styles.mutedText.Render("No tasks match.\n"))
+		}
+
+	case tagSelectionView:
+		if len(m.tagsList.Items()) > 0 {
+			content = m.styles.listContainer.Render(m.tagsList.View())
+		} else {
+			content = fmt.Sprintf(`
+  %s
+
+  %s`, m.styles.activeListTitle.Render(tagsTitle), m.styles.mutedText.Render("No tags. Add them to tasks via their summaries (eg. 'fix login flow #auth').\n"))
+		}
+
+	case tagEntryView:
+		var header string
+		var hint string
+		item, _ := m.tagsList.SelectedItem().(tagItem)
+		switch m.tagChange {
+		case tagRename:
+			header = fmt.Sprintf("rename %s%s", types.TagPrefix, item.tag.Name)
+			hint = "the tag will be renamed in the summaries of all tasks that have it"
+		case tagMerge:
+			header = fmt.Sprintf("merge %s%s", types.TagPrefix, item.tag.Name)
+			hint = "the tag will be replaced with the one entered in the summaries of all tasks that have it"
+		case tagRecolor:
+			header = fmt.Sprintf("set color for %s%s", types.TagPrefix, item.tag.Name)
+			hint = "colors can be hex colors (eg. #fabd2f), or ANSI color numbers (0-255)"
+		}
+		content = fmt.Sprintf(`
+  %s
+
+  %s
+
+  %s
+
+  %s`,
+			m.styles.taskEntryTitle.Render(header),
+			m.styles.mutedText.Render(hint),
+			m.tagInput.View(),
+			m.styles.mutedText.Render("press <esc> to go back, ⏎ to submit"),
+		)
+		for range m.terminalHeight - 9 {
+			content += "\n"
+		}
+
+	case boardView:
+		content = m.getBoardView()
+
 	case contextBookmarksView:
 		content = m.styles.listContainer.Render(m.taskBMList.View())
 
 	case prefixSelectionView:
 		content = m.styles.listContainer.Render(m.prefixSearchList.View())
 
+	case prefixManagementView:
+		if len(m.prefixesList.Items()) > 0 {
+			content = m.styles.listContainer.Render(m.prefixesList.View())
+		} else {
+			content = fmt.Sprintf(`
+  %s
+
+  %s`, m.styles.activeListTitle.Render(prefixesTitle), m.styles.mutedText.Render("No prefixes.\n"))
+		}
+
+	case prefixEntryView:
+		var header string
+		var hint string
+		item, _ := m.prefixesList.SelectedItem().(prefixCountItem)
+		switch m.prefixChange {
+		case prefixRename:
+			header = fmt.Sprintf("rename prefix %q", item.Title())
+			hint = "the prefix will be renamed in the summaries of all tasks (active and archived) that have it"
+		case prefixMerge:
+			header = fmt.Sprintf("merge prefix %q", item.Title())
+			hint = "the prefix will be replaced with the one entered in the summaries of all tasks (active and archived) that have it"
+		}
+		content = fmt.Sprintf(`
+  %s
+
+  %s
+
+  %s
+
+  %s`,
+			m.styles.taskEntryTitle.Render(header),
+			m.styles.mutedText.Render(hint),
+			m.prefixInput.View(),
+			m.styles.mutedText.Render("press <esc> to go back, ⏎ to submit"),
+		)
+		for range m.terminalHeight - 9 {
+			content += "\n"
+		}
+
+	case snoozedTaskListView:
+		if len(m.snoozedTaskList.Items()) > 0 {
+			content = m.styles.listContainer.Render(m.snoozedTaskList.View())
+		} else {
+			content = fmt.Sprintf(`
+  %s
+
+  %s`, m.styles.activeListTitle.Render(snoozedTitle), m.styles.mutedText.Render("No snoozed tasks. You snooze tasks by pressing s in the task list.\n"))
+		}
+
+	case snoozeEntryView:
+		var summary string
+		if t, ok := m.getTaskByID(m.snoozeTaskID); ok {
+			summary = t.Summary
+		}
+		content = fmt.Sprintf(`
+  %s
+
+  %s
+
+  %s
+
+  %s`,
+			m.styles.taskEntryTitle.Render("snooze task"),
+			m.styles.mutedText.Render(fmt.Sprintf("%q will be hidden until then, and will show up at the top of the task list after that", summary)),
+			m.snoozeInput.View(),
+			m.styles.mutedText.Render("press <esc> to go back, ⏎ to submit"),
+		)
+		for range m.terminalHeight - 9 {
+			content += "\n"
+		}
+
 	case helpView:
 		header := fmt.Sprintf(`
   %s  %s
@@ -145,6 +339,17 @@ func (m Model) View() tea.View {
 		} else {
 			content = header + m.helpVP.View()
 		}
+
+	case statsView:
+		header := fmt.Sprintf(`
+  %s  %s
+
+`, m.styles.helpTitle.Render("stats"), m.styles.statusHint.Render("(scroll with j/k/↓/↑)"))
+		if !m.statsVPReady {
+			content = "Initializing..."
+		} else {
+			content = header + m.statsVP.View()
+		}
 	}
 
 	var components []string
@@ -94,6 +94,14 @@ facetime-audio: facetime-audio:example@example.com
 				"facetime-audio:example@example.com",
 			},
 		},
+		{
+			name:  "omm task uris",
+			input: `blocked on omm://task/12 (and omm://task/7).`,
+			expected: []string{
+				"omm://task/12",
+				"omm://task/7",
+			},
+		},
 		// failures
 		{
 			name:  "doesn't match a uri without a scheme",
@@ -0,0 +1,137 @@
+package views
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dhth/omm/internal/query"
+	"github.com/dhth/omm/internal/types"
+)
+
+// Source is where a view is defined.
+type Source string
+
+const (
+	FromConfig Source = "config"
+	FromDB     Source = "saved"
+)
+
+var (
+	ErrNameEmpty         = errors.New("view name is empty")
+	ErrNameInvalid       = errors.New("view name can't contain dots")
+	ErrDefinitionEmpty   = errors.New("view definition is empty")
+	ErrViewNotFound      = errors.New("view not found")
+	ErrViewAlreadyExists = errors.New("a view with this name already exists")
+)
+
+// View is a named query (see the query package), eg.
+//
+//	prefix:api|infra stale:14d
+//	prefix:infra AND (has:links OR text~"deploy")
+type View struct {
+	Name       string
+	Definition string
+	Source     Source
+	query      query.Query
+}
+
+// Parse parses a view's definition. View names are case-insensitive (since the
+// keys of the config file are), and are stored in lower case.
+func Parse(name, definition string, source Source) (View, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return View{}, ErrNameEmpty
+	}
+	if strings.Contains(name, ".") {
+		return View{}, ErrNameInvalid
+	}
+
+	if strings.TrimSpace(definition) == "" {
+		return View{}, ErrDefinitionEmpty
+	}
+
+	q, err := query.Parse(definition)
+	if err != nil {
+		return View{}, err
+	}
+
+	return View{
+		Name:       name,
+		Definition: q.String(),
+		Source:     source,
+		query:      q,
+	}, nil
+}
+
+// Query returns the view's query.
+func (v View) Query() query.Query {
+	return v.query
+}
+
+// Matches reports whether a task matches the view's query.
+func (v View) Matches(t types.Task, now time.Time) bool {
+	return v.query.Matches(t, now)
+}
+
+// Filter returns the tasks that match a view, in the order they're passed in.
+func Filter(tasks []types.Task, v View, now time.Time) []types.Task {
+	var matching []types.Task
+	for _, t := range tasks {
+		if v.Matches(t, now) {
+			matching = append(matching, t)
+		}
+	}
+
+	return matching
+}
+
+// FromConfigValues parses views defined in the config file, sorted by name.
+func FromConfigValues(values map[string]string) ([]View, error) {
+	views := make([]View, 0, len(values))
+	for name, definition := range values {
+		v, err := Parse(name, definition, FromConfig)
+		if err != nil {
+			return nil, fmt.Errorf("view %q: %w", name, err)
+		}
+		views = append(views, v)
+	}
+
+	slices.SortFunc(views, func(a, b View) int { return strings.Compare(a.Name, b.Name) })
+
+	return views, nil
+}
+
+// Merge returns views defined in the config file, followed by saved ones; saved
+// views with the same name as one in the config file are left out.
+func Merge(configViews []View, saved []types.SavedView) []View {
+	views := slices.Clone(configViews)
+	for _, s := range saved {
+		_, ok := Find(configViews, s.Name)
+		if ok {
+			continue
+		}
+
+		v, err := Parse(s.Name, s.Definition, FromDB)
+		if err != nil {
+			continue
+		}
+		views = append(views, v)
+	}
+
+	return views
+}
+
+// Find returns the view with a name (case-insensitively).
+func Find(views []View, name string) (View, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, v := range views {
+		if v.Name == name {
+			return v, true
+		}
+	}
+
+	return View{}, false
+}
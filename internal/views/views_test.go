@@ -0,0 +1,164 @@
+package views
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dhth/omm/internal/query"
+	"github.com/dhth/omm/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name        string
+		viewName    string
+		definition  string
+		expectedErr error
+	}{
+		{
+			name:       "all terms",
+			viewName:   "Work",
+			definition: "prefix:api|infra stale:2w has:links has:context",
+		},
+		{
+			name:       "query",
+			viewName:   "work",
+			definition: `prefix:infra AND (age>7d OR text~"deploy")`,
+		},
+		{
+			name:        "empty name",
+			viewName:    " ",
+			definition:  "has:links",
+			expectedErr: ErrNameEmpty,
+		},
+		{
+			name:        "name with a dot",
+			viewName:    "a.b",
+			definition:  "has:links",
+			expectedErr: ErrNameInvalid,
+		},
+		{
+			name:        "empty definition",
+			viewName:    "work",
+			definition:  "  ",
+			expectedErr: ErrDefinitionEmpty,
+		},
+		{
+			name:        "unknown term",
+			viewName:    "work",
+			definition:  "prefix:api owner:me",
+			expectedErr: query.ErrFieldUnknown,
+		},
+		{
+			name:        "empty prefix",
+			viewName:    "work",
+			definition:  "prefix:api|",
+			expectedErr: query.ErrPrefixEmpty,
+		},
+		{
+			name:        "invalid period",
+			viewName:    "stale",
+			definition:  "stale:14",
+			expectedErr: query.ErrPeriodInvalid,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			// WHEN
+			got, err := Parse(tt.viewName, tt.definition, FromConfig)
+
+			// THEN
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "work", got.Name)
+		})
+	}
+}
+
+func TestFilter(t *testing.T) {
+	now := time.Date(2026, 3, 20, 12, 0, 0, 0, time.UTC)
+	withLink := "see https://example.com"
+	withoutLink := "some notes"
+	tasks := []types.Task{
+		{ID: 1, Summary: "api: add endpoint", UpdatedAt: now.AddDate(0, 0, -20), Context: &withLink},
+		{ID: 2, Summary: "infra: resize cluster", UpdatedAt: now.AddDate(0, 0, -1)},
+		{ID: 3, Summary: "home: fix sink", UpdatedAt: now.AddDate(0, 0, -30), Context: &withoutLink},
+		{ID: 4, Summary: "read https://example.com/post", UpdatedAt: now},
+	}
+
+	testCases := []struct {
+		name        string
+		definition  string
+		expectedIDs []uint64
+	}{
+		{
+			name:        "prefixes",
+			definition:  "prefix:api|infra",
+			expectedIDs: []uint64{1, 2},
+		},
+		{
+			name:        "stale tasks",
+			definition:  "stale:14d",
+			expectedIDs: []uint64{1, 3},
+		},
+		{
+			name:        "tasks with links",
+			definition:  "has:links",
+			expectedIDs: []uint64{1, 4},
+		},
+		{
+			name:        "all terms need to match",
+			definition:  "prefix:api|infra stale:2w has:context",
+			expectedIDs: []uint64{1},
+		},
+		{
+			name:        "query",
+			definition:  `prefix:home OR (NOT prefix:api AND text~"HTTPS")`,
+			expectedIDs: []uint64{3, 4},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			v, err := Parse("view", tt.definition, FromConfig)
+			require.NoError(t, err)
+
+			// WHEN
+			got := Filter(tasks, v, now)
+
+			// THEN
+			ids := make([]uint64, len(got))
+			for i, t := range got {
+				ids[i] = t.ID
+			}
+			assert.Equal(t, tt.expectedIDs, ids)
+		})
+	}
+}
+
+func TestMergeLeavesOutSavedViewsDefinedInConfig(t *testing.T) {
+	// GIVEN
+	configViews, err := FromConfigValues(map[string]string{"work": "prefix:api", "links": "has:links"})
+	require.NoError(t, err)
+	saved := []types.SavedView{
+		{Name: "work", Definition: "prefix:infra"},
+		{Name: "stale", Definition: "stale:14d"},
+	}
+
+	// WHEN
+	got := Merge(configViews, saved)
+
+	// THEN
+	require.Len(t, got, 3)
+	assert.Equal(t, []string{"links", "work", "stale"}, []string{got[0].Name, got[1].Name, got[2].Name})
+	assert.Equal(t, "prefix:api", got[1].Definition)
+	assert.Equal(t, FromDB, got[2].Source)
+}